@@ -30,6 +30,7 @@ import (
 	cfg "github.com/elastic/beats/v7/filebeat/config"
 	"github.com/elastic/beats/v7/filebeat/fileset"
 	_ "github.com/elastic/beats/v7/filebeat/include"
+	"github.com/elastic/beats/v7/filebeat/ingest"
 	"github.com/elastic/beats/v7/filebeat/input"
 	"github.com/elastic/beats/v7/filebeat/input/filestream/takeover"
 	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
@@ -43,12 +44,14 @@ import (
 	"github.com/elastic/beats/v7/libbeat/management"
 	"github.com/elastic/beats/v7/libbeat/monitoring/inputmon"
 	"github.com/elastic/beats/v7/libbeat/outputs/elasticsearch"
+	"github.com/elastic/beats/v7/libbeat/processors"
 	"github.com/elastic/beats/v7/libbeat/publisher/pipetool"
 	"github.com/elastic/beats/v7/libbeat/statestore"
 	conf "github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
 	"github.com/elastic/elastic-agent-libs/monitoring"
 	"github.com/elastic/elastic-agent-libs/paths"
+	"github.com/elastic/elastic-agent-libs/version"
 	"github.com/elastic/go-concert/unison"
 
 	// Add filebeat level processors
@@ -257,7 +260,26 @@ func (fb *Filebeat) Run(b *beat.Beat) error {
 	var err error
 	config := fb.config
 
-	if !fb.moduleRegistry.Empty() {
+	// Module pipelines run either inside Filebeat or in Elasticsearch.
+	var localPipelines *ingest.Registry
+	if config.LocalPipelines.Enabled {
+		localPipelines = ingest.NewRegistry(config.LocalPipelines, logp.NewLogger("ingest"))
+		defer localPipelines.Close()
+	}
+
+	// Pipelines that can't run inside Filebeat are run by Elasticsearch,
+	// which requires the Elasticsearch output.
+	esOutput := b.Config.Output.Name() == "elasticsearch"
+	if !fb.moduleRegistry.Empty() && localPipelines != nil {
+		v, err := version.New(b.Info.Version)
+		if err != nil {
+			return err
+		}
+		if err := fb.moduleRegistry.LoadLocalPipelines(localPipelines, *v, esOutput); err != nil {
+			return err
+		}
+	}
+	if !fb.moduleRegistry.Empty() && (localPipelines == nil || esOutput) {
 		err = fb.loadModulesPipelines(b)
 		if err != nil {
 			return err
@@ -319,16 +341,23 @@ func (fb *Filebeat) Run(b *beat.Beat) error {
 	// guarantees explicitly when connecting with the pipeline.
 	fb.pipeline = pipetool.WithDefaultGuarantees(fb.pipeline, beat.GuaranteedSend)
 
+	if localPipelines != nil {
+		fb.pipeline = withLocalPipelines(fb.pipeline, localPipelines)
+	}
+
 	outDone := make(chan struct{}) // outDone closes down all active pipeline connections
 	pipelineConnector := channel.NewOutletFactory(outDone).Create
 
 	// Create a ES connection factory for dynamic modules pipeline loading
 	var pipelineLoaderFactory fileset.PipelineLoaderFactory
-	if b.Config.Output.Name() == "elasticsearch" {
+	if esOutput {
 		pipelineLoaderFactory = newPipelineLoaderFactory(b.Config.Output.Config())
-	} else {
+	} else if localPipelines == nil {
 		logp.Warn(pipelinesWarning)
 	}
+	if localPipelines != nil {
+		logp.Info("Module ingest pipelines run inside Filebeat where possible.")
+	}
 
 	inputsLogger := logp.NewLogger("input")
 	v2Inputs := fb.pluginFactory(b.Info, inputsLogger, stateStore)
@@ -350,7 +379,7 @@ func (fb *Filebeat) Run(b *beat.Beat) error {
 		compat.RunnerFactory(inputsLogger, b.Info, v2InputLoader),
 		input.NewRunnerFactory(pipelineConnector, registrar, fb.done),
 	))
-	moduleLoader := fileset.NewFactory(inputLoader, b.Info, pipelineLoaderFactory, config.OverwritePipelines)
+	moduleLoader := fileset.NewFactory(inputLoader, b.Info, pipelineLoaderFactory, config.OverwritePipelines, localPipelines)
 
 	crawler, err := newCrawler(inputLoader, moduleLoader, config.Inputs, fb.done, *once)
 	if err != nil {
@@ -486,6 +515,23 @@ func (fb *Filebeat) Stop() {
 	fb.stopOnce.Do(func() { close(fb.done) })
 }
 
+// withLocalPipelines runs the module ingest pipelines of the events of all
+// clients as the last processing step, after the global processors, like
+// Elasticsearch would run them on ingest.
+func withLocalPipelines(pipeline beat.PipelineConnector, reg *ingest.Registry) beat.PipelineConnector {
+	return pipetool.WithClientConfigEdit(pipeline, func(cfg beat.ClientConfig) (beat.ClientConfig, error) {
+		if cfg.Processing.PostProcessor == nil {
+			cfg.Processing.PostProcessor = reg.Processor()
+			return cfg, nil
+		}
+		procs := processors.NewList(nil)
+		procs.AddProcessor(cfg.Processing.PostProcessor)
+		procs.AddProcessor(reg.Processor())
+		cfg.Processing.PostProcessor = procs
+		return cfg, nil
+	})
+}
+
 // Create a new pipeline loader (es client) factory
 func newPipelineLoaderFactory(esConfig *conf.C) fileset.PipelineLoaderFactory {
 	pipelineLoaderFactory := func() (fileset.PipelineLoader, error) {
@@ -119,6 +119,17 @@ func withClientConfig(
 	return pipetool.WithClientConfigEdit(pipeline, editor), nil
 }
 
+// ClientConfig returns the pipeline client configuration, including the
+// processors, that the common input settings in cfg apply to the clients of
+// an input.
+func ClientConfig(beatInfo beat.Info, cfg *conf.C) (beat.ClientConfig, error) {
+	editor, err := newCommonConfigEditor(beatInfo, cfg)
+	if err != nil {
+		return beat.ClientConfig{}, err
+	}
+	return editor(beat.ClientConfig{})
+}
+
 func newCommonConfigEditor(
 	beatInfo beat.Info,
 	cfg *conf.C,
@@ -31,6 +31,7 @@ import (
 
 	// Import processors.
 	_ "github.com/elastic/beats/v7/libbeat/processors/cache"
+	_ "github.com/elastic/beats/v7/libbeat/processors/deduplicate"
 	_ "github.com/elastic/beats/v7/libbeat/processors/timestamp"
 )
 
@@ -62,6 +63,7 @@ func Filebeat(inputs beater.PluginFactory, settings instance.Settings) *cmd.Beat
 	command := cmd.GenRootCmdWithSettings(beater.New(inputs), settings)
 	command.PersistentFlags().AddGoFlag(flag.CommandLine.Lookup("M"))
 	command.TestCmd.Flags().AddGoFlag(flag.CommandLine.Lookup("modules"))
+	command.TestCmd.AddCommand(genTestProcessorsCmd(settings))
 	command.SetupCmd.Flags().AddGoFlag(flag.CommandLine.Lookup("modules"))
 	command.AddCommand(cmd.GenModulesCmd(Name, "", buildModulesManager))
 	command.AddCommand(genGenerateCmd())
@@ -0,0 +1,592 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/beats/v7/filebeat/channel"
+	"github.com/elastic/beats/v7/filebeat/fileset"
+	"github.com/elastic/beats/v7/filebeat/ingest"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/cmd/instance"
+	"github.com/elastic/beats/v7/libbeat/common/cli"
+	"github.com/elastic/beats/v7/libbeat/common/file"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	"github.com/elastic/beats/v7/libbeat/publisher/processing"
+	"github.com/elastic/beats/v7/libbeat/reader"
+	"github.com/elastic/beats/v7/libbeat/reader/parser"
+	"github.com/elastic/beats/v7/libbeat/reader/readfile"
+	"github.com/elastic/beats/v7/libbeat/reader/readfile/encoding"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/version"
+)
+
+// errProcessorsFailed is returned when processors returned errors for some
+// of the sample events.
+var errProcessorsFailed = errors.New("processors returned errors")
+
+func genTestProcessorsCmd(settings instance.Settings) *cobra.Command {
+	testProcessorsCmd := &cobra.Command{
+		Use:   "processors",
+		Short: "Run sample lines through the parsers and processors of an input",
+		Long: `Reads sample lines from a file, runs them through the parsers and
+processors of the selected input, the global processors and the local ingest
+pipelines of the modules in filebeat.modules, and prints the resulting events.
+The command fails if a processor returns an error.`,
+		Run: cli.RunWith(func(cmd *cobra.Command, args []string) error {
+			inputName, _ := cmd.Flags().GetString("input")
+			path, _ := cmd.Flags().GetString("file")
+			ndjson, _ := cmd.Flags().GetBool("ndjson")
+			diff, _ := cmd.Flags().GetBool("diff")
+
+			if path == "" {
+				return errors.New("--file is required")
+			}
+
+			b, err := instance.NewInitializedBeat(settings)
+			if err != nil {
+				return fmt.Errorf("error initializing beat: %w", err)
+			}
+
+			var in *os.File
+			if path == "-" {
+				in = os.Stdin
+			} else {
+				in, err = os.Open(path)
+				if err != nil {
+					return err
+				}
+				defer in.Close()
+			}
+
+			tester, err := newProcessorsTester(b.Info, settings.Processing, b.RawConfig, inputName)
+			if err != nil {
+				return err
+			}
+			defer tester.close()
+
+			tester.diff = diff
+			tester.out = os.Stdout
+			tester.errOut = os.Stderr
+			if ndjson {
+				return tester.runNDJSON(in)
+			}
+			return tester.runLines(in, path)
+		}),
+	}
+
+	testProcessorsCmd.Flags().String("input", "", "ID or index of the input in filebeat.inputs. Defaults to the first input")
+	testProcessorsCmd.Flags().String("file", "", "File with sample lines, or - to read from stdin")
+	testProcessorsCmd.Flags().Bool("ndjson", false, "Read one JSON event per line instead of lines for the parsers")
+	testProcessorsCmd.Flags().Bool("diff", false, "Print the changes of each processor before the resulting events")
+
+	return testProcessorsCmd
+}
+
+// processorsTester runs sample events through the processors the publisher
+// pipeline would apply to the events of an input.
+type processorsTester struct {
+	inputCfg       *conf.C
+	processor      beat.Processor
+	localPipelines *ingest.Registry
+	closers        []func() error
+	trace          *processorTrace
+
+	diff   bool
+	out    io.Writer
+	errOut io.Writer
+}
+
+func newProcessorsTester(
+	info beat.Info,
+	processingFactory processing.SupportFactory,
+	rawConfig *conf.C,
+	inputName string,
+) (*processorsTester, error) {
+	inputCfg, err := selectInput(rawConfig, inputName)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &processorsTester{
+		inputCfg: inputCfg,
+		trace:    &processorTrace{},
+		out:      io.Discard,
+		errOut:   io.Discard,
+	}
+	ok := false
+	defer func() {
+		if !ok {
+			t.close()
+		}
+	}()
+
+	clientCfg, err := channel.ClientConfig(info, inputCfg)
+	if err != nil {
+		return nil, fmt.Errorf("error in input configuration: %w", err)
+	}
+	if procs, isList := clientCfg.Processing.Processor.(*processors.Processors); isList {
+		t.closers = append(t.closers, procs.Close)
+		t.traceList(procs)
+	}
+
+	var config struct {
+		Modules        []*conf.C      `config:"filebeat.modules"`
+		LocalPipelines ingest.Config  `config:"filebeat.local_pipelines"`
+		Output         conf.Namespace `config:"output"`
+	}
+	if err := rawConfig.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	// The module ingest pipelines run as the post processor of the client,
+	// after the global processors, as in filebeat/beater.
+	if config.LocalPipelines.Enabled {
+		t.localPipelines = ingest.NewRegistry(config.LocalPipelines, logp.NewLogger("ingest"))
+		t.closers = append(t.closers, t.localPipelines.Close)
+		err := loadLocalPipelines(info, t.localPipelines, config.Modules, config.Output.Name() == "elasticsearch")
+		if err != nil {
+			return nil, err
+		}
+
+		post := newTracedProcessor(t.localPipelines.Processor(), t.trace)
+		if clientCfg.Processing.PostProcessor != nil {
+			procs := processors.NewList(nil)
+			procs.AddProcessor(clientCfg.Processing.PostProcessor)
+			procs.AddProcessor(post)
+			post = procs
+		}
+		clientCfg.Processing.PostProcessor = post
+	}
+
+	if processingFactory == nil {
+		processingFactory = processing.MakeDefaultBeatSupport(true)
+	}
+	support, err := processingFactory(info, logp.NewLogger("processors"), rawConfig)
+	if err != nil {
+		return nil, err
+	}
+	t.closers = append(t.closers, support.Close)
+
+	// The global processors are created by the processing support, so that
+	// they run at the same step as in the publisher pipeline.
+	if wrapper, ok := support.(processorsWrapper); ok {
+		wrapper.WrapProcessors(func(p beat.Processor) beat.Processor {
+			return newTracedProcessor(p, t.trace)
+		})
+	}
+
+	t.processor, err = support.Create(clientCfg.Processing, false)
+	if err != nil {
+		return nil, err
+	}
+
+	ok = true
+	return t, nil
+}
+
+// processorsWrapper is implemented by processing supports that let the global
+// processors be traced.
+type processorsWrapper interface {
+	WrapProcessors(wrap func(beat.Processor) beat.Processor)
+}
+
+// loadLocalPipelines adds the ingest pipelines of the configured modules to
+// the registry. Pipelines that can't run locally are skipped if remote is
+// set, as Elasticsearch runs them.
+func loadLocalPipelines(info beat.Info, registry *ingest.Registry, modules []*conf.C, remote bool) error {
+	if len(modules) == 0 {
+		return nil
+	}
+	moduleRegistry, err := fileset.NewModuleRegistry(modules, info, true, fileset.FilesetOverrides{})
+	if err != nil {
+		return err
+	}
+	if moduleRegistry.Empty() {
+		return nil
+	}
+	v, err := version.New(info.Version)
+	if err != nil {
+		return err
+	}
+	return moduleRegistry.LoadLocalPipelines(registry, *v, remote)
+}
+
+// selectInput returns the input of filebeat.inputs with the given ID or
+// index, or the first input.
+func selectInput(rawConfig *conf.C, name string) (*conf.C, error) {
+	var config struct {
+		Inputs []*conf.C `config:"filebeat.inputs"`
+	}
+	if err := rawConfig.Unpack(&config); err != nil {
+		return nil, err
+	}
+	if len(config.Inputs) == 0 {
+		return nil, errors.New("no inputs are configured in filebeat.inputs")
+	}
+	if name == "" {
+		return config.Inputs[0], nil
+	}
+
+	for _, input := range config.Inputs {
+		if id, err := input.String("id", -1); err == nil && id == name {
+			return input, nil
+		}
+	}
+	if i, err := strconv.Atoi(name); err == nil && i >= 0 && i < len(config.Inputs) {
+		return config.Inputs[i], nil
+	}
+	return nil, fmt.Errorf("no input with ID or index %q in filebeat.inputs", name)
+}
+
+func (t *processorsTester) traceList(procs *processors.Processors) {
+	for i, p := range procs.List {
+		procs.List[i] = newTracedProcessor(p, t.trace)
+	}
+}
+
+func (t *processorsTester) close() {
+	for _, c := range t.closers {
+		_ = c()
+	}
+}
+
+// runLines reads the lines of r with the encoding and the parsers of the
+// input and processes the resulting messages.
+func (t *processorsTester) runLines(in *os.File, path string) error {
+	var config struct {
+		Encoding string        `config:"encoding"`
+		MaxBytes int           `config:"message_max_bytes"`
+		Parsers  parser.Config `config:",inline"`
+	}
+	config.MaxBytes = 10 * 1024 * 1024
+	if err := t.inputCfg.Unpack(&config); err != nil {
+		return fmt.Errorf("error in input configuration: %w", err)
+	}
+
+	encodingFactory, ok := encoding.FindEncoding(config.Encoding)
+	if !ok || encodingFactory == nil {
+		return fmt.Errorf("unknown encoding('%v')", config.Encoding)
+	}
+	enc, err := encodingFactory(in)
+	if err != nil {
+		return fmt.Errorf("failed to initialize encoding: %w", err)
+	}
+
+	var r reader.Reader
+	r, err = readfile.NewEncodeReader(in, readfile.Config{
+		Codec:      enc,
+		BufferSize: 16 * 1024,
+		Terminator: readfile.AutoLineTerminator,
+		MaxBytes:   config.MaxBytes * 4,
+	})
+	if err != nil {
+		return err
+	}
+	r = readfile.NewStripNewline(r, readfile.AutoLineTerminator)
+	if fi, err := in.Stat(); err == nil && fi.Mode().IsRegular() {
+		r = readfile.NewFilemeta(r, path, file.ExtendFileInfo(fi), "", 0)
+	}
+	r = config.Parsers.Create(r)
+	r = readfile.NewLimitReader(r, config.MaxBytes)
+	defer r.Close()
+
+	n := 0
+	for {
+		message, err := r.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return t.result()
+			}
+			return err
+		}
+		if message.IsEmpty() {
+			continue
+		}
+		n++
+		t.process(n, message.ToEvent())
+	}
+}
+
+// runNDJSON processes the JSON events of the lines of r.
+func (t *processorsTester) runNDJSON(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 10*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		var fields mapstr.M
+		if err := json.Unmarshal(scanner.Bytes(), &fields); err != nil {
+			return fmt.Errorf("line %d is not a JSON object: %w", n, err)
+		}
+
+		event := beat.Event{Timestamp: time.Now(), Fields: fields}
+		if ts, ok := fields["@timestamp"].(string); ok {
+			parsed, err := time.Parse(time.RFC3339Nano, ts)
+			if err != nil {
+				return fmt.Errorf("line %d has an invalid @timestamp: %w", n, err)
+			}
+			event.Timestamp = parsed
+			delete(fields, "@timestamp")
+		}
+		if meta, ok := fields["@metadata"].(map[string]interface{}); ok {
+			event.Meta = meta
+			delete(fields, "@metadata")
+		}
+		t.process(n, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return t.result()
+}
+
+// process runs the event through the processors and prints the resulting
+// events.
+func (t *processorsTester) process(n int, event beat.Event) {
+	t.trace.start(t.diff)
+	if t.diff {
+		fmt.Fprintf(t.out, "=== event %d\n", n)
+		writeDiff(t.out, nil, eventFields(&event))
+	}
+
+	events, err := runProcessor(t.processor, &event)
+	if err != nil {
+		t.trace.fail("", err)
+	}
+
+	for _, step := range t.trace.steps {
+		if t.diff {
+			fmt.Fprintf(t.out, "--- %s\n", step.name)
+			writeDiff(t.out, step.before, step.after)
+		}
+		if step.err != nil {
+			fmt.Fprintf(t.errOut, "event %d: processor %s failed: %v\n", n, step.name, step.err)
+		}
+	}
+
+	if len(events) == 0 {
+		fmt.Fprintf(t.errOut, "event %d: dropped\n", n)
+		return
+	}
+	if t.diff {
+		fmt.Fprintln(t.out, "--- result")
+	}
+	for _, e := range events {
+		fmt.Fprintln(t.out, eventFields(e).String())
+	}
+}
+
+// runProcessor runs the processor with RunMulti if it can turn an event into
+// multiple events, as the publisher pipeline does, and with Run otherwise.
+func runProcessor(p beat.Processor, event *beat.Event) ([]*beat.Event, error) {
+	if multi, ok := p.(beat.MultiProcessor); ok && processors.IsMulti(p) {
+		return multi.RunMulti(event)
+	}
+	out, err := p.Run(event)
+	if out == nil {
+		return nil, err
+	}
+	return []*beat.Event{out}, err
+}
+
+func (t *processorsTester) result() error {
+	if t.trace.errors > 0 {
+		return fmt.Errorf("%w (%d)", errProcessorsFailed, t.trace.errors)
+	}
+	return nil
+}
+
+// processorTrace records the changes and the errors of the traced processors
+// for the current event.
+type processorTrace struct {
+	diff   bool
+	steps  []traceStep
+	errors int
+}
+
+type traceStep struct {
+	name          string
+	before, after mapstr.M
+	err           error
+}
+
+func (t *processorTrace) start(diff bool) {
+	t.diff = diff
+	t.steps = t.steps[:0]
+}
+
+func (t *processorTrace) fail(name string, err error) {
+	// errors of traced processors are already recorded
+	for _, step := range t.steps {
+		if step.err != nil {
+			return
+		}
+	}
+	t.steps = append(t.steps, traceStep{name: name, err: err})
+	t.errors++
+}
+
+// tracedProcessor records the changes and the errors of a processor.
+type tracedProcessor struct {
+	processor beat.Processor
+	trace     *processorTrace
+}
+
+// newTracedProcessor wraps p. Processors that can turn an event into
+// multiple events are wrapped such that they are still run with RunMulti.
+func newTracedProcessor(p beat.Processor, trace *processorTrace) beat.Processor {
+	traced := tracedProcessor{processor: p, trace: trace}
+	if processors.IsMulti(p) {
+		return &tracedMultiProcessor{traced}
+	}
+	return &traced
+}
+
+func (p *tracedProcessor) Run(event *beat.Event) (*beat.Event, error) {
+	before := p.before(event)
+	out, err := p.processor.Run(event)
+	if out == nil {
+		p.record(before, nil, err)
+	} else {
+		p.record(before, []*beat.Event{out}, err)
+	}
+	return out, err
+}
+
+func (p *tracedProcessor) before(event *beat.Event) mapstr.M {
+	if !p.trace.diff {
+		return nil
+	}
+	return eventFields(event)
+}
+
+// record adds a step per resulting event to the trace.
+func (p *tracedProcessor) record(before mapstr.M, out []*beat.Event, err error) {
+	if err != nil {
+		p.trace.errors++
+	}
+	if !p.trace.diff && err == nil {
+		return
+	}
+
+	name := p.processor.String()
+	if !p.trace.diff || len(out) == 0 {
+		p.trace.steps = append(p.trace.steps, traceStep{name: name, before: before, err: err})
+		return
+	}
+	for i, e := range out {
+		step := traceStep{name: name, before: before, after: eventFields(e)}
+		if len(out) > 1 {
+			step.name = fmt.Sprintf("%s (event %d of %d)", name, i+1, len(out))
+		}
+		if i == 0 {
+			step.err = err
+		}
+		p.trace.steps = append(p.trace.steps, step)
+	}
+}
+
+func (p *tracedProcessor) String() string {
+	return p.processor.String()
+}
+
+func (p *tracedProcessor) Close() error {
+	return processors.Close(p.processor)
+}
+
+// tracedMultiProcessor records the changes and the errors of a processor
+// that can turn an event into multiple events.
+type tracedMultiProcessor struct {
+	tracedProcessor
+}
+
+func (p *tracedMultiProcessor) RunMulti(event *beat.Event) ([]*beat.Event, error) {
+	before := p.before(event)
+	out, err := processors.RunMulti(p.processor, event)
+	p.record(before, out, err)
+	return out, err
+}
+
+// eventFields returns a copy of the event fields including @timestamp and
+// @metadata, as they are sent to the outputs.
+func eventFields(event *beat.Event) mapstr.M {
+	fields := event.Fields.Clone()
+	if fields == nil {
+		fields = mapstr.M{}
+	}
+	fields["@timestamp"] = event.Timestamp.UTC().Format(time.RFC3339Nano)
+	if len(event.Meta) > 0 {
+		fields["@metadata"] = event.Meta.Clone()
+	}
+	return fields
+}
+
+// writeDiff writes the fields added (+), removed (-) and changed (~) between
+// the two events. A nil after means that the event has been dropped.
+func writeDiff(w io.Writer, before, after mapstr.M) {
+	if after == nil {
+		fmt.Fprintln(w, "  event dropped")
+		return
+	}
+
+	b, a := before.Flatten(), after.Flatten()
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, found := a[k]; !found {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		old, inBefore := b[k]
+		value, inAfter := a[k]
+		switch {
+		case !inBefore:
+			fmt.Fprintf(w, "+ %s: %s\n", k, toJSON(value))
+		case !inAfter:
+			fmt.Fprintf(w, "- %s: %s\n", k, toJSON(old))
+		case !reflect.DeepEqual(old, value):
+			fmt.Fprintf(w, "~ %s: %s -> %s\n", k, toJSON(old), toJSON(value))
+		}
+	}
+}
+
+func toJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
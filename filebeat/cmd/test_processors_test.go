@@ -0,0 +1,207 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+)
+
+const testProcessorsConfig = `
+filebeat.inputs:
+  - type: filestream
+    id: first
+    paths: [/var/log/first.log]
+  - type: filestream
+    id: app
+    paths: [/var/log/app.log]
+    tags: [app]
+    parsers:
+      - ndjson:
+          target: ""
+    processors:
+      - rename:
+          fields:
+            - {from: lvl, to: log.level}
+      - drop_event:
+          when.equals.log.level: debug
+      - script:
+          lang: javascript
+          source: >
+            function process(event) {
+              if (event.Get("fail")) { throw "boom"; }
+            }
+  - type: filestream
+    id: split
+    paths: [/var/log/split.log]
+    processors:
+      - split:
+          field: message
+          separator: ","
+  - type: filestream
+    id: pipeline
+    paths: [/var/log/pipeline.log]
+    pipeline: test-pipeline
+filebeat.local_pipelines.enabled: true
+processors:
+  - add_fields:
+      target: ""
+      fields:
+        env: test
+`
+
+func newTestProcessorsTester(t *testing.T, input string) (*processorsTester, *bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+
+	cfg, err := conf.NewConfigWithYAML([]byte(testProcessorsConfig), "test")
+	require.NoError(t, err)
+
+	tester, err := newProcessorsTester(beat.Info{Beat: "filebeat", Version: "8.0.0"}, nil, cfg, input)
+	require.NoError(t, err)
+	t.Cleanup(tester.close)
+
+	var out, errOut bytes.Buffer
+	tester.out, tester.errOut = &out, &errOut
+	return tester, &out, &errOut
+}
+
+func writeSamples(t *testing.T, lines ...string) *os.File {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "samples.log")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600))
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestProcessorsTesterLines(t *testing.T) {
+	tester, out, errOut := newTestProcessorsTester(t, "app")
+
+	f := writeSamples(t,
+		`{"lvl": "info", "msg": "started"}`,
+		`{"lvl": "debug", "msg": "noise"}`,
+	)
+	require.NoError(t, tester.runLines(f, f.Name()))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 1)
+	require.Contains(t, lines[0], `"log":{"file":{`)
+	require.Contains(t, lines[0], `"level":"info"`)
+	require.Contains(t, lines[0], `"env":"test"`)
+	require.Contains(t, lines[0], `"tags":["app"]`)
+	require.Contains(t, lines[0], `"input":{"type":"filestream"}`)
+	require.Equal(t, "event 2: dropped\n", errOut.String())
+}
+
+func TestProcessorsTesterErrors(t *testing.T) {
+	tester, out, errOut := newTestProcessorsTester(t, "1")
+
+	err := tester.runNDJSON(strings.NewReader(`{"message": "ok"}` + "\n" + `{"message": "bad", "fail": true}` + "\n"))
+	require.ErrorIs(t, err, errProcessorsFailed)
+	require.Len(t, strings.Split(strings.TrimSpace(out.String()), "\n"), 2)
+	require.Contains(t, errOut.String(), "event 2: processor script=[type=javascript")
+	require.Contains(t, errOut.String(), "boom")
+}
+
+func TestProcessorsTesterDiff(t *testing.T) {
+	tester, out, _ := newTestProcessorsTester(t, "app")
+	tester.diff = true
+
+	err := tester.runNDJSON(strings.NewReader(`{"@timestamp": "2024-01-02T03:04:05Z", "lvl": "warn"}` + "\n"))
+	require.NoError(t, err)
+
+	diff := out.String()
+	require.Contains(t, diff, "=== event 1\n")
+	require.Contains(t, diff, `+ @timestamp: "2024-01-02T03:04:05Z"`)
+	require.Contains(t, diff, "--- rename=")
+	require.Contains(t, diff, "+ log.level: \"warn\"\n- lvl: \"warn\"\n")
+	require.Contains(t, diff, "--- add_fields=")
+	require.Contains(t, diff, "+ env: \"test\"\n")
+	require.Contains(t, diff, "--- result\n")
+}
+
+func TestProcessorsTesterSplit(t *testing.T) {
+	tester, out, errOut := newTestProcessorsTester(t, "split")
+
+	f := writeSamples(t, "a,b,c", "d")
+	require.NoError(t, tester.runLines(f, f.Name()))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+	for i, msg := range []string{"a", "b", "c", "d"} {
+		require.Contains(t, lines[i], `"message":"`+msg+`"`)
+		require.Contains(t, lines[i], `"env":"test"`)
+	}
+	require.Empty(t, errOut.String())
+
+	out.Reset()
+	tester.diff = true
+	require.NoError(t, tester.runNDJSON(strings.NewReader(`{"message": "x,y"}`+"\n")))
+	diff := out.String()
+	require.Contains(t, diff, "(event 1 of 2)\n")
+	require.Contains(t, diff, "(event 2 of 2)\n")
+	require.Contains(t, diff, "--- result\n")
+}
+
+func TestSelectInput(t *testing.T) {
+	cfg, err := conf.NewConfigWithYAML([]byte(testProcessorsConfig), "test")
+	require.NoError(t, err)
+
+	for name, id := range map[string]string{"": "first", "app": "app", "0": "first", "1": "app"} {
+		input, err := selectInput(cfg, name)
+		require.NoError(t, err)
+		got, err := input.String("id", -1)
+		require.NoError(t, err)
+		require.Equal(t, id, got)
+	}
+
+	_, err = selectInput(cfg, "missing")
+	require.Error(t, err)
+}
+
+func TestProcessorsTesterLocalPipelines(t *testing.T) {
+	tester, out, errOut := newTestProcessorsTester(t, "pipeline")
+	require.NoError(t, tester.localPipelines.Add("test-pipeline", map[string]interface{}{
+		"processors": []interface{}{
+			map[string]interface{}{"rename": map[string]interface{}{"field": "env", "target_field": "labels.env"}},
+		},
+	}))
+	tester.diff = true
+
+	require.NoError(t, tester.runNDJSON(strings.NewReader(`{"message": "x"}`+"\n")))
+	require.Empty(t, errOut.String())
+
+	// The pipeline runs after the global processors, so it sees their fields.
+	diff := out.String()
+	globals := strings.Index(diff, "--- add_fields=")
+	pipelines := strings.Index(diff, "--- local_ingest_pipelines\n")
+	require.NotEqual(t, -1, globals)
+	require.Greater(t, pipelines, globals)
+	require.Contains(t, diff[pipelines:], "- env: \"test\"\n+ labels.env: \"test\"\n")
+	require.NotContains(t, diff[strings.Index(diff, "--- result\n"):], `"pipeline"`)
+}
@@ -25,6 +25,7 @@ import (
 	"sort"
 	"time"
 
+	"github.com/elastic/beats/v7/filebeat/ingest"
 	"github.com/elastic/beats/v7/libbeat/autodiscover"
 	"github.com/elastic/beats/v7/libbeat/cfgfile"
 	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
@@ -48,6 +49,7 @@ type Config struct {
 	ConfigModules      *conf.C              `config:"config.modules"`
 	Autodiscover       *autodiscover.Config `config:"autodiscover"`
 	OverwritePipelines bool                 `config:"overwrite_pipelines"`
+	LocalPipelines     ingest.Config        `config:"local_pipelines"`
 }
 
 type Registry struct {
@@ -24,6 +24,7 @@ import (
 	"github.com/gofrs/uuid"
 	"github.com/mitchellh/hashstructure"
 
+	"github.com/elastic/beats/v7/filebeat/ingest"
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/cfgfile"
 	"github.com/elastic/beats/v7/libbeat/esleg/eslegclient"
@@ -31,6 +32,7 @@ import (
 	conf "github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
 	"github.com/elastic/elastic-agent-libs/monitoring"
+	"github.com/elastic/elastic-agent-libs/version"
 )
 
 var moduleList = monitoring.NewUniqueList()
@@ -50,6 +52,7 @@ type Factory struct {
 	overwritePipelines    bool
 	pipelineCallbackID    uuid.UUID
 	inputFactory          cfgfile.RunnerFactory
+	localPipelines        *ingest.Registry
 }
 
 // Wrap an array of inputs and implements cfgfile.Runner interface
@@ -60,15 +63,19 @@ type inputsRunner struct {
 	pipelineLoaderFactory PipelineLoaderFactory
 	pipelineCallbackID    uuid.UUID
 	overwritePipelines    bool
+	localPipelines        *ingest.Registry
+	beatVersion           string
 	log                   *logp.Logger
 }
 
-// NewFactory instantiates a new Factory
+// NewFactory instantiates a new Factory. localPipelines is the registry the
+// fileset pipelines are added to when they run inside Filebeat, or nil.
 func NewFactory(
 	inputFactory cfgfile.RunnerFactory,
 	beatInfo beat.Info,
 	pipelineLoaderFactory PipelineLoaderFactory,
 	overwritePipelines bool,
+	localPipelines *ingest.Registry,
 ) *Factory {
 	return &Factory{
 		inputFactory:          inputFactory,
@@ -76,6 +83,7 @@ func NewFactory(
 		pipelineLoaderFactory: pipelineLoaderFactory,
 		pipelineCallbackID:    uuid.Nil,
 		overwritePipelines:    overwritePipelines,
+		localPipelines:        localPipelines,
 	}
 }
 
@@ -111,6 +119,8 @@ func (f *Factory) Create(p beat.PipelineConnector, c *conf.C) (cfgfile.Runner, e
 		pipelineLoaderFactory: f.pipelineLoaderFactory,
 		pipelineCallbackID:    f.pipelineCallbackID,
 		overwritePipelines:    f.overwritePipelines,
+		localPipelines:        f.localPipelines,
+		beatVersion:           f.beatInfo.Version,
 		log:                   logp.NewLogger(logName),
 	}, nil
 }
@@ -177,6 +187,18 @@ func (p *inputsRunner) Start() {
 		}
 	}
 
+	// Load the pipelines that run inside Filebeat, if enabled. Pipelines
+	// that can't run inside Filebeat are left to Elasticsearch if they
+	// have been loaded there.
+	if p.localPipelines != nil {
+		v, err := version.New(p.beatVersion)
+		if err != nil {
+			p.log.Errorf("Error loading local pipelines: %v", err)
+		} else if err := p.moduleRegistry.LoadLocalPipelines(p.localPipelines, *v, p.pipelineLoaderFactory != nil); err != nil {
+			p.log.Errorf("Error loading local pipelines: %v", err)
+		}
+	}
+
 	for _, input := range p.inputs {
 		input.Start()
 	}
@@ -19,11 +19,13 @@ package fileset
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/joeshaw/multierror"
 
+	"github.com/elastic/beats/v7/filebeat/ingest"
 	"github.com/elastic/elastic-agent-libs/logp"
 	"github.com/elastic/elastic-agent-libs/version"
 )
@@ -114,6 +116,36 @@ func (reg *ModuleRegistry) LoadPipelines(esClient PipelineLoader, overwrite bool
 	return nil
 }
 
+// LoadLocalPipelines adds the pipelines of all filesets to a registry of
+// pipelines that run inside Filebeat. The version is used to render the
+// pipelines as for an Elasticsearch cluster of that version. Pipelines that
+// can't run inside Filebeat are left to Elasticsearch if allowRemote is set,
+// and fail otherwise.
+func (reg *ModuleRegistry) LoadLocalPipelines(local *ingest.Registry, v version.V, allowRemote bool) error {
+	for _, module := range reg.registry {
+		for _, fileset := range module.filesets {
+			pipelines, err := fileset.GetPipelines(v)
+			if err != nil {
+				return fmt.Errorf("error getting pipeline for fileset %s/%s: %w", module.config.Module, fileset.name, err)
+			}
+			for _, pipeline := range pipelines {
+				if local.Has(pipeline.id) {
+					continue
+				}
+				err := local.Add(pipeline.id, pipeline.contents)
+				if errors.Is(err, ingest.ErrUnsupported) && allowRemote {
+					reg.log.Infof("Pipeline %s of fileset %s/%s runs in Elasticsearch: %v", pipeline.id, module.config.Module, fileset.name, err)
+					continue
+				}
+				if err != nil {
+					return fmt.Errorf("error loading pipeline for fileset %s/%s: %w", module.config.Module, fileset.name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
 func LoadPipeline(esClient PipelineLoader, pipelineID string, content map[string]interface{}, overwrite bool, log *logp.Logger) error {
 	path := makeIngestPipelinePath(pipelineID)
 	if !overwrite {
@@ -0,0 +1,34 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package ingest runs the Elasticsearch ingest pipelines of Filebeat
+// modules inside Filebeat, so that module events can be shipped parsed to
+// outputs other than Elasticsearch.
+package ingest
+
+// Config configures the local execution of module ingest pipelines.
+type Config struct {
+	Enabled bool        `config:"enabled"`
+	GeoIP   GeoIPConfig `config:"geoip"`
+}
+
+// GeoIPConfig configures the databases used by the geoip processor.
+type GeoIPConfig struct {
+	// DatabaseDir is the directory holding the MaxMind databases referenced
+	// by the database_file option, GeoLite2-City.mmdb by default.
+	DatabaseDir string `config:"database_dir"`
+}
@@ -0,0 +1,274 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultDateOutputFormat = "yyyy-MM-dd'T'HH:mm:ss.SSSXXX"
+
+// iso8601Layouts are the layouts tried for the ISO8601 format.
+var iso8601Layouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// dateParser parses a date in the given location.
+type dateParser func(s string, loc *time.Location) (time.Time, error)
+
+func newDate(_ *compiler, o *options) (processor, error) {
+	field, err := o.template("field", true)
+	if err != nil {
+		return nil, err
+	}
+	target, err := o.string("target_field", "@timestamp")
+	if err != nil {
+		return nil, err
+	}
+	formats, err := o.strings("formats")
+	if err != nil {
+		return nil, err
+	}
+	if len(formats) == 0 {
+		return nil, o.errorf("[formats] required property is missing")
+	}
+	timezone, err := o.template("timezone", false)
+	if err != nil {
+		return nil, err
+	}
+	outputFormat, err := o.string("output_format", defaultDateOutputFormat)
+	if err != nil {
+		return nil, err
+	}
+	o.raw("locale")
+	parsers := make([]dateParser, len(formats))
+	for i, format := range formats {
+		if parsers[i], err = newDateParser(format); err != nil {
+			return nil, unsupportedf("unsupported format %q: %v", format, err)
+		}
+	}
+	outputLayout, err := javaLayout(outputFormat, false)
+	if err != nil {
+		return nil, unsupportedf("unsupported output_format %q: %v", outputFormat, err)
+	}
+	return processorFunc(func(_ *runContext, d *document) error {
+		path := field.render(d)
+		v, found := d.get(path)
+		if !found || v == nil {
+			return fmt.Errorf("field [%s] not present as part of path [%s]", lastElement(path), path)
+		}
+		s := formatValue(v)
+		loc := time.UTC
+		if timezone != nil {
+			tz := timezone.render(d)
+			if loc, err = loadLocation(tz); err != nil {
+				return fmt.Errorf("unable to parse timezone [%s]: %w", tz, err)
+			}
+		}
+		for _, parse := range parsers {
+			t, err := parse(s, loc)
+			if err == nil {
+				return d.set(target, t.Format(outputLayout))
+			}
+		}
+		return fmt.Errorf("unable to parse date [%s]", s)
+	}), nil
+}
+
+// loadLocation loads a time zone by name or by offset, like +02:00.
+func loadLocation(tz string) (*time.Location, error) {
+	if tz == "" || tz == "Z" || tz == "UTC" {
+		return time.UTC, nil
+	}
+	if tz[0] == '+' || tz[0] == '-' {
+		for _, layout := range []string{"-07:00", "-0700", "-07"} {
+			if t, err := time.Parse(layout, tz); err == nil {
+				_, offset := t.Zone()
+				return time.FixedZone(tz, offset), nil
+			}
+		}
+	}
+	return time.LoadLocation(tz)
+}
+
+func newDateParser(format string) (dateParser, error) {
+	switch format {
+	case "ISO8601", "ISO_INSTANT", "ISO_OFFSET_DATE_TIME", "ISO_ZONED_DATE_TIME", "ISO_LOCAL_DATE_TIME",
+		"strict_date_optional_time", "date_optional_time", "strict_date_optional_time_nanos":
+		return parseISO8601, nil
+	case "UNIX", "epoch_second":
+		return parseEpoch(time.Second), nil
+	case "UNIX_MS", "epoch_millis":
+		return parseEpoch(time.Millisecond), nil
+	case "TAI64N":
+		return parseTAI64N, nil
+	}
+	layout, err := javaLayout(format, true)
+	if err != nil {
+		return nil, err
+	}
+	hasYear := strings.Contains(layout, "2006") || strings.Contains(layout, "06")
+	return func(s string, loc *time.Location) (time.Time, error) {
+		t, err := time.ParseInLocation(layout, s, loc)
+		if err != nil {
+			return t, err
+		}
+		if !hasYear {
+			t = t.AddDate(time.Now().In(loc).Year(), 0, 0)
+		}
+		return t, nil
+	}, nil
+}
+
+func parseISO8601(s string, loc *time.Location) (time.Time, error) {
+	var err error
+	for _, layout := range iso8601Layouts {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+func parseEpoch(unit time.Duration) dateParser {
+	return func(s string, loc *time.Location) (time.Time, error) {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		sec, frac := math.Modf(f * float64(unit) / float64(time.Second))
+		return time.Unix(int64(sec), int64(math.Round(frac*1e9))).In(loc), nil
+	}
+}
+
+func parseTAI64N(s string, loc *time.Location) (time.Time, error) {
+	s = strings.TrimPrefix(s, "@")
+	if len(s) != 24 {
+		return time.Time{}, fmt.Errorf("invalid TAI64N date")
+	}
+	sec, err := strconv.ParseUint(s[:16], 16, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	nsec, err := strconv.ParseUint(s[16:], 16, 32)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// TAI64 labels are offset by 2^62 and TAI is 10 seconds ahead of UTC.
+	return time.Unix(int64(sec-(1<<62))-10, int64(nsec)).In(loc), nil
+}
+
+// javaLayout converts a Java date time pattern to a Go layout. Fractions
+// of seconds are dropped from layouts used for parsing, as Go accepts them
+// after the seconds field.
+func javaLayout(pattern string, parse bool) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(pattern); {
+		c := pattern[i]
+		if c == '\'' {
+			end := strings.IndexByte(pattern[i+1:], '\'')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated quoted literal")
+			}
+			if end == 0 {
+				b.WriteByte('\'')
+			} else {
+				b.WriteString(pattern[i+1 : i+1+end])
+			}
+			i += end + 2
+			continue
+		}
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			b.WriteByte(c)
+			i++
+			continue
+		}
+		n := 1
+		for i+n < len(pattern) && pattern[i+n] == c {
+			n++
+		}
+		i += n
+		var layout string
+		switch c {
+		case 'y', 'u':
+			layout = "2006"
+			if n == 2 {
+				layout = "06"
+			}
+		case 'M':
+			layout = [...]string{"1", "01", "Jan", "January"}[min(n, 4)-1]
+		case 'd':
+			layout = [...]string{"2", "02"}[min(n, 2)-1]
+		case 'D':
+			layout = "002"
+		case 'E':
+			layout = "Mon"
+			if n >= 4 {
+				layout = "Monday"
+			}
+		case 'a':
+			layout = "PM"
+		case 'H':
+			layout = "15"
+		case 'h':
+			layout = [...]string{"3", "03"}[min(n, 2)-1]
+		case 'm':
+			layout = [...]string{"4", "04"}[min(n, 2)-1]
+		case 's':
+			layout = [...]string{"5", "05"}[min(n, 2)-1]
+		case 'S':
+			s := b.String()
+			if !strings.HasSuffix(s, ".") && !strings.HasSuffix(s, ",") {
+				return "", fmt.Errorf("fraction of second must follow a separator")
+			}
+			if parse {
+				b.Reset()
+				b.WriteString(s[:len(s)-1])
+				continue
+			}
+			layout = strings.Repeat("0", n)
+		case 'Z':
+			layout = "-0700"
+			if n == 2 || n == 5 {
+				layout = "Z07:00"
+			}
+		case 'X':
+			layout = [...]string{"Z07", "Z0700", "Z07:00"}[min(n, 3)-1]
+		case 'x':
+			layout = [...]string{"-07", "-0700", "-07:00"}[min(n, 3)-1]
+		case 'z':
+			layout = "MST"
+		default:
+			return "", fmt.Errorf("unsupported pattern letter %q", c)
+		}
+		b.WriteString(layout)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,51 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJavaLayout(t *testing.T) {
+	tests := []struct {
+		pattern string
+		parse   string
+		format  string
+	}{
+		{"yyyy-MM-dd'T'HH:mm:ss.SSSXXX", "2006-01-02T15:04:05Z07:00", "2006-01-02T15:04:05.000Z07:00"},
+		{"dd/MMM/yyyy:HH:mm:ss Z", "02/Jan/2006:15:04:05 -0700", "02/Jan/2006:15:04:05 -0700"},
+		{"MMM  d HH:mm:ss", "Jan  2 15:04:05", "Jan  2 15:04:05"},
+		{"EEE MMM dd HH:mm:ss yyyy", "Mon Jan 02 15:04:05 2006", "Mon Jan 02 15:04:05 2006"},
+		{"yy-M-d h:m:s a ''z''", "06-1-2 3:4:5 PM 'MST'", "06-1-2 3:4:5 PM 'MST'"},
+	}
+	for _, tc := range tests {
+		layout, err := javaLayout(tc.pattern, true)
+		if assert.NoError(t, err, tc.pattern) {
+			assert.Equal(t, tc.parse, layout, tc.pattern)
+		}
+		layout, err = javaLayout(tc.pattern, false)
+		if assert.NoError(t, err, tc.pattern) {
+			assert.Equal(t, tc.format, layout, tc.pattern)
+		}
+	}
+
+	_, err := javaLayout("yyyy-MM-dd G", true)
+	assert.Error(t, err)
+}
@@ -0,0 +1,444 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/beat/events"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// timestampLayout is the layout used to render dates in documents. It
+// matches the default output format of the date processor.
+const timestampLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// document is an event being processed by a pipeline. Like an Elasticsearch
+// ingest document, the source holds the event fields including @timestamp
+// and the _id and _index metadata fields, while the _ingest metadata is kept
+// apart. Maps are map[string]interface{} and lists are *list so that
+// scripts and processors share the same representation.
+type document struct {
+	source  map[string]interface{}
+	ingest  map[string]interface{}
+	dropped bool
+}
+
+// list is a list of a document. It is a pointer so that appending to a list
+// nested in the document doesn't require updating its parent.
+type list struct {
+	items []interface{}
+}
+
+func newDocument(event *beat.Event) *document {
+	source := make(map[string]interface{}, len(event.Fields)+1)
+	for k, v := range event.Fields {
+		source[k] = toDoc(v)
+	}
+	source[beat.TimestampFieldKey] = event.Timestamp.UTC().Format(timestampLayout)
+	if id, err := events.GetMetaStringValue(*event, events.FieldMetaID); err == nil && id != "" {
+		source["_id"] = id
+	}
+	if index, err := events.GetMetaStringValue(*event, events.FieldMetaRawIndex); err == nil && index != "" {
+		source["_index"] = index
+	}
+	return &document{
+		source: source,
+		ingest: map[string]interface{}{
+			"timestamp": time.Now().UTC().Format(time.RFC3339Nano),
+		},
+	}
+}
+
+// apply writes the document back to the event.
+func (d *document) apply(event *beat.Event) {
+	source := d.source
+	if ts, found := source[beat.TimestampFieldKey]; found {
+		if t, ok := parseTimestamp(ts); ok {
+			event.Timestamp = t
+		}
+		delete(source, beat.TimestampFieldKey)
+	}
+	if id, found := source["_id"]; found {
+		if s, ok := id.(string); ok {
+			if event.Meta == nil {
+				event.Meta = mapstr.M{}
+			}
+			event.Meta[events.FieldMetaID] = s
+		}
+		delete(source, "_id")
+	}
+	if index, found := source["_index"]; found {
+		if s, ok := index.(string); ok {
+			if event.Meta == nil {
+				event.Meta = mapstr.M{}
+			}
+			event.Meta[events.FieldMetaRawIndex] = s
+		}
+		delete(source, "_index")
+	}
+	event.Fields = fromDoc(source).(mapstr.M)
+}
+
+func parseTimestamp(v interface{}) (time.Time, bool) {
+	switch v := v.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return t, err == nil
+	case time.Time:
+		return v, true
+	}
+	return time.Time{}, false
+}
+
+// toDoc converts an event value to its document representation.
+func toDoc(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, string, bool, int64, float64:
+		return v
+	case mapstr.M:
+		return toDocMap(v)
+	case map[string]interface{}:
+		return toDocMap(v)
+	case []interface{}:
+		l := &list{items: make([]interface{}, len(v))}
+		for i, item := range v {
+			l.items[i] = toDoc(item)
+		}
+		return l
+	case *list:
+		return v
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint:
+		return int64(v)
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case uint64:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case time.Time:
+		return v.UTC().Format(timestampLayout)
+	case net.IP:
+		return v.String()
+	case []byte:
+		return string(v)
+	case fmt.Stringer:
+		return v.String()
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		l := &list{items: make([]interface{}, rv.Len())}
+		for i := range l.items {
+			l.items[i] = toDoc(rv.Index(i).Interface())
+		}
+		return l
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			m := make(map[string]interface{}, rv.Len())
+			iter := rv.MapRange()
+			for iter.Next() {
+				m[iter.Key().String()] = toDoc(iter.Value().Interface())
+			}
+			return m
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+func toDocMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = toDoc(v)
+	}
+	return out
+}
+
+// fromDoc converts a document value back to an event value.
+func fromDoc(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(mapstr.M, len(v))
+		for k, item := range v {
+			m[k] = fromDoc(item)
+		}
+		return m
+	case *list:
+		l := make([]interface{}, len(v.items))
+		for i, item := range v.items {
+			l[i] = fromDoc(item)
+		}
+		return l
+	}
+	return v
+}
+
+// deepCopy copies maps and lists so that values can be copied between
+// fields without sharing.
+func deepCopy(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[k] = deepCopy(item)
+		}
+		return m
+	case *list:
+		l := &list{items: make([]interface{}, len(v.items))}
+		for i, item := range v.items {
+			l.items[i] = deepCopy(item)
+		}
+		return l
+	}
+	return v
+}
+
+// root returns the map holding the path and the path relative to it.
+func (d *document) root(path string) (map[string]interface{}, string) {
+	switch {
+	case path == "_ingest" || strings.HasPrefix(path, "_ingest."):
+		return d.ingest, strings.TrimPrefix(strings.TrimPrefix(path, "_ingest"), ".")
+	case strings.HasPrefix(path, "_source."):
+		return d.source, path[len("_source."):]
+	}
+	return d.source, path
+}
+
+// get returns the value of a field. Path elements are separated by dots,
+// numeric elements index lists.
+func (d *document) get(path string) (interface{}, bool) {
+	var cur interface{}
+	root, rel := d.root(path)
+	cur = root
+	if rel == "" {
+		return root, true
+	}
+	for _, key := range strings.Split(rel, ".") {
+		switch c := cur.(type) {
+		case map[string]interface{}:
+			v, found := c[key]
+			if !found {
+				return nil, false
+			}
+			cur = v
+		case *list:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(c.items) {
+				return nil, false
+			}
+			cur = c.items[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func (d *document) has(path string) bool {
+	_, found := d.get(path)
+	return found
+}
+
+// getString returns the value of a string field. It fails when the field is
+// missing, unless ignoreMissing is set, or when it isn't a string.
+func (d *document) getString(path string, ignoreMissing bool) (string, bool, error) {
+	v, found := d.get(path)
+	if !found || v == nil {
+		if ignoreMissing {
+			return "", false, nil
+		}
+		if !found {
+			return "", false, fmt.Errorf("field [%s] not present as part of path [%s]", lastElement(path), path)
+		}
+		return "", false, fmt.Errorf("field [%s] is null, cannot process it", path)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", false, fmt.Errorf("field [%s] of type [%s] cannot be cast to [java.lang.String]", path, javaType(v))
+	}
+	return s, true, nil
+}
+
+// set sets the value of a field, creating the intermediate objects.
+func (d *document) set(path string, v interface{}) error {
+	root, rel := d.root(path)
+	if rel == "" {
+		return fmt.Errorf("cannot set [%s]", path)
+	}
+	keys := strings.Split(rel, ".")
+	var cur interface{} = root
+	for i, key := range keys {
+		last := i == len(keys)-1
+		switch c := cur.(type) {
+		case map[string]interface{}:
+			if last {
+				c[key] = v
+				return nil
+			}
+			next, found := c[key]
+			if !found || next == nil {
+				next = map[string]interface{}{}
+				c[key] = next
+			}
+			cur = next
+		case *list:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(c.items) {
+				return fmt.Errorf("[%s] is not an integer, cannot be used as an index as part of path [%s]", key, path)
+			}
+			if last {
+				c.items[idx] = v
+				return nil
+			}
+			cur = c.items[idx]
+		default:
+			return fmt.Errorf("cannot set [%s] with parent object of type [%s] as part of path [%s]", key, javaType(cur), path)
+		}
+	}
+	return nil
+}
+
+// remove removes a field and reports whether it existed.
+func (d *document) remove(path string) bool {
+	var container interface{}
+	key := path
+	if i := strings.LastIndexByte(path, '.'); i >= 0 {
+		var found bool
+		if container, found = d.get(path[:i]); !found {
+			return false
+		}
+		key = path[i+1:]
+	} else {
+		container, _ = d.root(path)
+	}
+	switch c := container.(type) {
+	case map[string]interface{}:
+		if _, found := c[key]; !found {
+			return false
+		}
+		delete(c, key)
+		return true
+	case *list:
+		idx, err := strconv.Atoi(key)
+		if err != nil || idx < 0 || idx >= len(c.items) {
+			return false
+		}
+		c.items = append(c.items[:idx], c.items[idx+1:]...)
+		return true
+	}
+	return false
+}
+
+// appendValue appends values to a field, converting it to a list if needed.
+func (d *document) appendValue(path string, values []interface{}, allowDuplicates bool) error {
+	cur, found := d.get(path)
+	l, isList := cur.(*list)
+	switch {
+	case isList:
+	case cur == nil:
+		l = &list{}
+		if found {
+			l.items = append(l.items, nil)
+		}
+	default:
+		l = &list{items: []interface{}{cur}}
+	}
+	for _, v := range values {
+		if !allowDuplicates && containsValue(l.items, v) {
+			continue
+		}
+		l.items = append(l.items, v)
+	}
+	if isList {
+		return nil
+	}
+	return d.set(path, l)
+}
+
+func containsValue(items []interface{}, v interface{}) bool {
+	for _, item := range items {
+		if reflect.DeepEqual(item, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func lastElement(path string) string {
+	if i := strings.LastIndexByte(path, '.'); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
+
+// javaType returns the Java type name of a value as used in the error
+// messages of Elasticsearch.
+func javaType(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "java.lang.String"
+	case bool:
+		return "java.lang.Boolean"
+	case int64:
+		return "java.lang.Long"
+	case float64:
+		return "java.lang.Double"
+	case map[string]interface{}:
+		return "java.util.HashMap"
+	case *list:
+		return "java.util.ArrayList"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// formatValue renders a value in templates and string conversions.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(fromDoc(v))
+}
@@ -0,0 +1,263 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+const defaultGeoIPDatabase = "GeoLite2-City.mmdb"
+
+var (
+	geoIPCityProperties    = []string{"continent_name", "country_iso_code", "country_name", "region_iso_code", "region_name", "city_name", "location"}
+	geoIPCountryProperties = []string{"continent_name", "country_iso_code", "country_name"}
+	geoIPASNProperties     = []string{"ip", "asn", "organization_name", "network"}
+)
+
+var errGeoIPUnavailable = errors.New("database unavailable")
+
+// geoIPDatabases opens the MaxMind databases of a directory on first use.
+type geoIPDatabases struct {
+	dir string
+	log *logp.Logger
+
+	mu      sync.Mutex
+	readers map[string]*maxminddb.Reader
+}
+
+func newGeoIPDatabases(dir string, log *logp.Logger) *geoIPDatabases {
+	return &geoIPDatabases{dir: dir, log: log, readers: map[string]*maxminddb.Reader{}}
+}
+
+// get returns the reader of a database file. Files that can't be opened
+// are reported once and then remembered as unavailable.
+func (g *geoIPDatabases) get(file string) (*maxminddb.Reader, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if r, found := g.readers[file]; found {
+		if r == nil {
+			return nil, errGeoIPUnavailable
+		}
+		return r, nil
+	}
+	if g.dir == "" {
+		g.log.Warnf("GeoIP database %s is unavailable: geoip.database_dir is not set", file)
+		g.readers[file] = nil
+		return nil, errGeoIPUnavailable
+	}
+	r, err := maxminddb.Open(filepath.Join(g.dir, file))
+	if err != nil {
+		g.log.Warnf("GeoIP database %s is unavailable: %v", file, err)
+		g.readers[file] = nil
+		return nil, errGeoIPUnavailable
+	}
+	g.readers[file] = r
+	return r, nil
+}
+
+func (g *geoIPDatabases) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var errs []error
+	for file, r := range g.readers {
+		if r != nil {
+			errs = append(errs, r.Close())
+		}
+		delete(g.readers, file)
+	}
+	return errors.Join(errs...)
+}
+
+type geoIPNames struct {
+	IsoCode string            `maxminddb:"iso_code"`
+	Code    string            `maxminddb:"code"`
+	Names   map[string]string `maxminddb:"names"`
+}
+
+type geoIPRecord struct {
+	Continent    geoIPNames   `maxminddb:"continent"`
+	Country      geoIPNames   `maxminddb:"country"`
+	Subdivisions []geoIPNames `maxminddb:"subdivisions"`
+	City         geoIPNames   `maxminddb:"city"`
+	Location     struct {
+		Latitude  *float64 `maxminddb:"latitude"`
+		Longitude *float64 `maxminddb:"longitude"`
+		TimeZone  string   `maxminddb:"time_zone"`
+	} `maxminddb:"location"`
+
+	ASN          uint   `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+func newGeoIP(c *compiler, o *options) (processor, error) {
+	f, err := o.fieldOptions()
+	if err != nil {
+		return nil, err
+	}
+	target := "geoip"
+	if f.targetField != nil {
+		if !f.targetField.static() {
+			return nil, o.errorf("[target_field] must not be a template")
+		}
+		target = f.targetField.render(nil)
+	}
+	file, err := o.string("database_file", defaultGeoIPDatabase)
+	if err != nil {
+		return nil, err
+	}
+	properties, err := o.strings("properties")
+	if err != nil {
+		return nil, err
+	}
+	firstOnly, err := o.bool("first_only", true)
+	if err != nil {
+		return nil, err
+	}
+	o.raw("download_database_on_pipeline_creation")
+	if len(properties) == 0 {
+		switch {
+		case strings.Contains(file, "ASN"):
+			properties = geoIPASNProperties
+		case strings.Contains(file, "Country"):
+			properties = geoIPCountryProperties
+		default:
+			properties = geoIPCityProperties
+		}
+	}
+	databases := c.registry.geoip
+	return processorFunc(func(_ *runContext, d *document) error {
+		field := f.field.render(d)
+		v, found := d.get(field)
+		if !found || v == nil {
+			if f.ignoreMissing {
+				return nil
+			}
+			return fmt.Errorf("field [%s] not present as part of path [%s]", lastElement(field), field)
+		}
+		reader, err := databases.get(file)
+		if err != nil {
+			return d.appendValue("tags", []interface{}{"_geoip_database_unavailable_" + file}, false)
+		}
+		items, isList := asList(v)
+		if !isList {
+			items = []interface{}{v}
+		}
+		var results []interface{}
+		for _, item := range items {
+			s, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("field [%s] of type [%s] cannot be cast to [java.lang.String]", field, javaType(item))
+			}
+			info, err := lookupGeoIP(reader, s, properties)
+			if err != nil {
+				return err
+			}
+			if info == nil {
+				if !isList {
+					return nil
+				}
+				continue
+			}
+			if !isList || firstOnly {
+				return d.set(target, info)
+			}
+			results = append(results, info)
+		}
+		if len(results) == 0 {
+			return nil
+		}
+		return d.set(target, toDoc(results))
+	}), nil
+}
+
+// lookupGeoIP returns the properties of an IP address, or nil if it isn't
+// in the database.
+func lookupGeoIP(reader *maxminddb.Reader, s string, properties []string) (map[string]interface{}, error) {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("'%s' is not an IP string literal.", s)
+	}
+	var rec geoIPRecord
+	network, found, err := reader.LookupNetwork(ip, &rec)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, nil
+	}
+	info := map[string]interface{}{}
+	put := func(k, v string) {
+		if v != "" {
+			info[k] = v
+		}
+	}
+	for _, p := range properties {
+		switch p {
+		case "ip":
+			info["ip"] = s
+		case "continent_code":
+			put(p, rec.Continent.Code)
+		case "continent_name":
+			put(p, rec.Continent.Names["en"])
+		case "country_iso_code":
+			put(p, rec.Country.IsoCode)
+		case "country_name":
+			put(p, rec.Country.Names["en"])
+		case "region_iso_code":
+			if len(rec.Subdivisions) > 0 && rec.Subdivisions[0].IsoCode != "" {
+				info[p] = rec.Country.IsoCode + "-" + rec.Subdivisions[0].IsoCode
+			}
+		case "region_name":
+			if len(rec.Subdivisions) > 0 {
+				put(p, rec.Subdivisions[0].Names["en"])
+			}
+		case "city_name":
+			put(p, rec.City.Names["en"])
+		case "timezone":
+			put(p, rec.Location.TimeZone)
+		case "location":
+			if rec.Location.Latitude != nil && rec.Location.Longitude != nil {
+				info[p] = map[string]interface{}{
+					"lat": *rec.Location.Latitude,
+					"lon": *rec.Location.Longitude,
+				}
+			}
+		case "asn":
+			if rec.ASN != 0 {
+				info[p] = int64(rec.ASN)
+			}
+		case "organization_name":
+			put(p, rec.Organization)
+		case "network":
+			info[p] = network.String()
+		}
+	}
+	if len(info) == 0 {
+		return nil, nil
+	}
+	return info, nil
+}
@@ -0,0 +1,80 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/elastic/beats/v7/libbeat/processors/grok"
+)
+
+func newGrok(_ *compiler, o *options) (processor, error) {
+	f, err := o.fieldOptions()
+	if err != nil {
+		return nil, err
+	}
+	patterns, err := o.strings("patterns")
+	if err != nil {
+		return nil, err
+	}
+	if len(patterns) == 0 {
+		return nil, o.errorf("[patterns] List of patterns must not be empty")
+	}
+	definitions, err := o.stringMap("pattern_definitions")
+	if err != nil {
+		return nil, err
+	}
+	traceMatch, err := o.bool("trace_match", false)
+	if err != nil {
+		return nil, err
+	}
+	o.raw("ecs_compatibility")
+	expressions := make([]*grok.Expression, len(patterns))
+	for i, pattern := range patterns {
+		if expressions[i], err = grok.Compile(pattern, definitions); err != nil {
+			return nil, unsupportedf("unsupported pattern %q: %v", pattern, err)
+		}
+	}
+	return processorFunc(func(_ *runContext, d *document) error {
+		field := f.field.render(d)
+		s, found, err := d.getString(field, f.ignoreMissing)
+		if err != nil || !found {
+			return err
+		}
+		for i, e := range expressions {
+			captures, matched, err := e.Match(s)
+			if err != nil {
+				return err
+			}
+			if !matched {
+				continue
+			}
+			for _, k := range sortedKeys(captures) {
+				if err := d.set(k, captures[k]); err != nil {
+					return err
+				}
+			}
+			if traceMatch && len(expressions) > 1 {
+				d.ingest["_grok_match_index"] = strconv.Itoa(i)
+			}
+			return nil
+		}
+		return fmt.Errorf("Provided Grok expressions do not match field value: [%s]", s)
+	}), nil
+}
@@ -0,0 +1,208 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// options gives typed access to the options of a processor definition. The
+// definitions are read as plain maps rather than unpacked with go-ucfg, as
+// ingest pipelines use dotted field names and ${} expressions that must be
+// kept as is.
+type options struct {
+	typ  string
+	m    map[string]interface{}
+	used map[string]bool
+}
+
+func newOptions(typ string, m map[string]interface{}) *options {
+	return &options{typ: typ, m: m, used: map[string]bool{}}
+}
+
+func (o *options) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("[%s] processor: %s", o.typ, fmt.Sprintf(format, args...))
+}
+
+func (o *options) raw(key string) (interface{}, bool) {
+	o.used[key] = true
+	v, found := o.m[key]
+	return v, found && v != nil
+}
+
+func (o *options) string(key, def string) (string, error) {
+	v, found := o.raw(key)
+	if !found {
+		return def, nil
+	}
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case int, int64, float64, bool:
+		return fmt.Sprint(v), nil
+	}
+	return "", o.errorf("[%s] must be a string", key)
+}
+
+func (o *options) requiredString(key string) (string, error) {
+	s, err := o.string(key, "")
+	if err != nil {
+		return "", err
+	}
+	if s == "" {
+		return "", o.errorf("[%s] required property is missing", key)
+	}
+	return s, nil
+}
+
+func (o *options) bool(key string, def bool) (bool, error) {
+	v, found := o.raw(key)
+	if !found {
+		return def, nil
+	}
+	switch v := v.(type) {
+	case bool:
+		return v, nil
+	case string:
+		switch strings.ToLower(v) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return false, o.errorf("[%s] must be a boolean", key)
+}
+
+func (o *options) strings(key string) ([]string, error) {
+	v, found := o.raw(key)
+	if !found {
+		return nil, nil
+	}
+	switch v := v.(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, o.errorf("[%s] must be a list of strings", key)
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	}
+	return nil, o.errorf("[%s] must be a string or a list of strings", key)
+}
+
+func (o *options) stringMap(key string) (map[string]string, error) {
+	v, found := o.raw(key)
+	if !found {
+		return nil, nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, o.errorf("[%s] must be an object", key)
+	}
+	out := make(map[string]string, len(m))
+	for k, item := range m {
+		s, ok := item.(string)
+		if !ok {
+			return nil, o.errorf("[%s.%s] must be a string", key, k)
+		}
+		out[k] = s
+	}
+	return out, nil
+}
+
+func (o *options) template(key string, required bool) (*template, error) {
+	s, err := o.string(key, "")
+	if err != nil {
+		return nil, err
+	}
+	if s == "" {
+		if required {
+			return nil, o.errorf("[%s] required property is missing", key)
+		}
+		return nil, nil
+	}
+	return compileTemplate(s)
+}
+
+// params returns an object option converted to its document representation.
+func (o *options) params(key string) (map[string]interface{}, error) {
+	v, found := o.raw(key)
+	if !found {
+		return map[string]interface{}{}, nil
+	}
+	m, ok := toDoc(v).(map[string]interface{})
+	if !ok {
+		return nil, o.errorf("[%s] must be an object", key)
+	}
+	return m, nil
+}
+
+// fieldOptions are the options of processors that transform a field.
+type fieldOptions struct {
+	field         *template
+	targetField   *template
+	ignoreMissing bool
+}
+
+func (o *options) fieldOptions() (fieldOptions, error) {
+	var f fieldOptions
+	var err error
+	if f.field, err = o.template("field", true); err != nil {
+		return f, err
+	}
+	if f.targetField, err = o.template("target_field", false); err != nil {
+		return f, err
+	}
+	f.ignoreMissing, err = o.bool("ignore_missing", false)
+	return f, err
+}
+
+func (f fieldOptions) paths(d *document) (field, target string) {
+	field = f.field.render(d)
+	target = field
+	if f.targetField != nil {
+		target = f.targetField.render(d)
+	}
+	return field, target
+}
+
+// unused returns the options that were not read by the processor.
+func (o *options) unused() []string {
+	var keys []string
+	for k := range o.m {
+		if !o.used[k] {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func asList(v interface{}) ([]interface{}, bool) {
+	l, ok := v.(*list)
+	if !ok {
+		return nil, false
+	}
+	return l.items, true
+}
@@ -0,0 +1,1564 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// script is a Painless script of a processor condition or of a script
+// processor. Local pipelines run a bounded subset of Painless, enough for the
+// conditions and the simple scripts of the module pipelines:
+//
+//   - null, boolean, number and string literals, list and map initializers,
+//     ctx, params and local variables,
+//   - field access, with the null safe ?. operator, and list and map indexing,
+//   - arithmetic, comparison, equality, instanceof, casts, the logical
+//     operators and the conditional operator,
+//   - assignments to variables, fields and list or map elements,
+//   - if and else, for each loops, return, break, continue, try and catch, and
+//     functions declared in the script,
+//   - the methods listed in scriptMethods and the static methods listed in
+//     scriptStatics.
+//
+// Scripts using other constructs, like regular expressions, while loops or
+// unknown methods, fail to compile and their pipeline is left to
+// Elasticsearch. Loops only iterate over copies of collections, and
+// executions are limited to maxScriptSteps statements and to maxScriptDepth
+// nested calls, so that scripts always terminate.
+type script struct {
+	funcs map[string]*scriptFunc
+	body  []scriptStmt
+}
+
+type scriptFunc struct {
+	name   string
+	params []string
+	body   *blockStmt
+}
+
+const (
+	maxScriptSteps = 1_000_000
+	maxScriptDepth = 100
+)
+
+// scriptKeywords can't be used as names.
+var scriptKeywords = stringSet([]string{
+	"if", "else", "for", "while", "do", "return", "break", "continue", "try",
+	"catch", "throw", "new", "null", "true", "false", "instanceof", "switch",
+	"case", "default", "class", "this",
+})
+
+// scriptTypes are the types that can be used in declarations and casts.
+var scriptTypes = stringSet([]string{
+	"def", "void", "boolean", "byte", "short", "int", "long", "float", "double",
+	"Object", "String", "Boolean", "Number", "Integer", "Long", "Float",
+	"Double", "Map", "HashMap", "List", "ArrayList", "Collection", "Set",
+	"StringTokenizer", "Exception",
+})
+
+var scriptInstanceOf = stringSet([]string{
+	"def", "Object", "String", "Boolean", "Number", "Integer", "Long", "Float",
+	"Double", "Map", "HashMap", "List", "ArrayList", "Collection",
+})
+
+var scriptConstructors = stringSet([]string{"ArrayList", "HashMap", "StringTokenizer"})
+
+var scriptStatics = map[string]map[string]bool{
+	"Integer": stringSet([]string{"parseInt", "valueOf", "toString"}),
+	"Long":    stringSet([]string{"parseLong", "valueOf", "toString"}),
+	"Double":  stringSet([]string{"parseDouble", "valueOf", "toString"}),
+	"Boolean": stringSet([]string{"parseBoolean", "valueOf", "toString"}),
+	"String":  stringSet([]string{"valueOf", "join"}),
+	"Math":    stringSet([]string{"abs", "min", "max", "round", "floor", "ceil"}),
+}
+
+// scriptMethods are the methods that can be called on values. Whether a
+// method applies to a value is checked when it's called.
+var scriptMethods = stringSet([]string{
+	// Strings.
+	"contains", "startsWith", "endsWith", "toLowerCase", "toUpperCase", "trim",
+	"length", "isEmpty", "substring", "indexOf", "lastIndexOf", "replace",
+	"equalsIgnoreCase", "splitOnToken",
+	// Lists, maps and their views.
+	"size", "get", "add", "addAll", "set", "remove", "removeIf", "clear",
+	"containsKey", "put", "getOrDefault", "keySet", "values",
+	// String tokenizers.
+	"hasMoreTokens", "nextToken", "countTokens",
+	// Exceptions.
+	"getMessage",
+	// All values.
+	"equals", "toString",
+})
+
+type (
+	scriptStmt interface{}
+
+	exprStmt struct{ x scriptExpr }
+	declStmt struct {
+		typ    string
+		names  []string
+		values []scriptExpr
+	}
+	blockStmt struct{ stmts []scriptStmt }
+	ifStmt    struct {
+		cond      scriptExpr
+		then, els scriptStmt
+	}
+	forEachStmt struct {
+		name string
+		x    scriptExpr
+		body scriptStmt
+	}
+	returnStmt   struct{ x scriptExpr }
+	breakStmt    struct{}
+	continueStmt struct{}
+	tryStmt      struct {
+		body    *blockStmt
+		catches []catchClause
+	}
+	catchClause struct {
+		class, name string
+		body        *blockStmt
+	}
+)
+
+type (
+	scriptExpr interface{}
+
+	literalExpr struct{ v interface{} }
+	varExpr     struct{ name string }
+	fieldExpr   struct {
+		x        scriptExpr
+		name     string
+		nullSafe bool
+	}
+	indexExpr struct{ x, index scriptExpr }
+	callExpr  struct {
+		x        scriptExpr
+		name     string
+		args     []scriptExpr
+		nullSafe bool
+	}
+	staticCallExpr struct {
+		class, name string
+		args        []scriptExpr
+	}
+	funcCallExpr struct {
+		name string
+		args []scriptExpr
+	}
+	newExpr struct {
+		class string
+		args  []scriptExpr
+	}
+	listInitExpr struct{ items []scriptExpr }
+	mapInitExpr  struct{ keys, values []scriptExpr }
+	unaryExpr    struct {
+		op string
+		x  scriptExpr
+	}
+	binaryExpr struct {
+		op   string
+		x, y scriptExpr
+	}
+	condExpr       struct{ cond, then, els scriptExpr }
+	instanceOfExpr struct {
+		x     scriptExpr
+		class string
+	}
+	castExpr struct {
+		class string
+		x     scriptExpr
+	}
+	assignExpr struct {
+		target scriptExpr
+		op     string
+		value  scriptExpr
+	}
+	incExpr struct {
+		target  scriptExpr
+		delta   int64
+		postfix bool
+	}
+	lambdaExpr struct {
+		param string
+		body  scriptExpr
+	}
+)
+
+// Runtime values, besides the document values.
+type (
+	// mapView is the live view of the keys or of the values of a map.
+	mapView struct {
+		m      map[string]interface{}
+		values bool
+	}
+	stringTokenizer struct {
+		tokens []string
+	}
+	scriptLambda struct {
+		param string
+		body  scriptExpr
+		env   *scriptEnv
+	}
+)
+
+// scriptException is an exception thrown by a script, that can be caught by
+// the script.
+type scriptException struct {
+	class   string
+	message string
+}
+
+func (e *scriptException) Error() string {
+	if e.message == "" {
+		return e.class
+	}
+	return e.class + ": " + e.message
+}
+
+func throwf(class, format string, args ...interface{}) error {
+	return &scriptException{class: class, message: fmt.Sprintf(format, args...)}
+}
+
+func nullPointer(what string) error {
+	return throwf("NullPointerException", "cannot %s of null", what)
+}
+
+// compileScript compiles a script, returning unsupported errors for scripts
+// outside of the subset.
+func compileScript(src string) (*script, error) {
+	s, err := parseScript(src)
+	if err != nil {
+		return nil, unsupportedf("unsupported script: %v", err)
+	}
+	return s, nil
+}
+
+// compileScriptOptions compiles the script described by the options of a
+// script processor or of a condition.
+func compileScriptOptions(o *options) (*script, map[string]interface{}, error) {
+	lang, err := o.string("lang", "painless")
+	if err != nil {
+		return nil, nil, err
+	}
+	if lang != "painless" {
+		return nil, nil, unsupportedf("script language [%s] is not supported", lang)
+	}
+	if id, _ := o.string("id", ""); id != "" {
+		return nil, nil, unsupportedf("stored scripts are not supported")
+	}
+	source, err := o.string("source", "")
+	if err != nil {
+		return nil, nil, err
+	}
+	if source == "" {
+		// Old pipelines use inline instead of source.
+		if source, err = o.requiredString("inline"); err != nil {
+			return nil, nil, err
+		}
+	}
+	params, err := o.params("params")
+	if err != nil {
+		return nil, nil, err
+	}
+	s, err := compileScript(source)
+	return s, params, err
+}
+
+// execute runs the script with the given context and parameters. It returns
+// the value of the script, which is the value of its last statement if it is
+// an expression.
+func (s *script) execute(ctx map[string]interface{}, params map[string]interface{}) (interface{}, error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	e := &scriptEnv{
+		script: s,
+		scopes: []map[string]interface{}{{"ctx": ctx, "params": params}},
+		steps:  new(int),
+	}
+	for i, st := range s.body {
+		if x, ok := st.(*exprStmt); ok && i == len(s.body)-1 {
+			return e.eval(x.x)
+		}
+		f, err := e.exec(st)
+		if err != nil {
+			return nil, err
+		}
+		switch f {
+		case flowReturn:
+			return e.result, nil
+		case flowBreak, flowContinue:
+			return nil, errors.New("break or continue outside of a loop")
+		}
+	}
+	return nil, nil
+}
+
+type scriptFlow int
+
+const (
+	flowNext scriptFlow = iota
+	flowBreak
+	flowContinue
+	flowReturn
+)
+
+type scriptEnv struct {
+	script *script
+	scopes []map[string]interface{}
+	depth  int
+	steps  *int
+	result interface{}
+}
+
+func (e *scriptEnv) lookup(name string) (interface{}, bool) {
+	for i := len(e.scopes) - 1; i >= 0; i-- {
+		if v, found := e.scopes[i][name]; found {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func (e *scriptEnv) define(name string, v interface{}) {
+	e.scopes[len(e.scopes)-1][name] = v
+}
+
+func (e *scriptEnv) push() { e.scopes = append(e.scopes, map[string]interface{}{}) }
+
+func (e *scriptEnv) pop() { e.scopes = e.scopes[:len(e.scopes)-1] }
+
+func (e *scriptEnv) exec(st scriptStmt) (scriptFlow, error) {
+	if *e.steps++; *e.steps > maxScriptSteps {
+		return flowNext, fmt.Errorf("script exceeded the limit of %d statements", maxScriptSteps)
+	}
+	switch st := st.(type) {
+	case *exprStmt:
+		_, err := e.eval(st.x)
+		return flowNext, err
+	case *declStmt:
+		for i, name := range st.names {
+			var v interface{}
+			if st.values[i] != nil {
+				var err error
+				if v, err = e.eval(st.values[i]); err != nil {
+					return flowNext, err
+				}
+				if v != nil && st.typ != "def" {
+					if v, err = castValue(st.typ, v); err != nil {
+						return flowNext, err
+					}
+				}
+			}
+			e.define(name, v)
+		}
+		return flowNext, nil
+	case *blockStmt:
+		e.push()
+		defer e.pop()
+		for _, s := range st.stmts {
+			if f, err := e.exec(s); err != nil || f != flowNext {
+				return f, err
+			}
+		}
+		return flowNext, nil
+	case *ifStmt:
+		cond, err := e.evalBool(st.cond)
+		if err != nil {
+			return flowNext, err
+		}
+		if cond {
+			return e.exec(st.then)
+		}
+		if st.els != nil {
+			return e.exec(st.els)
+		}
+		return flowNext, nil
+	case *forEachStmt:
+		x, err := e.eval(st.x)
+		if err != nil {
+			return flowNext, err
+		}
+		items, err := iterate(x)
+		if err != nil {
+			return flowNext, err
+		}
+		for _, item := range items {
+			e.push()
+			e.define(st.name, item)
+			f, err := e.exec(st.body)
+			e.pop()
+			if err != nil || f == flowReturn {
+				return f, err
+			}
+			if f == flowBreak {
+				break
+			}
+		}
+		return flowNext, nil
+	case *returnStmt:
+		e.result = nil
+		if st.x != nil {
+			v, err := e.eval(st.x)
+			if err != nil {
+				return flowNext, err
+			}
+			e.result = v
+		}
+		return flowReturn, nil
+	case breakStmt:
+		return flowBreak, nil
+	case continueStmt:
+		return flowContinue, nil
+	case *tryStmt:
+		f, err := e.exec(st.body)
+		var ex *scriptException
+		if !errors.As(err, &ex) {
+			return f, err
+		}
+		for _, c := range st.catches {
+			if !catches(c.class, ex.class) {
+				continue
+			}
+			e.push()
+			defer e.pop()
+			e.define(c.name, ex)
+			return e.exec(c.body)
+		}
+		return f, err
+	}
+	return flowNext, fmt.Errorf("unexpected statement %T", st)
+}
+
+func catches(class, thrown string) bool {
+	switch class {
+	case "Exception", "RuntimeException", "Throwable", thrown:
+		return true
+	case "IllegalArgumentException":
+		return thrown == "NumberFormatException"
+	case "IndexOutOfBoundsException":
+		return thrown == "ArrayIndexOutOfBoundsException" || thrown == "StringIndexOutOfBoundsException"
+	}
+	return false
+}
+
+// iterate returns a copy of the items of a collection.
+func iterate(x interface{}) ([]interface{}, error) {
+	switch x := x.(type) {
+	case nil:
+		return nil, nullPointer("iterate")
+	case *list:
+		return append([]interface{}(nil), x.items...), nil
+	case *mapView:
+		return x.items(), nil
+	}
+	return nil, throwf("IllegalArgumentException", "cannot iterate over [%s]", javaType(x))
+}
+
+func (v *mapView) items() []interface{} {
+	keys := sortedKeys(v.m)
+	items := make([]interface{}, len(keys))
+	for i, k := range keys {
+		if v.values {
+			items[i] = v.m[k]
+		} else {
+			items[i] = k
+		}
+	}
+	return items
+}
+
+func (e *scriptEnv) evalBool(x scriptExpr) (bool, error) {
+	v, err := e.eval(x)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, throwf("ClassCastException", "cannot cast [%s] to boolean", javaType(v))
+	}
+	return b, nil
+}
+
+func (e *scriptEnv) evalAll(xs []scriptExpr) ([]interface{}, error) {
+	values := make([]interface{}, len(xs))
+	for i, x := range xs {
+		v, err := e.eval(x)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+func (e *scriptEnv) eval(x scriptExpr) (interface{}, error) {
+	switch x := x.(type) {
+	case *literalExpr:
+		return x.v, nil
+	case *varExpr:
+		v, found := e.lookup(x.name)
+		if !found {
+			return nil, fmt.Errorf("variable [%s] is not defined", x.name)
+		}
+		return v, nil
+	case *fieldExpr:
+		v, err := e.eval(x.x)
+		if err != nil || v == nil && x.nullSafe {
+			return nil, err
+		}
+		return getField(v, x.name)
+	case *indexExpr:
+		v, err := e.eval(x.x)
+		if err != nil {
+			return nil, err
+		}
+		index, err := e.eval(x.index)
+		if err != nil {
+			return nil, err
+		}
+		return getIndex(v, index)
+	case *callExpr:
+		v, err := e.eval(x.x)
+		if err != nil || v == nil && x.nullSafe {
+			return nil, err
+		}
+		args, err := e.evalAll(x.args)
+		if err != nil {
+			return nil, err
+		}
+		return callMethod(v, x.name, args)
+	case *staticCallExpr:
+		args, err := e.evalAll(x.args)
+		if err != nil {
+			return nil, err
+		}
+		return callStatic(x.class, x.name, args)
+	case *funcCallExpr:
+		args, err := e.evalAll(x.args)
+		if err != nil {
+			return nil, err
+		}
+		return e.call(e.script.funcs[x.name], args)
+	case *newExpr:
+		args, err := e.evalAll(x.args)
+		if err != nil {
+			return nil, err
+		}
+		return construct(x.class, args)
+	case *listInitExpr:
+		items, err := e.evalAll(x.items)
+		if err != nil {
+			return nil, err
+		}
+		return &list{items: items}, nil
+	case *mapInitExpr:
+		m := make(map[string]interface{}, len(x.keys))
+		for i := range x.keys {
+			k, err := e.eval(x.keys[i])
+			if err != nil {
+				return nil, err
+			}
+			v, err := e.eval(x.values[i])
+			if err != nil {
+				return nil, err
+			}
+			m[javaString(k)] = v
+		}
+		return m, nil
+	case *unaryExpr:
+		if x.op == "!" {
+			b, err := e.evalBool(x.x)
+			return !b, err
+		}
+		v, err := e.eval(x.x)
+		if err != nil {
+			return nil, err
+		}
+		return arithmetic("-", int64(0), v)
+	case *binaryExpr:
+		return e.evalBinary(x)
+	case *condExpr:
+		cond, err := e.evalBool(x.cond)
+		if err != nil {
+			return nil, err
+		}
+		if cond {
+			return e.eval(x.then)
+		}
+		return e.eval(x.els)
+	case *instanceOfExpr:
+		v, err := e.eval(x.x)
+		if err != nil {
+			return nil, err
+		}
+		return isInstance(v, x.class), nil
+	case *castExpr:
+		v, err := e.eval(x.x)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		return castValue(x.class, v)
+	case *assignExpr:
+		v, err := e.eval(x.value)
+		if err != nil {
+			return nil, err
+		}
+		if x.op != "=" {
+			cur, err := e.eval(x.target)
+			if err != nil {
+				return nil, err
+			}
+			if v, err = e.evalOp(x.op[:1], cur, v); err != nil {
+				return nil, err
+			}
+		}
+		return v, e.assign(x.target, v)
+	case *incExpr:
+		cur, err := e.eval(x.target)
+		if err != nil {
+			return nil, err
+		}
+		v, err := arithmetic("+", cur, x.delta)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.assign(x.target, v); err != nil {
+			return nil, err
+		}
+		if x.postfix {
+			return cur, nil
+		}
+		return v, nil
+	case *lambdaExpr:
+		scopes := append([]map[string]interface{}(nil), e.scopes...)
+		return &scriptLambda{param: x.param, body: x.body, env: &scriptEnv{script: e.script, scopes: scopes, depth: e.depth, steps: e.steps}}, nil
+	}
+	return nil, fmt.Errorf("unexpected expression %T", x)
+}
+
+func (e *scriptEnv) evalBinary(x *binaryExpr) (interface{}, error) {
+	switch x.op {
+	case "&&", "||":
+		l, err := e.evalBool(x.x)
+		if err != nil {
+			return nil, err
+		}
+		if l == (x.op == "||") {
+			return l, nil
+		}
+		return e.evalBool(x.y)
+	}
+	l, err := e.eval(x.x)
+	if err != nil {
+		return nil, err
+	}
+	r, err := e.eval(x.y)
+	if err != nil {
+		return nil, err
+	}
+	return e.evalOp(x.op, l, r)
+}
+
+func (e *scriptEnv) evalOp(op string, l, r interface{}) (interface{}, error) {
+	switch op {
+	case "==":
+		return scriptEquals(l, r), nil
+	case "!=":
+		return !scriptEquals(l, r), nil
+	case "===":
+		return identical(l, r), nil
+	case "!==":
+		return !identical(l, r), nil
+	case "<", "<=", ">", ">=":
+		return compare(op, l, r)
+	case "+":
+		_, ls := l.(string)
+		_, rs := r.(string)
+		if ls || rs {
+			return javaString(l) + javaString(r), nil
+		}
+	}
+	return arithmetic(op, l, r)
+}
+
+func (e *scriptEnv) assign(target scriptExpr, v interface{}) error {
+	switch t := target.(type) {
+	case *varExpr:
+		for i := len(e.scopes) - 1; i >= 0; i-- {
+			if _, found := e.scopes[i][t.name]; found {
+				e.scopes[i][t.name] = v
+				return nil
+			}
+		}
+		return fmt.Errorf("variable [%s] is not defined", t.name)
+	case *fieldExpr:
+		parent, err := e.eval(t.x)
+		if err != nil {
+			return err
+		}
+		m, ok := parent.(map[string]interface{})
+		if !ok {
+			if parent == nil {
+				return nullPointer(fmt.Sprintf("set field [%s]", t.name))
+			}
+			return throwf("IllegalArgumentException", "cannot set field [%s] of [%s]", t.name, javaType(parent))
+		}
+		m[t.name] = v
+		return nil
+	case *indexExpr:
+		parent, err := e.eval(t.x)
+		if err != nil {
+			return err
+		}
+		index, err := e.eval(t.index)
+		if err != nil {
+			return err
+		}
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[javaString(index)] = v
+			return nil
+		case *list:
+			i, err := listIndex(p, index)
+			if err != nil {
+				return err
+			}
+			p.items[i] = v
+			return nil
+		case nil:
+			return nullPointer("set element")
+		}
+		return throwf("IllegalArgumentException", "cannot set element of [%s]", javaType(parent))
+	}
+	return fmt.Errorf("invalid assignment target %T", target)
+}
+
+func (e *scriptEnv) call(f *scriptFunc, args []interface{}) (interface{}, error) {
+	if len(args) != len(f.params) {
+		return nil, fmt.Errorf("function [%s] expects %d arguments, got %d", f.name, len(f.params), len(args))
+	}
+	if e.depth >= maxScriptDepth {
+		return nil, fmt.Errorf("script exceeded the limit of %d nested calls", maxScriptDepth)
+	}
+	scope := make(map[string]interface{}, len(args))
+	for i, name := range f.params {
+		scope[name] = args[i]
+	}
+	fe := &scriptEnv{script: e.script, scopes: []map[string]interface{}{scope}, depth: e.depth + 1, steps: e.steps}
+	if _, err := fe.exec(f.body); err != nil {
+		return nil, err
+	}
+	return fe.result, nil
+}
+
+func (l *scriptLambda) call(arg interface{}) (interface{}, error) {
+	l.env.push()
+	defer l.env.pop()
+	l.env.define(l.param, arg)
+	return l.env.eval(l.body)
+}
+
+func getField(v interface{}, name string) (interface{}, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nullPointer(fmt.Sprintf("access field [%s]", name))
+	case map[string]interface{}:
+		return v[name], nil
+	case *list:
+		if name == "length" {
+			return int64(len(v.items)), nil
+		}
+	}
+	return nil, throwf("IllegalArgumentException", "field [%s] not found for [%s]", name, javaType(v))
+}
+
+func getIndex(v, index interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nullPointer("access element")
+	case map[string]interface{}:
+		return v[javaString(index)], nil
+	case *list:
+		i, err := listIndex(v, index)
+		if err != nil {
+			return nil, err
+		}
+		return v.items[i], nil
+	}
+	return nil, throwf("IllegalArgumentException", "cannot access element of [%s]", javaType(v))
+}
+
+func listIndex(l *list, index interface{}) (int, error) {
+	i, ok := index.(int64)
+	if !ok {
+		return 0, throwf("ClassCastException", "cannot cast [%s] to int", javaType(index))
+	}
+	if i < 0 || i >= int64(len(l.items)) {
+		return 0, throwf("IndexOutOfBoundsException", "index %d out of bounds for length %d", i, len(l.items))
+	}
+	return int(i), nil
+}
+
+func isInstance(v interface{}, class string) bool {
+	switch class {
+	case "def", "Object":
+		return v != nil
+	case "String":
+		_, ok := v.(string)
+		return ok
+	case "Boolean":
+		_, ok := v.(bool)
+		return ok
+	case "Number":
+		_, ok := toFloat(v)
+		return ok
+	case "Integer", "Long":
+		_, ok := v.(int64)
+		return ok
+	case "Float", "Double":
+		_, ok := v.(float64)
+		return ok
+	case "Map", "HashMap":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "List", "ArrayList":
+		_, ok := v.(*list)
+		return ok
+	case "Collection":
+		switch v.(type) {
+		case *list, *mapView:
+			return true
+		}
+	case "Set":
+		_, ok := v.(*mapView)
+		return ok
+	case "StringTokenizer":
+		_, ok := v.(*stringTokenizer)
+		return ok
+	case "Exception":
+		_, ok := v.(*scriptException)
+		return ok
+	}
+	return false
+}
+
+func castValue(class string, v interface{}) (interface{}, error) {
+	switch class {
+	case "def", "Object":
+		return v, nil
+	case "byte", "short", "int", "long", "Integer", "Long":
+		switch n := v.(type) {
+		case int64:
+			return n, nil
+		case float64:
+			return int64(n), nil
+		}
+	case "float", "double", "Float", "Double":
+		if f, ok := toFloat(v); ok {
+			return f, nil
+		}
+	case "Number":
+		if _, ok := toFloat(v); ok {
+			return v, nil
+		}
+	case "List", "ArrayList":
+		if v, ok := v.(*mapView); ok {
+			return &list{items: v.items()}, nil
+		}
+	}
+	if isInstance(v, class) {
+		return v, nil
+	}
+	return nil, throwf("ClassCastException", "cannot cast [%s] to [%s]", javaType(v), class)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func scriptEquals(l, r interface{}) bool {
+	if lf, ok := toFloat(l); ok {
+		rf, ok := toFloat(r)
+		if !ok {
+			return false
+		}
+		li, lint := l.(int64)
+		ri, rint := r.(int64)
+		if lint && rint {
+			return li == ri
+		}
+		return lf == rf
+	}
+	return reflect.DeepEqual(l, r)
+}
+
+// identical implements ===, which compares references of collections.
+func identical(l, r interface{}) bool {
+	switch l := l.(type) {
+	case map[string]interface{}:
+		r, ok := r.(map[string]interface{})
+		return ok && reflect.ValueOf(l).Pointer() == reflect.ValueOf(r).Pointer()
+	case *list:
+		return l == r
+	}
+	return scriptEquals(l, r)
+}
+
+func compare(op string, l, r interface{}) (interface{}, error) {
+	if l == nil || r == nil {
+		return nil, throwf("NullPointerException", "cannot compare null")
+	}
+	li, lint := l.(int64)
+	ri, rint := r.(int64)
+	var c int
+	switch lf, lok := toFloat(l); {
+	case lint && rint:
+		c = cmpOrdered(li, ri)
+	case lok:
+		rf, ok := toFloat(r)
+		if !ok {
+			return nil, throwf("ClassCastException", "cannot compare [%s] with [%s]", javaType(l), javaType(r))
+		}
+		c = cmpOrdered(lf, rf)
+	default:
+		return nil, throwf("ClassCastException", "cannot compare [%s] with [%s]", javaType(l), javaType(r))
+	}
+	switch op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	}
+	return c >= 0, nil
+}
+
+func cmpOrdered[T int64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func arithmetic(op string, l, r interface{}) (interface{}, error) {
+	if l == nil || r == nil {
+		return nil, throwf("NullPointerException", "cannot apply [%s] to null", op)
+	}
+	li, lint := l.(int64)
+	ri, rint := r.(int64)
+	if lint && rint {
+		switch op {
+		case "+":
+			return li + ri, nil
+		case "-":
+			return li - ri, nil
+		case "*":
+			return li * ri, nil
+		case "/", "%":
+			if ri == 0 {
+				return nil, throwf("ArithmeticException", "/ by zero")
+			}
+			if op == "/" {
+				return li / ri, nil
+			}
+			return li % ri, nil
+		}
+	}
+	lf, lok := toFloat(l)
+	rf, rok := toFloat(r)
+	if !lok || !rok {
+		return nil, throwf("ClassCastException", "cannot apply [%s] to [%s] and [%s]", op, javaType(l), javaType(r))
+	}
+	switch op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		return lf / rf, nil
+	case "%":
+		return math.Mod(lf, rf), nil
+	}
+	return nil, fmt.Errorf("unexpected operator [%s]", op)
+}
+
+// javaString formats values like String.valueOf.
+func javaString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case float64:
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.ContainsAny(s, ".eIN") {
+			s += ".0"
+		}
+		return s
+	case map[string]interface{}:
+		parts := make([]string, 0, len(v))
+		for _, k := range sortedKeys(v) {
+			parts = append(parts, k+"="+javaString(v[k]))
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	case *list:
+		parts := make([]string, len(v.items))
+		for i, item := range v.items {
+			parts[i] = javaString(item)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case *mapView:
+		return javaString(&list{items: v.items()})
+	case *scriptException:
+		return v.Error()
+	}
+	return formatValue(v)
+}
+
+func construct(class string, args []interface{}) (interface{}, error) {
+	switch class {
+	case "ArrayList":
+		if len(args) == 0 {
+			return &list{}, nil
+		}
+		items, err := iterate(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return &list{items: items}, nil
+	case "HashMap":
+		if len(args) == 0 {
+			return map[string]interface{}{}, nil
+		}
+		m, ok := args[0].(map[string]interface{})
+		if !ok {
+			return nil, throwf("ClassCastException", "cannot cast [%s] to [Map]", javaType(args[0]))
+		}
+		c := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			c[k] = v
+		}
+		return c, nil
+	case "StringTokenizer":
+		if len(args) == 0 || len(args) > 2 {
+			break
+		}
+		s, err := stringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		delims := " \t\n\r\f"
+		if len(args) == 2 {
+			if delims, err = stringArg(args, 1); err != nil {
+				return nil, err
+			}
+		}
+		return &stringTokenizer{tokens: strings.FieldsFunc(s, func(r rune) bool {
+			return strings.ContainsRune(delims, r)
+		})}, nil
+	}
+	return nil, fmt.Errorf("no constructor of [%s] with %d arguments", class, len(args))
+}
+
+func stringArg(args []interface{}, i int) (string, error) {
+	switch s := args[i].(type) {
+	case string:
+		return s, nil
+	case nil:
+		return "", nullPointer("use string")
+	}
+	return "", throwf("ClassCastException", "cannot cast [%s] to [String]", javaType(args[i]))
+}
+
+func intArg(args []interface{}, i int) (int, error) {
+	n, ok := args[i].(int64)
+	if !ok {
+		return 0, throwf("ClassCastException", "cannot cast [%s] to int", javaType(args[i]))
+	}
+	return int(n), nil
+}
+
+func callStatic(class, name string, args []interface{}) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("[%s.%s] expects arguments", class, name)
+	}
+	switch class + "." + name {
+	case "Integer.parseInt", "Integer.valueOf", "Long.parseLong", "Long.valueOf":
+		if n, ok := args[0].(int64); ok && strings.HasSuffix(name, "valueOf") {
+			return n, nil
+		}
+		s, err := stringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		bits := 64
+		if class == "Integer" {
+			bits = 32
+		}
+		n, err := strconv.ParseInt(s, 10, bits)
+		if err != nil {
+			return nil, throwf("NumberFormatException", "For input string: %q", s)
+		}
+		return n, nil
+	case "Double.parseDouble", "Double.valueOf":
+		if f, ok := toFloat(args[0]); ok {
+			return f, nil
+		}
+		s, err := stringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return nil, throwf("NumberFormatException", "For input string: %q", s)
+		}
+		return f, nil
+	case "Boolean.parseBoolean", "Boolean.valueOf":
+		if b, ok := args[0].(bool); ok {
+			return b, nil
+		}
+		s, _ := args[0].(string)
+		return strings.EqualFold(s, "true"), nil
+	case "Integer.toString", "Long.toString", "Double.toString", "Boolean.toString", "String.valueOf":
+		return javaString(args[0]), nil
+	case "String.join":
+		sep, err := stringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		items := args[1:]
+		if len(args) == 2 {
+			if _, ok := args[1].(string); !ok {
+				if items, err = iterate(args[1]); err != nil {
+					return nil, err
+				}
+			}
+		}
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = javaString(item)
+		}
+		return strings.Join(parts, sep), nil
+	case "Math.abs":
+		if n, ok := args[0].(int64); ok {
+			if n < 0 {
+				return -n, nil
+			}
+			return n, nil
+		}
+		if f, ok := toFloat(args[0]); ok {
+			return math.Abs(f), nil
+		}
+	case "Math.min", "Math.max":
+		if len(args) != 2 {
+			break
+		}
+		less, err := compare("<", args[0], args[1])
+		if err != nil {
+			return nil, err
+		}
+		if less.(bool) == (name == "min") {
+			return args[0], nil
+		}
+		return args[1], nil
+	case "Math.round":
+		if f, ok := toFloat(args[0]); ok {
+			return int64(math.Floor(f + 0.5)), nil
+		}
+	case "Math.floor", "Math.ceil":
+		if f, ok := toFloat(args[0]); ok {
+			if name == "floor" {
+				return math.Floor(f), nil
+			}
+			return math.Ceil(f), nil
+		}
+	}
+	return nil, throwf("IllegalArgumentException", "cannot call [%s.%s] with [%s]", class, name, javaType(args[0]))
+}
+
+func callMethod(v interface{}, name string, args []interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nullPointer(fmt.Sprintf("invoke method [%s]", name))
+	}
+	switch name {
+	case "equals":
+		if len(args) == 1 {
+			return scriptEquals(v, args[0]), nil
+		}
+	case "toString":
+		if len(args) == 0 {
+			return javaString(v), nil
+		}
+	}
+	var (
+		result interface{}
+		found  bool
+		err    error
+	)
+	switch v := v.(type) {
+	case string:
+		result, found, err = stringMethod(v, name, args)
+	case *list:
+		result, found, err = listMethod(v, name, args)
+	case map[string]interface{}:
+		result, found, err = mapMethod(v, name, args)
+	case *mapView:
+		result, found, err = viewMethod(v, name, args)
+	case *stringTokenizer:
+		result, found, err = tokenizerMethod(v, name, args)
+	case *scriptException:
+		if name == "getMessage" && len(args) == 0 {
+			return v.message, nil
+		}
+	}
+	if !found && err == nil {
+		return nil, throwf("IllegalArgumentException", "unknown method [%s] with %d arguments for [%s]", name, len(args), javaType(v))
+	}
+	return result, err
+}
+
+// runeIndex converts a byte offset of s to an index in characters.
+func runeIndex(s string, i int) int64 {
+	if i < 0 {
+		return -1
+	}
+	return int64(utf8.RuneCountInString(s[:i]))
+}
+
+func stringMethod(s, name string, args []interface{}) (interface{}, bool, error) {
+	switch {
+	case len(args) == 0:
+		switch name {
+		case "length":
+			return int64(utf8.RuneCountInString(s)), true, nil
+		case "isEmpty":
+			return s == "", true, nil
+		case "toLowerCase":
+			return strings.ToLower(s), true, nil
+		case "toUpperCase":
+			return strings.ToUpper(s), true, nil
+		case "trim":
+			return strings.TrimSpace(s), true, nil
+		}
+	case name == "substring":
+		runes := []rune(s)
+		begin, err := intArg(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		end := len(runes)
+		if len(args) > 1 {
+			if end, err = intArg(args, 1); err != nil {
+				return nil, true, err
+			}
+		}
+		if begin < 0 || end > len(runes) || begin > end {
+			return nil, true, throwf("StringIndexOutOfBoundsException", "begin %d, end %d, length %d", begin, end, len(runes))
+		}
+		return string(runes[begin:end]), true, nil
+	case name == "replace" && len(args) == 2:
+		old, err := stringArg(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		repl, err := stringArg(args, 1)
+		if err != nil {
+			return nil, true, err
+		}
+		return strings.ReplaceAll(s, old, repl), true, nil
+	case len(args) == 1:
+		arg, err := stringArg(args, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		switch name {
+		case "contains":
+			return strings.Contains(s, arg), true, nil
+		case "startsWith":
+			return strings.HasPrefix(s, arg), true, nil
+		case "endsWith":
+			return strings.HasSuffix(s, arg), true, nil
+		case "indexOf":
+			return runeIndex(s, strings.Index(s, arg)), true, nil
+		case "lastIndexOf":
+			return runeIndex(s, strings.LastIndex(s, arg)), true, nil
+		case "equalsIgnoreCase":
+			return strings.EqualFold(s, arg), true, nil
+		case "splitOnToken":
+			if arg == "" {
+				return nil, true, throwf("IllegalArgumentException", "empty token")
+			}
+			parts := strings.Split(s, arg)
+			l := &list{items: make([]interface{}, len(parts))}
+			for i, p := range parts {
+				l.items[i] = p
+			}
+			return l, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func listMethod(l *list, name string, args []interface{}) (interface{}, bool, error) {
+	switch len(args) {
+	case 0:
+		switch name {
+		case "size":
+			return int64(len(l.items)), true, nil
+		case "isEmpty":
+			return len(l.items) == 0, true, nil
+		case "clear":
+			l.items = l.items[:0]
+			return nil, true, nil
+		}
+	case 1:
+		switch name {
+		case "get":
+			i, err := listIndex(l, args[0])
+			if err != nil {
+				return nil, true, err
+			}
+			return l.items[i], true, nil
+		case "add":
+			l.items = append(l.items, args[0])
+			return true, true, nil
+		case "addAll":
+			items, err := iterate(args[0])
+			if err != nil {
+				return nil, true, err
+			}
+			l.items = append(l.items, items...)
+			return len(items) > 0, true, nil
+		case "contains":
+			return indexOf(l.items, args[0]) >= 0, true, nil
+		case "indexOf":
+			return int64(indexOf(l.items, args[0])), true, nil
+		case "remove":
+			if _, ok := args[0].(int64); !ok {
+				i := indexOf(l.items, args[0])
+				if i >= 0 {
+					l.items = append(l.items[:i], l.items[i+1:]...)
+				}
+				return i >= 0, true, nil
+			}
+			i, err := listIndex(l, args[0])
+			if err != nil {
+				return nil, true, err
+			}
+			removed := l.items[i]
+			l.items = append(l.items[:i], l.items[i+1:]...)
+			return removed, true, nil
+		case "removeIf":
+			return removeIf(args[0], func(keep func(interface{}) (bool, error)) (bool, error) {
+				kept := l.items[:0:0]
+				for _, item := range l.items {
+					k, err := keep(item)
+					if err != nil {
+						return false, err
+					}
+					if k {
+						kept = append(kept, item)
+					}
+				}
+				removed := len(kept) != len(l.items)
+				l.items = kept
+				return removed, nil
+			})
+		}
+	case 2:
+		switch name {
+		case "set":
+			i, err := listIndex(l, args[0])
+			if err != nil {
+				return nil, true, err
+			}
+			old := l.items[i]
+			l.items[i] = args[1]
+			return old, true, nil
+		case "add":
+			i, ok := args[0].(int64)
+			if !ok || i < 0 || i > int64(len(l.items)) {
+				return nil, true, throwf("IndexOutOfBoundsException", "index %v out of bounds for length %d", args[0], len(l.items))
+			}
+			l.items = append(l.items, nil)
+			copy(l.items[i+1:], l.items[i:])
+			l.items[i] = args[1]
+			return nil, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func indexOf(items []interface{}, v interface{}) int {
+	for i, item := range items {
+		if scriptEquals(item, v) {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeIf calls remove with a function telling whether to keep an item,
+// from the predicate passed to removeIf.
+func removeIf(predicate interface{}, remove func(keep func(interface{}) (bool, error)) (bool, error)) (interface{}, bool, error) {
+	fn, ok := predicate.(*scriptLambda)
+	if !ok {
+		return nil, true, throwf("IllegalArgumentException", "removeIf expects a lambda, got [%s]", javaType(predicate))
+	}
+	removed, err := remove(func(item interface{}) (bool, error) {
+		v, err := fn.call(item)
+		if err != nil {
+			return false, err
+		}
+		b, ok := v.(bool)
+		if !ok {
+			return false, throwf("ClassCastException", "cannot cast [%s] to boolean", javaType(v))
+		}
+		return !b, nil
+	})
+	return removed, true, err
+}
+
+func mapMethod(m map[string]interface{}, name string, args []interface{}) (interface{}, bool, error) {
+	switch len(args) {
+	case 0:
+		switch name {
+		case "size":
+			return int64(len(m)), true, nil
+		case "isEmpty":
+			return len(m) == 0, true, nil
+		case "keySet":
+			return &mapView{m: m}, true, nil
+		case "values":
+			return &mapView{m: m, values: true}, true, nil
+		case "clear":
+			for k := range m {
+				delete(m, k)
+			}
+			return nil, true, nil
+		}
+	case 1:
+		k, ok := args[0].(string)
+		if !ok {
+			// Document maps only have string keys.
+			switch name {
+			case "get", "remove":
+				return nil, true, nil
+			case "containsKey":
+				return false, true, nil
+			}
+			break
+		}
+		switch name {
+		case "get":
+			return m[k], true, nil
+		case "containsKey":
+			_, found := m[k]
+			return found, true, nil
+		case "remove":
+			old := m[k]
+			delete(m, k)
+			return old, true, nil
+		}
+	case 2:
+		switch name {
+		case "put":
+			k := javaString(args[0])
+			old := m[k]
+			m[k] = args[1]
+			return old, true, nil
+		case "getOrDefault":
+			if v, found := m[javaString(args[0])]; found {
+				return v, true, nil
+			}
+			return args[1], true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func viewMethod(v *mapView, name string, args []interface{}) (interface{}, bool, error) {
+	switch {
+	case name == "size" && len(args) == 0:
+		return int64(len(v.m)), true, nil
+	case name == "isEmpty" && len(args) == 0:
+		return len(v.m) == 0, true, nil
+	case name == "contains" && len(args) == 1:
+		return indexOf(v.items(), args[0]) >= 0, true, nil
+	case name == "removeIf" && len(args) == 1:
+		return removeIf(args[0], func(keep func(interface{}) (bool, error)) (bool, error) {
+			removed := false
+			for _, k := range sortedKeys(v.m) {
+				item := interface{}(k)
+				if v.values {
+					item = v.m[k]
+				}
+				k2, err := keep(item)
+				if err != nil {
+					return false, err
+				}
+				if !k2 {
+					delete(v.m, k)
+					removed = true
+				}
+			}
+			return removed, nil
+		})
+	}
+	return nil, false, nil
+}
+
+func tokenizerMethod(t *stringTokenizer, name string, args []interface{}) (interface{}, bool, error) {
+	if len(args) != 0 {
+		return nil, false, nil
+	}
+	switch name {
+	case "hasMoreTokens":
+		return len(t.tokens) > 0, true, nil
+	case "countTokens":
+		return int64(len(t.tokens)), true, nil
+	case "nextToken":
+		if len(t.tokens) == 0 {
+			return nil, true, throwf("NoSuchElementException", "no more tokens")
+		}
+		next := t.tokens[0]
+		t.tokens = t.tokens[1:]
+		return next, true, nil
+	}
+	return nil, false, nil
+}
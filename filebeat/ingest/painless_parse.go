@@ -0,0 +1,895 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokInt
+	tokFloat
+	tokString
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string // identifier, punctuation or literal source
+	str  string // decoded value of string literals
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of script"
+	}
+	return strconv.Quote(t.text)
+}
+
+// punctuation lists the operators and separators of the subset, longest
+// first so that the lexer matches greedily. Other operators, like the regex
+// operators =~ and ==~ or bitwise operators, are rejected by the lexer.
+var punctuation = []string{
+	"===", "!==",
+	"==", "!=", "<=", ">=", "&&", "||", "++", "--", "+=", "-=", "?.", "->",
+	"{", "}", "(", ")", "[", "]", ";", ",", ".", "?", ":", "=", "<", ">", "!",
+	"+", "-", "*", "/", "%",
+}
+
+func lexScript(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at position %d", i)
+			}
+			i += end + 4
+		case isIdentStart(c):
+			start := i
+			for i < len(src) && isIdentPart(src[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], pos: start})
+		case c >= '0' && c <= '9':
+			start := i
+			kind := tokInt
+			for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+				i++
+			}
+			if i+1 < len(src) && src[i] == '.' && src[i+1] >= '0' && src[i+1] <= '9' {
+				kind = tokFloat
+				i++
+				for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+					i++
+				}
+			}
+			text := src[start:i]
+			if i < len(src) {
+				switch src[i] {
+				case 'L', 'l':
+					i++
+				case 'D', 'd', 'F', 'f':
+					kind = tokFloat
+					i++
+				}
+			}
+			tokens = append(tokens, token{kind: kind, text: text, pos: start})
+		case c == '\'' || c == '"':
+			start := i
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(src) {
+					return nil, fmt.Errorf("unterminated string at position %d", start)
+				}
+				if src[i] == c {
+					i++
+					break
+				}
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+					switch src[i] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 't':
+						sb.WriteByte('\t')
+					case 'r':
+						sb.WriteByte('\r')
+					default:
+						sb.WriteByte(src[i])
+					}
+					i++
+					continue
+				}
+				sb.WriteByte(src[i])
+				i++
+			}
+			tokens = append(tokens, token{kind: tokString, text: src[start:i], str: sb.String(), pos: start})
+		default:
+			matched := false
+			for _, p := range punctuation {
+				if strings.HasPrefix(src[i:], p) {
+					tokens = append(tokens, token{kind: tokPunct, text: p, pos: i})
+					i += len(p)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unsupported character %q at position %d", c, i)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
+
+// scriptParser parses the tokens of a script. Its errors are returned for
+// constructs outside of the subset as well as for invalid scripts, as both
+// are left to Elasticsearch.
+type scriptParser struct {
+	tokens []token
+	pos    int
+	funcs  map[string]*scriptFunc
+	// calls are the functions called by the script, checked once all the
+	// functions have been declared.
+	calls []string
+}
+
+func parseScript(src string) (*script, error) {
+	tokens, err := lexScript(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &scriptParser{tokens: tokens, funcs: map[string]*scriptFunc{}}
+	s := &script{funcs: p.funcs}
+	for p.peek().kind != tokEOF {
+		if p.isFuncDecl() {
+			if err := p.parseFunc(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		st, err := p.parseStatement()
+		if err != nil {
+			return nil, err
+		}
+		s.body = append(s.body, st)
+	}
+	for _, name := range p.calls {
+		if _, found := p.funcs[name]; !found {
+			return nil, fmt.Errorf("unknown function [%s]", name)
+		}
+	}
+	return s, nil
+}
+
+func (p *scriptParser) peek() token { return p.tokens[p.pos] }
+
+func (p *scriptParser) peekAt(n int) token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *scriptParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *scriptParser) is(text string) bool {
+	t := p.peek()
+	return (t.kind == tokPunct || t.kind == tokIdent) && t.text == text
+}
+
+func (p *scriptParser) accept(text string) bool {
+	if p.is(text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *scriptParser) expect(text string) error {
+	if !p.accept(text) {
+		return p.unexpected()
+	}
+	return nil
+}
+
+func (p *scriptParser) unexpected() error {
+	t := p.peek()
+	return fmt.Errorf("unexpected %v at position %d", t, t.pos)
+}
+
+func (p *scriptParser) ident() (string, error) {
+	t := p.peek()
+	if t.kind != tokIdent || scriptKeywords[t.text] {
+		return "", p.unexpected()
+	}
+	p.pos++
+	return t.text, nil
+}
+
+// typeLen returns the number of tokens of the type starting at the current
+// token, including type arguments and array brackets, or 0 if the current
+// token doesn't start a type.
+func (p *scriptParser) typeLen() int {
+	t := p.peek()
+	if t.kind != tokIdent || !scriptTypes[t.text] {
+		return 0
+	}
+	n := 1
+	if tt := p.peekAt(n); tt.kind == tokPunct && tt.text == "<" {
+		depth := 0
+		for ; ; n++ {
+			tt := p.peekAt(n)
+			switch {
+			case tt.kind == tokEOF:
+				return 0
+			case tt.kind == tokPunct && tt.text == "<":
+				depth++
+			case tt.kind == tokPunct && tt.text == ">":
+				depth--
+			case tt.kind == tokPunct && tt.text == ",", tt.kind == tokIdent && scriptTypes[tt.text]:
+			default:
+				return 0
+			}
+			if depth == 0 {
+				n++
+				break
+			}
+		}
+	}
+	for p.peekAt(n).text == "[" && p.peekAt(n+1).text == "]" {
+		n += 2
+	}
+	return n
+}
+
+// isDecl reports whether the current tokens start a variable declaration.
+func (p *scriptParser) isDecl() bool {
+	n := p.typeLen()
+	return n > 0 && p.peekAt(n).kind == tokIdent
+}
+
+func (p *scriptParser) isFuncDecl() bool {
+	n := p.typeLen()
+	return n > 0 && p.peekAt(n).kind == tokIdent && p.peekAt(n+1).text == "("
+}
+
+// parseType returns the name of the type, without type arguments and array
+// brackets.
+func (p *scriptParser) parseType() string {
+	n := p.typeLen()
+	name := p.peek().text
+	if p.peekAt(n-1).text == "]" {
+		// Arrays are lists.
+		name = "List"
+	}
+	p.pos += n
+	return name
+}
+
+func (p *scriptParser) parseFunc() error {
+	p.parseType()
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if _, found := p.funcs[name]; found {
+		return fmt.Errorf("duplicate function [%s]", name)
+	}
+	f := &scriptFunc{name: name}
+	p.funcs[name] = f
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for !p.accept(")") {
+		if len(f.params) > 0 {
+			if err := p.expect(","); err != nil {
+				return err
+			}
+		}
+		if p.typeLen() == 0 {
+			return p.unexpected()
+		}
+		p.parseType()
+		param, err := p.ident()
+		if err != nil {
+			return err
+		}
+		f.params = append(f.params, param)
+	}
+	f.body, err = p.parseBlock()
+	return err
+}
+
+func (p *scriptParser) parseBlock() (*blockStmt, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	b := &blockStmt{}
+	for !p.accept("}") {
+		if p.peek().kind == tokEOF {
+			return nil, p.unexpected()
+		}
+		st, err := p.parseStatement()
+		if err != nil {
+			return nil, err
+		}
+		b.stmts = append(b.stmts, st)
+	}
+	return b, nil
+}
+
+// endStatement consumes the semicolon ending a statement. Like in Painless,
+// it can be omitted before the end of a block or of the script.
+func (p *scriptParser) endStatement() error {
+	if p.accept(";") || p.is("}") || p.peek().kind == tokEOF {
+		return nil
+	}
+	return p.unexpected()
+}
+
+func (p *scriptParser) parseStatement() (scriptStmt, error) {
+	t := p.peek()
+	if t.kind == tokIdent {
+		switch t.text {
+		case "if":
+			return p.parseIf()
+		case "for":
+			return p.parseForEach()
+		case "return":
+			p.next()
+			st := &returnStmt{}
+			if !p.is(";") && !p.is("}") && p.peek().kind != tokEOF {
+				x, err := p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+				st.x = x
+			}
+			return st, p.endStatement()
+		case "break":
+			p.next()
+			return breakStmt{}, p.endStatement()
+		case "continue":
+			p.next()
+			return continueStmt{}, p.endStatement()
+		case "try":
+			return p.parseTry()
+		}
+		if p.isDecl() {
+			return p.parseDecl()
+		}
+	}
+	if p.is("{") {
+		return p.parseBlock()
+	}
+	if p.accept(";") {
+		return &blockStmt{}, nil
+	}
+	x, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	return &exprStmt{x: x}, p.endStatement()
+}
+
+func (p *scriptParser) parseDecl() (scriptStmt, error) {
+	typ := p.parseType()
+	st := &declStmt{typ: typ}
+	for {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		var value scriptExpr
+		if p.accept("=") {
+			if value, err = p.parseExpr(); err != nil {
+				return nil, err
+			}
+		}
+		st.names = append(st.names, name)
+		st.values = append(st.values, value)
+		if !p.accept(",") {
+			break
+		}
+	}
+	return st, p.endStatement()
+}
+
+func (p *scriptParser) parseIf() (scriptStmt, error) {
+	p.next()
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	cond, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	st := &ifStmt{cond: cond}
+	if st.then, err = p.parseStatement(); err != nil {
+		return nil, err
+	}
+	if p.accept("else") {
+		if st.els, err = p.parseStatement(); err != nil {
+			return nil, err
+		}
+	}
+	return st, nil
+}
+
+// parseForEach parses for each loops. Other loops are not supported, so that
+// scripts always terminate.
+func (p *scriptParser) parseForEach() (scriptStmt, error) {
+	p.next()
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	if !p.isDecl() {
+		return nil, fmt.Errorf("unsupported for loop at position %d", p.peek().pos)
+	}
+	p.parseType()
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept(":") {
+		return nil, fmt.Errorf("unsupported for loop at position %d", p.peek().pos)
+	}
+	x, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	body, err := p.parseStatement()
+	if err != nil {
+		return nil, err
+	}
+	return &forEachStmt{name: name, x: x, body: body}, nil
+}
+
+func (p *scriptParser) parseTry() (scriptStmt, error) {
+	p.next()
+	body, err := p.parseBlock()
+	if err != nil {
+		return nil, err
+	}
+	st := &tryStmt{body: body}
+	for p.accept("catch") {
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		class, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		cbody, err := p.parseBlock()
+		if err != nil {
+			return nil, err
+		}
+		st.catches = append(st.catches, catchClause{class: class, name: name, body: cbody})
+	}
+	if len(st.catches) == 0 {
+		return nil, p.unexpected()
+	}
+	return st, nil
+}
+
+func (p *scriptParser) parseExpr() (scriptExpr, error) {
+	x, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"=", "+=", "-="} {
+		if !p.is(op) {
+			continue
+		}
+		switch x.(type) {
+		case *varExpr, *fieldExpr, *indexExpr:
+		default:
+			return nil, fmt.Errorf("invalid assignment target at position %d", p.peek().pos)
+		}
+		p.next()
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		return &assignExpr{target: x, op: op, value: value}, nil
+	}
+	return x, nil
+}
+
+func (p *scriptParser) parseTernary() (scriptExpr, error) {
+	cond, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept("?") {
+		return cond, nil
+	}
+	then, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	els, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	return &condExpr{cond: cond, then: then, els: els}, nil
+}
+
+// binaryLevels lists the binary operators by increasing precedence.
+var binaryLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">=", "instanceof"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *scriptParser) parseBinary(level int) (scriptExpr, error) {
+	if level == len(binaryLevels) {
+		return p.parseUnary()
+	}
+	x, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := ""
+		for _, candidate := range binaryLevels[level] {
+			if p.is(candidate) {
+				op = candidate
+				break
+			}
+		}
+		if op == "" {
+			return x, nil
+		}
+		p.next()
+		if op == "instanceof" {
+			class := p.peek().text
+			if p.typeLen() == 0 || !scriptInstanceOf[class] {
+				return nil, fmt.Errorf("unsupported type %v at position %d", p.peek(), p.peek().pos)
+			}
+			p.parseType()
+			x = &instanceOfExpr{x: x, class: class}
+			continue
+		}
+		y, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryExpr{op: op, x: x, y: y}
+	}
+}
+
+func (p *scriptParser) parseUnary() (scriptExpr, error) {
+	switch {
+	case p.is("!"), p.is("-"):
+		op := p.next().text
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{op: op, x: x}, nil
+	case p.is("++"), p.is("--"):
+		op := p.next().text
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return p.incExpr(x, op, false)
+	case p.is("(") && p.isCast():
+		p.next()
+		class := p.parseType()
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &castExpr{class: class, x: x}, nil
+	}
+	x, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	if p.is("++") || p.is("--") {
+		return p.incExpr(x, p.next().text, true)
+	}
+	return x, nil
+}
+
+func (p *scriptParser) incExpr(x scriptExpr, op string, postfix bool) (scriptExpr, error) {
+	switch x.(type) {
+	case *varExpr, *fieldExpr, *indexExpr:
+	default:
+		return nil, fmt.Errorf("invalid %s operand at position %d", op, p.peek().pos)
+	}
+	delta := int64(1)
+	if op == "--" {
+		delta = -1
+	}
+	return &incExpr{target: x, delta: delta, postfix: postfix}, nil
+}
+
+// isCast reports whether the parenthesis at the current position starts a
+// cast.
+func (p *scriptParser) isCast() bool {
+	p.pos++
+	defer func() { p.pos-- }()
+	n := p.typeLen()
+	return n > 0 && p.peekAt(n).text == ")"
+}
+
+func (p *scriptParser) parsePostfix() (scriptExpr, error) {
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.is("."), p.is("?."):
+			nullSafe := p.next().text == "?."
+			// Keywords are valid field names, as in ctx.event.class.
+			if p.peek().kind != tokIdent {
+				return nil, p.unexpected()
+			}
+			name := p.next().text
+			if !p.is("(") {
+				x = &fieldExpr{x: x, name: name, nullSafe: nullSafe}
+				continue
+			}
+			if !scriptMethods[name] {
+				return nil, fmt.Errorf("unsupported method [%s]", name)
+			}
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			x = &callExpr{x: x, name: name, args: args, nullSafe: nullSafe}
+		case p.is("["):
+			p.next()
+			index, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			x = &indexExpr{x: x, index: index}
+		default:
+			return x, nil
+		}
+	}
+}
+
+func (p *scriptParser) parseArgs() ([]scriptExpr, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var args []scriptExpr
+	for !p.accept(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		var arg scriptExpr
+		var err error
+		if p.peek().kind == tokIdent && p.peekAt(1).text == "->" {
+			arg, err = p.parseLambda()
+		} else {
+			arg, err = p.parseExpr()
+		}
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// parseLambda parses lambdas with a single parameter and an expression body,
+// as used by removeIf.
+func (p *scriptParser) parseLambda() (scriptExpr, error) {
+	name := p.next().text
+	p.next()
+	body, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	return &lambdaExpr{param: name, body: body}, nil
+}
+
+func (p *scriptParser) parsePrimary() (scriptExpr, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokInt:
+		p.next()
+		v, err := strconv.ParseInt(t.text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %v at position %d", t, t.pos)
+		}
+		return &literalExpr{v: v}, nil
+	case tokFloat:
+		p.next()
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %v at position %d", t, t.pos)
+		}
+		return &literalExpr{v: v}, nil
+	case tokString:
+		p.next()
+		return &literalExpr{v: t.str}, nil
+	case tokPunct:
+		switch t.text {
+		case "(":
+			p.next()
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		case "[":
+			return p.parseInitializer()
+		}
+		return nil, p.unexpected()
+	case tokIdent:
+		switch t.text {
+		case "null":
+			p.next()
+			return &literalExpr{}, nil
+		case "true", "false":
+			p.next()
+			return &literalExpr{v: t.text == "true"}, nil
+		case "new":
+			p.next()
+			class := p.peek().text
+			if p.typeLen() == 0 || !scriptConstructors[class] {
+				return nil, fmt.Errorf("unsupported type %v at position %d", p.peek(), p.peek().pos)
+			}
+			p.parseType()
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			return &newExpr{class: class, args: args}, nil
+		}
+		if statics, found := scriptStatics[t.text]; found && p.peekAt(1).text == "." {
+			p.pos += 2
+			name, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			if !statics[name] {
+				return nil, fmt.Errorf("unsupported method [%s.%s]", t.text, name)
+			}
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			return &staticCallExpr{class: t.text, name: name, args: args}, nil
+		}
+		name, err := p.ident()
+		if err != nil {
+			return nil, fmt.Errorf("unsupported expression %v at position %d", t, t.pos)
+		}
+		if p.is("(") {
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			p.calls = append(p.calls, name)
+			return &funcCallExpr{name: name, args: args}, nil
+		}
+		return &varExpr{name: name}, nil
+	}
+	return nil, p.unexpected()
+}
+
+// parseInitializer parses list ([a, b]) and map ([k: v] or [:])
+// initializers.
+func (p *scriptParser) parseInitializer() (scriptExpr, error) {
+	p.next()
+	if p.accept(":") {
+		return &mapInitExpr{}, p.expect("]")
+	}
+	var items []scriptExpr
+	m := &mapInitExpr{}
+	isMap := false
+	for !p.accept("]") {
+		if len(items)+len(m.keys) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.accept(":") {
+			if len(items) > 0 {
+				return nil, p.unexpected()
+			}
+			isMap = true
+			v, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			m.keys = append(m.keys, x)
+			m.values = append(m.values, v)
+			continue
+		}
+		if isMap {
+			return nil, p.unexpected()
+		}
+		items = append(items, x)
+	}
+	if isMap {
+		return m, nil
+	}
+	return &listInitExpr{items: items}, nil
+}
@@ -0,0 +1,152 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScript(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		want    interface{}
+		wantCtx map[string]interface{}
+		wantErr string
+	}{
+		{name: "null safe", source: "ctx.a?.b?.c == null", want: true},
+		{name: "null safe method", source: "ctx.s?.toLowerCase() == 'abc' && ctx.missing?.toLowerCase() == null", want: true},
+		{name: "null field", source: "ctx.missing.b", wantErr: "NullPointerException: cannot access field [b] of null"},
+		{name: "numbers", source: "ctx.n * 2 + 1.5 == 21.5 && 7 / 2 == 3 && 7 % 2 == 1", want: true},
+		{name: "comparison", source: "ctx.n >= 10 && ctx.n < 11 && !(ctx.n > 10)", want: true},
+		{name: "compare null", source: "ctx.missing < 1", wantErr: "NullPointerException: cannot compare null"},
+		{name: "string concatenation", source: "ctx.s + '-' + ctx.n + '-' + 1.0 + '-' + null", want: "ABC-10-1.0-null"},
+		{name: "equality", source: "ctx.l == ['x', 'y'] && ctx.m == ['k': 'v'] && 1 == 1.0", want: true},
+		{name: "contains", source: "ctx.l.contains('y') && ctx.s.contains('B') && ctx.m.containsKey('k')", want: true},
+		{name: "length", source: "ctx.l.length + ctx.l.size() + ctx.s.length()", want: int64(7)},
+		{name: "instanceof", source: "ctx.m instanceof Map && ctx.l instanceof List && ctx.n instanceof Number && !(ctx.s instanceof Map)", want: true},
+		{name: "ternary", source: "ctx.n > 5 ? 'big' : 'small'", want: "big"},
+		{name: "index", source: "ctx.l[1] + ctx.m['k']", want: "yv"},
+		{name: "index out of bounds", source: "ctx.l[2]", wantErr: "IndexOutOfBoundsException: index 2 out of bounds for length 2"},
+		{name: "boolean condition", source: "if (ctx.s) { return 1 }", wantErr: "ClassCastException: cannot cast [java.lang.String] to boolean"},
+		{
+			name:   "assignments",
+			source: "ctx.a = [:]; ctx.a.b = ctx.n; ctx.a.b += 5; ctx.l[0] = 'z'; ctx.m.put('k2', 'v2'); ctx.remove('s')",
+			wantCtx: map[string]interface{}{
+				"a":    map[string]interface{}{"b": int64(15)},
+				"n":    int64(10),
+				"l":    &list{items: []interface{}{"z", "y"}},
+				"m":    map[string]interface{}{"k": "v", "k2": "v2"},
+				"null": nil,
+			},
+		},
+		{
+			name: "loops and functions",
+			source: `
+				int count(def l, String skip) {
+					int n = 0;
+					for (def item : l) {
+						if (item == skip) {
+							continue;
+						}
+						n++;
+					}
+					return n;
+				}
+				def total = 0;
+				for (String k : ctx.m.keySet()) {
+					total += count(ctx.l, 'x') + k.length();
+				}
+				return total`,
+			want: int64(2),
+		},
+		{
+			name: "try and catch",
+			source: `
+				try {
+					return Integer.parseInt(ctx.s);
+				} catch (NumberFormatException e) {
+					return e.getMessage();
+				}`,
+			want: `For input string: "ABC"`,
+		},
+		{
+			name:   "remove nulls",
+			source: "ctx.values().removeIf(v -> v == null); ctx.l.removeIf(v -> v == 'x')",
+			wantCtx: map[string]interface{}{
+				"s": "ABC",
+				"n": int64(10),
+				"l": &list{items: []interface{}{"y"}},
+				"m": map[string]interface{}{"k": "v"},
+			},
+		},
+		{name: "tokenizer", source: "def t = new StringTokenizer('10.0.0.1', '.'); t.nextToken() + t.countTokens()", want: "103"},
+		{name: "recursion", source: "int f(int n) { return f(n + 1) } f(0)", wantErr: "script exceeded the limit of 100 nested calls"},
+		{
+			name:    "statement limit",
+			source:  "void f(def l, int depth) { if (depth > 0) { for (def x : l) { f(l, depth - 1) } } } f([1, 2, 3, 4, 5, 6, 7, 8, 9, 10], 10)",
+			wantErr: "script exceeded the limit of 1000000 statements",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := compileScript(tc.source)
+			require.NoError(t, err)
+			ctx := map[string]interface{}{
+				"s":    "ABC",
+				"n":    int64(10),
+				"l":    &list{items: []interface{}{"x", "y"}},
+				"m":    map[string]interface{}{"k": "v"},
+				"null": nil,
+			}
+			got, err := s.execute(ctx, nil)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tc.wantCtx != nil {
+				assert.Equal(t, tc.wantCtx, ctx)
+				return
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestScriptUnsupported(t *testing.T) {
+	for name, source := range map[string]string{
+		"regex":          "ctx.a =~ /x/",
+		"while loop":     "while (true) {}",
+		"classic loop":   "for (int i = 0; i < 10; i++) {}",
+		"unknown method": "ctx.a.matches('x')",
+		"unknown class":  "Instant.now()",
+		"unknown func":   "f(ctx)",
+		"throw":          "throw new Exception()",
+		"syntax":         "ctx.a ==",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := compileScript(source)
+			var unsupported *unsupportedError
+			assert.ErrorAs(t, err, &unsupported)
+		})
+	}
+}
@@ -0,0 +1,257 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// maxPipelineDepth bounds the nesting of pipeline processors.
+const maxPipelineDepth = 100
+
+// processor is the implementation of an ingest processor type.
+type processor interface {
+	run(ctx *runContext, d *document) error
+}
+
+// factory creates a processor from its options.
+type factory func(c *compiler, o *options) (processor, error)
+
+// runContext holds the state of the execution of a pipeline.
+type runContext struct {
+	registry *Registry
+	depth    int
+}
+
+// entry is a processor of a pipeline with its common options.
+type entry struct {
+	typ           string
+	tag           string
+	pipeline      string
+	cond          *script
+	condParams    map[string]interface{}
+	ignoreFailure bool
+	onFailure     []*entry
+	proc          processor
+}
+
+type pipeline struct {
+	id         string
+	processors []*entry
+	onFailure  []*entry
+	// calls are the pipelines run by pipeline processors.
+	calls []string
+}
+
+// processorError is the error of a processor. It records the processor to
+// populate the _ingest.on_failure_* metadata fields.
+type processorError struct {
+	typ, tag, pipeline string
+	err                error
+}
+
+func (e *processorError) Error() string { return e.err.Error() }
+func (e *processorError) Unwrap() error { return e.err }
+
+// errDropped stops the execution of a pipeline for dropped documents.
+var errDropped = errors.New("document dropped")
+
+func (p *pipeline) execute(ctx *runContext, d *document) error {
+	err := runEntries(ctx, d, p.processors)
+	if err == nil || len(p.onFailure) == 0 || errors.Is(err, errDropped) {
+		return err
+	}
+	setFailureMetadata(d, err)
+	return runEntries(ctx, d, p.onFailure)
+}
+
+func runEntries(ctx *runContext, d *document, entries []*entry) error {
+	for _, e := range entries {
+		if err := e.run(ctx, d); err != nil {
+			return err
+		}
+		if d.dropped {
+			return errDropped
+		}
+	}
+	return nil
+}
+
+func (e *entry) run(ctx *runContext, d *document) error {
+	err := e.runProcessor(ctx, d)
+	if err == nil || errors.Is(err, errDropped) {
+		return err
+	}
+	if e.ignoreFailure {
+		return nil
+	}
+	var perr *processorError
+	if !errors.As(err, &perr) {
+		err = &processorError{typ: e.typ, tag: e.tag, pipeline: e.pipeline, err: err}
+	}
+	if len(e.onFailure) == 0 {
+		return err
+	}
+	setFailureMetadata(d, err)
+	return runEntries(ctx, d, e.onFailure)
+}
+
+func (e *entry) runProcessor(ctx *runContext, d *document) error {
+	if e.cond != nil {
+		v, err := e.cond.execute(d.source, e.condParams)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate condition: %w", err)
+		}
+		match, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("condition must return a boolean, got [%s]", javaType(v))
+		}
+		if !match {
+			return nil
+		}
+	}
+	return e.proc.run(ctx, d)
+}
+
+func setFailureMetadata(d *document, err error) {
+	d.ingest["on_failure_message"] = err.Error()
+	var perr *processorError
+	if errors.As(err, &perr) {
+		d.ingest["on_failure_processor_type"] = perr.typ
+		d.ingest["on_failure_processor_tag"] = perr.tag
+		d.ingest["on_failure_pipeline"] = perr.pipeline
+	}
+}
+
+// compiler compiles the processors of a pipeline definition.
+type compiler struct {
+	registry *Registry
+	pipeline string
+	calls    []string
+}
+
+func (c *compiler) compilePipeline(def map[string]interface{}) (*pipeline, error) {
+	p := &pipeline{id: c.pipeline}
+	var err error
+	if p.processors, err = c.compileEntries(def["processors"]); err != nil {
+		return nil, err
+	}
+	if p.onFailure, err = c.compileEntries(def["on_failure"]); err != nil {
+		return nil, err
+	}
+	p.calls = c.calls
+	return p, nil
+}
+
+func (c *compiler) compileEntries(v interface{}) ([]*entry, error) {
+	if v == nil {
+		return nil, nil
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, errors.New("processors must be a list")
+	}
+	entries := make([]*entry, 0, len(list))
+	for _, item := range list {
+		def, ok := item.(map[string]interface{})
+		if !ok || len(def) != 1 {
+			return nil, errors.New("processor definitions must be objects with a single processor type")
+		}
+		for typ, cfg := range def {
+			e, err := c.compileEntry(typ, cfg)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// compileEntry compiles a processor. It returns an unsupportedError for
+// processors that can't run locally.
+func (c *compiler) compileEntry(typ string, cfg interface{}) (*entry, error) {
+	m, ok := cfg.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("[%s] processor: configuration must be an object", typ)
+	}
+	o := newOptions(typ, m)
+	e := &entry{typ: typ, pipeline: c.pipeline}
+	var err error
+	if e.tag, err = o.string("tag", ""); err != nil {
+		return nil, err
+	}
+	o.raw("description")
+	if e.ignoreFailure, err = o.bool("ignore_failure", false); err != nil {
+		return nil, err
+	}
+	if cond, found := o.raw("if"); found {
+		// Conditions are scripts, given either as their source or as an
+		// object like the options of script processors.
+		switch cond := cond.(type) {
+		case string:
+			e.cond, err = compileScript(cond)
+		case map[string]interface{}:
+			e.cond, e.condParams, err = compileScriptOptions(newOptions(typ, cond))
+		default:
+			err = o.errorf("[if] must be a script")
+		}
+		if err != nil {
+			var unsupported *unsupportedError
+			if errors.As(err, &unsupported) {
+				return nil, unsupportedf("[%s] processor: unsupported condition: %v", typ, err)
+			}
+			return nil, err
+		}
+	}
+	onFailure, _ := o.raw("on_failure")
+	if e.onFailure, err = c.compileEntries(onFailure); err != nil {
+		return nil, err
+	}
+
+	create, found := factories[typ]
+	if !found {
+		return nil, unsupportedf("[%s] processor: processor type is not supported", typ)
+	}
+	if e.proc, err = create(c, o); err != nil {
+		var unsupported *unsupportedError
+		if errors.As(err, &unsupported) {
+			return nil, unsupportedf("[%s] processor: %v", typ, err)
+		}
+		return nil, err
+	}
+	if unused := o.unused(); len(unused) > 0 {
+		sort.Strings(unused)
+		c.registry.log.Debugf("Ignoring unsupported options %v of [%s] processor of pipeline %s", unused, typ, c.pipeline)
+	}
+	return e, nil
+}
+
+// unsupportedError is returned by factories for valid processor definitions
+// that can't be run locally.
+type unsupportedError struct {
+	reason string
+}
+
+func (e *unsupportedError) Error() string { return e.reason }
+
+func unsupportedf(format string, args ...interface{}) error {
+	return &unsupportedError{reason: fmt.Sprintf(format, args...)}
+}
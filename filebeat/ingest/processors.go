@@ -0,0 +1,1012 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/beats/v7/libbeat/processors/dissect"
+)
+
+var factories map[string]factory
+
+func init() {
+	// Set in init to break the initialization cycle through the pipeline
+	// and foreach processors, which compile nested processors.
+	factories = map[string]factory{
+		"append":       newAppend,
+		"convert":      newConvert,
+		"date":         newDate,
+		"dissect":      newDissect,
+		"dot_expander": newDotExpander,
+		"drop":         newDrop,
+		"fail":         newFail,
+		"foreach":      newForeach,
+		"geoip":        newGeoIP,
+		"grok":         newGrok,
+		"gsub":         newGsub,
+		"join":         newJoin,
+		"json":         newJSON,
+		"kv":           newKV,
+		"lowercase":    newStringTransform(strings.ToLower),
+		"pipeline":     newPipelineProcessor,
+		"remove":       newRemove,
+		"rename":       newRename,
+		"script":       newScript,
+		"set":          newSet,
+		"split":        newSplit,
+		"trim":         newStringTransform(strings.TrimSpace),
+		"uppercase":    newStringTransform(strings.ToUpper),
+		"uri_parts":    newURIParts,
+		"urldecode":    newStringTransformErr(url.QueryUnescape),
+		"user_agent":   newUserAgent,
+	}
+}
+
+// processorFunc adapts a function to the processor interface.
+type processorFunc func(ctx *runContext, d *document) error
+
+func (f processorFunc) run(ctx *runContext, d *document) error { return f(ctx, d) }
+
+func newSet(_ *compiler, o *options) (processor, error) {
+	field, err := o.template("field", true)
+	if err != nil {
+		return nil, err
+	}
+	override, err := o.bool("override", true)
+	if err != nil {
+		return nil, err
+	}
+	ignoreEmpty, err := o.bool("ignore_empty_value", false)
+	if err != nil {
+		return nil, err
+	}
+	o.raw("media_type")
+	copyFrom, err := o.string("copy_from", "")
+	if err != nil {
+		return nil, err
+	}
+	raw, hasValue := o.raw("value")
+	if _, found := o.m["value"]; found && !hasValue {
+		// An explicit null value.
+		hasValue = true
+	}
+	if hasValue == (copyFrom != "") {
+		return nil, o.errorf("either [value] or [copy_from] must be set")
+	}
+	var value *valueSource
+	if hasValue {
+		if value, err = compileValue(raw); err != nil {
+			return nil, err
+		}
+	}
+	return processorFunc(func(_ *runContext, d *document) error {
+		path := field.render(d)
+		if !override {
+			if v, found := d.get(path); found && v != nil {
+				return nil
+			}
+		}
+		var v interface{}
+		if value != nil {
+			v = value.render(d)
+		} else {
+			src, found := d.get(copyFrom)
+			if !found {
+				return fmt.Errorf("field [%s] not present as part of path [%s]", lastElement(copyFrom), copyFrom)
+			}
+			v = deepCopy(src)
+		}
+		if ignoreEmpty && (v == nil || v == "") {
+			return nil
+		}
+		return d.set(path, v)
+	}), nil
+}
+
+func newAppend(_ *compiler, o *options) (processor, error) {
+	field, err := o.template("field", true)
+	if err != nil {
+		return nil, err
+	}
+	allowDuplicates, err := o.bool("allow_duplicates", true)
+	if err != nil {
+		return nil, err
+	}
+	o.raw("media_type")
+	raw, found := o.raw("value")
+	if !found {
+		return nil, o.errorf("[value] required property is missing")
+	}
+	value, err := compileValue(raw)
+	if err != nil {
+		return nil, err
+	}
+	return processorFunc(func(_ *runContext, d *document) error {
+		v := value.render(d)
+		values, ok := asList(v)
+		if !ok {
+			values = []interface{}{v}
+		}
+		return d.appendValue(field.render(d), values, allowDuplicates)
+	}), nil
+}
+
+func newRename(_ *compiler, o *options) (processor, error) {
+	f, err := o.fieldOptions()
+	if err != nil {
+		return nil, err
+	}
+	if f.targetField == nil {
+		return nil, o.errorf("[target_field] required property is missing")
+	}
+	override, err := o.bool("override", false)
+	if err != nil {
+		return nil, err
+	}
+	return processorFunc(func(_ *runContext, d *document) error {
+		field, target := f.paths(d)
+		v, found := d.get(field)
+		if !found {
+			if f.ignoreMissing {
+				return nil
+			}
+			return fmt.Errorf("field [%s] doesn't exist", field)
+		}
+		if d.has(target) && !override {
+			return fmt.Errorf("field [%s] already exists", target)
+		}
+		d.remove(field)
+		if err := d.set(target, v); err != nil {
+			// Restore the original field like Elasticsearch does.
+			_ = d.set(field, v)
+			return err
+		}
+		return nil
+	}), nil
+}
+
+func newRemove(_ *compiler, o *options) (processor, error) {
+	fields, err := o.strings("field")
+	if err != nil {
+		return nil, err
+	}
+	keep, err := o.strings("keep")
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 && len(keep) == 0 {
+		return nil, o.errorf("[field] required property is missing")
+	}
+	ignoreMissing, err := o.bool("ignore_missing", false)
+	if err != nil {
+		return nil, err
+	}
+	templates := make([]*template, len(fields))
+	for i, field := range fields {
+		if templates[i], err = compileTemplate(field); err != nil {
+			return nil, err
+		}
+	}
+	return processorFunc(func(_ *runContext, d *document) error {
+		if len(keep) > 0 {
+			kept := map[string]interface{}{}
+			for _, k := range keep {
+				if v, found := d.get(k); found {
+					kept[k] = v
+				}
+			}
+			for k := range d.source {
+				if !strings.HasPrefix(k, "_") {
+					delete(d.source, k)
+				}
+			}
+			for k, v := range kept {
+				if err := d.set(k, v); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for _, t := range templates {
+			field := t.render(d)
+			if !d.remove(field) && !ignoreMissing {
+				return fmt.Errorf("field [%s] not present as part of path [%s]", lastElement(field), field)
+			}
+		}
+		return nil
+	}), nil
+}
+
+// transformField applies fn to the value of a field, or to each item of
+// the field if it is a list.
+func transformField(d *document, f fieldOptions, fn func(interface{}) (interface{}, error)) error {
+	field, target := f.paths(d)
+	v, found := d.get(field)
+	if !found || v == nil {
+		if f.ignoreMissing {
+			return nil
+		}
+		if !found {
+			return fmt.Errorf("field [%s] not present as part of path [%s]", lastElement(field), field)
+		}
+		return fmt.Errorf("field [%s] is null, cannot process it", field)
+	}
+	var out interface{}
+	if items, ok := asList(v); ok {
+		l := &list{items: make([]interface{}, len(items))}
+		for i, item := range items {
+			converted, err := fn(item)
+			if err != nil {
+				return err
+			}
+			l.items[i] = converted
+		}
+		out = l
+	} else {
+		var err error
+		if out, err = fn(v); err != nil {
+			return err
+		}
+	}
+	return d.set(target, out)
+}
+
+func newStringTransform(fn func(string) string) factory {
+	return newStringTransformErr(func(s string) (string, error) {
+		return fn(s), nil
+	})
+}
+
+func newStringTransformErr(fn func(string) (string, error)) factory {
+	return func(_ *compiler, o *options) (processor, error) {
+		f, err := o.fieldOptions()
+		if err != nil {
+			return nil, err
+		}
+		return processorFunc(func(_ *runContext, d *document) error {
+			return transformField(d, f, func(v interface{}) (interface{}, error) {
+				s, ok := v.(string)
+				if !ok {
+					return nil, fmt.Errorf("field [%s] of type [%s] cannot be cast to [java.lang.String]", f.field.render(d), javaType(v))
+				}
+				return fn(s)
+			})
+		}), nil
+	}
+}
+
+func newGsub(_ *compiler, o *options) (processor, error) {
+	f, err := o.fieldOptions()
+	if err != nil {
+		return nil, err
+	}
+	pattern, err := o.requiredString("pattern")
+	if err != nil {
+		return nil, err
+	}
+	replacement, err := o.string("replacement", "")
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, unsupportedf("unsupported pattern %q: %v", pattern, err)
+	}
+	replacement = javaReplacement(replacement)
+	return processorFunc(func(_ *runContext, d *document) error {
+		return transformField(d, f, func(v interface{}) (interface{}, error) {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("field [%s] of type [%s] cannot be cast to [java.lang.String]", f.field.render(d), javaType(v))
+			}
+			return re.ReplaceAllString(s, replacement), nil
+		})
+	}), nil
+}
+
+// javaReplacement converts the group references of Java replacement strings
+// ($1) to the syntax of the regexp package (${1}).
+func javaReplacement(repl string) string {
+	if !strings.ContainsAny(repl, "$\\") {
+		return repl
+	}
+	var sb strings.Builder
+	for i := 0; i < len(repl); i++ {
+		c := repl[i]
+		switch {
+		case c == '\\' && i+1 < len(repl):
+			i++
+			if repl[i] == '$' {
+				sb.WriteString("$$")
+			} else {
+				sb.WriteByte(repl[i])
+			}
+		case c == '$':
+			j := i + 1
+			for j < len(repl) && repl[j] >= '0' && repl[j] <= '9' {
+				j++
+			}
+			if j == i+1 {
+				sb.WriteString("$$")
+				continue
+			}
+			sb.WriteString("${" + repl[i+1:j] + "}")
+			i = j - 1
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+func newSplit(_ *compiler, o *options) (processor, error) {
+	f, err := o.fieldOptions()
+	if err != nil {
+		return nil, err
+	}
+	separator, err := o.requiredString("separator")
+	if err != nil {
+		return nil, err
+	}
+	preserveTrailing, err := o.bool("preserve_trailing", false)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(separator)
+	if err != nil {
+		return nil, unsupportedf("unsupported separator %q: %v", separator, err)
+	}
+	return processorFunc(func(_ *runContext, d *document) error {
+		field, target := f.paths(d)
+		s, found, err := d.getString(field, f.ignoreMissing)
+		if err != nil || !found {
+			return err
+		}
+		parts := re.Split(s, -1)
+		if !preserveTrailing {
+			for len(parts) > 0 && parts[len(parts)-1] == "" {
+				parts = parts[:len(parts)-1]
+			}
+		}
+		l := &list{items: make([]interface{}, len(parts))}
+		for i, p := range parts {
+			l.items[i] = p
+		}
+		return d.set(target, l)
+	}), nil
+}
+
+func newJoin(_ *compiler, o *options) (processor, error) {
+	f, err := o.fieldOptions()
+	if err != nil {
+		return nil, err
+	}
+	separator, err := o.string("separator", "")
+	if err != nil {
+		return nil, err
+	}
+	return processorFunc(func(_ *runContext, d *document) error {
+		field, target := f.paths(d)
+		v, found := d.get(field)
+		items, ok := asList(v)
+		if !found || !ok {
+			return fmt.Errorf("field [%s] of type [%s] cannot be cast to [java.util.List]", field, javaType(v))
+		}
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = formatValue(item)
+		}
+		return d.set(target, strings.Join(parts, separator))
+	}), nil
+}
+
+func newConvert(_ *compiler, o *options) (processor, error) {
+	f, err := o.fieldOptions()
+	if err != nil {
+		return nil, err
+	}
+	typ, err := o.requiredString("type")
+	if err != nil {
+		return nil, err
+	}
+	var convert func(interface{}) (interface{}, error)
+	switch typ {
+	case "integer", "long":
+		bits := 64
+		if typ == "integer" {
+			bits = 32
+		}
+		convert = func(v interface{}) (interface{}, error) {
+			s := formatValue(v)
+			n, err := strconv.ParseInt(s, 0, bits)
+			if err != nil {
+				return nil, fmt.Errorf("unable to convert [%s] to %s", s, typ)
+			}
+			return n, nil
+		}
+	case "float", "double":
+		convert = func(v interface{}) (interface{}, error) {
+			s := formatValue(v)
+			n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				return nil, fmt.Errorf("unable to convert [%s] to %s", s, typ)
+			}
+			return n, nil
+		}
+	case "boolean":
+		convert = func(v interface{}) (interface{}, error) {
+			s := formatValue(v)
+			switch strings.ToLower(s) {
+			case "true":
+				return true, nil
+			case "false":
+				return false, nil
+			}
+			return nil, fmt.Errorf("[%s] is not a boolean value, cannot convert to boolean", s)
+		}
+	case "string":
+		convert = func(v interface{}) (interface{}, error) {
+			return formatValue(v), nil
+		}
+	case "ip":
+		convert = func(v interface{}) (interface{}, error) {
+			s := formatValue(v)
+			if net.ParseIP(s) == nil {
+				return nil, fmt.Errorf("'%s' is not an IP string literal.", s)
+			}
+			return s, nil
+		}
+	case "auto":
+		convert = func(v interface{}) (interface{}, error) {
+			s, ok := v.(string)
+			if !ok {
+				return v, nil
+			}
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return n, nil
+			}
+			if n, err := strconv.ParseFloat(s, 64); err == nil {
+				return n, nil
+			}
+			switch strings.ToLower(s) {
+			case "true":
+				return true, nil
+			case "false":
+				return false, nil
+			}
+			return s, nil
+		}
+	default:
+		return nil, o.errorf("type [%s] not supported, cannot convert field", typ)
+	}
+	return processorFunc(func(_ *runContext, d *document) error {
+		return transformField(d, f, convert)
+	}), nil
+}
+
+func newDrop(_ *compiler, _ *options) (processor, error) {
+	return processorFunc(func(_ *runContext, d *document) error {
+		d.dropped = true
+		return nil
+	}), nil
+}
+
+func newFail(_ *compiler, o *options) (processor, error) {
+	message, err := o.template("message", true)
+	if err != nil {
+		return nil, err
+	}
+	return processorFunc(func(_ *runContext, d *document) error {
+		return errors.New(message.render(d))
+	}), nil
+}
+
+func newJSON(_ *compiler, o *options) (processor, error) {
+	f, err := o.fieldOptions()
+	if err != nil {
+		return nil, err
+	}
+	addToRoot, err := o.bool("add_to_root", false)
+	if err != nil {
+		return nil, err
+	}
+	strategy, err := o.string("add_to_root_conflict_strategy", "replace")
+	if err != nil {
+		return nil, err
+	}
+	if strategy != "replace" && strategy != "merge" {
+		return nil, o.errorf("conflict strategy [%s] not supported, cannot convert field", strategy)
+	}
+	o.raw("allow_duplicate_keys")
+	o.raw("strict_json_parsing")
+	if addToRoot && f.targetField != nil {
+		return nil, o.errorf("Cannot set a target field while also setting `add_to_root` to true")
+	}
+	return processorFunc(func(_ *runContext, d *document) error {
+		field, target := f.paths(d)
+		s, found, err := d.getString(field, f.ignoreMissing)
+		if err != nil || !found {
+			return err
+		}
+		dec := json.NewDecoder(bytes.NewReader([]byte(s)))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return fmt.Errorf("field [%s] cannot be parsed as JSON: %w", field, err)
+		}
+		v = jsonToDoc(v)
+		if !addToRoot {
+			return d.set(target, v)
+		}
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("cannot add non-map fields to root of document")
+		}
+		for k, item := range m {
+			if strategy == "merge" {
+				if cur, ok := d.source[k].(map[string]interface{}); ok {
+					if add, ok := item.(map[string]interface{}); ok {
+						mergeMaps(cur, add)
+						continue
+					}
+				}
+			}
+			d.source[k] = item
+		}
+		return nil
+	}), nil
+}
+
+func jsonToDoc(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = jsonToDoc(item)
+		}
+		return v
+	case []interface{}:
+		l := &list{items: v}
+		for i, item := range v {
+			v[i] = jsonToDoc(item)
+		}
+		return l
+	}
+	return v
+}
+
+func mergeMaps(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if dm, ok := dst[k].(map[string]interface{}); ok {
+			if sm, ok := v.(map[string]interface{}); ok {
+				mergeMaps(dm, sm)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
+
+func newDotExpander(_ *compiler, o *options) (processor, error) {
+	field, err := o.requiredString("field")
+	if err != nil {
+		return nil, err
+	}
+	parent, err := o.string("path", "")
+	if err != nil {
+		return nil, err
+	}
+	override, err := o.bool("override", false)
+	if err != nil {
+		return nil, err
+	}
+	if field != "*" && !strings.Contains(field, ".") {
+		return nil, o.errorf("[field] field does not contain a dot and is not a wildcard")
+	}
+	return processorFunc(func(_ *runContext, d *document) error {
+		container := d.source
+		if parent != "" {
+			v, _ := d.get(parent)
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			container = m
+		}
+		var keys []string
+		if field == "*" {
+			for k := range container {
+				if strings.Contains(k, ".") {
+					keys = append(keys, k)
+				}
+			}
+		} else if _, found := container[field]; found {
+			keys = []string{field}
+		}
+		sub := &document{source: container, ingest: d.ingest}
+		for _, k := range keys {
+			v := container[k]
+			delete(container, k)
+			if sub.has(k) && !override {
+				if err := sub.appendValue(k, []interface{}{v}, true); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := sub.set(k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}), nil
+}
+
+func newURIParts(_ *compiler, o *options) (processor, error) {
+	field, err := o.template("field", true)
+	if err != nil {
+		return nil, err
+	}
+	target, err := o.string("target_field", "url")
+	if err != nil {
+		return nil, err
+	}
+	keepOriginal, err := o.bool("keep_original", true)
+	if err != nil {
+		return nil, err
+	}
+	removeIfSuccessful, err := o.bool("remove_if_successful", false)
+	if err != nil {
+		return nil, err
+	}
+	ignoreMissing, err := o.bool("ignore_missing", false)
+	if err != nil {
+		return nil, err
+	}
+	return processorFunc(func(_ *runContext, d *document) error {
+		path := field.render(d)
+		s, found, err := d.getString(path, ignoreMissing)
+		if err != nil || !found {
+			return err
+		}
+		parts, err := uriParts(s)
+		if err != nil {
+			return err
+		}
+		if keepOriginal {
+			parts["original"] = s
+		}
+		if removeIfSuccessful && path != target {
+			d.remove(path)
+		}
+		cur, _ := d.get(target)
+		if m, ok := cur.(map[string]interface{}); ok {
+			for k, v := range parts {
+				m[k] = v
+			}
+			return nil
+		}
+		return d.set(target, parts)
+	}), nil
+}
+
+func uriParts(s string) (map[string]interface{}, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse URI [%s]", s)
+	}
+	parts := map[string]interface{}{}
+	if u.Scheme != "" {
+		parts["scheme"] = u.Scheme
+	}
+	if host := u.Hostname(); host != "" {
+		parts["domain"] = host
+	}
+	if port := u.Port(); port != "" {
+		if n, err := strconv.ParseInt(port, 10, 64); err == nil {
+			parts["port"] = n
+		}
+	}
+	p := u.EscapedPath()
+	if u.Opaque != "" {
+		p = u.Opaque
+	}
+	if p != "" {
+		parts["path"] = p
+		if ext := path.Ext(p); len(ext) > 1 {
+			parts["extension"] = ext[1:]
+		}
+	}
+	if u.RawQuery != "" {
+		parts["query"] = u.RawQuery
+	}
+	if u.Fragment != "" {
+		parts["fragment"] = u.Fragment
+	}
+	if u.User != nil {
+		parts["user_info"] = u.User.String()
+		parts["username"] = u.User.Username()
+		if password, set := u.User.Password(); set {
+			parts["password"] = password
+		}
+	}
+	return parts, nil
+}
+
+func newKV(_ *compiler, o *options) (processor, error) {
+	f, err := o.fieldOptions()
+	if err != nil {
+		return nil, err
+	}
+	fieldSplit, err := o.requiredString("field_split")
+	if err != nil {
+		return nil, err
+	}
+	valueSplit, err := o.requiredString("value_split")
+	if err != nil {
+		return nil, err
+	}
+	include, err := o.strings("include_keys")
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := o.strings("exclude_keys")
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := o.string("prefix", "")
+	if err != nil {
+		return nil, err
+	}
+	trimKey, err := o.string("trim_key", "")
+	if err != nil {
+		return nil, err
+	}
+	trimValue, err := o.string("trim_value", "")
+	if err != nil {
+		return nil, err
+	}
+	stripBrackets, err := o.bool("strip_brackets", false)
+	if err != nil {
+		return nil, err
+	}
+	fieldRE, err := regexp.Compile(fieldSplit)
+	if err != nil {
+		return nil, unsupportedf("unsupported field_split %q: %v", fieldSplit, err)
+	}
+	valueRE, err := regexp.Compile(valueSplit)
+	if err != nil {
+		return nil, unsupportedf("unsupported value_split %q: %v", valueSplit, err)
+	}
+	includeSet := stringSet(include)
+	excludeSet := stringSet(exclude)
+	return processorFunc(func(_ *runContext, d *document) error {
+		field := f.field.render(d)
+		s, found, err := d.getString(field, f.ignoreMissing)
+		if err != nil || !found {
+			return err
+		}
+		for _, pair := range fieldRE.Split(s, -1) {
+			kv := valueRE.Split(pair, 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("field [%s] does not contain value_split [%s]", field, valueSplit)
+			}
+			key := strings.Trim(kv[0], trimKey)
+			value := strings.Trim(kv[1], trimValue)
+			if stripBrackets {
+				value = stripBracketsAndQuotes(value)
+			}
+			if len(includeSet) > 0 && !includeSet[key] || excludeSet[key] {
+				continue
+			}
+			path := prefix + key
+			if f.targetField != nil {
+				path = f.targetField.render(d) + "." + path
+			}
+			if err := d.appendValueOrSet(path, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}), nil
+}
+
+// appendValueOrSet sets a field, or appends to it if it already exists.
+func (d *document) appendValueOrSet(path string, v interface{}) error {
+	if d.has(path) {
+		return d.appendValue(path, []interface{}{v}, true)
+	}
+	return d.set(path, v)
+}
+
+func stripBracketsAndQuotes(s string) string {
+	for _, pair := range []string{"()", "<>", "[]", `""`, "''"} {
+		if len(s) >= 2 && s[0] == pair[0] && s[len(s)-1] == pair[1] {
+			return s[1 : len(s)-1]
+		}
+	}
+	return s
+}
+
+func stringSet(items []string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func newDissect(_ *compiler, o *options) (processor, error) {
+	f, err := o.fieldOptions()
+	if err != nil {
+		return nil, err
+	}
+	pattern, err := o.requiredString("pattern")
+	if err != nil {
+		return nil, err
+	}
+	o.raw("append_separator")
+	dissector, err := dissect.New(pattern)
+	if err != nil {
+		return nil, unsupportedf("unsupported pattern %q: %v", pattern, err)
+	}
+	return processorFunc(func(_ *runContext, d *document) error {
+		field := f.field.render(d)
+		s, found, err := d.getString(field, f.ignoreMissing)
+		if err != nil || !found {
+			return err
+		}
+		m, err := dissector.Dissect(s)
+		if err != nil {
+			return fmt.Errorf("Unable to find match for dissect pattern: %s against source: %s", pattern, s)
+		}
+		for k, v := range m {
+			if err := d.set(k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}), nil
+}
+
+func newForeach(c *compiler, o *options) (processor, error) {
+	field, err := o.template("field", true)
+	if err != nil {
+		return nil, err
+	}
+	ignoreMissing, err := o.bool("ignore_missing", false)
+	if err != nil {
+		return nil, err
+	}
+	raw, _ := o.raw("processor")
+	def, ok := raw.(map[string]interface{})
+	if !ok || len(def) != 1 {
+		return nil, o.errorf("[processor] must be an object with a single processor type")
+	}
+	var inner *entry
+	for typ, cfg := range def {
+		if inner, err = c.compileEntry(typ, cfg); err != nil {
+			return nil, err
+		}
+	}
+	if inner == nil {
+		return nil, unsupportedf("nested processor is not supported")
+	}
+	return processorFunc(func(ctx *runContext, d *document) error {
+		path := field.render(d)
+		v, found := d.get(path)
+		if !found || v == nil {
+			if ignoreMissing {
+				return nil
+			}
+			return fmt.Errorf("field [%s] not present as part of path [%s]", lastElement(path), path)
+		}
+		defer delete(d.ingest, "_value")
+		defer delete(d.ingest, "_key")
+		switch v := v.(type) {
+		case *list:
+			for i, item := range v.items {
+				d.ingest["_value"] = item
+				if err := inner.run(ctx, d); err != nil {
+					return err
+				}
+				v.items[i] = d.ingest["_value"]
+			}
+		case map[string]interface{}:
+			for _, k := range sortedKeys(v) {
+				d.ingest["_key"] = k
+				d.ingest["_value"] = v[k]
+				if err := inner.run(ctx, d); err != nil {
+					return err
+				}
+				v[k] = d.ingest["_value"]
+			}
+		default:
+			return fmt.Errorf("field [%s] of type [%s] cannot be cast to a list or map", path, javaType(v))
+		}
+		return nil
+	}), nil
+}
+
+func newPipelineProcessor(c *compiler, o *options) (processor, error) {
+	name, err := o.template("name", true)
+	if err != nil {
+		return nil, err
+	}
+	if !name.static() {
+		// The called pipelines must be known to decide whether the
+		// pipeline runs locally.
+		return nil, unsupportedf("pipeline names with templates are not supported")
+	}
+	c.calls = append(c.calls, name.render(nil))
+	ignoreMissing, err := o.bool("ignore_missing_pipeline", false)
+	if err != nil {
+		return nil, err
+	}
+	return processorFunc(func(ctx *runContext, d *document) error {
+		id := name.render(d)
+		p, found := ctx.registry.get(id)
+		if !found {
+			if ignoreMissing {
+				return nil
+			}
+			return fmt.Errorf("Pipeline processor configured for non-existent pipeline [%s]", id)
+		}
+		if ctx.depth >= maxPipelineDepth {
+			return fmt.Errorf("Cycle detected for pipeline: %s", id)
+		}
+		ctx.depth++
+		defer func() { ctx.depth-- }()
+		return p.execute(ctx, d)
+	}), nil
+}
+
+func newScript(_ *compiler, o *options) (processor, error) {
+	s, params, err := compileScriptOptions(o)
+	if err != nil {
+		return nil, err
+	}
+	return processorFunc(func(_ *runContext, d *document) error {
+		_, err := s.execute(d.source, params)
+		return err
+	}), nil
+}
@@ -0,0 +1,251 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestProcessors(t *testing.T) {
+	tests := []struct {
+		name       string
+		processors string
+		fields     mapstr.M
+		want       mapstr.M
+		wantErr    string
+	}{
+		{
+			name:       "set",
+			processors: `[{"set": {"field": "a.b", "value": "{{c}}"}}, {"set": {"field": "c", "value": "x", "override": false}}]`,
+			fields:     mapstr.M{"c": "y"},
+			want:       mapstr.M{"a": mapstr.M{"b": "y"}, "c": "y"},
+		},
+		{
+			name:       "set copy_from and ignore_empty_value",
+			processors: `[{"set": {"field": "b", "copy_from": "a"}}, {"set": {"field": "c", "value": "{{missing}}", "ignore_empty_value": true}}]`,
+			fields:     mapstr.M{"a": mapstr.M{"x": "1"}},
+			want:       mapstr.M{"a": mapstr.M{"x": "1"}, "b": mapstr.M{"x": "1"}},
+		},
+		{
+			name:       "append",
+			processors: `[{"append": {"field": "tags", "value": ["a", "{{b}}"], "allow_duplicates": false}}]`,
+			fields:     mapstr.M{"tags": "a", "b": "c"},
+			want:       mapstr.M{"tags": []interface{}{"a", "c"}, "b": "c"},
+		},
+		{
+			name:       "rename",
+			processors: `[{"rename": {"field": "a", "target_field": "b.c"}}]`,
+			fields:     mapstr.M{"a": "x"},
+			want:       mapstr.M{"b": mapstr.M{"c": "x"}},
+		},
+		{
+			name:       "rename existing target",
+			processors: `[{"rename": {"field": "a", "target_field": "b"}}]`,
+			fields:     mapstr.M{"a": "x", "b": "y"},
+			wantErr:    "field [b] already exists",
+		},
+		{
+			name:       "remove",
+			processors: `[{"remove": {"field": ["a", "b.c", "missing"], "ignore_missing": true}}]`,
+			fields:     mapstr.M{"a": "x", "b": mapstr.M{"c": "y", "d": "z"}},
+			want:       mapstr.M{"b": mapstr.M{"d": "z"}},
+		},
+		{
+			name:       "remove keep",
+			processors: `[{"remove": {"keep": ["b.d"]}}]`,
+			fields:     mapstr.M{"a": "x", "b": mapstr.M{"c": "y", "d": "z"}},
+			want:       mapstr.M{"b": mapstr.M{"d": "z"}},
+		},
+		{
+			name: "convert",
+			processors: `[
+				{"convert": {"field": "i", "type": "long"}},
+				{"convert": {"field": "f", "type": "float"}},
+				{"convert": {"field": "b", "type": "boolean"}},
+				{"convert": {"field": "l", "type": "integer", "target_field": "l2"}},
+				{"convert": {"field": "a", "type": "auto"}}
+			]`,
+			fields: mapstr.M{"i": "12", "f": "1.5", "b": "TRUE", "l": []interface{}{"1", "0x10"}, "a": "3.25"},
+			want:   mapstr.M{"i": int64(12), "f": 1.5, "b": true, "l": []interface{}{"1", "0x10"}, "l2": []interface{}{int64(1), int64(16)}, "a": 3.25},
+		},
+		{
+			name:       "convert invalid",
+			processors: `[{"convert": {"field": "i", "type": "integer"}}]`,
+			fields:     mapstr.M{"i": "abc"},
+			wantErr:    "unable to convert [abc] to integer",
+		},
+		{
+			name: "string transforms",
+			processors: `[
+				{"lowercase": {"field": "a"}},
+				{"uppercase": {"field": "b"}},
+				{"trim": {"field": "c"}},
+				{"urldecode": {"field": "d"}},
+				{"gsub": {"field": "e", "pattern": "(\\d+)-(\\d+)", "replacement": "$2:$1"}}
+			]`,
+			fields: mapstr.M{"a": []interface{}{"X", "Y"}, "b": "x", "c": " x ", "d": "a%20b+c", "e": "1-2 3-4"},
+			want:   mapstr.M{"a": []interface{}{"x", "y"}, "b": "X", "c": "x", "d": "a b c", "e": "2:1 4:3"},
+		},
+		{
+			name:       "split and join",
+			processors: `[{"split": {"field": "a", "separator": "\\s*,\\s*"}}, {"join": {"field": "a", "separator": "|", "target_field": "b"}}]`,
+			fields:     mapstr.M{"a": "x , y,z,,"},
+			want:       mapstr.M{"a": []interface{}{"x", "y", "z"}, "b": "x|y|z"},
+		},
+		{
+			name:       "json",
+			processors: `[{"json": {"field": "a", "target_field": "b"}}, {"json": {"field": "c", "add_to_root": true}}]`,
+			fields:     mapstr.M{"a": `{"x": [1, 2.5, null]}`, "c": `{"d": "e"}`},
+			want:       mapstr.M{"a": `{"x": [1, 2.5, null]}`, "b": mapstr.M{"x": []interface{}{int64(1), 2.5, nil}}, "c": `{"d": "e"}`, "d": "e"},
+		},
+		{
+			name:       "dot_expander",
+			processors: `[{"dot_expander": {"field": "*"}}]`,
+			fields:     mapstr.M{"a.b": "x", "a.c": "y"},
+			want:       mapstr.M{"a": mapstr.M{"b": "x", "c": "y"}},
+		},
+		{
+			name:       "uri_parts",
+			processors: `[{"uri_parts": {"field": "u"}}]`,
+			fields:     mapstr.M{"u": "https://user:pw@example.com:8443/a/b.php?q=1#f"},
+			want: mapstr.M{"u": "https://user:pw@example.com:8443/a/b.php?q=1#f", "url": mapstr.M{
+				"original": "https://user:pw@example.com:8443/a/b.php?q=1#f", "scheme": "https", "domain": "example.com",
+				"port": int64(8443), "path": "/a/b.php", "extension": "php", "query": "q=1", "fragment": "f",
+				"user_info": "user:pw", "username": "user", "password": "pw",
+			}},
+		},
+		{
+			name:       "kv",
+			processors: `[{"kv": {"field": "m", "field_split": " ", "value_split": "=", "target_field": "kv", "exclude_keys": ["z"], "strip_brackets": true}}]`,
+			fields:     mapstr.M{"m": "a=1 b=(2) a=3 z=4"},
+			want:       mapstr.M{"m": "a=1 b=(2) a=3 z=4", "kv": mapstr.M{"a": []interface{}{"1", "3"}, "b": "2"}},
+		},
+		{
+			name:       "foreach",
+			processors: `[{"foreach": {"field": "l", "processor": {"uppercase": {"field": "_ingest._value"}}}}]`,
+			fields:     mapstr.M{"l": []interface{}{"a", "b"}},
+			want:       mapstr.M{"l": []interface{}{"A", "B"}},
+		},
+		{
+			name:       "dissect",
+			processors: `[{"dissect": {"field": "m", "pattern": "%{a} [%{b.c}] %{d}"}}]`,
+			fields:     mapstr.M{"m": "x [y] z z"},
+			want:       mapstr.M{"m": "x [y] z z", "a": "x", "b": mapstr.M{"c": "y"}, "d": "z z"},
+		},
+		{
+			name: "grok",
+			processors: `[{"grok": {
+				"field": "m",
+				"patterns": ["^%{NUMBER:n:int} %{WORD:a.b}$", "%{IP:source.ip} %{MYWORD:[c]}(?<d.e>\\w+)"],
+				"pattern_definitions": {"MYWORD": "%{WORD:f} "}
+			}}]`,
+			fields: mapstr.M{"m": "10.0.0.1 x y"},
+			want:   mapstr.M{"m": "10.0.0.1 x y", "source": mapstr.M{"ip": "10.0.0.1"}, "[c]": "x ", "f": "x", "d": mapstr.M{"e": "y"}},
+		},
+		{
+			name:       "grok type hints",
+			processors: `[{"grok": {"field": "m", "patterns": ["%{NUMBER:n:int} %{NUMBER:f:float}"]}}]`,
+			fields:     mapstr.M{"m": "10 1.5"},
+			want:       mapstr.M{"m": "10 1.5", "n": int64(10), "f": 1.5},
+		},
+		{
+			name:       "grok no match",
+			processors: `[{"grok": {"field": "m", "patterns": ["^%{NUMBER:n}$"]}}]`,
+			fields:     mapstr.M{"m": "x"},
+			wantErr:    "Provided Grok expressions do not match field value: [x]",
+		},
+		{
+			name: "date",
+			processors: `[
+				{"date": {"field": "a", "formats": ["yyyy-MM-dd HH:mm:ss,SSS"], "timezone": "{{tz}}", "target_field": "b"}},
+				{"date": {"field": "c", "formats": ["ISO8601", "UNIX_MS"], "target_field": "d", "output_format": "yyyy/MM/dd"}}
+			]`,
+			fields: mapstr.M{"a": "2024-03-01 12:00:00,123", "tz": "Europe/Paris", "c": "1709290800000"},
+			want:   mapstr.M{"a": "2024-03-01 12:00:00,123", "tz": "Europe/Paris", "b": "2024-03-01T12:00:00.123+01:00", "c": "1709290800000", "d": "2024/03/01"},
+		},
+		{
+			name:       "geoip",
+			processors: `[{"geoip": {"field": "ip", "target_field": "as", "database_file": "GeoLite2-ASN.mmdb"}}, {"geoip": {"field": "ip2", "ignore_missing": true}}]`,
+			fields:     mapstr.M{"ip": "1.128.0.1"},
+			want: mapstr.M{"ip": "1.128.0.1", "as": mapstr.M{
+				"ip": "1.128.0.1", "asn": int64(1221), "organization_name": "Telstra Pty Ltd", "network": "1.128.0.0/11",
+			}},
+		},
+		{
+			name:       "geoip unavailable database",
+			processors: `[{"geoip": {"field": "ip", "database_file": "missing.mmdb"}}]`,
+			fields:     mapstr.M{"ip": "8.8.8.8"},
+			want:       mapstr.M{"ip": "8.8.8.8", "tags": []interface{}{"_geoip_database_unavailable_missing.mmdb"}},
+		},
+		{
+			name:       "user_agent",
+			processors: `[{"user_agent": {"field": "ua", "properties": ["name", "version", "os"]}}]`,
+			fields:     mapstr.M{"ua": "curl/8.4.0"},
+			want:       mapstr.M{"ua": "curl/8.4.0", "user_agent": mapstr.M{"name": "curl", "version": "8.4.0"}},
+		},
+		{
+			name: "condition",
+			processors: `[
+				{"set": {"field": "a", "value": "x", "if": "ctx.b?.c == 'y' && ctx.b.c.length() > 0"}},
+				{"set": {"field": "d", "value": "x", "if": "ctx.missing?.c != null"}},
+				{"set": {"field": "e", "value": "x", "if": {"source": "ctx.b.c == params.c", "params": {"c": "y"}}}}
+			]`,
+			fields: mapstr.M{"b": mapstr.M{"c": "y"}},
+			want:   mapstr.M{"a": "x", "b": mapstr.M{"c": "y"}, "e": "x"},
+		},
+		{
+			name:       "condition failure",
+			processors: `[{"set": {"field": "a", "value": "x", "if": "ctx.b.c == 'y'"}}]`,
+			fields:     mapstr.M{},
+			wantErr:    "failed to evaluate condition: NullPointerException: cannot access field [c] of null",
+		},
+		{
+			name: "script",
+			processors: `[{"script": {
+				"source": "ctx.b = params.prefix + ctx.a.toUpperCase(); if (ctx.tags == null) { ctx.tags = [] } ctx.tags.add(ctx.a)",
+				"params": {"prefix": "x-"}
+			}}]`,
+			fields: mapstr.M{"a": "y"},
+			want:   mapstr.M{"a": "y", "b": "x-Y", "tags": []interface{}{"y"}},
+		},
+		{
+			name:       "script failure",
+			processors: `[{"script": {"source": "ctx.a.b = 1"}}]`,
+			fields:     mapstr.M{},
+			wantErr:    "NullPointerException: cannot set field [b] of null",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestRegistry(t, map[string]string{"p": `{"processors": ` + tc.processors + `}`})
+			out, err := r.Run(newTestEvent("p", tc.fields))
+			require.NoError(t, err)
+			if tc.wantErr != "" {
+				msg, _ := out.Fields.GetValue("error.message")
+				assert.Equal(t, tc.wantErr, msg)
+				return
+			}
+			assert.Equal(t, tc.want, out.Fields)
+		})
+	}
+}
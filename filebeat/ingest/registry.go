@@ -0,0 +1,179 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/beat/events"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// ErrUnsupported is returned by Add for pipelines with processors that can't
+// run locally. Events for these pipelines are left to Elasticsearch.
+var ErrUnsupported = errors.New("pipeline can't run inside Filebeat")
+
+// Registry holds the pipelines that run locally, indexed by pipeline ID.
+type Registry struct {
+	log   *logp.Logger
+	geoip *geoIPDatabases
+
+	mu        sync.RWMutex
+	pipelines map[string]*pipeline
+	// rejected are the pipelines that can't run locally.
+	rejected map[string]bool
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry(config Config, log *logp.Logger) *Registry {
+	return &Registry{
+		log:       log,
+		geoip:     newGeoIPDatabases(config.GeoIP.DatabaseDir, log),
+		pipelines: map[string]*pipeline{},
+		rejected:  map[string]bool{},
+	}
+}
+
+// Add compiles a pipeline definition, as sent to the Elasticsearch ingest
+// pipeline API, and registers it. If any processor of the pipeline can't run
+// locally, the whole pipeline is rejected with an error wrapping
+// ErrUnsupported, so that documents are never partially processed.
+func (r *Registry) Add(id string, definition map[string]interface{}) error {
+	c := &compiler{registry: r, pipeline: id}
+	p, err := c.compilePipeline(definition)
+	var unsupported *unsupportedError
+	if errors.As(err, &unsupported) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.rejected[id] = true
+		return fmt.Errorf("%w: pipeline %s: %v", ErrUnsupported, id, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to compile pipeline %s: %w", id, err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pipelines[id] = p
+	return nil
+}
+
+// Has reports whether a pipeline has been added, including pipelines that
+// have been rejected.
+func (r *Registry) Has(id string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, found := r.pipelines[id]
+	return found || r.rejected[id]
+}
+
+func (r *Registry) get(id string) (*pipeline, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, found := r.pipelines[id]
+	return p, found
+}
+
+// Close releases the GeoIP databases.
+func (r *Registry) Close() error {
+	return r.geoip.Close()
+}
+
+// local returns the pipeline if it and all the pipelines it calls run
+// locally.
+func (r *Registry) local(id string) (*pipeline, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, found := r.pipelines[id]
+	if !found || !r.callsLocal(p, map[string]bool{id: true}) {
+		return nil, false
+	}
+	return p, true
+}
+
+func (r *Registry) callsLocal(p *pipeline, seen map[string]bool) bool {
+	for _, id := range p.calls {
+		if r.rejected[id] {
+			return false
+		}
+		// Pipelines that are not known fail in the same way in
+		// Elasticsearch.
+		called, found := r.pipelines[id]
+		if !found || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if !r.callsLocal(called, seen) {
+			return false
+		}
+	}
+	return true
+}
+
+// Run executes the pipeline named by the pipeline metadata field of the
+// event. The metadata field is removed so that the pipeline isn't executed
+// again by Elasticsearch. Events without pipeline, with an unknown pipeline
+// or with a pipeline that can't run locally are returned unchanged. It
+// returns nil if the event is dropped. Failures not handled by the pipeline
+// are reported in error.message.
+func (r *Registry) Run(event *beat.Event) (*beat.Event, error) {
+	id, err := events.GetMetaStringValue(*event, events.FieldMetaPipeline)
+	if err != nil || id == "" {
+		return event, nil
+	}
+	p, found := r.local(id)
+	if !found {
+		return event, nil
+	}
+	delete(event.Meta, events.FieldMetaPipeline)
+
+	d := newDocument(event)
+	err = p.execute(&runContext{registry: r}, d)
+	if errors.Is(err, errDropped) {
+		return nil, nil
+	}
+	if err != nil {
+		// Keep the partially processed document like Filebeat processors do.
+		d.apply(event)
+		event.Fields[beat.ErrorFieldKey] = mapstr.M{
+			"message": err.Error(),
+			"type":    "ingest_pipeline",
+		}
+		return event, nil
+	}
+	d.apply(event)
+	return event, nil
+}
+
+// Processor returns the registry as a beat.Processor.
+func (r *Registry) Processor() beat.Processor {
+	return (*localPipelines)(r)
+}
+
+type localPipelines Registry
+
+func (p *localPipelines) Run(event *beat.Event) (*beat.Event, error) {
+	return (*Registry)(p).Run(event)
+}
+
+func (p *localPipelines) String() string {
+	return "local_ingest_pipelines"
+}
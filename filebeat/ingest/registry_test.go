@@ -0,0 +1,314 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// newTestRegistry creates a registry with pipelines given as JSON.
+func newTestRegistry(t *testing.T, pipelines map[string]string) *Registry {
+	t.Helper()
+	r := NewRegistry(Config{GeoIP: GeoIPConfig{DatabaseDir: "../../testing/environments"}}, logp.NewLogger("ingest"))
+	t.Cleanup(func() { r.Close() })
+	for id, src := range pipelines {
+		var def map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(src), &def))
+		require.NoError(t, r.Add(id, def))
+	}
+	return r
+}
+
+func newTestEvent(pipeline string, fields mapstr.M) *beat.Event {
+	return &beat.Event{
+		Timestamp: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		Meta:      mapstr.M{"pipeline": pipeline},
+		Fields:    fields,
+	}
+}
+
+func TestRegistryRun(t *testing.T) {
+	r := newTestRegistry(t, map[string]string{
+		"main": `{"processors": [{"set": {"field": "a", "value": "{{b}}-x"}}]}`,
+		"drop": `{"processors": [{"drop": {}}]}`,
+		"fail": `{"processors": [
+			{"set": {"field": "a", "value": "{{b}}-x"}},
+			{"fail": {"message": "failed on {{b}}"}}
+		]}`,
+	})
+
+	t.Run("runs pipeline", func(t *testing.T) {
+		out, err := r.Run(newTestEvent("main", mapstr.M{"b": "y"}))
+		require.NoError(t, err)
+		assert.Equal(t, mapstr.M{"a": "y-x", "b": "y"}, out.Fields)
+		assert.NotContains(t, out.Meta, "pipeline")
+	})
+
+	t.Run("unknown pipeline", func(t *testing.T) {
+		event := newTestEvent("other", mapstr.M{"b": "y"})
+		out, err := r.Run(event)
+		require.NoError(t, err)
+		assert.Equal(t, mapstr.M{"b": "y"}, out.Fields)
+		assert.Equal(t, "other", out.Meta["pipeline"])
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		out, err := r.Run(newTestEvent("drop", mapstr.M{"b": "y"}))
+		require.NoError(t, err)
+		assert.Nil(t, out)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		out, err := r.Run(newTestEvent("fail", mapstr.M{"b": "fail"}))
+		require.NoError(t, err)
+		assert.Equal(t, "fail-x", out.Fields["a"])
+		assert.Equal(t, mapstr.M{"message": "failed on fail", "type": "ingest_pipeline"}, out.Fields["error"])
+	})
+}
+
+func TestRegistryRunMetadata(t *testing.T) {
+	r := newTestRegistry(t, map[string]string{
+		"main": `{"processors": [
+			{"set": {"field": "_id", "value": "{{id}}"}},
+			{"set": {"field": "_index", "value": "logs-{{b}}"}},
+			{"date": {"field": "ts", "formats": ["dd/MMM/yyyy:HH:mm:ss Z"]}}
+		]}`,
+	})
+	out, err := r.Run(newTestEvent("main", mapstr.M{"id": "1", "b": "y", "ts": "07/Dec/2016:11:05:07 +0100"}))
+	require.NoError(t, err)
+	assert.Equal(t, "1", out.Meta["_id"])
+	assert.Equal(t, "logs-y", out.Meta["raw_index"])
+	assert.True(t, time.Date(2016, 12, 7, 10, 5, 7, 0, time.UTC).Equal(out.Timestamp), out.Timestamp)
+	assert.NotContains(t, out.Fields, "@timestamp")
+}
+
+func TestPipelineFailureHandling(t *testing.T) {
+	r := newTestRegistry(t, map[string]string{
+		"main": `{
+			"processors": [
+				{"rename": {"field": "missing", "target_field": "x", "ignore_failure": true}},
+				{"rename": {"field": "missing", "target_field": "x", "tag": "r1", "on_failure": [
+					{"set": {"field": "handled", "value": "{{_ingest.on_failure_processor_type}}:{{_ingest.on_failure_processor_tag}}"}}
+				]}},
+				{"pipeline": {"name": "inner"}},
+				{"set": {"field": "after", "value": true}}
+			],
+			"on_failure": [
+				{"set": {"field": "error.message", "value": "{{_ingest.on_failure_message}}"}}
+			]
+		}`,
+		"inner": `{"processors": [
+			{"set": {"field": "inner", "value": "ok"}},
+			{"fail": {"message": "inner failed"}}
+		]}`,
+	})
+	out, err := r.Run(newTestEvent("main", mapstr.M{}))
+	require.NoError(t, err)
+	assert.Equal(t, mapstr.M{
+		"handled": "rename:r1",
+		"inner":   "ok",
+		"error":   mapstr.M{"message": "inner failed"},
+	}, out.Fields)
+}
+
+func TestPipelineRejectsUnsupported(t *testing.T) {
+	for name, src := range map[string]string{
+		"processor type": `{"processors": [{"community_id": {}}, {"set": {"field": "b", "value": "x"}}]}`,
+		"script":         `{"processors": [{"script": {"source": "while (ctx.a == null) { ctx.a = 1 }"}}]}`,
+		"stored script":  `{"processors": [{"script": {"id": "my-script"}}]}`,
+		"condition":      `{"processors": [{"set": {"field": "a", "value": 1, "if": "ctx.b =~ /x/"}}]}`,
+		"method":         `{"processors": [{"set": {"field": "a", "value": 1, "if": "ctx.b.matches('x')"}}]}`,
+		"on_failure":     `{"processors": [{"set": {"field": "a", "value": 1, "on_failure": [{"community_id": {}}]}}]}`,
+		"pipeline name":  `{"processors": [{"pipeline": {"name": "{{name}}"}}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := newTestRegistry(t, nil)
+			var def map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(src), &def))
+			require.ErrorIs(t, r.Add("main", def), ErrUnsupported)
+			assert.True(t, r.Has("main"))
+
+			out, err := r.Run(newTestEvent("main", mapstr.M{}))
+			require.NoError(t, err)
+			assert.Equal(t, mapstr.M{}, out.Fields)
+			assert.Equal(t, "main", out.Meta["pipeline"])
+		})
+	}
+}
+
+func TestPipelineCallsUnsupported(t *testing.T) {
+	r := newTestRegistry(t, map[string]string{
+		"main": `{"processors": [{"set": {"field": "a", "value": 1}}, {"pipeline": {"name": "inner"}}]}`,
+	})
+	require.ErrorIs(t, r.Add("inner", map[string]interface{}{
+		"processors": []interface{}{map[string]interface{}{"community_id": map[string]interface{}{}}},
+	}), ErrUnsupported)
+
+	out, err := r.Run(newTestEvent("main", mapstr.M{}))
+	require.NoError(t, err)
+	assert.Equal(t, mapstr.M{}, out.Fields)
+	assert.Equal(t, "main", out.Meta["pipeline"])
+}
+
+func TestPipelineInvalid(t *testing.T) {
+	r := newTestRegistry(t, nil)
+	err := r.Add("main", map[string]interface{}{"processors": "set"})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrUnsupported)
+	assert.False(t, r.Has("main"))
+}
+
+func TestPipelineCycle(t *testing.T) {
+	r := newTestRegistry(t, map[string]string{
+		"main": `{"processors": [{"pipeline": {"name": "main"}}]}`,
+	})
+	out, err := r.Run(newTestEvent("main", mapstr.M{}))
+	require.NoError(t, err)
+	assert.Contains(t, out.Fields["error"].(mapstr.M)["message"], "Cycle detected")
+}
+
+// TestModulePipeline runs the nginx access module pipeline, with its
+// conditions and scripts, and compares the result with
+// ../module/nginx/access/test/test.log-expected.json.
+func TestModulePipeline(t *testing.T) {
+	data, err := os.ReadFile("../module/nginx/access/ingest/pipeline.yml")
+	require.NoError(t, err)
+	var def interface{}
+	require.NoError(t, yaml.Unmarshal(data, &def))
+	r := newTestRegistry(t, nil)
+	require.NoError(t, r.Add("nginx", fixYAML(def).(map[string]interface{})))
+
+	line := `10.0.0.2, 10.0.0.1, 81.2.69.143 - - [07/Dec/2016:11:05:07 +0100] "GET /ocelot HTTP/1.1" 200 571 "-" "Mozilla/5.0 (Macintosh; Intel Mac OS X 10.12; rv:49.0) Gecko/20100101 Firefox/49.0"`
+	out, err := r.Run(newTestEvent("nginx", mapstr.M{"message": line}))
+	require.NoError(t, err)
+
+	want := map[string]interface{}{
+		"source.address":              "81.2.69.143",
+		"source.ip":                   "81.2.69.143",
+		"source.geo.city_name":        "London",
+		"source.geo.country_iso_code": "GB",
+		"http.request.method":         "GET",
+		"http.response.status_code":   int64(200),
+		"http.response.body.bytes":    int64(571),
+		"http.version":                "1.1",
+		"url.original":                "/ocelot",
+		"url.path":                    "/ocelot",
+		"user_agent.name":             "Firefox",
+		"user_agent.os.full":          "Mac OS X 10.12",
+		"event.kind":                  "event",
+		"event.category":              []interface{}{"web"},
+		"event.type":                  []interface{}{"access"},
+		"event.outcome":               "success",
+		"related.ip":                  []interface{}{"81.2.69.143"},
+		"nginx.access.remote_ip_list": []interface{}{"10.0.0.2", "10.0.0.1", "81.2.69.143"},
+	}
+	for k, v := range want {
+		got, err := out.Fields.GetValue(k)
+		if assert.NoError(t, err, k) {
+			assert.Equal(t, v, got, k)
+		}
+	}
+	assert.NotContains(t, out.Fields, "error")
+	assert.True(t, time.Date(2016, 12, 7, 10, 5, 7, 0, time.UTC).Equal(out.Timestamp), out.Timestamp)
+}
+
+// TestAccessLogPipeline runs a pipeline like the nginx access module pipeline
+// and compares the result with the events indexed by Elasticsearch.
+func TestAccessLogPipeline(t *testing.T) {
+	r := newTestRegistry(t, map[string]string{
+		"access": `{
+			"processors": [
+				{"rename": {"field": "message", "target_field": "event.original"}},
+				{"grok": {
+					"field": "event.original",
+					"patterns": ["%{NGINX_ADDRESS_LIST:nginx.access.remote_ip_list} - (-|%{DATA:user.name}) \\[%{HTTPDATE:nginx.access.time}\\] \"%{WORD:http.request.method} %{DATA:url.original} HTTP/%{NUMBER:http.version}\" %{NUMBER:http.response.status_code:long} %{NUMBER:http.response.body.bytes:long} \"(-|%{DATA:http.request.referrer})\" \"(-|%{DATA:user_agent.original})\""],
+					"pattern_definitions": {"NGINX_ADDRESS_LIST": "(?:%{IP}|%{WORD})(\"?,?\\s*(?:%{IP}|%{WORD}))*"}
+				}},
+				{"split": {"field": "nginx.access.remote_ip_list", "separator": "\"?,?\\s+"}},
+				{"grok": {"field": "event.original", "patterns": ["^%{IP:source.address}"]}},
+				{"grok": {"field": "source.address", "patterns": ["^%{IP:source.ip}$"]}},
+				{"date": {"field": "nginx.access.time", "target_field": "@timestamp", "formats": ["dd/MMM/yyyy:H:m:s Z"]}},
+				{"remove": {"field": ["nginx.access.time", "event.original"]}},
+				{"user_agent": {"field": "user_agent.original"}},
+				{"geoip": {"field": "source.ip", "target_field": "source.geo"}},
+				{"set": {"field": "event.outcome", "value": "success"}},
+				{"append": {"field": "related.ip", "value": "{{source.ip}}"}}
+			],
+			"on_failure": [{"set": {"field": "error.message", "value": "{{ _ingest.on_failure_message }}"}}]
+		}`,
+	})
+
+	line := `81.2.69.143, 10.0.0.1 - - [07/Dec/2016:11:05:07 +0100] "GET /ocelot HTTP/1.1" 200 571 "-" "Mozilla/5.0 (Macintosh; Intel Mac OS X 10.12; rv:49.0) Gecko/20100101 Firefox/49.0"`
+	out, err := r.Run(newTestEvent("access", mapstr.M{"message": line}))
+	require.NoError(t, err)
+
+	want := map[string]interface{}{
+		"source.address":              "81.2.69.143",
+		"source.ip":                   "81.2.69.143",
+		"source.geo.city_name":        "London",
+		"source.geo.country_iso_code": "GB",
+		"source.geo.region_iso_code":  "GB-ENG",
+		"source.geo.location.lat":     51.5142,
+		"http.request.method":         "GET",
+		"http.response.status_code":   int64(200),
+		"http.response.body.bytes":    int64(571),
+		"url.original":                "/ocelot",
+		"user_agent.name":             "Firefox",
+		"user_agent.version":          "49.0",
+		"user_agent.os.full":          "Mac OS X 10.12",
+		"event.outcome":               "success",
+		"related.ip":                  []interface{}{"81.2.69.143"},
+		"nginx.access.remote_ip_list": []interface{}{"81.2.69.143", "10.0.0.1"},
+	}
+	for k, v := range want {
+		got, err := out.Fields.GetValue(k)
+		if assert.NoError(t, err, k) {
+			assert.Equal(t, v, got, k)
+		}
+	}
+	assert.NotContains(t, out.Fields, "message")
+	assert.NotContains(t, out.Fields, "error")
+	assert.True(t, time.Date(2016, 12, 7, 10, 5, 7, 0, time.UTC).Equal(out.Timestamp), out.Timestamp)
+}
+
+func fixYAML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[k.(string)] = fixYAML(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = fixYAML(item)
+		}
+	}
+	return v
+}
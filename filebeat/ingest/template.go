@@ -0,0 +1,134 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// template is a string with mustache style {{field}} or {{{field}}}
+// references to document fields, as used in processor options.
+type template struct {
+	literals []string // one more literal than fields
+	fields   []string
+}
+
+func compileTemplate(s string) (*template, error) {
+	t := &template{}
+	for {
+		start := strings.Index(s, "{{")
+		if start < 0 {
+			t.literals = append(t.literals, s)
+			return t, nil
+		}
+		open, closing := "{{", "}}"
+		if strings.HasPrefix(s[start:], "{{{") {
+			open, closing = "{{{", "}}}"
+		}
+		end := strings.Index(s[start+len(open):], closing)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated template reference in %q", s)
+		}
+		field := strings.TrimSpace(s[start+len(open) : start+len(open)+end])
+		t.literals = append(t.literals, s[:start])
+		t.fields = append(t.fields, field)
+		s = s[start+len(open)+end+len(closing):]
+	}
+}
+
+// static reports whether the template has no field references.
+func (t *template) static() bool {
+	return len(t.fields) == 0
+}
+
+func (t *template) render(d *document) string {
+	if t.static() {
+		return t.literals[0]
+	}
+	var sb strings.Builder
+	for i, field := range t.fields {
+		sb.WriteString(t.literals[i])
+		if v, found := d.get(field); found {
+			sb.WriteString(formatValue(v))
+		}
+	}
+	sb.WriteString(t.literals[len(t.literals)-1])
+	return sb.String()
+}
+
+// valueSource is a processor option value whose strings may hold templates.
+type valueSource struct {
+	value interface{} // document value, strings are replaced by *template
+}
+
+func compileValue(v interface{}) (*valueSource, error) {
+	compiled, err := compileValueTemplates(toDoc(v))
+	if err != nil {
+		return nil, err
+	}
+	return &valueSource{value: compiled}, nil
+}
+
+func compileValueTemplates(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return compileTemplate(v)
+	case map[string]interface{}:
+		for k, item := range v {
+			compiled, err := compileValueTemplates(item)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = compiled
+		}
+	case *list:
+		for i, item := range v.items {
+			compiled, err := compileValueTemplates(item)
+			if err != nil {
+				return nil, err
+			}
+			v.items[i] = compiled
+		}
+	}
+	return v, nil
+}
+
+func (s *valueSource) render(d *document) interface{} {
+	return renderValue(s.value, d)
+}
+
+func renderValue(v interface{}, d *document) interface{} {
+	switch v := v.(type) {
+	case *template:
+		return v.render(d)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[k] = renderValue(item, d)
+		}
+		return m
+	case *list:
+		l := &list{items: make([]interface{}, len(v.items))}
+		for i, item := range v.items {
+			l.items[i] = renderValue(item, d)
+		}
+		return l
+	}
+	return v
+}
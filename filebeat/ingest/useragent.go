@@ -0,0 +1,96 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/elastic/beats/v7/libbeat/common/useragent"
+)
+
+var defaultUserAgentProperties = []string{"name", "os", "device", "original", "version"}
+
+// userAgentCacheSize is the number of parsed user agents cached by each
+// processor, as by the ingest.user_agent.cache_size default of Elasticsearch.
+const userAgentCacheSize = 1000
+
+func newUserAgent(_ *compiler, o *options) (processor, error) {
+	f, err := o.fieldOptions()
+	if err != nil {
+		return nil, err
+	}
+	properties, err := o.strings("properties")
+	if err != nil {
+		return nil, err
+	}
+	if len(properties) == 0 {
+		properties = defaultUserAgentProperties
+	}
+	if file, _ := o.string("regex_file", ""); file != "" {
+		return nil, unsupportedf("custom regex files are not supported")
+	}
+	o.raw("extract_device_type")
+	o.raw("ecs")
+	cache, err := lru.New(userAgentCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	return processorFunc(func(_ *runContext, d *document) error {
+		field := f.field.render(d)
+		target := "user_agent"
+		if f.targetField != nil {
+			target = f.targetField.render(d)
+		}
+		s, found, err := d.getString(field, f.ignoreMissing)
+		if err != nil || !found {
+			return err
+		}
+		var ua useragent.UserAgent
+		if cached, ok := cache.Get(s); ok {
+			ua = cached.(useragent.UserAgent)
+		} else {
+			ua = useragent.Parse(s)
+			cache.Add(s, ua)
+		}
+		info := map[string]interface{}{}
+		for _, p := range properties {
+			switch p {
+			case "name":
+				info["name"] = ua.Name
+			case "version":
+				if ua.Version != "" {
+					info["version"] = ua.Version
+				}
+			case "original":
+				info["original"] = s
+			case "device":
+				info["device"] = map[string]interface{}{"name": ua.Device}
+			case "os":
+				if ua.OS.Name == "" {
+					continue
+				}
+				os := map[string]interface{}{"name": ua.OS.Name, "full": ua.OS.Full}
+				if ua.OS.Version != "" {
+					os["version"] = ua.OS.Version
+				}
+				info["os"] = os
+			}
+		}
+		return d.set(target, info)
+	}), nil
+}
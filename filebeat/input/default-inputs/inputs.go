@@ -21,6 +21,7 @@ import (
 	"github.com/elastic/beats/v7/filebeat/beater"
 	"github.com/elastic/beats/v7/filebeat/input/filestream"
 	"github.com/elastic/beats/v7/filebeat/input/kafka"
+	"github.com/elastic/beats/v7/filebeat/input/kubernetesevents"
 	"github.com/elastic/beats/v7/filebeat/input/tcp"
 	"github.com/elastic/beats/v7/filebeat/input/udp"
 	"github.com/elastic/beats/v7/filebeat/input/unix"
@@ -40,6 +41,7 @@ func genericInputs(log *logp.Logger, components beater.StateStore) []v2.Plugin {
 	return []v2.Plugin{
 		filestream.Plugin(log, components),
 		kafka.Plugin(),
+		kubernetesevents.Plugin(log, components),
 		tcp.Plugin(),
 		udp.Plugin(),
 		unix.Plugin(),
@@ -38,6 +38,9 @@ func osInputs(info beat.Info, log *logp.Logger, components osComponents) []v2.Pl
 	if journald := journald.Plugin(log, components); journald != zeroPlugin {
 		plugins = append(plugins, journald)
 	}
+	if remote := journald.RemotePlugin(); remote != zeroPlugin {
+		plugins = append(plugins, remote)
+	}
 
 	return plugins
 }
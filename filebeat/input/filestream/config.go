@@ -43,6 +43,8 @@ type config struct {
 	CleanInactive  time.Duration      `config:"clean_inactive" validate:"min=-1"`
 	CleanRemoved   bool               `config:"clean_removed"`
 	HarvesterLimit uint32             `config:"harvester_limit" validate:"min=0"`
+	RateLimit      rateLimitConfig    `config:"rate_limit"`
+	FairShare      fairShareConfig    `config:"fair_share"`
 	IgnoreOlder    time.Duration      `config:"ignore_older"`
 	IgnoreInactive ignoreInactiveType `config:"ignore_inactive"`
 	Rotation       *conf.Namespace    `config:"rotation"`
@@ -75,6 +77,7 @@ type readerConfig struct {
 	LineTerminator readfile.LineTerminator `config:"line_terminator"`
 	MaxBytes       int                     `config:"message_max_bytes" validate:"min=0,nonzero"`
 	Tail           bool                    `config:"seek_to_tail"`
+	WholeFile      wholeFileConfig         `config:"whole_file"`
 
 	Parsers parser.Config `config:",inline"`
 }
@@ -103,6 +106,7 @@ func defaultConfig() config {
 		CleanInactive:  -1,
 		CleanRemoved:   true,
 		HarvesterLimit: 0,
+		FairShare:      defaultFairShareConfig(),
 		IgnoreOlder:    0,
 	}
 }
@@ -132,6 +136,7 @@ func defaultReaderConfig() readerConfig {
 		LineTerminator: readfile.AutoLineTerminator,
 		MaxBytes:       10 * humanize.MiByte,
 		Tail:           false,
+		WholeFile:      defaultWholeFileConfig(),
 	}
 }
 
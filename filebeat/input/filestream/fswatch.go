@@ -63,11 +63,15 @@ type fileWatcherConfig struct {
 // fileWatcher gets the list of files from a FSWatcher and creates events by
 // comparing the files between its last two runs.
 type fileWatcher struct {
-	cfg     fileWatcherConfig
-	prev    map[string]loginp.FileDescriptor
-	scanner loginp.FSScanner
-	log     *logp.Logger
-	events  chan loginp.FSEvent
+	cfg fileWatcherConfig
+	// writeOnModTime reports files whose modification time changed without
+	// a size change as written. Whole files rewritten with the same size are
+	// read again this way.
+	writeOnModTime bool
+	prev           map[string]loginp.FileDescriptor
+	scanner        loginp.FSScanner
+	log            *logp.Logger
+	events         chan loginp.FSEvent
 }
 
 func newFileWatcher(paths []string, ns *conf.Namespace) (loginp.FSWatcher, error) {
@@ -160,6 +164,9 @@ func (w *fileWatcher) watch(ctx unison.Canceler) {
 			if w.cfg.ResendOnModTime {
 				e = truncateEvent(path, fd)
 				truncatedCount++
+			} else if w.writeOnModTime {
+				e = writeEvent(path, fd)
+				writtenCount++
 			}
 
 		// the new size is larger, something was written
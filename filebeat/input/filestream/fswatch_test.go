@@ -255,6 +255,46 @@ scanner:
 		require.Equal(t, loginp.OpDone, e.Op)
 	})
 
+	t.Run("emits write on touch when writeOnModTime is set", func(t *testing.T) {
+		dir := t.TempDir()
+		paths := []string{filepath.Join(dir, "*.log")}
+		cfgStr := `
+scanner:
+  check_interval: 10ms
+`
+
+		ctx, cancel := context.WithTimeout(context.Background(), 1000*time.Millisecond)
+		defer cancel()
+
+		fw := createWatcherWithConfig(t, paths, cfgStr)
+		fw.(*fileWatcher).writeOnModTime = true
+		go fw.Run(ctx)
+
+		basename := "created.log"
+		filename := filepath.Join(dir, basename)
+		err := os.WriteFile(filename, []byte(strings.Repeat("a", 1024)), 0777)
+		require.NoError(t, err)
+
+		e := fw.Event()
+		require.Equal(t, loginp.OpCreate, e.Op)
+
+		time := time.Now().Local().Add(time.Hour)
+		err = os.Chtimes(filename, time, time)
+		require.NoError(t, err)
+
+		e = fw.Event()
+		expEvent := loginp.FSEvent{
+			NewPath: filename,
+			OldPath: filename,
+			Op:      loginp.OpWrite,
+			Descriptor: loginp.FileDescriptor{
+				Filename: filename,
+				Info:     file.ExtendFileInfo(&testFileInfo{name: basename, size: 1024}),
+			},
+		}
+		requireEqualEvents(t, expEvent, e)
+	})
+
 	t.Run("does not emit events for empty files", func(t *testing.T) {
 		dir := t.TempDir()
 		paths := []string{filepath.Join(dir, "*.log")}
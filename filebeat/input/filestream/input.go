@@ -49,6 +49,9 @@ const pluginName = "filestream"
 
 type state struct {
 	Offset int64 `json:"offset" struct:"offset"`
+	// ModTime is the modification time of files read with whole_file, in
+	// nanoseconds since the epoch.
+	ModTime int64 `json:"mod_time,omitempty" struct:"mod_time,omitempty"`
 }
 
 type fileMeta struct {
@@ -64,6 +67,10 @@ type filestream struct {
 	closerConfig    closerConfig
 	parsers         parser.Config
 	takeOver        bool
+	harvesterLimit  uint32
+	rateLimit       rateLimitConfig
+	fairShare       fairShareConfig
+	turns           *turnstile // shared by the harvesters of the input, nil if fair share is disabled
 }
 
 // Plugin creates a new filestream input plugin for creating a stateful input.
@@ -106,6 +113,12 @@ func configure(cfg *conf.C) (loginp.Prospector, loginp.Harvester, error) {
 		closerConfig:    config.Close,
 		parsers:         config.Reader.Parsers,
 		takeOver:        config.TakeOver,
+		harvesterLimit:  config.HarvesterLimit,
+		rateLimit:       config.RateLimit,
+		fairShare:       config.FairShare,
+	}
+	if config.FairShare.Enabled {
+		filestream.turns = &turnstile{}
 	}
 
 	return prospector, filestream, nil
@@ -141,6 +154,27 @@ func (inp *filestream) Run(
 	log := ctx.Logger.With("path", fs.newPath).With("state-id", src.Name())
 	state := initState(log, cursor, fs)
 
+	if inp.readerConfig.WholeFile.Enabled {
+		fi, err := waitForStableFile(ctx.Cancelation, fs.newPath, inp.readerConfig.WholeFile)
+		if err != nil {
+			if ctx.Cancelation.Err() != nil {
+				return nil
+			}
+			log.Errorf("File could not be checked for changes: %v", err)
+			return err
+		}
+		// The file is read again only if its size or its modification time
+		// has changed since it was read. States stored before the
+		// modification time was tracked only have the size.
+		modTime := fi.ModTime().UnixNano()
+		if fi.Size() == state.Offset && (state.ModTime == 0 || state.ModTime == modTime) {
+			log.Debugf("File has already been read. Path='%s'", fs.newPath)
+			return nil
+		}
+		state.Offset = 0
+		state.ModTime = modTime
+	}
+
 	r, truncated, err := inp.open(log, ctx.Cancelation, fs, state.Offset)
 	if err != nil {
 		log.Errorf("File could not be opened for reading: %v", err)
@@ -166,6 +200,9 @@ func (inp *filestream) Run(
 	defer streamCancel()
 
 	if err := inp.readFromSource(ctx, log, r, fs.newPath, state, publisher, metrics); err != nil {
+		if errors.Is(err, loginp.ErrHarvesterYield) {
+			return err
+		}
 		ctx.UpdateStatus(status.Degraded, fmt.Sprintf("error while reading from source: %v", err))
 		return err
 	}
@@ -205,6 +242,34 @@ func (inp *filestream) open(
 	ok := false // used for cleanup
 	defer cleanup.IfNot(&ok, cleanup.IgnoreError(f.Close))
 
+	var r reader.Reader
+	if inp.readerConfig.WholeFile.Enabled {
+		r = newWholeFileReader(f, encoding, inp.readerConfig.MaxBytes)
+	} else {
+		r, err = inp.newLineReader(log, canceler, f, encoding, fs)
+		if err != nil {
+			return nil, truncated, err
+		}
+	}
+
+	r = readfile.NewFilemeta(r, fs.newPath, fs.desc.Info, fs.desc.Fingerprint, offset)
+
+	r = inp.parsers.Create(r)
+
+	r = readfile.NewLimitReader(r, inp.readerConfig.MaxBytes)
+
+	ok = true // no need to close the file
+	return r, truncated, nil
+}
+
+// newLineReader creates the reader splitting the file content into lines.
+func (inp *filestream) newLineReader(
+	log *logp.Logger,
+	canceler input.Canceler,
+	f *os.File,
+	encoding encoding.Encoding,
+	fs fileSource,
+) (reader.Reader, error) {
 	log.Debug("newLogFileReader with config.MaxBytes:", inp.readerConfig.MaxBytes)
 
 	// if the file is archived, it means that it is not going to be updated in the future
@@ -224,12 +289,12 @@ func (inp *filestream) open(
 	// don't require 'complicated' logic.
 	logReader, err := newFileReader(log, canceler, f, inp.readerConfig, closerCfg)
 	if err != nil {
-		return nil, truncated, err
+		return nil, err
 	}
 
 	dbgReader, err := debug.AppendReaders(logReader)
 	if err != nil {
-		return nil, truncated, err
+		return nil, err
 	}
 
 	// Configure MaxBytes limit for EncodeReader as multiplied by 4
@@ -238,27 +303,17 @@ func (inp *filestream) open(
 	// The further size limiting is performed by LimitReader at the end of the readers pipeline as needed.
 	encReaderMaxBytes := inp.readerConfig.MaxBytes * 4
 
-	var r reader.Reader
-	r, err = readfile.NewEncodeReader(dbgReader, readfile.Config{
+	r, err := readfile.NewEncodeReader(dbgReader, readfile.Config{
 		Codec:      encoding,
 		BufferSize: inp.readerConfig.BufferSize,
 		Terminator: inp.readerConfig.LineTerminator,
 		MaxBytes:   encReaderMaxBytes,
 	})
 	if err != nil {
-		return nil, truncated, err
+		return nil, err
 	}
 
-	r = readfile.NewStripNewline(r, inp.readerConfig.LineTerminator)
-
-	r = readfile.NewFilemeta(r, fs.newPath, fs.desc.Info, fs.desc.Fingerprint, offset)
-
-	r = inp.parsers.Create(r)
-
-	r = readfile.NewLimitReader(r, inp.readerConfig.MaxBytes)
-
-	ok = true // no need to close the file
-	return r, truncated, nil
+	return readfile.NewStripNewline(r, inp.readerConfig.LineTerminator), nil
 }
 
 // openFile opens a file and checks for the encoding. In case the encoding cannot be detected
@@ -361,6 +416,10 @@ func (inp *filestream) readFromSource(
 	defer metrics.HarvesterOpenFiles.Dec()
 	defer metrics.HarvesterClosed.Inc()
 
+	throttle := newThrottle(path, inp.rateLimit, inp.turns, metrics)
+	defer throttle.close()
+	started := time.Now()
+
 	for ctx.Cancelation.Err() == nil {
 		message, err := r.Next()
 		if err != nil {
@@ -392,17 +451,36 @@ func (inp *filestream) readFromSource(
 			_ = mapstr.AddTags(message.Fields, []string{"take_over"})
 		}
 
-		if err := p.Publish(message.ToEvent(), s); err != nil {
+		if err := throttle.wait(ctxtool.FromCanceller(ctx.Cancelation), message.Bytes); err != nil {
+			return nil
+		}
+		err = p.Publish(message.ToEvent(), s)
+		throttle.done()
+		if err != nil {
 			metrics.ProcessingErrors.Inc()
 			return err
 		}
 
 		metrics.EventsProcessed.Inc()
 		metrics.ProcessingTime.Update(time.Since(message.Ts).Nanoseconds())
+
+		if inp.shouldYield(started, metrics) {
+			log.Debugf("Harvester limit reached, giving the slot to a waiting harvester. Path='%s'", path)
+			return loginp.ErrHarvesterYield
+		}
 	}
 	return nil
 }
 
+// shouldYield reports whether the harvester has run long enough to give its
+// slot to a harvester waiting because of the harvester limit.
+func (inp *filestream) shouldYield(started time.Time, metrics *loginp.Metrics) bool {
+	return inp.fairShare.Enabled &&
+		inp.harvesterLimit > 0 &&
+		time.Since(started) >= inp.fairShare.YieldAfter &&
+		metrics.HarvestersWaiting.Get() > 0
+}
+
 // isDroppedLine decides if the line is exported or not based on
 // the include_lines and exclude_lines options.
 func (inp *filestream) isDroppedLine(log *logp.Logger, line string) bool {
@@ -1060,3 +1060,51 @@ func TestRotatingCloseInactiveLowWriteRate(t *testing.T) {
 	cancelInput()
 	env.waitUntilInputStops()
 }
+
+func TestFilestreamWholeFile(t *testing.T) {
+	env := newInputTestingEnvironment(t)
+
+	testlogName := "report.json"
+	inp := env.mustCreateInput(map[string]interface{}{
+		"id":                                "fake-ID",
+		"paths":                             []string{env.abspath(testlogName)},
+		"prospector.scanner.check_interval": "1ms",
+		"whole_file.enabled":                "true",
+		"whole_file.stable_for":             "50ms",
+		"whole_file.check_interval":         "10ms",
+	})
+
+	testlines := []byte("{\n  \"status\": \"ok\"\n}\n")
+	env.mustWriteToFile(testlogName, testlines)
+
+	ctx, cancelInput := context.WithCancel(context.Background())
+	env.startInput(ctx, inp)
+
+	env.waitUntilEventCount(1)
+	env.requireEventsReceived([]string{string(testlines)})
+	env.requireOffsetInRegistry(testlogName, "fake-ID", len(testlines))
+
+	env.waitUntilHarvesterIsDone()
+
+	// the file is read again once it changes
+	moreLines := []byte("{\n  \"status\": \"failed\"\n}\n")
+	env.mustAppendToFile(testlogName, moreLines)
+
+	env.waitUntilEventCount(2)
+	env.requireEventContents(1, "message", string(testlines)+string(moreLines))
+
+	env.waitUntilHarvesterIsDone()
+
+	// the file is read again if it is rewritten with the same size
+	rewritten := bytes.ToUpper(append(append([]byte{}, testlines...), moreLines...))
+	env.mustWriteToFile(testlogName, rewritten)
+	modTime := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(env.abspath(testlogName), modTime, modTime))
+
+	env.waitUntilEventCount(3)
+	env.requireEventContents(2, "message", string(rewritten))
+
+	cancelInput()
+	env.waitUntilInputStops()
+	env.requireOffsetInRegistry(testlogName, "fake-ID", len(testlines)+len(moreLines))
+}
@@ -158,6 +158,76 @@ paths:
 	}
 }
 
+func TestWholeFile(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("content as single event", func(t *testing.T) {
+		filename := generateFile(t, dir, 5)
+		content, err := os.ReadFile(filename)
+		require.NoError(t, err)
+
+		cfg := fmt.Sprintf(`
+type: filestream
+prospector.scanner.check_interval: 1s
+whole_file:
+  enabled: true
+  stable_for: 0
+paths:
+    - %s`, filename)
+		runner := createFilestreamTestRunner(context.Background(), t, "whole-file-plain", cfg, 1, true)
+		events := runner(t)
+		require.Len(t, events, 1)
+		require.Equal(t, string(content), events[0].Fields["message"])
+		_, err = events[0].GetValue("log.flags")
+		require.ErrorIs(t, err, mapstr.ErrKeyNotFound)
+	})
+
+	t.Run("json document", func(t *testing.T) {
+		filename := filepath.Join(dir, "report.json")
+		err := os.WriteFile(filename, []byte("{\n  \"status\": \"ok\",\n  \"checks\": [1, 2]\n}\n"), 0o644)
+		require.NoError(t, err)
+
+		cfg := fmt.Sprintf(`
+type: filestream
+prospector.scanner.check_interval: 1s
+whole_file:
+  enabled: true
+  stable_for: 0
+parsers:
+  - ndjson:
+      target: report
+paths:
+    - %s`, filename)
+		runner := createFilestreamTestRunner(context.Background(), t, "whole-file-json", cfg, 1, true)
+		events := runner(t)
+		require.Len(t, events, 1)
+		status, err := events[0].GetValue("report.status")
+		require.NoError(t, err)
+		require.Equal(t, "ok", status)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		filename := generateFile(t, dir, 5)
+
+		cfg := fmt.Sprintf(`
+type: filestream
+prospector.scanner.check_interval: 1s
+message_max_bytes: 10
+whole_file:
+  enabled: true
+  stable_for: 0
+paths:
+    - %s`, filename)
+		runner := createFilestreamTestRunner(context.Background(), t, "whole-file-truncated", cfg, 1, true)
+		events := runner(t)
+		require.Len(t, events, 1)
+		require.Equal(t, "rather med", events[0].Fields["message"])
+		flags, err := events[0].GetValue("log.flags")
+		require.NoError(t, err)
+		require.Equal(t, []string{"truncated"}, flags)
+	})
+}
+
 // runFilestreamBenchmark runs the entire filestream input with the in-memory registry and the test pipeline.
 // `testID` must be unique for each test run
 // `cfg` must be a valid YAML string containing valid filestream configuration
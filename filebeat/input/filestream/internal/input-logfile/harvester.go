@@ -36,6 +36,11 @@ import (
 var (
 	ErrHarvesterAlreadyRunning = errors.New("harvester is already running for file")
 	ErrHarvesterLimitReached   = errors.New("harvester limit reached")
+
+	// ErrHarvesterYield is returned by Harvester.Run to give its slot to the
+	// harvesters waiting because the harvester limit has been reached. The
+	// harvester is started again after them.
+	ErrHarvesterYield = errors.New("harvester yielded its slot")
 )
 
 // Harvester is the reader which collects the lines from
@@ -139,7 +144,7 @@ func (hg *defaultHarvesterGroup) Start(ctx inputv2.Context, src Source) {
 	ctx.Logger = ctx.Logger.With("source_file", sourceName)
 	ctx.Logger.Debug("Starting harvester for file")
 
-	if err := hg.tg.Go(startHarvester(ctx, hg, src, false, hg.metrics)); err != nil {
+	if err := hg.goHarvester(startHarvester(ctx, hg, src, false, hg.metrics)); err != nil {
 		ctx.Logger.Warnf(
 			"tried to start harvester with task group already closed",
 			ctx.ID)
@@ -156,13 +161,31 @@ func (hg *defaultHarvesterGroup) Restart(ctx inputv2.Context, src Source) {
 	ctx.Logger = ctx.Logger.With("source_file", sourceName)
 	ctx.Logger.Debug("Restarting harvester for file")
 
-	if err := hg.tg.Go(startHarvester(ctx, hg, src, true, hg.metrics)); err != nil {
+	if err := hg.goHarvester(startHarvester(ctx, hg, src, true, hg.metrics)); err != nil {
 		ctx.Logger.Warnf(
 			"input %s tried to restart harvester with task group already closed",
 			ctx.ID)
 	}
 }
 
+// goHarvester runs the harvester in the task group. Until the harvester limit
+// allows it to start, it is counted as waiting.
+func (hg *defaultHarvesterGroup) goHarvester(fn func(context.Context) error) error {
+	if hg.metrics != nil {
+		hg.metrics.HarvestersWaiting.Inc()
+	}
+	err := hg.tg.Go(func(ctx context.Context) error {
+		if hg.metrics != nil {
+			hg.metrics.HarvestersWaiting.Dec()
+		}
+		return fn(ctx)
+	})
+	if err != nil && hg.metrics != nil {
+		hg.metrics.HarvestersWaiting.Dec()
+	}
+	return err
+}
+
 // startHarvester start starts the harvester. if restart is true, it'll first remove the
 // associated reader.
 // startHarvester does NOT check if the harvester limit has been reached. Its caller
@@ -177,6 +200,7 @@ func startHarvester(
 	srcID := hg.identifier.ID(src)
 
 	return func(canceler context.Context) error {
+		inputCtx := ctx
 		defer func() {
 			if v := recover(); v != nil {
 				err := fmt.Errorf("harvester panic with: %+v\n%s", v, debug.Stack())
@@ -235,6 +259,14 @@ func startHarvester(
 		publisher := &cursorPublisher{canceler: ctx.Cancelation, client: client, cursor: &cursor}
 
 		err = hg.harvester.Run(ctx, src, cursor, publisher, metrics)
+		if errors.Is(err, ErrHarvesterYield) {
+			ctx.Logger.Debug("Harvester yielded its slot to waiting harvesters")
+			hg.readers.remove(srcID)
+			if err := hg.goHarvester(startHarvester(inputCtx, hg, src, false, metrics)); err != nil {
+				ctx.Logger.Warnf("tried to restart harvester with task group already closed")
+			}
+			return nil
+		}
 		if err != nil && !errors.Is(err, context.Canceled) {
 			hg.readers.remove(srcID)
 			ctx.UpdateStatus(status.Degraded, fmt.Sprintf("error while running harvester: %v", err))
@@ -212,6 +212,56 @@ func TestDefaultHarvesterGroup(t *testing.T) {
 		require.Nil(t, hg.StopHarvesters())
 	})
 
+	t.Run("assert a yielding harvester is started again after the waiting harvesters", func(t *testing.T) {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var runs []string
+
+		hg := testDefaultHarvesterGroup(t, nil)
+		hg.tg = task.NewGroup(1, time.Second, &logp.Logger{}, "")
+		hg.metrics = NewMetrics("yield-test")
+		defer hg.metrics.Close()
+
+		harvesterRun := func(_ input.Context, s Source, _ Cursor, _ Publisher) error {
+			mu.Lock()
+			runs = append(runs, s.Name())
+			first := len(runs) == 1
+			mu.Unlock()
+
+			if first {
+				// yield once the 2nd harvester is waiting for the slot
+				assert.Eventually(t,
+					func() bool { return hg.metrics.HarvestersWaiting.Get() == 1 },
+					time.Second,
+					time.Millisecond)
+				return ErrHarvesterYield
+			}
+			return nil
+		}
+		hg.harvester = &mockHarvester{onRun: harvesterRun, wg: &wg}
+
+		goroutinesChecker := resources.NewGoroutinesChecker()
+		defer goroutinesChecker.WaitUntilOriginalCount()
+
+		source1 := &testSource{name: "/path/to/test/1"}
+		source2 := &testSource{name: "/path/to/test/2"}
+		wg.Add(3)
+		hg.Start(input.Context{Logger: logp.L(), Cancelation: context.Background()}, source1)
+		hg.Start(input.Context{Logger: logp.L(), Cancelation: context.Background()}, source2)
+
+		wg.Wait()
+		goroutinesChecker.WaitUntilOriginalCount()
+
+		// the first harvester to run yields to the other one
+		require.Len(t, runs, 3)
+		require.NotEqual(t, runs[0], runs[1])
+		require.Equal(t, runs[0], runs[2])
+		require.Equal(t, uint64(0), hg.metrics.HarvestersWaiting.Get())
+		requireSourceRemovedFromBookkeeper(t, hg, source1)
+		requireSourceRemovedFromBookkeeper(t, hg, source2)
+		require.Nil(t, hg.StopHarvesters())
+	})
+
 	t.Run("assert a harvester can be stopped and removed from bookkeeper", func(t *testing.T) {
 		mockHarvester := &mockHarvester{onRun: blockUntilCancelOnRun}
 		hg := testDefaultHarvesterGroup(t, mockHarvester)
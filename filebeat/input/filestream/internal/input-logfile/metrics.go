@@ -18,6 +18,9 @@
 package input_logfile
 
 import (
+	"sort"
+	"sync"
+
 	"github.com/rcrowley/go-metrics"
 
 	"github.com/elastic/beats/v7/libbeat/monitoring/inputmon"
@@ -38,6 +41,10 @@ type Metrics struct {
 	ProcessingErrors *monitoring.Uint // Number of processing errors.
 	ProcessingTime   metrics.Sample   // Histogram of the elapsed time for processing an event.
 
+	EventsThrottled   *monitoring.Uint // Number of events delayed by the rate limit of their file.
+	HarvestersWaiting *monitoring.Uint // Number of harvesters waiting for a free slot (gauge).
+	ThrottledFiles    *ThrottledFiles  // Files currently delayed by their rate limit.
+
 	// Those metrics use the same registry/keys as the log input uses
 	HarvesterStarted   *monitoring.Int
 	HarvesterClosed    *monitoring.Int
@@ -75,6 +82,10 @@ func NewMetrics(id string) *Metrics {
 		ProcessingErrors: monitoring.NewUint(reg, "processing_errors_total"),
 		ProcessingTime:   metrics.NewUniformSample(1024),
 
+		EventsThrottled:   monitoring.NewUint(reg, "events_throttled_total"),
+		HarvestersWaiting: monitoring.NewUint(reg, "harvesters_waiting"),
+		ThrottledFiles:    &ThrottledFiles{},
+
 		HarvesterStarted:   monitoring.NewInt(harvesterMetrics, "started"),
 		HarvesterClosed:    monitoring.NewInt(harvesterMetrics, "closed"),
 		HarvesterRunning:   monitoring.NewInt(harvesterMetrics, "running"),
@@ -82,6 +93,48 @@ func NewMetrics(id string) *Metrics {
 	}
 	_ = adapter.NewGoMetrics(reg, "processing_time", adapter.Accept).
 		Register("histogram", metrics.NewHistogram(m.ProcessingTime))
+	monitoring.NewFunc(reg, "throttled_files", func(_ monitoring.Mode, v monitoring.Visitor) {
+		v.OnStringSlice(m.ThrottledFiles.Paths())
+	})
 
 	return &m
 }
+
+// ThrottledFiles is the set of files whose harvesters are currently delayed
+// by their rate limit.
+type ThrottledFiles struct {
+	mu    sync.Mutex
+	paths map[string]struct{}
+}
+
+// Set marks the file as throttled or not.
+func (t *ThrottledFiles) Set(path string, throttled bool) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !throttled {
+		delete(t.paths, path)
+		return
+	}
+	if t.paths == nil {
+		t.paths = make(map[string]struct{})
+	}
+	t.paths[path] = struct{}{}
+}
+
+// Paths returns the sorted paths of the throttled files.
+func (t *ThrottledFiles) Paths() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	paths := make([]string, 0, len(t.paths))
+	for path := range t.paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -47,6 +47,9 @@ func newProspector(config config) (loginp.Prospector, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error while creating filewatcher %w", err)
 	}
+	if w, ok := filewatcher.(*fileWatcher); ok {
+		w.writeOnModTime = config.Reader.WholeFile.Enabled
+	}
 
 	identifier, err := newFileIdentifier(config.FileIdentity, config.Reader.Parsers.Suffix)
 	if err != nil {
@@ -0,0 +1,205 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filestream
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	loginp "github.com/elastic/beats/v7/filebeat/input/filestream/internal/input-logfile"
+	"github.com/elastic/beats/v7/libbeat/common/cfgtype"
+)
+
+// rateLimitConfig limits the rate at which each file of an input is published.
+// Zero means unlimited.
+type rateLimitConfig struct {
+	EventsPerSecond float64          `config:"events_per_second" validate:"min=0"`
+	BytesPerSecond  cfgtype.ByteSize `config:"bytes_per_second" validate:"min=0"`
+}
+
+// fairShareConfig configures how the harvesters of an input share publishing.
+type fairShareConfig struct {
+	Enabled    bool          `config:"enabled"`
+	YieldAfter time.Duration `config:"yield_after" validate:"min=0"`
+}
+
+func defaultFairShareConfig() fairShareConfig {
+	return fairShareConfig{
+		Enabled:    false,
+		YieldAfter: 1 * time.Minute,
+	}
+}
+
+// turnstile lets harvesters publish one at a time, in the order in which
+// they asked to. A harvester that has just published has to queue up behind
+// the harvesters already waiting, so a busy file can't starve the others.
+type turnstile struct {
+	mu      sync.Mutex
+	busy    bool
+	waiting []chan struct{}
+}
+
+// acquire waits for the turn of the caller.
+func (t *turnstile) acquire(ctx context.Context) error {
+	t.mu.Lock()
+	if !t.busy {
+		t.busy = true
+		t.mu.Unlock()
+		return nil
+	}
+	ch := make(chan struct{})
+	t.waiting = append(t.waiting, ch)
+	t.mu.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+	}
+
+	t.mu.Lock()
+	for i, w := range t.waiting {
+		if w == ch {
+			t.waiting = append(t.waiting[:i], t.waiting[i+1:]...)
+			t.mu.Unlock()
+			return ctx.Err()
+		}
+	}
+	t.mu.Unlock()
+
+	// the turn was handed over while the context was cancelled
+	t.release()
+	return ctx.Err()
+}
+
+// release hands the turn over to the next waiting caller.
+func (t *turnstile) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.waiting) == 0 {
+		t.busy = false
+		return
+	}
+	next := t.waiting[0]
+	t.waiting = t.waiting[1:]
+	close(next)
+}
+
+// throttle delays the messages of a harvester according to the rate limits
+// of its file and the fair share of the input.
+type throttle struct {
+	path    string
+	events  *rate.Limiter
+	bytes   *rate.Limiter
+	turns   *turnstile
+	metrics *loginp.Metrics
+}
+
+func newThrottle(path string, cfg rateLimitConfig, turns *turnstile, metrics *loginp.Metrics) *throttle {
+	t := &throttle{
+		path:    path,
+		turns:   turns,
+		metrics: metrics,
+	}
+	if cfg.EventsPerSecond > 0 {
+		burst := int(cfg.EventsPerSecond)
+		if burst < 1 {
+			burst = 1
+		}
+		t.events = rate.NewLimiter(rate.Limit(cfg.EventsPerSecond), burst)
+	}
+	if cfg.BytesPerSecond > 0 {
+		t.bytes = rate.NewLimiter(rate.Limit(cfg.BytesPerSecond), int(cfg.BytesPerSecond))
+	}
+	return t
+}
+
+// wait blocks until a message of the given size can be published. If it
+// returns without an error, done must be called after publishing.
+func (t *throttle) wait(ctx context.Context, size int) error {
+	var throttled bool
+	if t.events != nil {
+		delayed, err := waitN(ctx, t.events, 1)
+		if err != nil {
+			return err
+		}
+		throttled = delayed
+	}
+	if t.bytes != nil {
+		// messages larger than the burst are let through in parts
+		for size > 0 {
+			n := size
+			if n > t.bytes.Burst() {
+				n = t.bytes.Burst()
+			}
+			delayed, err := waitN(ctx, t.bytes, n)
+			if err != nil {
+				return err
+			}
+			throttled = throttled || delayed
+			size -= n
+		}
+	}
+
+	if t.events != nil || t.bytes != nil {
+		if throttled {
+			t.metrics.EventsThrottled.Inc()
+		}
+		t.metrics.ThrottledFiles.Set(t.path, throttled)
+	}
+
+	if t.turns != nil {
+		return t.turns.acquire(ctx)
+	}
+	return nil
+}
+
+// waitN waits until n tokens are available and reports whether it had to wait.
+func waitN(ctx context.Context, limiter *rate.Limiter, n int) (bool, error) {
+	r := limiter.ReserveN(time.Now(), n)
+	delay := r.Delay()
+	if delay == 0 {
+		return false, nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true, nil
+	case <-ctx.Done():
+		r.Cancel()
+		return true, ctx.Err()
+	}
+}
+
+// done ends the turn of the harvester.
+func (t *throttle) done() {
+	if t.turns != nil {
+		t.turns.release()
+	}
+}
+
+// close removes the file from the throttled files.
+func (t *throttle) close() {
+	t.metrics.ThrottledFiles.Set(t.path, false)
+}
@@ -0,0 +1,124 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filestream
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	loginp "github.com/elastic/beats/v7/filebeat/input/filestream/internal/input-logfile"
+)
+
+func TestTurnstile(t *testing.T) {
+	t.Run("turns are given in order", func(t *testing.T) {
+		var turns turnstile
+		require.NoError(t, turns.acquire(context.Background()))
+
+		var mu sync.Mutex
+		var order []int
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if err := turns.acquire(context.Background()); err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				order = append(order, i)
+				mu.Unlock()
+				turns.release()
+			}(i)
+			// make sure the goroutines queue up in order
+			require.Eventually(t, func() bool {
+				turns.mu.Lock()
+				defer turns.mu.Unlock()
+				return len(turns.waiting) == i+1
+			}, time.Second, time.Millisecond)
+		}
+
+		turns.release()
+		wg.Wait()
+		require.Equal(t, []int{0, 1, 2}, order)
+		require.False(t, turns.busy)
+	})
+
+	t.Run("cancelled waiter leaves the queue", func(t *testing.T) {
+		var turns turnstile
+		require.NoError(t, turns.acquire(context.Background()))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.ErrorIs(t, turns.acquire(ctx), context.Canceled)
+		require.Empty(t, turns.waiting)
+
+		turns.release()
+		require.False(t, turns.busy)
+	})
+}
+
+func TestThrottle(t *testing.T) {
+	metrics := loginp.NewMetrics("throttle-test")
+	defer metrics.Close()
+
+	t.Run("events per second", func(t *testing.T) {
+		th := newThrottle("/path/a", rateLimitConfig{EventsPerSecond: 20}, nil, metrics)
+		defer th.close()
+
+		start := time.Now()
+		for i := 0; i < 25; i++ {
+			require.NoError(t, th.wait(context.Background(), 10))
+			th.done()
+		}
+		// the burst of 20 events passes, the remaining 5 are delayed
+		require.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+		require.Equal(t, uint64(5), metrics.EventsThrottled.Get())
+		require.Equal(t, []string{"/path/a"}, metrics.ThrottledFiles.Paths())
+	})
+
+	t.Run("bytes per second", func(t *testing.T) {
+		th := newThrottle("/path/b", rateLimitConfig{BytesPerSecond: 100}, nil, metrics)
+
+		start := time.Now()
+		// larger than the burst
+		require.NoError(t, th.wait(context.Background(), 120))
+		th.done()
+		require.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+		require.Contains(t, metrics.ThrottledFiles.Paths(), "/path/b")
+
+		th.close()
+		require.NotContains(t, metrics.ThrottledFiles.Paths(), "/path/b")
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		th := newThrottle("/path/c", rateLimitConfig{EventsPerSecond: 1}, &turnstile{}, metrics)
+		defer th.close()
+
+		require.NoError(t, th.wait(context.Background(), 1))
+		th.done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, th.wait(ctx, 1), context.DeadlineExceeded)
+	})
+}
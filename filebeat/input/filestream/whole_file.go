@@ -0,0 +1,146 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filestream
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/elastic/go-concert/ctxtool"
+	"github.com/elastic/go-concert/timed"
+
+	input "github.com/elastic/beats/v7/filebeat/input/v2"
+	"github.com/elastic/beats/v7/libbeat/reader"
+	"github.com/elastic/beats/v7/libbeat/reader/readfile/encoding"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// wholeFileConfig configures reading each file as a single event.
+type wholeFileConfig struct {
+	Enabled       bool          `config:"enabled"`
+	StableFor     time.Duration `config:"stable_for" validate:"min=0"`
+	CheckInterval time.Duration `config:"check_interval" validate:"nonzero"`
+}
+
+func defaultWholeFileConfig() wholeFileConfig {
+	return wholeFileConfig{
+		Enabled:       false,
+		StableFor:     10 * time.Second,
+		CheckInterval: 1 * time.Second,
+	}
+}
+
+// waitForStableFile waits until the size and the modification time of the file
+// have not changed for the configured period, so the file is most likely no
+// longer written to.
+func waitForStableFile(canceler input.Canceler, path string, cfg wholeFileConfig) (os.FileInfo, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat source file %s: %w", path, err)
+	}
+
+	ctx := ctxtool.FromCanceller(canceler)
+	lastChange := fi.ModTime()
+	for time.Since(lastChange) < cfg.StableFor {
+		if err := timed.Wait(ctx, cfg.CheckInterval); err != nil {
+			return nil, err
+		}
+
+		current, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat source file %s: %w", path, err)
+		}
+		if current.Size() != fi.Size() || !current.ModTime().Equal(fi.ModTime()) {
+			fi = current
+			lastChange = time.Now()
+		}
+	}
+	return fi, nil
+}
+
+// wholeFileReader returns the complete content of a file as a single message.
+// Content after maxBytes is discarded, but still counted as read, so the
+// file is not read again.
+type wholeFileReader struct {
+	file     *os.File
+	encoding encoding.Encoding
+	maxBytes int
+	done     bool
+}
+
+func newWholeFileReader(f *os.File, enc encoding.Encoding, maxBytes int) *wholeFileReader {
+	return &wholeFileReader{
+		file:     f,
+		encoding: enc,
+		maxBytes: maxBytes,
+	}
+}
+
+func (r *wholeFileReader) Next() (reader.Message, error) {
+	if r.done {
+		return reader.Message{}, io.EOF
+	}
+	r.done = true
+
+	// bytes consumed while detecting the encoding, like a BOM
+	start, err := r.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return reader.Message{}, err
+	}
+
+	// As in the line based readers, up to 4 times the limit is read to
+	// account for encodings using up to 4 bytes per character.
+	raw, err := io.ReadAll(io.LimitReader(r.file, int64(r.maxBytes)*4))
+	if err != nil {
+		return reader.Message{}, err
+	}
+	discarded, err := io.Copy(io.Discard, r.file)
+	if err != nil {
+		return reader.Message{}, err
+	}
+
+	total := start + int64(len(raw)) + discarded
+	if total == 0 {
+		return reader.Message{}, io.EOF
+	}
+
+	content, err := r.encoding.NewDecoder().Bytes(raw)
+	if err != nil {
+		return reader.Message{}, fmt.Errorf("failed to decode file content: %w", err)
+	}
+
+	message := reader.Message{
+		Ts:      time.Now(),
+		Content: content,
+		Bytes:   int(total),
+		Fields:  mapstr.M{},
+	}
+	if discarded > 0 || len(content) > r.maxBytes {
+		if len(content) > r.maxBytes {
+			message.Content = content[:r.maxBytes]
+		}
+		_ = message.AddFlagsWithKey("log.flags", "truncated")
+	}
+	return message, nil
+}
+
+func (r *wholeFileReader) Close() error {
+	return r.file.Close()
+}
@@ -23,11 +23,15 @@ import (
 	"sync"
 	"time"
 
+	"github.com/dustin/go-humanize"
+
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
 	"github.com/elastic/go-ucfg"
 
 	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalctl"
 	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalfield"
 
+	"github.com/elastic/beats/v7/libbeat/common/cfgtype"
 	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
 	"github.com/elastic/beats/v7/libbeat/reader/parser"
 )
@@ -91,6 +95,34 @@ func (im *bwcIncludeMatches) Unpack(c *ucfg.Config) error {
 	return c.Unpack((*journalfield.IncludeMatches)(im))
 }
 
+// remoteConfig stores the options of a journald-remote input.
+type remoteConfig struct {
+	// ListenAddress and ListenPort are the address to listen on
+	// for uploads from systemd-journal-upload.
+	ListenAddress string `config:"listen_address"`
+	ListenPort    int    `config:"listen_port" validate:"min=0,max=65535"`
+
+	// TLS configures the server TLS settings.
+	TLS *tlscommon.ServerConfig `config:"ssl"`
+
+	// MaxEntrySize is the maximum size of a single journal entry.
+	MaxEntrySize cfgtype.ByteSize `config:"max_entry_size" validate:"nonzero,positive"`
+
+	// SaveRemoteHostname defines if the original source of the entry needs to be saved.
+	SaveRemoteHostname bool `config:"save_remote_hostname"`
+
+	// Parsers configuration
+	Parsers parser.Config `config:",inline"`
+}
+
+func defaultRemoteConfig() remoteConfig {
+	return remoteConfig{
+		ListenAddress: "localhost",
+		ListenPort:    19532,
+		MaxEntrySize:  10 * humanize.MiByte,
+	}
+}
+
 func defaultConfig() config {
 	return config{
 		Seek:               journalctl.SeekHead,
@@ -28,3 +28,7 @@ import (
 func Plugin(log *logp.Logger, store cursor.StateStore) v2.Plugin {
 	return v2.Plugin{}
 }
+
+func RemotePlugin() v2.Plugin {
+	return v2.Plugin{}
+}
@@ -0,0 +1,252 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package journalexport reads journal entries serialized in the journal
+// export format, as sent by systemd-journal-upload. The format is
+// described in https://systemd.io/JOURNAL_EXPORT_FORMATS/.
+package journalexport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalctl"
+)
+
+// ContentType is the media type of the journal export format.
+const ContentType = "application/vnd.fdo.journal"
+
+// ErrEntryTooLarge is returned when an entry is larger than the
+// maximum entry size of the Reader.
+var ErrEntryTooLarge = errors.New("journal entry too large")
+
+// Reader reads journal entries in the journal export format.
+type Reader struct {
+	r       *bufio.Reader
+	maxSize int
+}
+
+// NewReader returns a Reader reading from r. Entries larger than
+// maxSize bytes are rejected with ErrEntryTooLarge.
+func NewReader(r io.Reader, maxSize int) *Reader {
+	return &Reader{r: bufio.NewReader(r), maxSize: maxSize}
+}
+
+// Next returns the next entry. The fields of the entry have the same
+// representation as the fields decoded from the JSON output of journalctl:
+// values that are not printable text are arrays of byte values and fields
+// that appear more than once are arrays of values.
+//
+// Next returns io.EOF when there are no more entries, and
+// io.ErrUnexpectedEOF if the input ends within an entry.
+func (r *Reader) Next() (journalctl.JournalEntry, error) {
+	fields := map[string]any{}
+	size := 0
+	for {
+		line, err := r.readLine(r.maxSize - size)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				if len(line) != 0 {
+					return journalctl.JournalEntry{}, io.ErrUnexpectedEOF
+				}
+				if len(fields) != 0 {
+					// Accept a final entry without the terminating empty line.
+					return newEntry(fields)
+				}
+			}
+			return journalctl.JournalEntry{}, err
+		}
+		size += len(line) + 1
+
+		if len(line) == 0 {
+			if len(fields) == 0 {
+				// Skip empty lines between entries.
+				continue
+			}
+			return newEntry(fields)
+		}
+
+		var (
+			name  string
+			value any
+		)
+		if i := bytes.IndexByte(line, '='); i >= 0 {
+			name = string(line[:i])
+			value = string(line[i+1:])
+		} else {
+			name = string(line)
+			data, err := r.readBinary(r.maxSize - size)
+			if err != nil {
+				return journalctl.JournalEntry{}, fmt.Errorf("failed to read field %s: %w", name, err)
+			}
+			size += 8 + len(data) + 1
+			value = binaryValue(data)
+		}
+		if !validFieldName(name) {
+			return journalctl.JournalEntry{}, fmt.Errorf("invalid field name: %q", name)
+		}
+
+		switch v := fields[name].(type) {
+		case nil:
+			fields[name] = value
+		case multiValue:
+			fields[name] = append(v, value)
+		default:
+			fields[name] = multiValue{v, value}
+		}
+	}
+}
+
+// multiValue holds the values of a field that appears more than once
+// in an entry.
+type multiValue []any
+
+// readLine reads a line of at most limit bytes and returns it
+// without the trailing newline.
+func (r *Reader) readLine(limit int) ([]byte, error) {
+	var line []byte
+	for {
+		frag, err := r.r.ReadSlice('\n')
+		if len(line)+len(frag) > limit+1 {
+			return nil, ErrEntryTooLarge
+		}
+		line = append(line, frag...)
+		switch {
+		case err == nil:
+			return line[:len(line)-1], nil
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		default:
+			return line, err
+		}
+	}
+}
+
+// readBinary reads a binary field value: a little-endian 64-bit length,
+// the data and a newline.
+func (r *Reader) readBinary(limit int) ([]byte, error) {
+	var size uint64
+	err := binary.Read(r.r, binary.LittleEndian, &size)
+	if err != nil {
+		return nil, noEOF(err)
+	}
+	if limit < 9 || size > uint64(limit-9) {
+		return nil, ErrEntryTooLarge
+	}
+	data := make([]byte, size+1)
+	_, err = io.ReadFull(r.r, data)
+	if err != nil {
+		return nil, noEOF(err)
+	}
+	if data[size] != '\n' {
+		return nil, errors.New("missing newline after binary field")
+	}
+	return data[:size], nil
+}
+
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// binaryValue returns data as a string if it is printable text, and
+// as an array of byte values otherwise, as journalctl does.
+func binaryValue(data []byte) any {
+	if isPrintable(data) {
+		return string(data)
+	}
+	v := make([]any, len(data))
+	for i, b := range data {
+		v[i] = float64(b)
+	}
+	return v
+}
+
+func isPrintable(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) && r != '\n' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// validFieldName returns whether name is a valid journal field name.
+// Journal field names consist of uppercase letters, digits and
+// underscores and do not start with a digit.
+func validFieldName(name string) bool {
+	if name == "" || ('0' <= name[0] && name[0] <= '9') {
+		return false
+	}
+	for _, c := range []byte(name) {
+		if !('A' <= c && c <= 'Z') && !('0' <= c && c <= '9') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func newEntry(fields map[string]any) (journalctl.JournalEntry, error) {
+	for k, v := range fields {
+		if m, ok := v.(multiValue); ok {
+			fields[k] = []any(m)
+		}
+	}
+	realtime, err := timestamp(fields, "__REALTIME_TIMESTAMP")
+	if err != nil {
+		return journalctl.JournalEntry{}, err
+	}
+	monotonic, err := timestamp(fields, "__MONOTONIC_TIMESTAMP")
+	if err != nil {
+		return journalctl.JournalEntry{}, err
+	}
+	cursor, _ := fields["__CURSOR"].(string)
+	return journalctl.JournalEntry{
+		Fields:             fields,
+		Cursor:             cursor,
+		RealtimeTimestamp:  realtime,
+		MonotonicTimestamp: monotonic,
+	}, nil
+}
+
+func timestamp(fields map[string]any, name string) (uint64, error) {
+	v, ok := fields[name]
+	if !ok {
+		return 0, fmt.Errorf("missing %s field", name)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return 0, fmt.Errorf("'%s': '%[2]v', type %[2]T is not a string", name, v)
+	}
+	ts, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not convert '%s' to uint64: %w", name, err)
+	}
+	return ts, nil
+}
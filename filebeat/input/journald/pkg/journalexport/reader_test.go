@@ -0,0 +1,178 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package journalexport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalctl"
+)
+
+func binaryField(name string, data []byte) string {
+	var buf bytes.Buffer
+	buf.WriteString(name + "\n")
+	_ = binary.Write(&buf, binary.LittleEndian, uint64(len(data)))
+	buf.Write(data)
+	buf.WriteString("\n")
+	return buf.String()
+}
+
+func TestReader(t *testing.T) {
+	input := "__CURSOR=s=739ad463348b4ceca5a9e69c95a3c93f;i=4ece7;b=6c7c6013a8344c2b8b0a0a3cdf50dc8e;m=299086401;t=50b1d26cf4f1a;x=d3f1f9a0e4a4b5d4\n" +
+		"__REALTIME_TIMESTAMP=1423944916375353\n" +
+		"__MONOTONIC_TIMESTAMP=11140064257\n" +
+		"_BOOT_ID=6c7c6013a8344c2b8b0a0a3cdf50dc8e\n" +
+		"_TRANSPORT=journal\n" +
+		"_HOSTNAME=web-1\n" +
+		"SYSLOG_IDENTIFIER=sshd\n" +
+		"_PID=1234\n" +
+		binaryField("MESSAGE", []byte("first line\nsecond line")) +
+		"TAG=a\n" +
+		"TAG=b\n" +
+		binaryField("DATA", []byte{0x00, 0x01, 0xff}) +
+		"\n" +
+		"\n" +
+		"__CURSOR=s=739ad463348b4ceca5a9e69c95a3c93f;i=4ece8\n" +
+		"__REALTIME_TIMESTAMP=1423944916375400\n" +
+		"__MONOTONIC_TIMESTAMP=11140064300\n" +
+		"MESSAGE=value with = sign\n" +
+		"EMPTY=\n" +
+		binaryField("MULTI", []byte{0xff}) +
+		binaryField("MULTI", []byte("text")) +
+		"\n"
+
+	r := NewReader(strings.NewReader(input), 1<<20)
+
+	entry, err := r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, journalctl.JournalEntry{
+		Fields: map[string]any{
+			"__CURSOR":              "s=739ad463348b4ceca5a9e69c95a3c93f;i=4ece7;b=6c7c6013a8344c2b8b0a0a3cdf50dc8e;m=299086401;t=50b1d26cf4f1a;x=d3f1f9a0e4a4b5d4",
+			"__REALTIME_TIMESTAMP":  "1423944916375353",
+			"__MONOTONIC_TIMESTAMP": "11140064257",
+			"_BOOT_ID":              "6c7c6013a8344c2b8b0a0a3cdf50dc8e",
+			"_TRANSPORT":            "journal",
+			"_HOSTNAME":             "web-1",
+			"SYSLOG_IDENTIFIER":     "sshd",
+			"_PID":                  "1234",
+			"MESSAGE":               "first line\nsecond line",
+			"TAG":                   []any{"a", "b"},
+			"DATA":                  []any{float64(0), float64(1), float64(255)},
+		},
+		Cursor:             "s=739ad463348b4ceca5a9e69c95a3c93f;i=4ece7;b=6c7c6013a8344c2b8b0a0a3cdf50dc8e;m=299086401;t=50b1d26cf4f1a;x=d3f1f9a0e4a4b5d4",
+		RealtimeTimestamp:  1423944916375353,
+		MonotonicTimestamp: 11140064257,
+	}, entry)
+
+	entry, err = r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "value with = sign", entry.Fields["MESSAGE"])
+	assert.Equal(t, "", entry.Fields["EMPTY"])
+	assert.Equal(t, []any{[]any{float64(255)}, "text"}, entry.Fields["MULTI"])
+	assert.Equal(t, uint64(1423944916375400), entry.RealtimeTimestamp)
+
+	_, err = r.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestReaderErrors(t *testing.T) {
+	const header = "__REALTIME_TIMESTAMP=1\n__MONOTONIC_TIMESTAMP=2\n"
+
+	testCases := []struct {
+		name    string
+		input   string
+		maxSize int
+		wantErr error
+		wantMsg string
+	}{
+		{
+			name:  "no terminating empty line",
+			input: header + "MESSAGE=last\n",
+		},
+		{
+			name:    "truncated line",
+			input:   header + "MESSAGE=la",
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "truncated binary field",
+			input:   header + binaryField("MESSAGE", []byte("hello"))[:14],
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "missing newline after binary field",
+			input:   header + strings.TrimSuffix(binaryField("MESSAGE", []byte("hello")), "\n") + "X\n\n",
+			wantMsg: "failed to read field MESSAGE: missing newline after binary field",
+		},
+		{
+			name:    "invalid field name",
+			input:   header + "message=lower case\n\n",
+			wantMsg: `invalid field name: "message"`,
+		},
+		{
+			name:    "missing timestamp",
+			input:   "MESSAGE=hello\n\n",
+			wantMsg: "missing __REALTIME_TIMESTAMP field",
+		},
+		{
+			name:    "entry too large",
+			input:   header + "MESSAGE=" + strings.Repeat("x", 100) + "\n\n",
+			maxSize: 64,
+			wantErr: ErrEntryTooLarge,
+		},
+		{
+			name:    "binary field too large",
+			input:   header + binaryField("MESSAGE", bytes.Repeat([]byte("x"), 100)) + "\n",
+			maxSize: 128,
+			wantErr: ErrEntryTooLarge,
+		},
+		{
+			name:    "long line",
+			input:   header + "MESSAGE=" + strings.Repeat("x", 10000) + "\n\n",
+			maxSize: 1 << 20,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			maxSize := tc.maxSize
+			if maxSize == 0 {
+				maxSize = 1 << 20
+			}
+			r := NewReader(strings.NewReader(tc.input), maxSize)
+			_, err := r.Next()
+			switch {
+			case tc.wantErr != nil:
+				assert.ErrorIs(t, err, tc.wantErr)
+			case tc.wantMsg != "":
+				assert.EqualError(t, err, tc.wantMsg)
+			default:
+				require.NoError(t, err)
+				_, err = r.Next()
+				assert.True(t, errors.Is(err, io.EOF), "expected EOF, got %v", err)
+			}
+		})
+	}
+}
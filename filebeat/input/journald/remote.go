@@ -0,0 +1,323 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package journald
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalctl"
+	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalexport"
+	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalfield"
+	input "github.com/elastic/beats/v7/filebeat/input/v2"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/acker"
+	"github.com/elastic/beats/v7/libbeat/feature"
+	"github.com/elastic/beats/v7/libbeat/monitoring/inputmon"
+	"github.com/elastic/beats/v7/libbeat/reader/parser"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+	"github.com/elastic/go-concert/ctxtool"
+)
+
+const remotePluginName = "journald-remote"
+
+// uploadPath is the path systemd-journal-upload sends entries to.
+const uploadPath = "/upload"
+
+// RemotePlugin creates a new journald-remote input plugin. The input
+// receives journal entries uploaded by systemd-journal-upload.
+func RemotePlugin() input.Plugin {
+	return input.Plugin{
+		Name:       remotePluginName,
+		Stability:  feature.Experimental,
+		Deprecated: false,
+		Info:       "journald remote upload receiver",
+		Doc:        "The journald-remote input receives journal entries uploaded by systemd-journal-upload",
+		Manager:    input.ConfigureWith(configureRemote),
+	}
+}
+
+type journaldRemote struct {
+	config    remoteConfig
+	addr      string
+	tlsConfig *tls.Config
+}
+
+func configureRemote(cfg *conf.C) (input.Input, error) {
+	config := defaultRemoteConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	addr := net.JoinHostPort(config.ListenAddress, strconv.Itoa(config.ListenPort))
+	var tlsConfig *tls.Config
+	tlsConfigBuilder, err := tlscommon.LoadTLSServerConfig(config.TLS)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfigBuilder != nil {
+		tlsConfig = tlsConfigBuilder.BuildServerConfig(addr)
+	}
+
+	return &journaldRemote{
+		config:    config,
+		addr:      addr,
+		tlsConfig: tlsConfig,
+	}, nil
+}
+
+func (inp *journaldRemote) Name() string { return remotePluginName }
+
+func (inp *journaldRemote) Test(_ input.TestContext) error {
+	l, err := net.Listen("tcp", inp.addr)
+	if err != nil {
+		return err
+	}
+	return l.Close()
+}
+
+func (inp *journaldRemote) Run(ctx input.Context, pipeline beat.Pipeline) error {
+	log := ctx.Logger.With("address", inp.addr)
+
+	metrics := newRemoteMetrics(ctx.ID)
+	defer metrics.Close()
+
+	client, err := pipeline.ConnectWith(beat.ClientConfig{
+		EventListener: acker.ConnectionOnly(
+			acker.EventPrivateReporter(func(_ int, privates []interface{}) {
+				for _, private := range privates {
+					if t, ok := private.(*uploadACKTracker); ok {
+						t.ACK()
+					}
+				}
+			}),
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create pipeline client: %w", err)
+	}
+	defer client.Close()
+
+	h := &uploadHandler{
+		log:                log,
+		canceler:           ctx.Cancelation,
+		publish:            client.Publish,
+		parsers:            inp.config.Parsers,
+		converter:          journalfield.NewConverter(ctx.Logger, nil),
+		saveRemoteHostname: inp.config.SaveRemoteHostname,
+		maxEntrySize:       int(inp.config.MaxEntrySize),
+		metrics:            metrics,
+	}
+	srv := &http.Server{
+		Addr:              inp.addr,
+		Handler:           h,
+		TLSConfig:         inp.tlsConfig,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	_, cancel := ctxtool.WithFunc(ctx.Cancelation, func() { srv.Close() })
+	defer cancel()
+
+	ln, err := net.Listen("tcp", inp.addr)
+	if err != nil {
+		return err
+	}
+	metrics.bindAddr.Set(ln.Addr().String())
+	if inp.tlsConfig != nil {
+		log.Infof("Starting HTTPS journal upload receiver on %s", inp.addr)
+		err = srv.ServeTLS(ln, "", "")
+	} else {
+		log.Infof("Starting HTTP journal upload receiver on %s", inp.addr)
+		err = srv.Serve(ln)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// uploadHandler handles uploads in the journal export format, as sent
+// by systemd-journal-upload.
+type uploadHandler struct {
+	log                *logp.Logger
+	canceler           input.Canceler
+	publish            func(beat.Event)
+	parsers            parser.Config
+	converter          *journalfield.Converter
+	saveRemoteHostname bool
+	maxEntrySize       int
+	metrics            *remoteMetrics
+}
+
+func (h *uploadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != uploadPath {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Unsupported method.", http.StatusMethodNotAllowed)
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != journalexport.ContentType {
+		http.Error(w, "Content-Type: "+journalexport.ContentType+" is required.", http.StatusUnsupportedMediaType)
+		return
+	}
+	if enc := r.Header.Get("Content-Encoding"); enc != "" && enc != "identity" {
+		http.Error(w, "Unsupported Content-Encoding: "+enc+".", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	h.metrics.uploadsReceived.Inc()
+	log := h.log.With("client.address", r.RemoteAddr)
+
+	acked := make(chan struct{})
+	tracker := newUploadACKTracker(func() { close(acked) })
+	p := h.parsers.Create(&readerAdapter{
+		r:                  &uploadReader{r: journalexport.NewReader(r.Body, h.maxEntrySize)},
+		canceler:           h.canceler,
+		converter:          h.converter,
+		saveRemoteHostname: h.saveRemoteHostname,
+	})
+	for {
+		msg, err := p.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if errors.Is(err, journalctl.ErrCancelled) {
+				http.Error(w, "Server is shutting down.", http.StatusServiceUnavailable)
+				return
+			}
+			h.metrics.uploadErrors.Inc()
+			log.Errorw("failed to read journal upload", "error", err)
+			http.Error(w, fmt.Sprintf("Failed to read journal entries: %v.", err), http.StatusBadRequest)
+			return
+		}
+
+		event := msg.ToEvent()
+		// The sender keeps track of its position in the journal, so no
+		// checkpoint is needed; track the acknowledgement of the upload.
+		event.Private = tracker
+		tracker.Add()
+		h.publish(event)
+		h.metrics.eventsPublished.Inc()
+	}
+	tracker.Ready()
+
+	// Only confirm the upload once all entries have been acknowledged,
+	// so that systemd-journal-upload retries them otherwise.
+	select {
+	case <-acked:
+	case <-r.Context().Done():
+		return
+	case <-h.canceler.Done():
+		http.Error(w, "Server is shutting down.", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = io.WriteString(w, "OK.\n")
+}
+
+// uploadReader adapts a journalexport.Reader to the journalReader interface.
+type uploadReader struct {
+	r *journalexport.Reader
+}
+
+func (u *uploadReader) Close() error { return nil }
+
+func (u *uploadReader) Next(cancel input.Canceler) (journalctl.JournalEntry, error) {
+	if cancel.Err() != nil {
+		return journalctl.JournalEntry{}, journalctl.ErrCancelled
+	}
+	return u.r.Next()
+}
+
+// uploadACKTracker invokes done once all events of an upload have been
+// published and acknowledged by an output.
+type uploadACKTracker struct {
+	done func()
+
+	mu      sync.Mutex
+	pending int
+}
+
+// newUploadACKTracker returns a new uploadACKTracker. Ready must be called
+// once all events of the upload have been published.
+func newUploadACKTracker(done func()) *uploadACKTracker {
+	return &uploadACKTracker{done: done, pending: 1}
+}
+
+// Add increments the number of pending ACKs.
+func (t *uploadACKTracker) Add() {
+	t.mu.Lock()
+	t.pending++
+	t.mu.Unlock()
+}
+
+// Ready signals that all events of the upload have been published.
+func (t *uploadACKTracker) Ready() { t.ACK() }
+
+// ACK decrements the number of pending ACKs.
+func (t *uploadACKTracker) ACK() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending--
+	if t.pending == 0 {
+		t.done()
+	}
+}
+
+// remoteMetrics handles the input's metric reporting.
+type remoteMetrics struct {
+	unregister func()
+
+	bindAddr        *monitoring.String // bind address of input
+	uploadsReceived *monitoring.Uint   // number of uploads received
+	uploadErrors    *monitoring.Uint   // number of uploads that failed to be read
+	eventsPublished *monitoring.Uint   // number of events published
+}
+
+func newRemoteMetrics(id string) *remoteMetrics {
+	reg, unreg := inputmon.NewInputRegistry(remotePluginName, id, nil)
+	return &remoteMetrics{
+		unregister:      unreg,
+		bindAddr:        monitoring.NewString(reg, "bind_address"),
+		uploadsReceived: monitoring.NewUint(reg, "uploads_received_total"),
+		uploadErrors:    monitoring.NewUint(reg, "upload_errors_total"),
+		eventsPublished: monitoring.NewUint(reg, "events_published_total"),
+	}
+}
+
+func (m *remoteMetrics) Close() {
+	m.unregister()
+}
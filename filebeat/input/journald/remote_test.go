@@ -0,0 +1,276 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package journald
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalexport"
+	"github.com/elastic/beats/v7/filebeat/input/journald/pkg/journalfield"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// exportEntry serializes fields in the journal export format, using the
+// binary serialization for values containing newlines.
+func exportEntry(fields ...string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(fields); i += 2 {
+		name, value := fields[i], fields[i+1]
+		if !strings.Contains(value, "\n") {
+			buf.WriteString(name + "=" + value + "\n")
+			continue
+		}
+		buf.WriteString(name + "\n")
+		_ = binary.Write(&buf, binary.LittleEndian, uint64(len(value)))
+		buf.WriteString(value + "\n")
+	}
+	buf.WriteString("\n")
+	return buf.String()
+}
+
+type uploadPublisher struct {
+	mu     sync.Mutex
+	events []beat.Event
+	ack    bool
+}
+
+func (p *uploadPublisher) Publish(e beat.Event) {
+	p.mu.Lock()
+	p.events = append(p.events, e)
+	p.mu.Unlock()
+	if p.ack {
+		e.Private.(*uploadACKTracker).ACK()
+	}
+}
+
+func newTestUploadHandler(t *testing.T, cfg mapstr.M, pub *uploadPublisher) *uploadHandler {
+	t.Helper()
+	c := defaultRemoteConfig()
+	require.NoError(t, conf.MustNewConfigFrom(cfg).Unpack(&c))
+	log := logp.NewLogger("journald-remote.test")
+	return &uploadHandler{
+		log:                log,
+		canceler:           context.Background(),
+		publish:            pub.Publish,
+		parsers:            c.Parsers,
+		converter:          journalfield.NewConverter(log, nil),
+		saveRemoteHostname: c.SaveRemoteHostname,
+		maxEntrySize:       int(c.MaxEntrySize),
+		metrics:            newRemoteMetrics(""),
+	}
+}
+
+func newUploadRequest(body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, uploadPath, strings.NewReader(body))
+	r.Header.Set("Content-Type", journalexport.ContentType)
+	return r
+}
+
+func TestUploadHandler(t *testing.T) {
+	pub := &uploadPublisher{ack: true}
+	h := newTestUploadHandler(t, mapstr.M{"save_remote_hostname": true}, pub)
+	defer h.metrics.Close()
+
+	body := exportEntry(
+		"__CURSOR", "s=739ad463348b4ceca5a9e69c95a3c93f;i=4ece7",
+		"__REALTIME_TIMESTAMP", "1700000000123456",
+		"__MONOTONIC_TIMESTAMP", "11140064257",
+		"_HOSTNAME", "web-1",
+		"_TRANSPORT", "journal",
+		"_PID", "1234",
+		"_SYSTEMD_UNIT", "sshd.service",
+		"SYSLOG_IDENTIFIER", "sshd",
+		"PRIORITY", "6",
+		"MESSAGE", "Accepted publickey for core",
+	) + exportEntry(
+		"__REALTIME_TIMESTAMP", "1700000000223456",
+		"__MONOTONIC_TIMESTAMP", "11140064357",
+		"_HOSTNAME", "web-1",
+		"MESSAGE", "first line\nsecond line",
+		"CUSTOM_FIELD", "custom",
+	)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newUploadRequest(body))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, "OK.\n", rec.Body.String())
+
+	require.Len(t, pub.events, 2)
+	first := pub.events[0]
+	assert.Equal(t, time.UnixMicro(1700000000123456), first.Timestamp)
+	for k, want := range map[string]interface{}{
+		"message":             "Accepted publickey for core",
+		"host.hostname":       "web-1",
+		"log.source.address":  "web-1",
+		"systemd.transport":   "journal",
+		"systemd.unit":        "sshd.service",
+		"journald.pid":        int64(1234),
+		"process.pid":         int64(1234),
+		"syslog.identifier":   "sshd",
+		"log.syslog.priority": int64(6),
+		"event.kind":          "event",
+	} {
+		got, err := first.Fields.GetValue(k)
+		if assert.NoError(t, err, k) {
+			assert.Equal(t, want, got, k)
+		}
+	}
+	assert.Equal(t, "first line\nsecond line", pub.events[1].Fields["message"])
+	custom, err := pub.events[1].Fields.GetValue("journald.custom.custom_field")
+	assert.NoError(t, err)
+	assert.Equal(t, "custom", custom)
+	assert.Equal(t, uint64(1), h.metrics.uploadsReceived.Get())
+	assert.Equal(t, uint64(2), h.metrics.eventsPublished.Get())
+}
+
+func TestUploadHandlerWaitsForACK(t *testing.T) {
+	pub := &uploadPublisher{}
+	h := newTestUploadHandler(t, mapstr.M{}, pub)
+	defer h.metrics.Close()
+
+	body := exportEntry("__REALTIME_TIMESTAMP", "1", "__MONOTONIC_TIMESTAMP", "1", "MESSAGE", "a") +
+		exportEntry("__REALTIME_TIMESTAMP", "2", "__MONOTONIC_TIMESTAMP", "2", "MESSAGE", "b")
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.ServeHTTP(rec, newUploadRequest(body))
+	}()
+
+	require.Eventually(t, func() bool {
+		pub.mu.Lock()
+		defer pub.mu.Unlock()
+		return len(pub.events) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	pub.events[0].Private.(*uploadACKTracker).ACK()
+	select {
+	case <-done:
+		t.Fatal("upload confirmed before all events were acknowledged")
+	case <-time.After(50 * time.Millisecond):
+	}
+	pub.events[1].Private.(*uploadACKTracker).ACK()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("upload not confirmed after all events were acknowledged")
+	}
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+}
+
+func TestUploadHandlerParsers(t *testing.T) {
+	pub := &uploadPublisher{ack: true}
+	h := newTestUploadHandler(t, mapstr.M{
+		"parsers": []mapstr.M{
+			{"multiline": mapstr.M{"type": "count", "count_lines": 2}},
+		},
+	}, pub)
+	defer h.metrics.Close()
+
+	var body string
+	for i, msg := range []string{"1st line", "2nd line", "3rd line", "4th line"} {
+		ts := string(rune('1' + i))
+		body += exportEntry("__REALTIME_TIMESTAMP", ts, "__MONOTONIC_TIMESTAMP", ts, "MESSAGE", msg)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newUploadRequest(body))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	require.Len(t, pub.events, 2)
+	assert.Equal(t, "1st line\n2nd line", pub.events[0].Fields["message"])
+	assert.Equal(t, "3rd line\n4th line", pub.events[1].Fields["message"])
+}
+
+func TestUploadHandlerErrors(t *testing.T) {
+	valid := exportEntry("__REALTIME_TIMESTAMP", "1", "__MONOTONIC_TIMESTAMP", "1", "MESSAGE", "a")
+	testCases := []struct {
+		name       string
+		request    func() *http.Request
+		wantStatus int
+		wantEvents int
+	}{
+		{
+			name: "wrong path",
+			request: func() *http.Request {
+				r := newUploadRequest(valid)
+				r.URL.Path = "/"
+				return r
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "wrong method",
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, uploadPath, nil)
+			},
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name: "wrong content type",
+			request: func() *http.Request {
+				r := newUploadRequest(valid)
+				r.Header.Set("Content-Type", "application/json")
+				return r
+			},
+			wantStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name: "unsupported encoding",
+			request: func() *http.Request {
+				r := newUploadRequest(valid)
+				r.Header.Set("Content-Encoding", "zstd")
+				return r
+			},
+			wantStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name: "malformed entry",
+			request: func() *http.Request {
+				return newUploadRequest(valid + "MESSAGE=no timestamp\n\n")
+			},
+			wantStatus: http.StatusBadRequest,
+			wantEvents: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pub := &uploadPublisher{ack: true}
+			h := newTestUploadHandler(t, mapstr.M{}, pub)
+			defer h.metrics.Close()
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, tc.request())
+			assert.Equal(t, tc.wantStatus, rec.Code)
+			assert.Len(t, pub.events, tc.wantEvents)
+		})
+	}
+}
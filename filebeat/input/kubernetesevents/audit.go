@@ -0,0 +1,196 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kubernetesevents
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"time"
+
+	input "github.com/elastic/beats/v7/filebeat/input/v2"
+	cursor "github.com/elastic/beats/v7/filebeat/input/v2/input-cursor"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/jsontransform"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/go-concert/ctxtool"
+)
+
+// auditEventList is the body sent by the API server audit webhook backend.
+type auditEventList struct {
+	Kind       string                   `json:"kind"`
+	APIVersion string                   `json:"apiVersion"`
+	Items      []map[string]interface{} `json:"items"`
+}
+
+func (inp *kubeEvents) runAuditWebhook(ctx input.Context, pub cursor.Publisher) error {
+	addr := inp.addr()
+	log := ctx.Logger.With("address", addr)
+
+	h := &auditHandler{
+		log:         log,
+		path:        inp.config.URL,
+		maxBodySize: int64(inp.config.MaxBodySize),
+		publish: func(event beat.Event) error {
+			return pub.Publish(event, nil)
+		},
+	}
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		TLSConfig:         inp.tlsConfig,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	_, cancel := ctxtool.WithFunc(ctx.Cancelation, func() { srv.Close() })
+	defer cancel()
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	if inp.tlsConfig != nil {
+		log.Infof("Starting HTTPS audit webhook receiver on %s", addr)
+		err = srv.ServeTLS(ln, "", "")
+	} else {
+		log.Infof("Starting HTTP audit webhook receiver on %s", addr)
+		err = srv.Serve(ln)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// auditHandler handles batches of audit events sent by the API server
+// audit webhook backend.
+type auditHandler struct {
+	log         *logp.Logger
+	path        string
+	maxBodySize int64
+	publish     func(beat.Event) error
+}
+
+func (h *auditHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != h.path {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Unsupported method.", http.StatusMethodNotAllowed)
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		http.Error(w, "Content-Type: application/json is required.", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var body bytes.Buffer
+	_, err := body.ReadFrom(http.MaxBytesReader(w, r.Body, h.maxBodySize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, "Request body too large.", http.StatusRequestEntityTooLarge)
+			return
+		}
+		h.log.Errorw("Failed to read audit webhook request", "error", err)
+		http.Error(w, "Failed to read request body.", http.StatusBadRequest)
+		return
+	}
+
+	events, err := decodeAuditEvents(body.Bytes())
+	if err != nil {
+		h.log.Debugw("Invalid audit webhook request", "error", err, "client.address", r.RemoteAddr)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, event := range events {
+		if err := h.publish(event); err != nil {
+			h.log.Errorw("Failed to publish audit event", "error", err)
+			http.Error(w, "Failed to publish events.", http.StatusServiceUnavailable)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// decodeAuditEvents returns the events for the audit events in an
+// audit.k8s.io EventList.
+func decodeAuditEvents(data []byte) ([]beat.Event, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var list auditEventList
+	if err := dec.Decode(&list); err != nil {
+		return nil, fmt.Errorf("malformed JSON body: %w", err)
+	}
+	if list.Kind != "EventList" {
+		return nil, fmt.Errorf("unexpected kind %q, expected EventList", list.Kind)
+	}
+
+	events := make([]beat.Event, 0, len(list.Items))
+	for _, item := range list.Items {
+		audit := mapstr.M(item)
+		jsontransform.TransformNumbers(audit)
+
+		fields := mapstr.M{
+			"kubernetes": mapstr.M{
+				"audit": audit,
+			},
+			"event": mapstr.M{
+				"kind": "event",
+			},
+		}
+		if v, _ := audit.GetValue("verb"); v != nil {
+			fields.Put("event.action", v)
+		}
+		if v, _ := audit.GetValue("user.username"); v != nil {
+			fields.Put("user.name", v)
+		}
+		if ips, ok := audit["sourceIPs"].([]interface{}); ok && len(ips) != 0 {
+			fields.Put("source.ip", ips[0])
+		}
+
+		events = append(events, beat.Event{
+			Timestamp: auditTimestamp(audit),
+			Fields:    fields,
+		})
+	}
+	return events, nil
+}
+
+// auditTimestamp returns the time of the stage of the audit event, falling
+// back to the time the request was received.
+func auditTimestamp(audit mapstr.M) time.Time {
+	for _, k := range []string{"stageTimestamp", "requestReceivedTimestamp"} {
+		s, ok := audit[k].(string)
+		if !ok {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339Nano, s)
+		if err == nil {
+			return ts.UTC()
+		}
+	}
+	return time.Now().UTC()
+}
@@ -0,0 +1,120 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kubernetesevents
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+const testAuditEventList = `{
+  "kind": "EventList",
+  "apiVersion": "audit.k8s.io/v1",
+  "items": [
+    {
+      "level": "Metadata",
+      "auditID": "2ed6a2a4-0c5f-4c3a-9a38-7e2b8a1f6b0e",
+      "stage": "ResponseComplete",
+      "requestURI": "/api/v1/namespaces/default/pods",
+      "verb": "list",
+      "user": {"username": "system:admin", "groups": ["system:masters"]},
+      "sourceIPs": ["10.0.0.1"],
+      "objectRef": {"resource": "pods", "namespace": "default", "apiVersion": "v1"},
+      "responseStatus": {"code": 200},
+      "requestReceivedTimestamp": "2024-05-01T12:00:00.000000Z",
+      "stageTimestamp": "2024-05-01T12:00:00.012345Z"
+    }
+  ]
+}`
+
+func TestAuditHandler(t *testing.T) {
+	logp.TestingSetup()
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantEvents  int
+	}{
+		{name: "valid", method: http.MethodPost, path: "/audit", contentType: "application/json", body: testAuditEventList, wantStatus: http.StatusOK, wantEvents: 1},
+		{name: "wrong path", method: http.MethodPost, path: "/", contentType: "application/json", body: testAuditEventList, wantStatus: http.StatusNotFound},
+		{name: "wrong method", method: http.MethodGet, path: "/audit", wantStatus: http.StatusMethodNotAllowed},
+		{name: "wrong content type", method: http.MethodPost, path: "/audit", contentType: "text/plain", body: testAuditEventList, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "wrong kind", method: http.MethodPost, path: "/audit", contentType: "application/json", body: `{"kind":"Event"}`, wantStatus: http.StatusBadRequest},
+		{name: "malformed", method: http.MethodPost, path: "/audit", contentType: "application/json", body: `{"kind":`, wantStatus: http.StatusBadRequest},
+		{name: "too large", method: http.MethodPost, path: "/audit", contentType: "application/json", body: `{"kind":"EventList","items":[` + strings.Repeat(" ", 1024) + `]}`, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var events []beat.Event
+			h := &auditHandler{
+				log:         logp.NewLogger(pluginName),
+				path:        "/audit",
+				maxBodySize: 1024,
+				publish: func(e beat.Event) error {
+					events = append(events, e)
+					return nil
+				},
+			}
+			r := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+			if test.contentType != "" {
+				r.Header.Set("Content-Type", test.contentType)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			assert.Equal(t, test.wantStatus, w.Code)
+			assert.Len(t, events, test.wantEvents)
+		})
+	}
+}
+
+func TestDecodeAuditEvents(t *testing.T) {
+	events, err := decodeAuditEvents([]byte(testAuditEventList))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	e := events[0]
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 0, 0, 12345000, time.UTC), e.Timestamp)
+	for k, want := range map[string]interface{}{
+		"event.action":                         "list",
+		"user.name":                            "system:admin",
+		"source.ip":                            "10.0.0.1",
+		"kubernetes.audit.objectRef.resource":  "pods",
+		"kubernetes.audit.responseStatus.code": int64(200),
+		"kubernetes.audit.stage":               "ResponseComplete",
+		"kubernetes.audit.user.groups":         []interface{}{"system:masters"},
+	} {
+		got, err := e.Fields.GetValue(k)
+		if assert.NoError(t, err, k) {
+			assert.Equal(t, want, got, k)
+		}
+	}
+}
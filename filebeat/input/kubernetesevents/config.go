@@ -0,0 +1,82 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kubernetesevents
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/elastic/beats/v7/libbeat/common/cfgtype"
+	"github.com/elastic/elastic-agent-autodiscover/kubernetes"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+const (
+	// modeWatch watches Events through the Kubernetes API.
+	modeWatch = "watch"
+	// modeAuditWebhook receives audit event batches from the API server
+	// audit webhook backend.
+	modeAuditWebhook = "audit_webhook"
+)
+
+type config struct {
+	// Mode is either watch or audit_webhook.
+	Mode string `config:"mode"`
+
+	// Options of the watch mode.
+	KubeConfig        string                       `config:"kube_config"`
+	KubeClientOptions kubernetes.KubeClientOptions `config:"kube_client_options"`
+	Namespace         string                       `config:"namespace"`
+	SyncPeriod        time.Duration                `config:"sync_period"`
+	LabelsDedot       bool                         `config:"labels.dedot"`
+	AnnotationsDedot  bool                         `config:"annotations.dedot"`
+
+	// Options of the audit_webhook mode.
+	ListenAddress string                  `config:"listen_address"`
+	ListenPort    int                     `config:"listen_port" validate:"min=0,max=65535"`
+	URL           string                  `config:"url"`
+	TLS           *tlscommon.ServerConfig `config:"ssl"`
+	MaxBodySize   cfgtype.ByteSize        `config:"max_body_size" validate:"nonzero,positive"`
+}
+
+func defaultConfig() config {
+	return config{
+		Mode:             modeWatch,
+		SyncPeriod:       10 * time.Minute,
+		LabelsDedot:      true,
+		AnnotationsDedot: true,
+		ListenAddress:    "localhost",
+		ListenPort:       8443,
+		URL:              "/",
+		MaxBodySize:      10 * humanize.MiByte,
+	}
+}
+
+func (c *config) Validate() error {
+	switch c.Mode {
+	case modeWatch, modeAuditWebhook:
+	default:
+		return fmt.Errorf("mode must be %s or %s: %s", modeWatch, modeAuditWebhook, c.Mode)
+	}
+	if c.SyncPeriod <= 0 {
+		return fmt.Errorf("sync_period must be positive: %s", c.SyncPeriod)
+	}
+	return nil
+}
@@ -0,0 +1,343 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kubernetesevents
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	k8s "k8s.io/client-go/kubernetes"
+
+	input "github.com/elastic/beats/v7/filebeat/input/v2"
+	cursor "github.com/elastic/beats/v7/filebeat/input/v2/input-cursor"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/feature"
+	"github.com/elastic/elastic-agent-autodiscover/kubernetes"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/safemapstr"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+const pluginName = "kubernetes_events"
+
+// Plugin creates a new kubernetes_events input plugin.
+func Plugin(log *logp.Logger, store cursor.StateStore) input.Plugin {
+	return input.Plugin{
+		Name:       pluginName,
+		Stability:  feature.Beta,
+		Deprecated: false,
+		Info:       "kubernetes events and audit webhook input",
+		Doc:        "The kubernetes_events input collects Events from the Kubernetes API and audit events from the API server audit webhook",
+		Manager: &cursor.InputManager{
+			Logger:     log,
+			StateStore: store,
+			Type:       pluginName,
+			Configure:  configure,
+		},
+	}
+}
+
+type kubeEvents struct {
+	config    config
+	tlsConfig *tls.Config
+
+	// newClient returns the client for the Kubernetes API.
+	newClient func() (k8s.Interface, error)
+}
+
+// checkpoint is the cursor state of the watch mode.
+type checkpoint struct {
+	// ResourceVersion is the resource version of the most recent
+	// published Event.
+	ResourceVersion string
+}
+
+type source string
+
+func (s source) Name() string { return string(s) }
+
+func configure(cfg *conf.C) ([]cursor.Source, cursor.Input, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, nil, err
+	}
+
+	inp := &kubeEvents{
+		config: config,
+		newClient: func() (k8s.Interface, error) {
+			return kubernetes.GetKubernetesClient(config.KubeConfig, config.KubeClientOptions)
+		},
+	}
+
+	var src source
+	switch config.Mode {
+	case modeWatch:
+		src = "events"
+		if config.Namespace != "" {
+			src += source("/" + config.Namespace)
+		}
+	case modeAuditWebhook:
+		src = source("audit/" + inp.addr())
+		tlsConfigBuilder, err := tlscommon.LoadTLSServerConfig(config.TLS)
+		if err != nil {
+			return nil, nil, err
+		}
+		if tlsConfigBuilder != nil {
+			inp.tlsConfig = tlsConfigBuilder.BuildServerConfig(inp.addr())
+		}
+	}
+	return []cursor.Source{src}, inp, nil
+}
+
+func (inp *kubeEvents) Name() string { return pluginName }
+
+func (inp *kubeEvents) addr() string {
+	return net.JoinHostPort(inp.config.ListenAddress, strconv.Itoa(inp.config.ListenPort))
+}
+
+func (inp *kubeEvents) Test(_ cursor.Source, _ input.TestContext) error {
+	if inp.config.Mode == modeAuditWebhook {
+		l, err := net.Listen("tcp", inp.addr())
+		if err != nil {
+			return err
+		}
+		return l.Close()
+	}
+	client, err := inp.newClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.Discovery().ServerVersion()
+	return err
+}
+
+func (inp *kubeEvents) Run(ctx input.Context, _ cursor.Source, cur cursor.Cursor, pub cursor.Publisher) error {
+	if inp.config.Mode == modeAuditWebhook {
+		return inp.runAuditWebhook(ctx, pub)
+	}
+
+	var cp checkpoint
+	if !cur.IsNew() {
+		if err := cur.Unpack(&cp); err != nil {
+			ctx.Logger.Errorf("Reset kubernetes_events position. Failed to read checkpoint from registry: %v", err)
+			cp = checkpoint{}
+		}
+	}
+
+	client, err := inp.newClient()
+	if err != nil {
+		return fmt.Errorf("failed to get kubernetes client: %w", err)
+	}
+	return inp.watch(ctx, client, cp, pub)
+}
+
+// watch publishes Events until the input is cancelled.
+//
+// Events are deduplicated across restarts using the resource version in the
+// checkpoint: after the watcher has listed the existing Events, those that are
+// not newer than the checkpoint are skipped, and the others are published in
+// resource version order so that the checkpoint only moves forward. Events
+// that are added or updated later are published as they are received.
+func (inp *kubeEvents) watch(ctx input.Context, client k8s.Interface, cp checkpoint, pub cursor.Publisher) error {
+	log := ctx.Logger
+
+	watcher, err := kubernetes.NewWatcher(client, &kubernetes.Event{}, kubernetes.WatchOptions{
+		SyncTimeout: inp.config.SyncPeriod,
+		Namespace:   inp.config.Namespace,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to init kubernetes watcher: %w", err)
+	}
+
+	var (
+		// synced is closed once the listed Events have been published.
+		synced = make(chan struct{})
+		// threshold is the resource version up to which Events have been
+		// published when synced is closed.
+		threshold uint64
+		published = parseResourceVersion(cp.ResourceVersion)
+	)
+	publish := func(e *kubernetes.Event) {
+		var update interface{}
+		rv := parseResourceVersion(e.ResourceVersion)
+		if rv > published {
+			published = rv
+			update = checkpoint{ResourceVersion: e.ResourceVersion}
+		}
+		if err := pub.Publish(inp.toEvent(e, log), update); err != nil {
+			log.Errorw("Failed to publish kubernetes event", "error", err)
+		}
+	}
+	onEvent := func(obj interface{}) {
+		select {
+		case <-synced:
+		case <-ctx.Cancelation.Done():
+			return
+		}
+		e, ok := obj.(*kubernetes.Event)
+		if !ok {
+			log.Debugf("Unexpected object type: %T", obj)
+			return
+		}
+		rv := parseResourceVersion(e.ResourceVersion)
+		if rv != 0 && rv <= threshold {
+			return
+		}
+		publish(e)
+	}
+	watcher.AddEventHandler(kubernetes.ResourceEventHandlerFuncs{
+		AddFunc:    onEvent,
+		UpdateFunc: onEvent,
+		// Deleted events are ignored.
+		DeleteFunc: nil,
+	})
+
+	err = watcher.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start kubernetes watcher: %w", err)
+	}
+	defer watcher.Stop()
+
+	var listed []*kubernetes.Event
+	for _, obj := range watcher.Store().List() {
+		if e, ok := obj.(*kubernetes.Event); ok {
+			listed = append(listed, e)
+		}
+	}
+	sort.SliceStable(listed, func(i, j int) bool {
+		return parseResourceVersion(listed[i].ResourceVersion) < parseResourceVersion(listed[j].ResourceVersion)
+	})
+	threshold = published
+	skipped := 0
+	for _, e := range listed {
+		rv := parseResourceVersion(e.ResourceVersion)
+		if rv != 0 && rv <= threshold {
+			skipped++
+			continue
+		}
+		publish(e)
+	}
+	if published > threshold {
+		threshold = published
+	}
+	log.Infow("Listed kubernetes events", "listed", len(listed), "skipped", skipped)
+	close(synced)
+
+	<-ctx.Cancelation.Done()
+	return nil
+}
+
+// parseResourceVersion returns the resource version as a number, or zero
+// if it is not numeric. Resource versions are opaque strings, but are
+// numeric and increasing with the etcd storage backend.
+func parseResourceVersion(rv string) uint64 {
+	v, err := strconv.ParseUint(rv, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// toEvent returns the beat.Event for a Kubernetes Event, with the fields
+// laid out as in the Metricbeat kubernetes event metricset.
+func (inp *kubeEvents) toEvent(e *kubernetes.Event, log *logp.Logger) beat.Event {
+	meta := mapstr.M{
+		"timestamp": mapstr.M{
+			"created": kubernetes.Time(&e.ObjectMeta.CreationTimestamp).UTC(),
+		},
+		"name":             e.ObjectMeta.GetName(),
+		"namespace":        e.ObjectMeta.GetNamespace(),
+		"self_link":        e.ObjectMeta.GetSelfLink(),
+		"generate_name":    e.ObjectMeta.GetGenerateName(),
+		"uid":              e.ObjectMeta.GetUID(),
+		"resource_version": e.ObjectMeta.GetResourceVersion(),
+	}
+	if len(e.ObjectMeta.Labels) != 0 {
+		meta["labels"] = dedotMap(e.ObjectMeta.Labels, inp.config.LabelsDedot, log)
+	}
+	if len(e.ObjectMeta.Annotations) != 0 {
+		meta["annotations"] = dedotMap(e.ObjectMeta.Annotations, inp.config.AnnotationsDedot, log)
+	}
+
+	ts := kubernetes.Time(&e.LastTimestamp)
+	if ts.IsZero() {
+		ts = kubernetes.MicroTime(&e.EventTime)
+	}
+	if ts.IsZero() {
+		ts = kubernetes.Time(&e.ObjectMeta.CreationTimestamp)
+	}
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	return beat.Event{
+		Timestamp: ts.UTC(),
+		Fields: mapstr.M{
+			"message": e.Message,
+			"kubernetes": mapstr.M{
+				"namespace": e.Namespace,
+				"event": mapstr.M{
+					"message": e.Message,
+					"reason":  e.Reason,
+					"type":    e.Type,
+					"count":   e.Count,
+					"source": mapstr.M{
+						"host":      e.Source.Host,
+						"component": e.Source.Component,
+					},
+					"involved_object": mapstr.M{
+						"api_version":      e.InvolvedObject.APIVersion,
+						"resource_version": e.InvolvedObject.ResourceVersion,
+						"name":             e.InvolvedObject.Name,
+						"kind":             e.InvolvedObject.Kind,
+						"uid":              e.InvolvedObject.UID,
+					},
+					"metadata": meta,
+					"timestamp": mapstr.M{
+						"first_occurrence": kubernetes.Time(&e.FirstTimestamp).UTC(),
+						"last_occurrence":  kubernetes.Time(&e.LastTimestamp).UTC(),
+					},
+				},
+			},
+			"event": mapstr.M{
+				"kind": "event",
+			},
+		},
+	}
+}
+
+func dedotMap(m map[string]string, dedot bool, log *logp.Logger) mapstr.M {
+	out := make(mapstr.M, len(m))
+	for k, v := range m {
+		if dedot {
+			out[common.DeDot(k)] = v
+			continue
+		}
+		if err := safemapstr.Put(out, k, v); err != nil {
+			log.Debugf("Failed to put field '%s' with value '%s': %s", k, v, err)
+		}
+	}
+	return out
+}
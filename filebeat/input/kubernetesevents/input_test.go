@@ -0,0 +1,168 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kubernetesevents
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	input "github.com/elastic/beats/v7/filebeat/input/v2"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+type publishedEvent struct {
+	event  beat.Event
+	cursor interface{}
+}
+
+type testPublisher struct {
+	mu     sync.Mutex
+	events []publishedEvent
+}
+
+func (p *testPublisher) Publish(event beat.Event, cursor interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, publishedEvent{event: event, cursor: cursor})
+	return nil
+}
+
+func (p *testPublisher) published() []publishedEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]publishedEvent(nil), p.events...)
+}
+
+func newTestEvent(name, rv string) *v1.Event {
+	return &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			ResourceVersion:   rv,
+			CreationTimestamp: metav1.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+			Labels:            map[string]string{"app.kubernetes.io/name": "test"},
+		},
+		InvolvedObject: v1.ObjectReference{
+			Kind:      "Pod",
+			Name:      "test-pod",
+			Namespace: "default",
+		},
+		Reason:        "Started",
+		Message:       "Started container " + name,
+		Type:          "Normal",
+		Count:         1,
+		LastTimestamp: metav1.Date(2024, 5, 1, 12, 0, 1, 0, time.UTC),
+	}
+}
+
+func runWatch(t *testing.T, client *fake.Clientset, cp checkpoint, pub *testPublisher) (stop func()) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	inp := &kubeEvents{config: defaultConfig()}
+	done := make(chan error, 1)
+	go func() {
+		done <- inp.watch(input.Context{
+			Logger:      logp.NewLogger(pluginName),
+			ID:          "test",
+			Cancelation: ctx,
+		}, client, cp, pub)
+	}()
+	return func() {
+		cancel()
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(10 * time.Second):
+			t.Fatal("watch did not stop")
+		}
+	}
+}
+
+func messages(events []publishedEvent) []string {
+	var msgs []string
+	for _, e := range events {
+		msg, _ := e.event.Fields.GetValue("message")
+		msgs = append(msgs, msg.(string))
+	}
+	return msgs
+}
+
+func TestWatch(t *testing.T) {
+	logp.TestingSetup()
+
+	objs := []runtime.Object{
+		newTestEvent("c", "9"),
+		newTestEvent("a", "5"),
+		newTestEvent("b", "7"),
+	}
+
+	t.Run("new", func(t *testing.T) {
+		client := fake.NewSimpleClientset(objs...)
+		pub := &testPublisher{}
+		stop := runWatch(t, client, checkpoint{}, pub)
+		defer stop()
+
+		require.Eventually(t, func() bool { return len(pub.published()) == 3 }, 10*time.Second, 10*time.Millisecond)
+		events := pub.published()
+		assert.Equal(t, []string{"Started container a", "Started container b", "Started container c"}, messages(events))
+		assert.Equal(t, checkpoint{ResourceVersion: "9"}, events[2].cursor)
+
+		e := events[0].event
+		assert.Equal(t, time.Date(2024, 5, 1, 12, 0, 1, 0, time.UTC), e.Timestamp)
+		reason, _ := e.Fields.GetValue("kubernetes.event.reason")
+		assert.Equal(t, "Started", reason)
+		kind, _ := e.Fields.GetValue("kubernetes.event.involved_object.kind")
+		assert.Equal(t, "Pod", kind)
+		label, _ := e.Fields.GetValue("kubernetes.event.metadata.labels.app_kubernetes_io/name")
+		assert.Equal(t, "test", label)
+	})
+
+	t.Run("restart", func(t *testing.T) {
+		client := fake.NewSimpleClientset(objs...)
+		pub := &testPublisher{}
+		stop := runWatch(t, client, checkpoint{ResourceVersion: "7"}, pub)
+		defer stop()
+
+		require.Eventually(t, func() bool { return len(pub.published()) == 1 }, 10*time.Second, 10*time.Millisecond)
+
+		_, err := client.CoreV1().Events("default").Create(context.Background(), newTestEvent("d", "12"), metav1.CreateOptions{})
+		require.NoError(t, err)
+		// An update to an Event that was already published before the
+		// restart is published again with its new resource version.
+		_, err = client.CoreV1().Events("default").Update(context.Background(), newTestEvent("a", "13"), metav1.UpdateOptions{})
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool { return len(pub.published()) == 3 }, 10*time.Second, 10*time.Millisecond)
+		events := pub.published()
+		assert.Equal(t, []string{"Started container c", "Started container d", "Started container a"}, messages(events))
+		assert.Equal(t, checkpoint{ResourceVersion: "9"}, events[0].cursor)
+		assert.Equal(t, checkpoint{ResourceVersion: "12"}, events[1].cursor)
+		assert.Equal(t, checkpoint{ResourceVersion: "13"}, events[2].cursor)
+	})
+}
@@ -109,6 +109,10 @@ type ProcessingConfig struct {
 	// the pipeline processors.
 	Processor ProcessorList
 
+	// PostProcessor is executed after the pipeline processors, as the last
+	// step modifying the event.
+	PostProcessor Processor
+
 	// KeepNull determines whether published events will keep null values or omit them.
 	KeepNull bool
 
@@ -149,6 +153,35 @@ type Processor interface {
 	Run(in *Event) (event *Event, err error)
 }
 
+// MultiProcessor is an optional interface for processors that can turn a
+// single event into zero or more events (e.g. split an array into separate
+// events). Clients publishing through a processing pipeline with
+// multi-event processors are still ACKed per published event: the
+// EventListener is notified only once all resulting events have been ACKed.
+type MultiProcessor interface {
+	Processor
+
+	// RunMulti processes the event and returns the resulting events. An
+	// empty result drops the event.
+	RunMulti(in *Event) (events []*Event, err error)
+}
+
+// PublishingProcessor is an optional interface for processors that publish
+// events of their own, in addition to the events they process (e.g.
+// aggregates published when a time window closes). The pipeline passes itself
+// and the configuration of the client to the processor when a client using it
+// connects.
+type PublishingProcessor interface {
+	Processor
+
+	// SetPipeline sets the pipeline the processor publishes its events to,
+	// and the configuration of the client using the processor. The
+	// configuration is empty for processors shared by all clients.
+	// It is called for every client using the processor, so it must be cheap
+	// and must not connect to the pipeline synchronously.
+	SetPipeline(Pipeline, ClientConfig)
+}
+
 // PublishMode enum sets some requirements on the client connection to the beats
 // publisher pipeline
 type PublishMode uint8
@@ -0,0 +1,244 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package useragent parses user agent strings with regular expression
+// definitions in the uap-core format.
+//
+// The embedded definitions are the regexes.yaml of uap-core v0.18.0
+// (https://github.com/ua-parser/uap-core), the same definitions used by the
+// Elasticsearch user_agent ingest processor. Use Load with a newer
+// regexes.yaml to update them without a new release.
+package useragent
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+//go:embed regexes.yaml
+var defaultDefinitions []byte
+
+// defaultParser compiles the embedded definitions on first use, as it takes
+// a noticeable time that Beats not parsing user agents shouldn't spend.
+var defaultParser = sync.OnceValue(func() *Parser {
+	return MustNew(defaultDefinitions)
+})
+
+// UserAgent is the result of parsing a user agent string. Fields that are
+// not known are empty, except Name and Device which default to "Other".
+type UserAgent struct {
+	Name    string
+	Version string
+	OS      OS
+	Device  string
+}
+
+// OS is the operating system of a user agent.
+type OS struct {
+	Name    string
+	Version string
+	Full    string
+}
+
+// Parser parses user agent strings.
+type Parser struct {
+	agents  []rule
+	systems []rule
+	devices []rule
+}
+
+// rule is a regular expression with replacements for its result fields.
+// Empty replacements are filled from the capture groups in order.
+type rule struct {
+	re           *regexp.Regexp
+	replacements []string
+}
+
+type definitions struct {
+	UserAgentParsers []struct {
+		Regex             string `yaml:"regex"`
+		FamilyReplacement string `yaml:"family_replacement"`
+		V1Replacement     string `yaml:"v1_replacement"`
+		V2Replacement     string `yaml:"v2_replacement"`
+		V3Replacement     string `yaml:"v3_replacement"`
+	} `yaml:"user_agent_parsers"`
+	OSParsers []struct {
+		Regex           string `yaml:"regex"`
+		OSReplacement   string `yaml:"os_replacement"`
+		OSV1Replacement string `yaml:"os_v1_replacement"`
+		OSV2Replacement string `yaml:"os_v2_replacement"`
+		OSV3Replacement string `yaml:"os_v3_replacement"`
+		OSV4Replacement string `yaml:"os_v4_replacement"`
+	} `yaml:"os_parsers"`
+	DeviceParsers []struct {
+		Regex             string `yaml:"regex"`
+		RegexFlag         string `yaml:"regex_flag"`
+		DeviceReplacement string `yaml:"device_replacement"`
+	} `yaml:"device_parsers"`
+}
+
+// Default returns a parser using the embedded definitions.
+func Default() *Parser {
+	return defaultParser()
+}
+
+// New creates a parser from definitions in the uap-core regexes.yaml
+// format. Definitions with regular expressions that Go doesn't support
+// are returned as errors.
+func New(data []byte) (*Parser, error) {
+	var defs definitions
+	if err := yaml.Unmarshal(data, &defs); err != nil {
+		return nil, fmt.Errorf("failed to decode user agent definitions: %w", err)
+	}
+	p := &Parser{}
+	for _, d := range defs.UserAgentParsers {
+		r, err := newRule(d.Regex, "", d.FamilyReplacement, d.V1Replacement, d.V2Replacement, d.V3Replacement)
+		if err != nil {
+			return nil, err
+		}
+		p.agents = append(p.agents, r)
+	}
+	for _, d := range defs.OSParsers {
+		r, err := newRule(d.Regex, "", d.OSReplacement, d.OSV1Replacement, d.OSV2Replacement, d.OSV3Replacement, d.OSV4Replacement)
+		if err != nil {
+			return nil, err
+		}
+		p.systems = append(p.systems, r)
+	}
+	for _, d := range defs.DeviceParsers {
+		r, err := newRule(d.Regex, d.RegexFlag, d.DeviceReplacement)
+		if err != nil {
+			return nil, err
+		}
+		p.devices = append(p.devices, r)
+	}
+	return p, nil
+}
+
+// MustNew is like New but panics on error.
+func MustNew(data []byte) *Parser {
+	p, err := New(data)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func newRule(expr, flag string, replacements ...string) (rule, error) {
+	if flag == "i" {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return rule{}, fmt.Errorf("invalid user agent regex %q: %w", expr, err)
+	}
+	return rule{re: re, replacements: replacements}, nil
+}
+
+// match returns the fields of the first rule matching s.
+func match(rules []rule, s string) ([]string, bool) {
+	for _, r := range rules {
+		groups := r.re.FindStringSubmatch(s)
+		if groups == nil {
+			continue
+		}
+		fields := make([]string, len(r.replacements))
+		for i, repl := range r.replacements {
+			switch {
+			case repl != "":
+				fields[i] = strings.TrimSpace(expand(repl, groups))
+			case i+1 < len(groups):
+				fields[i] = groups[i+1]
+			}
+		}
+		return fields, true
+	}
+	return nil, false
+}
+
+// expand replaces $1 to $9 with the capture groups.
+func expand(repl string, groups []string) string {
+	if !strings.Contains(repl, "$") {
+		return repl
+	}
+	var b strings.Builder
+	for i := 0; i < len(repl); i++ {
+		if repl[i] == '$' && i+1 < len(repl) && repl[i+1] >= '1' && repl[i+1] <= '9' {
+			if n := int(repl[i+1] - '0'); n < len(groups) {
+				b.WriteString(groups[n])
+			}
+			i++
+			continue
+		}
+		b.WriteByte(repl[i])
+	}
+	return b.String()
+}
+
+// joinVersion joins the non-empty leading version components.
+func joinVersion(parts []string) string {
+	var version []string
+	for _, p := range parts {
+		if p == "" {
+			break
+		}
+		version = append(version, p)
+	}
+	return strings.Join(version, ".")
+}
+
+// Parse parses a user agent string.
+func (p *Parser) Parse(s string) UserAgent {
+	ua := UserAgent{Name: "Other", Device: "Other"}
+	if fields, ok := match(p.agents, s); ok && fields[0] != "" {
+		ua.Name = fields[0]
+		ua.Version = joinVersion(fields[1:])
+	}
+	if fields, ok := match(p.systems, s); ok && fields[0] != "" {
+		ua.OS.Name = fields[0]
+		ua.OS.Version = joinVersion(fields[1:])
+		ua.OS.Full = strings.TrimSpace(ua.OS.Name + " " + ua.OS.Version)
+	}
+	if fields, ok := match(p.devices, s); ok && fields[0] != "" {
+		ua.Device = fields[0]
+	}
+	return ua
+}
+
+// Parse parses a user agent string with the default parser.
+func Parse(s string) UserAgent {
+	return defaultParser().Parse(s)
+}
+
+// Load creates a parser from a file in the uap-core regexes.yaml format.
+func Load(path string) (*Parser, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := New(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	return p, nil
+}
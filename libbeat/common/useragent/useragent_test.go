@@ -0,0 +1,157 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package useragent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		ua   string
+		want UserAgent
+	}{
+		{
+			ua: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.109 Safari/537.36",
+			want: UserAgent{
+				Name: "Chrome", Version: "120.0.6099",
+				OS:     OS{Name: "Windows", Version: "10", Full: "Windows 10"},
+				Device: "Other",
+			},
+		},
+		{
+			ua: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			want: UserAgent{
+				Name: "Edge", Version: "120.0.2210",
+				OS:     OS{Name: "Windows", Version: "10", Full: "Windows 10"},
+				Device: "Other",
+			},
+		},
+		{
+			ua: "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			want: UserAgent{
+				Name: "Firefox", Version: "121.0",
+				OS:     OS{Name: "Ubuntu", Full: "Ubuntu"},
+				Device: "Other",
+			},
+		},
+		{
+			ua: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			want: UserAgent{
+				Name: "Mobile Safari", Version: "17.2",
+				OS:     OS{Name: "iOS", Version: "17.2", Full: "iOS 17.2"},
+				Device: "iPhone",
+			},
+		},
+		{
+			ua: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+			want: UserAgent{
+				Name: "Safari", Version: "17.2",
+				OS:     OS{Name: "Mac OS X", Version: "10.15.7", Full: "Mac OS X 10.15.7"},
+				Device: "Mac",
+			},
+		},
+		{
+			ua: "Mozilla/5.0 (Linux; Android 13; SM-S918B Build/TP1A.220624.014) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+			want: UserAgent{
+				Name: "Chrome Mobile", Version: "120.0.6099",
+				OS:     OS{Name: "Android", Version: "13", Full: "Android 13"},
+				Device: "Samsung SM-S918B",
+			},
+		},
+		{
+			ua: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			want: UserAgent{
+				Name: "Googlebot", Version: "2.1",
+				Device: "Spider",
+			},
+		},
+		{
+			ua: "Mozilla/5.0 (Linux; Android 14; SM-A546B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+			want: UserAgent{
+				Name: "Samsung Internet", Version: "23.0",
+				OS:     OS{Name: "Android", Version: "14", Full: "Android 14"},
+				Device: "Samsung SM-A546B",
+			},
+		},
+		{
+			ua: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 OPR/106.0.0.0",
+			want: UserAgent{
+				Name: "Opera", Version: "106.0.0",
+				OS:     OS{Name: "Windows", Version: "10", Full: "Windows 10"},
+				Device: "Other",
+			},
+		},
+		{
+			ua: "Mozilla/5.0 (compatible; YandexBot/3.0; +http://yandex.com/bots)",
+			want: UserAgent{
+				Name: "YandexBot", Version: "3.0",
+				Device: "Spider",
+			},
+		},
+		{
+			ua:   "curl/8.4.0",
+			want: UserAgent{Name: "curl", Version: "8.4.0", Device: "Other"},
+		},
+		{
+			ua:   "",
+			want: UserAgent{Name: "Other", Device: "Other"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.ua, func(t *testing.T) {
+			assert.Equal(t, tc.want, Parse(tc.ua))
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	p, err := New([]byte(`
+user_agent_parsers:
+  - regex: '(MyAgent)/(\d+)\.(\d+)'
+    family_replacement: 'My $1'
+device_parsers:
+  - regex: 'model=(\w+)'
+    regex_flag: 'i'
+    device_replacement: 'Device $1'
+`))
+	require.NoError(t, err)
+	assert.Equal(t, UserAgent{Name: "My MyAgent", Version: "1.2", Device: "Device X1"}, p.Parse("MyAgent/1.2 MODEL=X1"))
+
+	_, err = New([]byte(`user_agent_parsers: [{regex: '(?<=a)b'}]`))
+	assert.Error(t, err)
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "regexes.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+user_agent_parsers:
+  - regex: '(MyAgent)/(\d+)\.(\d+)'
+`), 0o644))
+	p, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, UserAgent{Name: "MyAgent", Version: "1.2", Device: "Other"}, p.Parse("MyAgent/1.2"))
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
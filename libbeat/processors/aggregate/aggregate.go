@@ -0,0 +1,474 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/beat/events"
+	"github.com/elastic/beats/v7/libbeat/common/acker"
+	"github.com/elastic/beats/v7/libbeat/common/atomic"
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+const (
+	procName = "aggregate"
+	logName  = "processor." + procName
+
+	// defaultWaitClose is the time to wait for summary events to be
+	// acknowledged on Close, if the client using the processor doesn't wait.
+	defaultWaitClose = 5 * time.Second
+)
+
+// instanceID is used to assign each instance a unique monitoring namespace.
+var instanceID atomic.Uint32
+
+func init() {
+	// We cannot use this as a JS plugin as it is stateful and includes a Close method.
+	processors.RegisterPlugin(procName, New)
+}
+
+type metrics struct {
+	aggregated *monitoring.Uint
+	overflow   *monitoring.Uint
+	published  *monitoring.Uint
+	acked      *monitoring.Uint
+	dropped    *monitoring.Uint
+}
+
+// aggregate is a processor that groups events over tumbling windows and
+// publishes a summary event per group when a window closes.
+type aggregate struct {
+	config config
+	log    *logp.Logger
+
+	metrics metrics
+
+	// mu protects window and closed.
+	mu     sync.Mutex
+	window *window
+	// closed are the windows waiting to be published.
+	closed []*window
+	// flush signals the publisher that windows were closed.
+	flush chan struct{}
+
+	// pipelineMu protects pipeline and clientConfig. It is separate from mu
+	// as connecting to the pipeline sets the pipeline of the processor again.
+	pipelineMu   sync.Mutex
+	pipeline     beat.Pipeline
+	clientConfig beat.ClientConfig
+	// client is only used by the publisher goroutine, and by Close after
+	// it has stopped.
+	client beat.Client
+	// pending is the number of summary events that have been published but
+	// not acknowledged or dropped yet.
+	pending atomic.Int64
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+	now       func() time.Time
+
+	// refs is the number of processors using the aggregate. It is
+	// protected by the mutex of aggregates.
+	refs int
+}
+
+// aggregates holds the aggregates of the running processors. Processors with
+// the same id share their aggregate, so that inputs creating a processor per
+// file, like filestream, aggregate the events of all their files together.
+var aggregates = aggregateSet{byID: map[string]*aggregate{}, unnamed: map[string]int{}}
+
+type aggregateSet struct {
+	mu   sync.Mutex
+	byID map[string]*aggregate
+	// unnamed counts the processors without an id by configuration, to
+	// detect processors that are created per file.
+	unnamed map[string]int
+}
+
+// get returns the aggregate of a new processor. The configuration of a
+// shared aggregate is set by its first processor.
+func (s *aggregateSet) get(c config, now func() time.Time) *aggregate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c.ID == "" {
+		p := newAggregate(c, now)
+		p.refs++
+		key := p.String()
+		if s.unnamed[key]++; s.unnamed[key] == 2 {
+			p.log.Warnw("Several "+procName+" processors with the same configuration are running, for example one per file of an input. "+
+				"Each of them aggregates its events separately. Set the id option to aggregate their events together.")
+		}
+		return p
+	}
+
+	p, ok := s.byID[c.ID]
+	if !ok {
+		p = newAggregate(c, now)
+		s.byID[c.ID] = p
+	} else if !reflect.DeepEqual(p.config, c) {
+		p.log.Warnw("The configuration of "+procName+" processors with the same id differs, using the configuration of the first one.", "id", c.ID)
+	}
+	p.refs++
+	return p
+}
+
+// release releases the aggregate of a closed processor. It reports whether
+// it was the last processor using the aggregate.
+func (s *aggregateSet) release(p *aggregate) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p.refs == 0 {
+		// The aggregate was not created with get, or is already released.
+		return true
+	}
+	p.refs--
+	if p.refs > 0 {
+		return false
+	}
+	if p.config.ID != "" {
+		delete(s.byID, p.config.ID)
+		return true
+	}
+	key := p.String()
+	if s.unnamed[key]--; s.unnamed[key] <= 0 {
+		delete(s.unnamed, key)
+	}
+	return true
+}
+
+// New constructs a new aggregate processor. The resulting processor
+// implements Close() to publish the pending aggregates. Processors with the
+// same id share their aggregates.
+func New(cfg *conf.C) (beat.Processor, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("fail to unpack the %v processor configuration: %w", procName, err)
+	}
+
+	return aggregates.get(config, time.Now), nil
+}
+
+func newAggregate(c config, now func() time.Time) *aggregate {
+	cfgwarn.Beta("The " + procName + " processor is beta.")
+
+	// Logging and metrics (each processor instance has a unique ID).
+	var (
+		id  = int(instanceID.Inc())
+		log = logp.NewLogger(logName).With("instance_id", id)
+		reg = monitoring.Default.NewRegistry(logName+"."+strconv.Itoa(id), monitoring.DoNotReport)
+	)
+
+	p := &aggregate{
+		config: c,
+		log:    log,
+		metrics: metrics{
+			aggregated: monitoring.NewUint(reg, "events.aggregated"),
+			overflow:   monitoring.NewUint(reg, "events.overflow"),
+			published:  monitoring.NewUint(reg, "summaries.published"),
+			acked:      monitoring.NewUint(reg, "summaries.acked"),
+			dropped:    monitoring.NewUint(reg, "summaries.dropped"),
+		},
+		window: newWindow(now().Truncate(c.Period)),
+		flush:  make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		now:    now,
+	}
+
+	p.wg.Add(1)
+	go p.run()
+	return p
+}
+
+// SetPipeline sets the pipeline summary events are published to, and the
+// configuration of the client using the processor.
+func (p *aggregate) SetPipeline(pipeline beat.Pipeline, cfg beat.ClientConfig) {
+	p.pipelineMu.Lock()
+	defer p.pipelineMu.Unlock()
+	p.pipeline = pipeline
+	p.clientConfig = cfg
+}
+
+// Run adds the event to the aggregates of its group. The event is dropped if
+// drop_events is set. Summary events published by the processor are returned
+// unchanged.
+func (p *aggregate) Run(event *beat.Event) (*beat.Event, error) {
+	if event.Private == p {
+		return event, nil
+	}
+
+	values := make([]interface{}, len(p.config.GroupBy))
+	for i, f := range p.config.GroupBy {
+		values[i], _ = event.GetValue(f)
+	}
+	key, err := json.Marshal(values)
+	if err != nil {
+		return event, fmt.Errorf("failed to compute %s group: %w", procName, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.rollover(p.now())
+	g, ok := p.window.groups[string(key)]
+	if !ok {
+		if len(p.window.groups) >= p.config.MaxGroups {
+			p.metrics.overflow.Inc()
+			return event, nil
+		}
+		g = &group{values: values, metrics: make([]accumulator, len(p.config.Metrics))}
+		p.window.groups[string(key)] = g
+	}
+	g.count++
+	for i, m := range p.config.Metrics {
+		v, err := event.GetValue(m.Field)
+		if err != nil {
+			continue
+		}
+		if f, ok := toFloat(v); ok {
+			g.metrics[i].add(f, p.config.MaxSamples)
+		}
+	}
+	p.metrics.aggregated.Inc()
+
+	if p.config.DropEvents {
+		return nil, nil
+	}
+	return event, nil
+}
+
+// rollover closes the current window if it has ended, and signals the
+// publisher. It must be called with mu held.
+func (p *aggregate) rollover(now time.Time) {
+	start := now.Truncate(p.config.Period)
+	if !start.After(p.window.start) {
+		return
+	}
+	if len(p.window.groups) > 0 {
+		p.closed = append(p.closed, p.window)
+		select {
+		case p.flush <- struct{}{}:
+		default:
+		}
+	}
+	p.window = newWindow(start)
+}
+
+// run closes windows when they end and publishes their summary events.
+func (p *aggregate) run() {
+	defer p.wg.Done()
+
+	timer := time.NewTimer(p.untilNextWindow())
+	defer timer.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-timer.C:
+			p.mu.Lock()
+			p.rollover(p.now())
+			p.mu.Unlock()
+			timer.Reset(p.untilNextWindow())
+		case <-p.flush:
+		}
+		p.publish(p.takeClosed())
+	}
+}
+
+func (p *aggregate) untilNextWindow() time.Duration {
+	now := p.now()
+	return now.Truncate(p.config.Period).Add(p.config.Period).Sub(now)
+}
+
+func (p *aggregate) takeClosed() []*window {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	closed := p.closed
+	p.closed = nil
+	return closed
+}
+
+// publish publishes the summary events of the windows.
+func (p *aggregate) publish(windows []*window) {
+	var events []beat.Event
+	for _, w := range windows {
+		for _, g := range w.groups {
+			events = append(events, p.summary(w, g))
+		}
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	client, err := p.connect()
+	if err != nil {
+		p.log.Errorw("Failed to publish aggregates", "summaries", len(events), "error", err)
+		p.metrics.dropped.Add(uint64(len(events)))
+		return
+	}
+	p.pending.Add(int64(len(events)))
+	client.PublishAll(events)
+}
+
+// connect returns the client summary events are published with, connecting
+// to the pipeline the first time.
+func (p *aggregate) connect() (beat.Client, error) {
+	if p.client != nil {
+		return p.client, nil
+	}
+
+	p.pipelineMu.Lock()
+	pipeline, cfg := p.pipeline, p.clientConfig
+	p.pipelineMu.Unlock()
+	if pipeline == nil {
+		return nil, fmt.Errorf("the %s processor is not used in a publishing pipeline", procName)
+	}
+
+	client, err := pipeline.ConnectWith(p.summaryClientConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+	p.client = client
+	return client, nil
+}
+
+// summaryClientConfig returns the configuration of the client summary events
+// are published with. Summaries are published like the events of the client
+// using the processor, with its publish mode, fields and metadata. They are
+// not run through its processors, which include the processor itself, nor
+// through the ingest pipeline of its events, and its listeners don't see them.
+func (p *aggregate) summaryClientConfig(cfg beat.ClientConfig) beat.ClientConfig {
+	cfg.Processing.Processor = nil
+	if _, found := cfg.Processing.Meta[events.FieldMetaPipeline]; found {
+		cfg.Processing.Meta = cfg.Processing.Meta.Clone()
+		delete(cfg.Processing.Meta, events.FieldMetaPipeline)
+	}
+	if cfg.WaitClose <= 0 {
+		cfg.WaitClose = defaultWaitClose
+	}
+	cfg.EventListener = acker.Counting(func(n int) {
+		p.metrics.acked.Add(uint64(n))
+		p.pending.Sub(int64(n))
+	})
+	cfg.ClientListener = (*summaryListener)(p)
+	return cfg
+}
+
+// summary returns the summary event of the group.
+func (p *aggregate) summary(w *window, g *group) beat.Event {
+	fields := mapstr.M{}
+	for i, f := range p.config.GroupBy {
+		if g.values[i] != nil {
+			fields.Put(f, g.values[i])
+		}
+	}
+
+	aggs := mapstr.M{
+		"count": g.count,
+		"window": mapstr.M{
+			"start": w.start,
+			"end":   w.start.Add(p.config.Period),
+		},
+	}
+	for i, m := range p.config.Metrics {
+		if g.metrics[i].count == 0 {
+			continue
+		}
+		aggs.Put(m.Field, g.metrics[i].fields(m))
+	}
+	fields.Put(p.config.TargetField, aggs)
+
+	return beat.Event{
+		Timestamp: w.start,
+		Fields:    fields,
+		// Mark the event, so that it is not aggregated again if the
+		// processor also processes the events it publishes.
+		Private: p,
+	}
+}
+
+// Close publishes the aggregates of the current window and closes the
+// client used to publish summary events, waiting for them to be
+// acknowledged. It returns an error if summary events were dropped because
+// the pipeline is shutting down. Aggregates shared by processors with the
+// same id are only published when the last of them is closed.
+func (p *aggregate) Close() error {
+	if !aggregates.release(p) {
+		return nil
+	}
+	var err error
+	p.closeOnce.Do(func() {
+		close(p.done)
+		p.wg.Wait()
+
+		p.mu.Lock()
+		closed := append(p.closed, p.window)
+		p.closed = nil
+		p.window = newWindow(p.window.start)
+		p.mu.Unlock()
+
+		dropped := p.metrics.dropped.Get()
+		p.publish(closed)
+		if p.client != nil {
+			err = p.client.Close()
+		}
+		if n := p.metrics.dropped.Get() - dropped; n > 0 {
+			err = errors.Join(err, fmt.Errorf("%d %s summaries were dropped on close", n, procName))
+		}
+		if n := p.pending.Load(); n > 0 {
+			p.log.Warnw("Summary events were not acknowledged before the processor was closed, they may be lost", "summaries", n)
+		}
+	})
+	return err
+}
+
+// summaryListener counts the summary events that enter the queue, and the
+// ones that are dropped because the client or the pipeline is closed.
+type summaryListener aggregate
+
+func (l *summaryListener) Closing() {}
+func (l *summaryListener) Closed()  {}
+
+func (l *summaryListener) Published() {
+	l.metrics.published.Inc()
+}
+
+func (l *summaryListener) DroppedOnPublish(beat.Event) {
+	l.metrics.dropped.Inc()
+	l.pending.Dec()
+}
+
+func (p *aggregate) String() string {
+	json, _ := json.Marshal(p.config)
+	return procName + "=" + string(json)
+}
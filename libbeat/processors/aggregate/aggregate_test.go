@@ -0,0 +1,402 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregate
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/acker"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// fakePipeline collects the events published by its clients, and ACKs them
+// right away. Events are dropped if the pipeline is shutting down.
+type fakePipeline struct {
+	mu       sync.Mutex
+	events   []beat.Event
+	config   beat.ClientConfig
+	closed   bool
+	shutdown bool
+}
+
+func (p *fakePipeline) Connect() (beat.Client, error) { return p.ConnectWith(beat.ClientConfig{}) }
+
+func (p *fakePipeline) ConnectWith(cfg beat.ClientConfig) (beat.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.config = cfg
+	return &fakeClient{pipeline: p, config: cfg}, nil
+}
+
+func (p *fakePipeline) published() []beat.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	events := p.events
+	p.events = nil
+	// Groups are published in no particular order.
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Fields.String() < events[j].Fields.String()
+	})
+	return events
+}
+
+type fakeClient struct {
+	pipeline *fakePipeline
+	config   beat.ClientConfig
+}
+
+func (c *fakeClient) Publish(e beat.Event) { c.PublishAll([]beat.Event{e}) }
+
+func (c *fakeClient) PublishAll(events []beat.Event) {
+	c.pipeline.mu.Lock()
+	defer c.pipeline.mu.Unlock()
+	for _, e := range events {
+		if c.pipeline.shutdown {
+			c.config.ClientListener.DroppedOnPublish(e)
+			continue
+		}
+		c.pipeline.events = append(c.pipeline.events, e)
+		c.config.ClientListener.Published()
+		c.config.EventListener.AddEvent(e, true)
+		c.config.EventListener.ACKEvents(1)
+	}
+}
+
+func (c *fakeClient) Close() error {
+	c.pipeline.mu.Lock()
+	defer c.pipeline.mu.Unlock()
+	c.pipeline.closed = true
+	return nil
+}
+
+func newTestAggregate(t *testing.T, cfg map[string]interface{}, clock *fakeClock) *aggregate {
+	t.Helper()
+	c := defaultConfig()
+	require.NoError(t, conf.MustNewConfigFrom(cfg).Unpack(&c))
+	return newAggregate(c, clock.Now)
+}
+
+func TestAggregate(t *testing.T) {
+	logp.TestingSetup()
+
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start.Add(10 * time.Second)}
+	p := newTestAggregate(t, map[string]interface{}{
+		"period":   "1h",
+		"group_by": []string{"url.path", "http.response.status_code"},
+		"metrics": []map[string]interface{}{
+			{"field": "http.response.body.bytes", "stats": []string{"sum", "max"}},
+			{"field": "event.duration", "percentiles": []float64{50, 100}},
+		},
+		"drop_events": true,
+	}, clock)
+	pipeline := &fakePipeline{}
+	p.SetPipeline(pipeline, beat.ClientConfig{})
+
+	for _, e := range []mapstr.M{
+		{"url": mapstr.M{"path": "/"}, "http": mapstr.M{"response": mapstr.M{"status_code": 200, "body": mapstr.M{"bytes": 100}}}, "event": mapstr.M{"duration": 10}},
+		{"url": mapstr.M{"path": "/"}, "http": mapstr.M{"response": mapstr.M{"status_code": 200, "body": mapstr.M{"bytes": "300"}}}, "event": mapstr.M{"duration": 30}},
+		{"url": mapstr.M{"path": "/"}, "http": mapstr.M{"response": mapstr.M{"status_code": 200, "body": mapstr.M{"bytes": "-"}}}, "event": mapstr.M{"duration": 20}},
+		{"url": mapstr.M{"path": "/"}, "http": mapstr.M{"response": mapstr.M{"status_code": 404}}},
+		{"http": mapstr.M{"response": mapstr.M{"status_code": 404}}},
+	} {
+		event, err := p.Run(&beat.Event{Fields: e})
+		require.NoError(t, err)
+		assert.Nil(t, event)
+	}
+	assert.Empty(t, pipeline.published())
+
+	// The first event of the next window closes the previous one.
+	clock.Set(start.Add(time.Hour))
+	_, err := p.Run(&beat.Event{Fields: mapstr.M{"url": mapstr.M{"path": "/next"}}})
+	require.NoError(t, err)
+
+	var events []beat.Event
+	require.Eventually(t, func() bool {
+		events = append(events, pipeline.published()...)
+		return len(events) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	sort.Slice(events, func(i, j int) bool { return events[i].Fields.String() < events[j].Fields.String() })
+
+	window := mapstr.M{"start": start, "end": start.Add(time.Hour)}
+	want := []mapstr.M{
+		{
+			"http":      mapstr.M{"response": mapstr.M{"status_code": 404}},
+			"aggregate": mapstr.M{"count": int64(1), "window": window},
+		},
+		{
+			"url":  mapstr.M{"path": "/"},
+			"http": mapstr.M{"response": mapstr.M{"status_code": 200}},
+			"aggregate": mapstr.M{
+				"count":  int64(3),
+				"window": window,
+				"http":   mapstr.M{"response": mapstr.M{"body": mapstr.M{"bytes": mapstr.M{"sum": 400.0, "max": 300.0}}}},
+				"event":  mapstr.M{"duration": mapstr.M{"p50": 20.0, "p100": 30.0}},
+			},
+		},
+		{
+			"url":       mapstr.M{"path": "/"},
+			"http":      mapstr.M{"response": mapstr.M{"status_code": 404}},
+			"aggregate": mapstr.M{"count": int64(1), "window": window},
+		},
+	}
+	sort.Slice(want, func(i, j int) bool { return want[i].String() < want[j].String() })
+	for i, e := range events {
+		assert.Equal(t, start, e.Timestamp)
+		assert.Equal(t, want[i], e.Fields)
+	}
+
+	// Summary events published by the processor are not aggregated again.
+	event, err := p.Run(&events[0])
+	require.NoError(t, err)
+	assert.Equal(t, &events[0], event)
+
+	// Closing publishes the current window.
+	require.NoError(t, p.Close())
+	events = pipeline.published()
+	require.Len(t, events, 1)
+	assert.Equal(t, mapstr.M{
+		"url":       mapstr.M{"path": "/next"},
+		"aggregate": mapstr.M{"count": int64(1), "window": mapstr.M{"start": start.Add(time.Hour), "end": start.Add(2 * time.Hour)}},
+	}, events[0].Fields)
+	assert.True(t, pipeline.closed)
+	assert.Equal(t, uint64(4), p.metrics.published.Get())
+	assert.Equal(t, uint64(4), p.metrics.acked.Get())
+	assert.Zero(t, p.pending.Load())
+}
+
+func TestAggregateSharedID(t *testing.T) {
+	logp.TestingSetup()
+
+	// Inputs like filestream create processors per file.
+	cfg := conf.MustNewConfigFrom(map[string]interface{}{"id": "access", "period": "1h"})
+	p1, err := New(cfg)
+	require.NoError(t, err)
+	p2, err := New(cfg)
+	require.NoError(t, err)
+	other, err := New(conf.MustNewConfigFrom(map[string]interface{}{"id": "other", "period": "1h"}))
+	require.NoError(t, err)
+	defer other.(*aggregate).Close()
+
+	pipeline := &fakePipeline{}
+	for _, p := range []beat.Processor{p1, p2} {
+		p.(*aggregate).SetPipeline(pipeline, beat.ClientConfig{})
+		_, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "hello"}})
+		require.NoError(t, err)
+	}
+
+	// The window is published once, when the last processor is closed.
+	require.NoError(t, p1.(*aggregate).Close())
+	assert.Empty(t, pipeline.published())
+	require.NoError(t, p2.(*aggregate).Close())
+	events := pipeline.published()
+	require.Len(t, events, 1)
+	count, _ := events[0].GetValue("aggregate.count")
+	assert.Equal(t, int64(2), count)
+
+	// A processor created after all processors of the id are closed starts
+	// a new aggregate.
+	p3, err := New(cfg)
+	require.NoError(t, err)
+	assert.NotSame(t, p1, p3)
+	require.NoError(t, p3.(*aggregate).Close())
+}
+
+func TestAggregateClientConfig(t *testing.T) {
+	logp.TestingSetup()
+
+	clock := &fakeClock{now: time.Now()}
+	p := newTestAggregate(t, map[string]interface{}{"period": "1h"}, clock)
+
+	procs, err := processors.New(nil)
+	require.NoError(t, err)
+	procs.AddProcessor(p)
+	listener := acker.Nil()
+	pipeline := &fakePipeline{}
+	p.SetPipeline(pipeline, beat.ClientConfig{
+		PublishMode: beat.GuaranteedSend,
+		Processing: beat.ProcessingConfig{
+			Meta:      mapstr.M{"pipeline": "nginx-access", "index": "logs"},
+			Fields:    mapstr.M{"service": mapstr.M{"name": "web"}},
+			Processor: procs,
+		},
+		EventListener: listener,
+	})
+
+	_, err = p.Run(&beat.Event{Fields: mapstr.M{"message": "hello"}})
+	require.NoError(t, err)
+	require.NoError(t, p.Close())
+	require.Len(t, pipeline.published(), 1)
+
+	// Summaries are published with the settings of the client, but without
+	// its processors, ingest pipeline and listeners.
+	cfg := pipeline.config
+	assert.Equal(t, beat.GuaranteedSend, cfg.PublishMode)
+	assert.Equal(t, mapstr.M{"index": "logs"}, cfg.Processing.Meta)
+	assert.Equal(t, mapstr.M{"service": mapstr.M{"name": "web"}}, cfg.Processing.Fields)
+	assert.Nil(t, cfg.Processing.Processor)
+	assert.NotEqual(t, listener, cfg.EventListener)
+	assert.Equal(t, defaultWaitClose, cfg.WaitClose)
+}
+
+func TestAggregateDroppedOnClose(t *testing.T) {
+	logp.TestingSetup()
+
+	clock := &fakeClock{now: time.Now()}
+	p := newTestAggregate(t, map[string]interface{}{"period": "1h"}, clock)
+	pipeline := &fakePipeline{shutdown: true}
+	p.SetPipeline(pipeline, beat.ClientConfig{})
+
+	_, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "hello"}})
+	require.NoError(t, err)
+	assert.ErrorContains(t, p.Close(), "1 aggregate summaries were dropped on close")
+	assert.Empty(t, pipeline.published())
+	assert.Equal(t, uint64(1), p.metrics.dropped.Get())
+	assert.Zero(t, p.pending.Load())
+}
+
+func TestAggregateMaxGroups(t *testing.T) {
+	logp.TestingSetup()
+
+	clock := &fakeClock{now: time.Now()}
+	p := newTestAggregate(t, map[string]interface{}{
+		"period":      "1h",
+		"group_by":    []string{"user"},
+		"max_groups":  1,
+		"drop_events": true,
+	}, clock)
+	defer p.Close()
+
+	event, err := p.Run(&beat.Event{Fields: mapstr.M{"user": "alice"}})
+	require.NoError(t, err)
+	assert.Nil(t, event)
+
+	// Events of further groups are passed through.
+	event, err = p.Run(&beat.Event{Fields: mapstr.M{"user": "bob"}})
+	require.NoError(t, err)
+	assert.Equal(t, mapstr.M{"user": "bob"}, event.Fields)
+	assert.Equal(t, uint64(1), p.metrics.overflow.Get())
+}
+
+func TestAggregateWithoutPipeline(t *testing.T) {
+	logp.TestingSetup()
+
+	clock := &fakeClock{now: time.Now()}
+	p := newTestAggregate(t, map[string]interface{}{"period": "1h"}, clock)
+
+	event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "hello"}})
+	require.NoError(t, err)
+	assert.NotNil(t, event)
+	assert.Error(t, p.Close())
+	assert.Equal(t, uint64(1), p.metrics.dropped.Get())
+}
+
+func TestSetPipeline(t *testing.T) {
+	logp.TestingSetup()
+
+	cfg, err := processors.NewPluginConfigFromList([]mapstr.M{
+		{
+			"aggregate": mapstr.M{
+				"period": "1h",
+				"when":   mapstr.M{"has_fields": []string{"message"}},
+			},
+		},
+		{
+			"if":   mapstr.M{"has_fields": []string{"message"}},
+			"then": []mapstr.M{{"aggregate": mapstr.M{"period": "1h", "target_field": "other"}}},
+		},
+	})
+	require.NoError(t, err)
+	procs, err := processors.New(cfg)
+	require.NoError(t, err)
+
+	pipeline := &fakePipeline{}
+	processors.SetPipeline(procs, pipeline, beat.ClientConfig{})
+	_, err = procs.Run(&beat.Event{Fields: mapstr.M{"message": "hello"}})
+	require.NoError(t, err)
+	require.NoError(t, procs.Close())
+
+	events := pipeline.published()
+	require.Len(t, events, 2)
+	assert.Contains(t, events[0].Fields, "aggregate")
+	assert.Contains(t, events[1].Fields, "other")
+}
+
+func TestPercentile(t *testing.T) {
+	assert.Equal(t, 7.0, percentile([]float64{7}, 50))
+	assert.Equal(t, 1.0, percentile([]float64{1, 2, 3, 4}, 0))
+	assert.Equal(t, 2.5, percentile([]float64{1, 2, 3, 4}, 50))
+	assert.Equal(t, 4.0, percentile([]float64{1, 2, 3, 4}, 100))
+	assert.Equal(t, "p99_9", percentileKey(99.9))
+
+	// Beyond max_samples, the values are sampled.
+	var a accumulator
+	for i := 0; i < 1000; i++ {
+		a.add(float64(i), 10)
+	}
+	assert.Len(t, a.samples, 10)
+	assert.Equal(t, int64(1000), a.count)
+	assert.Equal(t, 0.0, a.min)
+	assert.Equal(t, 999.0, a.max)
+}
+
+func TestConfig(t *testing.T) {
+	for name, cfg := range map[string]map[string]interface{}{
+		"zero period":     {"period": 0},
+		"bad stat":        {"metrics": []map[string]interface{}{{"field": "x", "stats": []string{"median"}}}},
+		"bad percentile":  {"metrics": []map[string]interface{}{{"field": "x", "percentiles": []float64{101}}}},
+		"missing field":   {"metrics": []map[string]interface{}{{"stats": []string{"sum"}}}},
+		"zero max_groups": {"max_groups": 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := defaultConfig()
+			assert.Error(t, conf.MustNewConfigFrom(cfg).Unpack(&c))
+		})
+	}
+
+	c := defaultConfig()
+	require.NoError(t, conf.MustNewConfigFrom(map[string]interface{}{"metrics": []map[string]interface{}{{"field": "x"}}}).Unpack(&c))
+	assert.Equal(t, defaultStats, c.Metrics[0].Stats)
+}
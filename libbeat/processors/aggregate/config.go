@@ -0,0 +1,94 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregate
+
+import (
+	"fmt"
+	"time"
+)
+
+// Statistics computed for metric fields.
+const (
+	statCount = "count"
+	statSum   = "sum"
+	statMin   = "min"
+	statMax   = "max"
+	statAvg   = "avg"
+)
+
+var defaultStats = []string{statCount, statSum, statMin, statMax, statAvg}
+
+type config struct {
+	// Period is the length of the tumbling windows. Windows are aligned to
+	// multiples of the period.
+	Period time.Duration `config:"period" validate:"nonzero,positive"`
+	// GroupBy are the fields whose values identify a group. Events missing
+	// a field are grouped together.
+	GroupBy []string `config:"group_by"`
+	// Metrics are the numeric fields aggregated for each group.
+	Metrics []metricConfig `config:"metrics"`
+	// TargetField is the field the aggregates are written to in summary
+	// events.
+	TargetField string `config:"target_field" validate:"required"`
+	// DropEvents drops the events that are aggregated.
+	DropEvents bool `config:"drop_events"`
+	// MaxGroups is the maximum number of groups per window. Events of
+	// further groups are not aggregated.
+	MaxGroups int `config:"max_groups" validate:"min=1"`
+	// MaxSamples is the maximum number of values per metric and group that
+	// are kept to compute percentiles. Beyond that, percentiles are
+	// estimated from a random sample.
+	MaxSamples int    `config:"max_samples" validate:"min=1"`
+	ID         string `config:"id"`
+}
+
+type metricConfig struct {
+	Field string `config:"field" validate:"required"`
+	// Stats are the statistics computed for the field. All statistics are
+	// computed if neither stats nor percentiles are set.
+	Stats       []string  `config:"stats"`
+	Percentiles []float64 `config:"percentiles"`
+}
+
+func defaultConfig() config {
+	return config{
+		Period:      time.Minute,
+		TargetField: "aggregate",
+		MaxGroups:   10000,
+		MaxSamples:  1000,
+	}
+}
+
+func (c *metricConfig) Validate() error {
+	if c.Stats == nil && len(c.Percentiles) == 0 {
+		c.Stats = defaultStats
+	}
+	for _, s := range c.Stats {
+		switch s {
+		case statCount, statSum, statMin, statMax, statAvg:
+		default:
+			return fmt.Errorf("unsupported statistic %q for field %s", s, c.Field)
+		}
+	}
+	for _, p := range c.Percentiles {
+		if p < 0 || p > 100 {
+			return fmt.Errorf("percentile %v for field %s is not between 0 and 100", p, c.Field)
+		}
+	}
+	return nil
+}
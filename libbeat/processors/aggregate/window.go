@@ -0,0 +1,129 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregate
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/processors/convert"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// window holds the groups of a tumbling window.
+type window struct {
+	start  time.Time
+	groups map[string]*group
+}
+
+func newWindow(start time.Time) *window {
+	return &window{start: start, groups: map[string]*group{}}
+}
+
+// group holds the aggregates of the events of a window sharing the values
+// of the group_by fields.
+type group struct {
+	// values are the values of the group_by fields, nil for missing fields.
+	values  []interface{}
+	count   int64
+	metrics []accumulator
+}
+
+// accumulator aggregates the values of a metric field.
+type accumulator struct {
+	count         int64
+	sum, min, max float64
+	// samples is a uniform random sample of the values, used to compute
+	// percentiles.
+	samples []float64
+}
+
+func (a *accumulator) add(v float64, maxSamples int) {
+	a.count++
+	a.sum += v
+	if a.count == 1 || v < a.min {
+		a.min = v
+	}
+	if a.count == 1 || v > a.max {
+		a.max = v
+	}
+
+	// Reservoir sampling keeps every value with the same probability.
+	if len(a.samples) < maxSamples {
+		a.samples = append(a.samples, v)
+	} else if i := rand.Int63n(a.count); i < int64(maxSamples) {
+		a.samples[i] = v
+	}
+}
+
+// fields returns the configured statistics of the field.
+func (a *accumulator) fields(c metricConfig) mapstr.M {
+	m := mapstr.M{}
+	for _, s := range c.Stats {
+		switch s {
+		case statCount:
+			m[s] = a.count
+		case statSum:
+			m[s] = a.sum
+		case statMin:
+			m[s] = a.min
+		case statMax:
+			m[s] = a.max
+		case statAvg:
+			m[s] = a.sum / float64(a.count)
+		}
+	}
+	if len(c.Percentiles) > 0 {
+		sorted := append([]float64(nil), a.samples...)
+		sort.Float64s(sorted)
+		for _, p := range c.Percentiles {
+			m[percentileKey(p)] = percentile(sorted, p)
+		}
+	}
+	return m
+}
+
+// percentile returns the p-th percentile of the sorted values, interpolating
+// linearly between the closest ranks.
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lo, hi := int(math.Floor(rank)), int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
+
+// percentileKey returns the name of the field of the p-th percentile, e.g.
+// p99_9 for the 99.9th percentile.
+func percentileKey(p float64) string {
+	return "p" + strings.ReplaceAll(strconv.FormatFloat(p, 'f', -1, 64), ".", "_")
+}
+
+// toDouble converts values to float64 the same way the convert processor does.
+var toDouble, _ = convert.NewTransform("double")
+
+// toFloat converts numbers and numeric strings to float64.
+func toFloat(v interface{}) (float64, bool) {
+	f, err := toDouble(v)
+	if err != nil {
+		return 0, false
+	}
+	return f.(float64), true
+}
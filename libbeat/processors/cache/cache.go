@@ -109,12 +109,53 @@ func getStoreFor(cfg config, log *logp.Logger) (Store, context.CancelFunc, error
 	}
 }
 
+// StoreOptions configures a store opened with OpenStore.
+type StoreOptions struct {
+	// ID is the ID of the store. Stores are shared by all the
+	// processors that use the same ID and kind of store.
+	ID string
+	// File selects a store that is backed by a file in the data path,
+	// so that its entries survive restarts.
+	File bool
+	// WriteInterval is the interval at which a file-backed store is
+	// written to its file. If zero, the file is only written when the
+	// store is released by its last user.
+	WriteInterval time.Duration
+	// TTL is the time entries are valid for.
+	TTL time.Duration
+	// Capacity is the number of entries that may be stored. When it is
+	// reached, the entries that expire first are evicted. If not positive,
+	// there is no limit.
+	Capacity int
+}
+
+// OpenStore returns a shared store for processors other than the cache
+// processor that need to keep state in a store. The TTL and capacity of a
+// store are set by the first processor using it. The returned
+// context.CancelFunc releases the store and should be called when the
+// processor is closed.
+func OpenStore(opts StoreOptions, log *logp.Logger) (Store, context.CancelFunc, error) {
+	cfg := config{
+		Put:   &putConfig{TTL: &opts.TTL},
+		Store: &storeConfig{Capacity: opts.Capacity},
+	}
+	if opts.File {
+		cfg.Store.File = &fileConfig{ID: opts.ID, WriteOutEvery: opts.WriteInterval}
+	} else {
+		cfg.Store.Memory = &memConfig{ID: opts.ID}
+	}
+	return getStoreFor(cfg, log)
+}
+
 // noop is a no-op context.CancelFunc.
 func noop() {}
 
 // Store is the interface implemented by metadata providers.
 type Store interface {
 	Put(key string, val any) error
+	// PutIfAbsent atomically stores the value unless the key has a value
+	// that has not expired, and reports whether the value was stored.
+	PutIfAbsent(key string, val any) (bool, error)
 	Get(key string) (any, error)
 	Delete(key string) error
 
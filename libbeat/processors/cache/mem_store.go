@@ -168,9 +168,28 @@ func (c *memStore) Get(key string) (any, error) {
 // The value is given an expiry time based on the configured TTL of the cache.
 // Put is safe for concurrent use.
 func (c *memStore) Put(key string, val any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.put(key, val, time.Now())
+	return nil
+}
+
+// PutIfAbsent stores the provided value in the cache like Put, unless the
+// key has a value that has not expired. It reports whether the value was
+// stored. PutIfAbsent is safe for concurrent use.
+func (c *memStore) PutIfAbsent(key string, val any) (bool, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	now := time.Now()
+	if prev, found := c.cache[key]; found && !now.After(prev.Expires) {
+		return false, nil
+	}
+	c.put(key, val, now)
+	return true, nil
+}
+
+// put stores the value with the lock held.
+func (c *memStore) put(key string, val any, now time.Time) {
 	c.evictExpired(now)
 	// If the key is being overwritten we remove its previous expiry entry
 	// this will prevent expiries heap to grow with large TTLs and recurring keys.
@@ -188,7 +207,6 @@ func (c *memStore) Put(key string, val any) error {
 		heap.Push(&c.expiries, e)
 	}
 	c.dirty = true
-	return nil
 }
 
 // evictExpired removes up to effort elements from the cache when the cache
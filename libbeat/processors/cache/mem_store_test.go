@@ -465,6 +465,44 @@ func TestMemStore(t *testing.T) {
 	}
 }
 
+func TestMemStorePutIfAbsent(t *testing.T) {
+	cfg := config{
+		Store: &storeConfig{Memory: &memConfig{"test"}},
+		Put:   &putConfig{TTL: ptrTo(50 * time.Millisecond)},
+	}
+	store := newMemStore(cfg, cfg.Store.Memory.ID)
+	store.add(cfg)
+
+	for i, step := range []struct {
+		val  string
+		want bool
+	}{
+		{val: "first", want: true},
+		{val: "second", want: false},
+	} {
+		stored, err := store.PutIfAbsent("key", step.val)
+		if err != nil {
+			t.Fatalf("unexpected error at step %d: %v", i, err)
+		}
+		if stored != step.want {
+			t.Errorf("unexpected result at step %d: got %t, want %t", i, stored, step.want)
+		}
+	}
+	if got, _ := store.Get("key"); got != "first" {
+		t.Errorf("unexpected value: got %v, want first", got)
+	}
+
+	// Expired values are replaced.
+	time.Sleep(100 * time.Millisecond)
+	stored, err := store.PutIfAbsent("key", "third")
+	if err != nil || !stored {
+		t.Errorf("unexpected result after expiry: stored=%t err=%v", stored, err)
+	}
+	if got, _ := store.Get("key"); got != "third" {
+		t.Errorf("unexpected value: got %v, want third", got)
+	}
+}
+
 // add adds the store to the set. It is used only for testing.
 func (s *memStoreSet) add(store *memStore) {
 	s.mu.Lock()
@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/joeshaw/multierror"
+
 	"github.com/elastic/beats/v7/libbeat/beat"
 	"github.com/elastic/beats/v7/libbeat/conditions"
 	"github.com/elastic/elastic-agent-libs/config"
@@ -84,6 +86,21 @@ func (r *WhenProcessor) Run(event *beat.Event) (*beat.Event, error) {
 	return r.p.Run(event)
 }
 
+// RunMulti executes this WhenProcessor, allowing the wrapped processor to
+// return multiple events.
+func (r *WhenProcessor) RunMulti(event *beat.Event) ([]*beat.Event, error) {
+	if !(r.condition).Check(event) {
+		return []*beat.Event{event}, nil
+	}
+	return RunMulti(r.p, event)
+}
+
+// Close closes the wrapped processor, so that processors holding resources
+// are closed with the processor list, also when they have a condition.
+func (r *WhenProcessor) Close() error {
+	return Close(r.p)
+}
+
 func (r *WhenProcessor) String() string {
 	return fmt.Sprintf("%v, condition=%v", r.p.String(), r.condition.String())
 }
@@ -171,6 +188,31 @@ func (p *IfThenElseProcessor) Run(event *beat.Event) (*beat.Event, error) {
 	return event, nil
 }
 
+// RunMulti is like Run, but allows the processors attached to the then or
+// else statement to return multiple events.
+func (p *IfThenElseProcessor) RunMulti(event *beat.Event) ([]*beat.Event, error) {
+	if p.cond.Check(event) {
+		return p.then.RunMulti(event)
+	} else if p.els != nil {
+		return p.els.RunMulti(event)
+	}
+	return []*beat.Event{event}, nil
+}
+
+// Close closes the processors attached to the then and else statements.
+func (p *IfThenElseProcessor) Close() error {
+	var errs multierror.Errors
+	if err := p.then.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if p.els != nil {
+		if err := p.els.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs.Err()
+}
+
 func (p *IfThenElseProcessor) String() string {
 	var sb strings.Builder
 	sb.WriteString("if ")
@@ -23,8 +23,10 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/conditions"
 	conf "github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/mapstr"
 )
@@ -231,3 +233,25 @@ func TestIfElseThenProcessor(t *testing.T) {
 		},
 	})
 }
+
+func TestConditionalProcessorsClose(t *testing.T) {
+	when := &mockCloserProcessor{}
+	p, err := NewConditionRule(conditions.Config{HasFields: []string{"uid"}}, when)
+	require.NoError(t, err)
+	require.NoError(t, Close(p))
+	assert.Equal(t, 1, when.closeCount)
+
+	then, els := &mockCloserProcessor{}, &mockCloserProcessor{}
+	ifThenElse := &IfThenElseProcessor{
+		then: &Processors{List: []beat.Processor{then, &countFilter{}}},
+		els:  &Processors{List: []beat.Processor{els}},
+	}
+	require.NoError(t, Close(ifThenElse))
+	assert.Equal(t, 1, then.closeCount)
+	assert.Equal(t, 1, els.closeCount)
+
+	// Processors without else are closed too.
+	then = &mockCloserProcessor{}
+	require.NoError(t, Close(&IfThenElseProcessor{then: &Processors{List: []beat.Processor{then}}}))
+	assert.Equal(t, 1, then.closeCount)
+}
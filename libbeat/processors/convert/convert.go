@@ -183,6 +183,23 @@ func transformType(typ dataType, value interface{}) (interface{}, error) {
 	}
 }
 
+// NewTransform returns a function that converts values to the named type
+// (integer, long, float, double, string, boolean or ip), and fails if the
+// type is unknown. The function returns an error for values that can not be
+// converted.
+func NewTransform(typ string) (func(interface{}) (interface{}, error), error) {
+	var dt dataType
+	if err := dt.Unpack(typ); err != nil {
+		return nil, err
+	}
+	if dt == unset {
+		return nil, fmt.Errorf("invalid data type: %v", typ)
+	}
+	return func(value interface{}) (interface{}, error) {
+		return transformType(dt, value)
+	}, nil
+}
+
 func toString(value interface{}) (string, error) {
 	switch v := value.(type) {
 	case nil:
@@ -22,6 +22,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/elastic/beats/v7/libbeat/beat"
 	conf "github.com/elastic/elastic-agent-libs/config"
@@ -450,6 +451,27 @@ func TestDataTypes(t *testing.T) {
 	}
 }
 
+func TestNewTransform(t *testing.T) {
+	toLong, err := NewTransform("long")
+	require.NoError(t, err)
+	v, err := toLong("0x10")
+	require.NoError(t, err)
+	assert.Equal(t, int64(16), v)
+	_, err = toLong("ten")
+	assert.Error(t, err)
+
+	toBool, err := NewTransform("Boolean")
+	require.NoError(t, err)
+	v, err = toBool("true")
+	require.NoError(t, err)
+	assert.Equal(t, true, v)
+
+	_, err = NewTransform("date")
+	assert.ErrorContains(t, err, "invalid data type")
+	_, err = NewTransform("[unset]")
+	assert.ErrorContains(t, err, "invalid data type")
+}
+
 func BenchmarkTestConvertRun(b *testing.B) {
 	c := defaultConfig()
 	c.IgnoreMissing = true
@@ -0,0 +1,93 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_kv
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/elastic/beats/v7/libbeat/processors/convert"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+type config struct {
+	Field string `config:"field"`
+	// Target is the field the decoded keys are written to. If not set, the
+	// source field is replaced. If empty, the keys are written to the root
+	// of the event.
+	Target         *string  `config:"target"`
+	FieldSplit     string   `config:"field_split"`
+	ValueSplit     string   `config:"value_split"`
+	QuoteChars     string   `config:"quote_chars"`
+	TrimWhitespace bool     `config:"trim_whitespace"`
+	TrimKey        string   `config:"trim_key"`
+	TrimValue      string   `config:"trim_value"`
+	IncludeKeys    []string `config:"include_keys"`
+	ExcludeKeys    []string `config:"exclude_keys"`
+	Prefix         string   `config:"prefix"`
+	// Types maps keys to the types their values are converted to.
+	Types         mapstr.M `config:"types"`
+	OverwriteKeys bool     `config:"overwrite_keys"`
+	IgnoreMissing bool     `config:"ignore_missing"`
+	IgnoreFailure bool     `config:"ignore_failure"`
+	ID            string   `config:"id"`
+}
+
+func defaultConfig() config {
+	return config{
+		Field:          "message",
+		FieldSplit:     " ",
+		ValueSplit:     "=",
+		QuoteChars:     `"'`,
+		TrimWhitespace: true,
+	}
+}
+
+func (c *config) Validate() error {
+	if c.FieldSplit == "" || c.ValueSplit == "" {
+		return errors.New("field_split and value_split must not be empty")
+	}
+	if c.FieldSplit == c.ValueSplit {
+		return errors.New("field_split and value_split must be different")
+	}
+	if len(c.IncludeKeys) != 0 && len(c.ExcludeKeys) != 0 {
+		return errors.New("include_keys and exclude_keys can not be used together")
+	}
+	_, err := c.transforms()
+	return err
+}
+
+// transforms returns the type conversions by key.
+func (c *config) transforms() (map[string]func(interface{}) (interface{}, error), error) {
+	if len(c.Types) == 0 {
+		return nil, nil
+	}
+	transforms := map[string]func(interface{}) (interface{}, error){}
+	for key, typ := range c.Types.Flatten() {
+		name, ok := typ.(string)
+		if !ok {
+			return nil, fmt.Errorf("type of key %s must be a string, not %T", key, typ)
+		}
+		fn, err := convert.NewTransform(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid type of key %s: %w", key, err)
+		}
+		transforms[key] = fn
+	}
+	return transforms, nil
+}
@@ -0,0 +1,191 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_kv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	jsprocessor "github.com/elastic/beats/v7/libbeat/processors/script/javascript/module/processor"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const procName = "decode_kv"
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+	jsprocessor.RegisterPlugin("DecodeKV", New)
+}
+
+type processor struct {
+	config
+	parser     parser
+	include    map[string]bool
+	exclude    map[string]bool
+	transforms map[string]func(interface{}) (interface{}, error)
+}
+
+// New constructs a new decode_kv processor built from ucfg config.
+func New(cfg *conf.C) (beat.Processor, error) {
+	c := defaultConfig()
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, fmt.Errorf("fail to unpack the %v processor configuration: %w", procName, err)
+	}
+
+	transforms, err := c.transforms()
+	if err != nil {
+		return nil, err
+	}
+	return &processor{
+		config: c,
+		parser: parser{
+			fieldSplit:     c.FieldSplit,
+			valueSplit:     c.ValueSplit,
+			quoteChars:     c.QuoteChars,
+			trimWhitespace: c.TrimWhitespace,
+			trimKey:        c.TrimKey,
+			trimValue:      c.TrimValue,
+		},
+		include:    toSet(c.IncludeKeys),
+		exclude:    toSet(c.ExcludeKeys),
+		transforms: transforms,
+	}, nil
+}
+
+func toSet(keys []string) map[string]bool {
+	if len(keys) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return set
+}
+
+func (p *processor) String() string {
+	json, _ := json.Marshal(p.config)
+	return procName + "=" + string(json)
+}
+
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	err := p.decode(event)
+	if err == nil || p.IgnoreFailure || (p.IgnoreMissing && errors.Is(err, mapstr.ErrKeyNotFound)) {
+		return event, nil
+	}
+	return event, err
+}
+
+func (p *processor) decode(event *beat.Event) error {
+	v, err := event.GetValue(p.Field)
+	if err != nil {
+		return fmt.Errorf("decode_kv source field [%v] not found: %w", p.Field, err)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("decode_kv source field [%v] is not a string but %T", p.Field, v)
+	}
+
+	out := mapstr.M{}
+	for _, kv := range p.parser.parse(s) {
+		if (p.include != nil && !p.include[kv.key]) || p.exclude[kv.key] {
+			continue
+		}
+
+		var value interface{} = kv.value
+		if fn, ok := p.transforms[kv.key]; ok {
+			if value, err = fn(kv.value); err != nil {
+				return fmt.Errorf("failed to convert value of key [%v]: %w", kv.key, err)
+			}
+		}
+
+		key := p.Prefix + kv.key
+		if prev, err := out.GetValue(key); err == nil {
+			// Repeated keys are collected in an array.
+			if list, ok := prev.([]interface{}); ok {
+				value = append(list, value)
+			} else {
+				value = []interface{}{prev, value}
+			}
+		}
+		if _, err := out.Put(key, value); err != nil {
+			return fmt.Errorf("failed to add key [%v]: %w", key, err)
+		}
+	}
+
+	return p.write(event, out)
+}
+
+// write writes the decoded keys to the target field. Existing objects are
+// merged with the decoded keys.
+func (p *processor) write(event *beat.Event, out mapstr.M) error {
+	target := p.Field
+	if p.Target != nil {
+		target = *p.Target
+	}
+
+	if target == "" {
+		for k := range out {
+			if strings.HasPrefix(k, "@") {
+				// Do not overwrite @timestamp or @metadata.
+				delete(out, k)
+			}
+		}
+		p.merge(event.Fields, out)
+		return nil
+	}
+
+	if target != p.Field {
+		if prev, err := event.GetValue(target); err == nil {
+			if m, ok := tryToMapStr(prev); ok {
+				p.merge(m, out)
+				return nil
+			}
+			if !p.OverwriteKeys {
+				return fmt.Errorf("decode_kv target field [%v] already exists", target)
+			}
+		}
+	}
+	if _, err := event.PutValue(target, out); err != nil {
+		return fmt.Errorf("failed to write decode_kv result to target field [%v]: %w", target, err)
+	}
+	return nil
+}
+
+func (p *processor) merge(to, from mapstr.M) {
+	if p.OverwriteKeys {
+		to.DeepUpdate(from)
+	} else {
+		to.DeepUpdateNoOverwrite(from)
+	}
+}
+
+func tryToMapStr(v interface{}) (mapstr.M, bool) {
+	switch m := v.(type) {
+	case mapstr.M:
+		return m, true
+	case map[string]interface{}:
+		return mapstr.M(m), true
+	}
+	return nil, false
+}
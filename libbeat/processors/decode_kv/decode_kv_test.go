@@ -0,0 +1,209 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_kv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestParse(t *testing.T) {
+	p := parser{
+		fieldSplit:     " ",
+		valueSplit:     "=",
+		quoteChars:     `"'`,
+		trimWhitespace: true,
+	}
+
+	tests := map[string][]pair{
+		`a=1 b=2`:                      {{"a", "1"}, {"b", "2"}},
+		`  a=1   b=  `:                 {{"a", "1"}, {"b", ""}},
+		`msg="hello world" level=info`: {{"msg", "hello world"}, {"level", "info"}},
+		`msg='it\'s ok' x="a \"b\""`:   {{"msg", "it's ok"}, {"x", `a "b"`}},
+		`"user name"=bob`:              {{"user name", "bob"}},
+		`junk a=1 =2 b=x=y`:            {{"a", "1"}, {"b", "x=y"}},
+		`msg="unterminated a=1`:        {{"msg", "unterminated a=1"}},
+		`msg="quoted"trailing b=2`:     {{"msg", "quoted"}, {"b", "2"}},
+		``:                             nil,
+	}
+	for in, expected := range tests {
+		assert.Equal(t, expected, p.parse(in), in)
+	}
+
+	p = parser{
+		fieldSplit:     "|",
+		valueSplit:     ":",
+		quoteChars:     `"`,
+		trimWhitespace: true,
+		trimKey:        "[]",
+		trimValue:      "<>",
+	}
+	assert.Equal(t,
+		[]pair{{"src", "10.0.0.1"}, {"dst port", "443"}, {"act", " allow "}},
+		p.parse(`[src]: <10.0.0.1> | [dst port] : 443|act:" allow "`))
+}
+
+func TestDecodeKV(t *testing.T) {
+	tests := map[string]struct {
+		config   mapstr.M
+		fields   mapstr.M
+		expected mapstr.M
+		err      string
+	}{
+		"replace source field": {
+			config:   mapstr.M{},
+			fields:   mapstr.M{"message": `a=1 b="x y"`},
+			expected: mapstr.M{"message": mapstr.M{"a": "1", "b": "x y"}},
+		},
+		"target field": {
+			config: mapstr.M{"target": "kv", "types": mapstr.M{"bytes": "long", "ok": "boolean", "src.port": "integer"}},
+			fields: mapstr.M{"message": `bytes=512 ok=true src.port=80 src.ip=10.0.0.1`},
+			expected: mapstr.M{
+				"message": `bytes=512 ok=true src.port=80 src.ip=10.0.0.1`,
+				"kv": mapstr.M{
+					"bytes": int64(512),
+					"ok":    true,
+					"src":   mapstr.M{"port": int32(80), "ip": "10.0.0.1"},
+				},
+			},
+		},
+		"root with prefix": {
+			config: mapstr.M{"target": "", "prefix": "fw.", "field_split": ",", "value_split": ":"},
+			fields: mapstr.M{"message": `action:deny, rule: 12`},
+			expected: mapstr.M{
+				"message": `action:deny, rule: 12`,
+				"fw":      mapstr.M{"action": "deny", "rule": "12"},
+			},
+		},
+		"root does not overwrite": {
+			config:   mapstr.M{"target": ""},
+			fields:   mapstr.M{"message": `message=x a=1 @timestamp=now`},
+			expected: mapstr.M{"message": `message=x a=1 @timestamp=now`, "a": "1"},
+		},
+		"root overwrite keys": {
+			config:   mapstr.M{"target": "", "overwrite_keys": true},
+			fields:   mapstr.M{"message": `message=x a=1`},
+			expected: mapstr.M{"message": "x", "a": "1"},
+		},
+		"merge with existing target": {
+			config: mapstr.M{"target": "kv"},
+			fields: mapstr.M{"message": `a=1 b=2`, "kv": mapstr.M{"a": "0", "c": "3"}},
+			expected: mapstr.M{
+				"message": `a=1 b=2`,
+				"kv":      mapstr.M{"a": "0", "b": "2", "c": "3"},
+			},
+		},
+		"target is not an object": {
+			config:   mapstr.M{"target": "kv"},
+			fields:   mapstr.M{"message": `a=1`, "kv": "x"},
+			expected: mapstr.M{"message": `a=1`, "kv": "x"},
+			err:      "already exists",
+		},
+		"include keys": {
+			config:   mapstr.M{"target": "kv", "include_keys": []string{"a", "c"}},
+			fields:   mapstr.M{"message": `a=1 b=2 c=3`},
+			expected: mapstr.M{"message": `a=1 b=2 c=3`, "kv": mapstr.M{"a": "1", "c": "3"}},
+		},
+		"exclude keys": {
+			config:   mapstr.M{"target": "kv", "exclude_keys": []string{"password"}},
+			fields:   mapstr.M{"message": `user=a password=b`},
+			expected: mapstr.M{"message": `user=a password=b`, "kv": mapstr.M{"user": "a"}},
+		},
+		"repeated keys": {
+			config:   mapstr.M{"target": "kv"},
+			fields:   mapstr.M{"message": `tag=a tag=b tag=c`},
+			expected: mapstr.M{"message": `tag=a tag=b tag=c`, "kv": mapstr.M{"tag": []interface{}{"a", "b", "c"}}},
+		},
+		"conversion error": {
+			config:   mapstr.M{"target": "kv", "types": mapstr.M{"n": "long"}},
+			fields:   mapstr.M{"message": `n=ten`},
+			expected: mapstr.M{"message": `n=ten`},
+			err:      "failed to convert value of key [n]",
+		},
+		"ignore failure": {
+			config:   mapstr.M{"target": "kv", "types": mapstr.M{"n": "long"}, "ignore_failure": true},
+			fields:   mapstr.M{"message": `n=ten`},
+			expected: mapstr.M{"message": `n=ten`},
+		},
+		"missing": {
+			config:   mapstr.M{},
+			fields:   mapstr.M{"log": "a=1"},
+			expected: mapstr.M{"log": "a=1"},
+			err:      "not found",
+		},
+		"ignore missing": {
+			config:   mapstr.M{"ignore_missing": true},
+			fields:   mapstr.M{"log": "a=1"},
+			expected: mapstr.M{"log": "a=1"},
+		},
+		"not a string": {
+			config:   mapstr.M{},
+			fields:   mapstr.M{"message": 1},
+			expected: mapstr.M{"message": 1},
+			err:      "is not a string",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := New(conf.MustNewConfigFrom(test.config))
+			require.NoError(t, err)
+
+			event, err := p.Run(&beat.Event{Fields: test.fields})
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expected, event.Fields)
+		})
+	}
+}
+
+func TestDecodeKVConfig(t *testing.T) {
+	tests := map[string]struct {
+		config mapstr.M
+		err    string
+	}{
+		"same separators": {
+			config: mapstr.M{"field_split": "=", "value_split": "="},
+			err:    "must be different",
+		},
+		"include and exclude": {
+			config: mapstr.M{"include_keys": []string{"a"}, "exclude_keys": []string{"b"}},
+			err:    "can not be used together",
+		},
+		"type": {
+			config: mapstr.M{"types": mapstr.M{"a": "date"}},
+			err:    "invalid type of key a",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(conf.MustNewConfigFrom(test.config))
+			assert.ErrorContains(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_kv
+
+import (
+	"strings"
+	"unicode"
+)
+
+// parser splits strings into key-value pairs.
+type parser struct {
+	fieldSplit     string
+	valueSplit     string
+	quoteChars     string
+	trimWhitespace bool
+	trimKey        string
+	trimValue      string
+}
+
+type pair struct {
+	key, value string
+}
+
+// parse returns the key-value pairs of s in order. Tokens without a value
+// separator and pairs with an empty key are skipped.
+func (p *parser) parse(s string) []pair {
+	var pairs []pair
+	for len(s) > 0 {
+		if strings.HasPrefix(s, p.fieldSplit) {
+			s = s[len(p.fieldSplit):]
+			continue
+		}
+		if p.trimWhitespace {
+			if trimmed := strings.TrimLeftFunc(s, unicode.IsSpace); len(trimmed) != len(s) {
+				s = trimmed
+				continue
+			}
+		}
+
+		key, quoted, rest, stop := p.token(s, p.valueSplit)
+		if stop != p.valueSplit {
+			// No value, skip the token.
+			s = rest
+			continue
+		}
+		key = p.trim(key, quoted, p.trimKey)
+
+		rest = rest[len(p.valueSplit):]
+		if p.trimWhitespace {
+			rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		}
+		value, quoted, rest, _ := p.token(rest, "")
+		value = p.trim(value, quoted, p.trimValue)
+
+		s = rest
+		if key != "" {
+			pairs = append(pairs, pair{key: key, value: value})
+		}
+	}
+	return pairs
+}
+
+// token reads a quoted or unquoted token from s, up to the next stop
+// string or field separator. It returns the token, whether it is quoted, the
+// remaining string starting at the separator that ended the token, and that
+// separator.
+func (p *parser) token(s, stop string) (token string, quoted bool, rest, sep string) {
+	if s != "" && strings.IndexByte(p.quoteChars, s[0]) >= 0 {
+		token, rest = unquote(s)
+		quoted = true
+		// Ignore anything between the closing quote and the next separator.
+		s = rest
+	}
+
+	end, sep := len(s), ""
+	if i := strings.Index(s, p.fieldSplit); i >= 0 {
+		end, sep = i, p.fieldSplit
+	}
+	if stop != "" {
+		if i := strings.Index(s[:end], stop); i >= 0 {
+			end, sep = i, stop
+		}
+	}
+	if !quoted {
+		token = s[:end]
+	}
+	return token, quoted, s[end:], sep
+}
+
+// unquote returns the content of the quoted string at the start of s, and
+// the string after the closing quote. A backslash escapes the quote
+// character or a backslash. An unterminated quoted string extends to the end
+// of s.
+func unquote(s string) (string, string) {
+	q := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == q || s[i+1] == '\\'):
+			b.WriteByte(s[i+1])
+			i++
+		case c == q:
+			return b.String(), s[i+1:]
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), ""
+}
+
+func (p *parser) trim(s string, quoted bool, cutset string) string {
+	if p.trimWhitespace && !quoted {
+		s = strings.TrimSpace(s)
+	}
+	if cutset != "" {
+		s = strings.Trim(s, cutset)
+	}
+	return s
+}
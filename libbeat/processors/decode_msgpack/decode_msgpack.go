@@ -0,0 +1,60 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_msgpack
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ugorji/go/codec"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	"github.com/elastic/beats/v7/libbeat/processors/internal/payload"
+	jsprocessor "github.com/elastic/beats/v7/libbeat/processors/script/javascript/module/processor"
+	conf "github.com/elastic/elastic-agent-libs/config"
+)
+
+const procName = "decode_msgpack"
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+	jsprocessor.RegisterPlugin("DecodeMsgpack", New)
+}
+
+// New constructs a new decode_msgpack processor.
+func New(cfg *conf.C) (beat.Processor, error) {
+	c := payload.DefaultConfig()
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, fmt.Errorf("fail to unpack the %v processor configuration: %w", procName, err)
+	}
+
+	return payload.New(procName, c, decode), nil
+}
+
+// handle is safe for concurrent use once configured. With WriteExt, strings
+// are decoded as strings and binary values as bytes.
+var handle = &codec.MsgpackHandle{WriteExt: true}
+
+func decode(data []byte) (interface{}, error) {
+	var v interface{}
+	if err := codec.NewDecoder(bytes.NewReader(data), handle).Decode(&v); err != nil {
+		return nil, err
+	}
+	return payload.Normalize(v), nil
+}
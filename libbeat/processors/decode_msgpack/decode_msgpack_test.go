@@ -0,0 +1,71 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_msgpack
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func encode(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, codec.NewEncoder(&buf, &codec.MsgpackHandle{WriteExt: true}).Encode(v))
+	return buf.Bytes()
+}
+
+func TestDecodeMsgpack(t *testing.T) {
+	data := encode(t, map[string]interface{}{
+		"device": "sensor-1",
+		"temp":   21.5,
+		"count":  3,
+		"tags":   []string{"a", "b"},
+		"raw":    []byte{0xff},
+		"nested": map[int]string{1: "one"},
+	})
+
+	p, err := New(conf.MustNewConfigFrom(map[string]interface{}{"target_field": "payload"}))
+	require.NoError(t, err)
+
+	event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": base64.StdEncoding.EncodeToString(data)}})
+	require.NoError(t, err)
+	decoded, err := event.GetValue("payload")
+	require.NoError(t, err)
+	assert.Equal(t, mapstr.M{
+		"device": "sensor-1",
+		"temp":   21.5,
+		"count":  int64(3),
+		"tags":   []interface{}{"a", "b"},
+		"raw":    "/w==",
+		"nested": mapstr.M{"1": "one"},
+	}, decoded)
+
+	// Raw bytes are decoded as is.
+	event, err = p.Run(&beat.Event{Fields: mapstr.M{"message": data[:len(data)/2]}})
+	assert.Error(t, err)
+	assert.Contains(t, event.Fields, "error")
+}
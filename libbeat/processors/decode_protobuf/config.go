@@ -0,0 +1,53 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_protobuf
+
+import (
+	"errors"
+
+	"github.com/elastic/beats/v7/libbeat/processors/internal/payload"
+)
+
+type config struct {
+	payload.Config `config:",inline"`
+
+	// ProtoFile is the path of the .proto file defining the message type.
+	ProtoFile string `config:"proto_file"`
+	// ImportPaths are the directories imports of the .proto file are
+	// searched in, in addition to the directory of the file.
+	ImportPaths []string `config:"import_paths"`
+	// DescriptorSet is the path of a file holding a serialized
+	// FileDescriptorSet, as written by protoc --descriptor_set_out.
+	DescriptorSet string `config:"descriptor_set"`
+	// MessageType is the fully qualified name of the message type.
+	MessageType string `config:"message_type" validate:"required"`
+}
+
+func defaultConfig() config {
+	return config{Config: payload.DefaultConfig()}
+}
+
+func (c *config) Validate() error {
+	if (c.ProtoFile == "") == (c.DescriptorSet == "") {
+		return errors.New("exactly one of proto_file or descriptor_set must be set")
+	}
+	if len(c.ImportPaths) > 0 && c.ProtoFile == "" {
+		return errors.New("import_paths can only be used with proto_file")
+	}
+	return c.Config.Validate()
+}
@@ -0,0 +1,120 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_protobuf
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	"github.com/elastic/beats/v7/libbeat/processors/internal/payload"
+	jsprocessor "github.com/elastic/beats/v7/libbeat/processors/script/javascript/module/processor"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const procName = "decode_protobuf"
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+	jsprocessor.RegisterPlugin("DecodeProtobuf", New)
+}
+
+// New constructs a new decode_protobuf processor.
+func New(cfg *conf.C) (beat.Processor, error) {
+	c := defaultConfig()
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, fmt.Errorf("fail to unpack the %v processor configuration: %w", procName, err)
+	}
+
+	cfgwarn.Beta("The " + procName + " processor is beta.")
+
+	md, err := loadMessageDescriptor(c)
+	if err != nil {
+		return nil, err
+	}
+	return payload.New(procName, c.Config, func(data []byte) (interface{}, error) {
+		msg := dynamicpb.NewMessage(md)
+		if err := proto.Unmarshal(data, msg); err != nil {
+			return nil, err
+		}
+		return messageFields(msg), nil
+	}), nil
+}
+
+// messageFields returns the populated fields of a message, keyed by their
+// names in the .proto file.
+func messageFields(m protoreflect.Message) mapstr.M {
+	fields := mapstr.M{}
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		fields[string(fd.Name())] = fieldValue(fd, v)
+		return true
+	})
+	return fields
+}
+
+func fieldValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch {
+	case fd.IsList():
+		list := v.List()
+		values := make([]interface{}, list.Len())
+		for i := range values {
+			values[i] = singularValue(fd, list.Get(i))
+		}
+		return values
+	case fd.IsMap():
+		values := mapstr.M{}
+		v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			values[k.String()] = singularValue(fd.MapValue(), v)
+			return true
+		})
+		return values
+	default:
+		return singularValue(fd, v)
+	}
+}
+
+func singularValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		m := v.Message()
+		if m.Descriptor().FullName() == "google.protobuf.Timestamp" {
+			fields := m.Descriptor().Fields()
+			seconds := m.Get(fields.ByName("seconds")).Int()
+			nanos := m.Get(fields.ByName("nanos")).Int()
+			return time.Unix(seconds, nanos).UTC()
+		}
+		return messageFields(m)
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return int32(v.Enum())
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString(v.Bytes())
+	default:
+		return v.Interface()
+	}
+}
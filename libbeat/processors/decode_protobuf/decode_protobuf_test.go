@@ -0,0 +1,197 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_protobuf
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const readingProto = `
+syntax = "proto3";
+
+package telemetry.v1;
+
+import "google/protobuf/timestamp.proto";
+import "common.proto";
+
+message Reading {
+  enum Unit {
+    UNIT_UNSPECIFIED = 0;
+    CELSIUS = 1;
+  }
+  string device_id = 1;
+  double value = 2;
+  Unit unit = 3;
+  repeated string tags = 4;
+  map<string, int64> counters = 5;
+  bytes raw = 6;
+  google.protobuf.Timestamp time = 7;
+  common.Location location = 8;
+  uint32 unset = 9;
+}
+`
+
+const commonProto = `
+syntax = "proto3";
+
+package common;
+
+message Location {
+  float lat = 1;
+  float lon = 2;
+}
+`
+
+func writeProtos(t *testing.T) (dir string) {
+	t.Helper()
+	dir = t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "reading.proto"), []byte(readingProto), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "include"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "include", "common.proto"), []byte(commonProto), 0o644))
+	return dir
+}
+
+// encodeReading returns a serialized telemetry.v1.Reading.
+func encodeReading(t *testing.T, md protoreflect.MessageDescriptor) []byte {
+	t.Helper()
+	msg := dynamicpb.NewMessage(md)
+	fields := md.Fields()
+	msg.Set(fields.ByName("device_id"), protoreflect.ValueOfString("sensor-1"))
+	msg.Set(fields.ByName("value"), protoreflect.ValueOfFloat64(21.5))
+	msg.Set(fields.ByName("unit"), protoreflect.ValueOfEnum(1))
+	tags := msg.Mutable(fields.ByName("tags")).List()
+	tags.Append(protoreflect.ValueOfString("a"))
+	tags.Append(protoreflect.ValueOfString("b"))
+	counters := msg.Mutable(fields.ByName("counters")).Map()
+	counters.Set(protoreflect.ValueOfString("errors").MapKey(), protoreflect.ValueOfInt64(2))
+	msg.Set(fields.ByName("raw"), protoreflect.ValueOfBytes([]byte{0xff}))
+	ts := msg.Mutable(fields.ByName("time")).Message()
+	ts.Set(ts.Descriptor().Fields().ByName("seconds"), protoreflect.ValueOfInt64(1704164645))
+	loc := msg.Mutable(fields.ByName("location")).Message()
+	loc.Set(loc.Descriptor().Fields().ByName("lat"), protoreflect.ValueOfFloat32(1.5))
+
+	data, err := proto.Marshal(msg)
+	require.NoError(t, err)
+	return data
+}
+
+var wantReading = mapstr.M{
+	"device_id": "sensor-1",
+	"value":     21.5,
+	"unit":      "CELSIUS",
+	"tags":      []interface{}{"a", "b"},
+	"counters":  mapstr.M{"errors": int64(2)},
+	"raw":       "/w==",
+	"time":      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	"location":  mapstr.M{"lat": float32(1.5)},
+}
+
+func TestDecodeProtobuf(t *testing.T) {
+	logp.TestingSetup()
+
+	dir := writeProtos(t)
+	c := defaultConfig()
+	c.ProtoFile = filepath.Join(dir, "reading.proto")
+	c.ImportPaths = []string{filepath.Join(dir, "include")}
+	c.MessageType = "telemetry.v1.Reading"
+	md, err := loadMessageDescriptor(c)
+	require.NoError(t, err)
+	data := encodeReading(t, md)
+
+	// Write a descriptor set including the imported files.
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(timestamppb.File_google_protobuf_timestamp_proto),
+	}}
+	for i := 0; i < md.ParentFile().Imports().Len(); i++ {
+		if imp := md.ParentFile().Imports().Get(i); imp.Path() == "common.proto" {
+			set.File = append(set.File, protodesc.ToFileDescriptorProto(imp.FileDescriptor))
+		}
+	}
+	set.File = append(set.File, protodesc.ToFileDescriptorProto(md.ParentFile()))
+	setData, err := proto.Marshal(set)
+	require.NoError(t, err)
+	setPath := filepath.Join(dir, "reading.desc")
+	require.NoError(t, os.WriteFile(setPath, setData, 0o644))
+
+	for name, cfg := range map[string]map[string]interface{}{
+		"proto file": {
+			"proto_file":   c.ProtoFile,
+			"import_paths": c.ImportPaths,
+			"message_type": "telemetry.v1.Reading",
+			"target_field": "reading",
+		},
+		"descriptor set": {
+			"descriptor_set": setPath,
+			"message_type":   "telemetry.v1.Reading",
+			"target_field":   "reading",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p, err := New(conf.MustNewConfigFrom(cfg))
+			require.NoError(t, err)
+
+			event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": base64.StdEncoding.EncodeToString(data)}})
+			require.NoError(t, err)
+			reading, err := event.GetValue("reading")
+			require.NoError(t, err)
+			assert.Equal(t, wantReading, reading)
+
+			event, err = p.Run(&beat.Event{Fields: mapstr.M{"message": "AAAA"}})
+			assert.Error(t, err)
+			assert.Contains(t, event.Fields, "error")
+		})
+	}
+}
+
+func TestConfig(t *testing.T) {
+	dir := writeProtos(t)
+	for name, cfg := range map[string]map[string]interface{}{
+		"no message type":   {"proto_file": filepath.Join(dir, "reading.proto")},
+		"no definitions":    {"message_type": "telemetry.v1.Reading"},
+		"both definitions":  {"proto_file": filepath.Join(dir, "reading.proto"), "descriptor_set": "reading.desc", "message_type": "telemetry.v1.Reading"},
+		"missing import":    {"proto_file": filepath.Join(dir, "reading.proto"), "message_type": "telemetry.v1.Reading"},
+		"unknown type":      {"proto_file": filepath.Join(dir, "reading.proto"), "import_paths": []string{filepath.Join(dir, "include")}, "message_type": "telemetry.v1.Other"},
+		"not a message":     {"proto_file": filepath.Join(dir, "reading.proto"), "import_paths": []string{filepath.Join(dir, "include")}, "message_type": "telemetry.v1.Reading.Unit"},
+		"missing set":       {"descriptor_set": filepath.Join(dir, "missing.desc"), "message_type": "telemetry.v1.Reading"},
+		"unknown encoding":  {"proto_file": filepath.Join(dir, "reading.proto"), "message_type": "telemetry.v1.Reading", "encoding": "base32"},
+		"misplaced imports": {"descriptor_set": "reading.desc", "import_paths": []string{"include"}, "message_type": "telemetry.v1.Reading"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(conf.MustNewConfigFrom(cfg))
+			assert.Error(t, err)
+		})
+	}
+}
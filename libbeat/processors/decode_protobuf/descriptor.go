@@ -0,0 +1,94 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package decode_protobuf
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// loadMessageDescriptor returns the descriptor of the configured message
+// type, from the .proto file or the descriptor set.
+func loadMessageDescriptor(c config) (protoreflect.MessageDescriptor, error) {
+	var (
+		resolver protodesc.Resolver
+		path     string
+		err      error
+	)
+	if c.ProtoFile != "" {
+		path = c.ProtoFile
+		resolver, err = compileProtoFile(c.ProtoFile, c.ImportPaths)
+	} else {
+		path = c.DescriptorSet
+		resolver, err = readDescriptorSet(c.DescriptorSet)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+
+	d, err := resolver.FindDescriptorByName(protoreflect.FullName(c.MessageType))
+	if err != nil {
+		return nil, fmt.Errorf("message type %s not found in %s: %w", c.MessageType, path, err)
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s in %s is not a message type", c.MessageType, path)
+	}
+	return md, nil
+}
+
+// compileProtoFile compiles a .proto file and its imports. The well-known
+// types of google/protobuf can always be imported.
+func compileProtoFile(path string, importPaths []string) (protodesc.Resolver, error) {
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			ImportPaths: append([]string{filepath.Dir(path)}, importPaths...),
+		}),
+	}
+	files, err := compiler.Compile(context.Background(), filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+	return files.AsResolver(), nil
+}
+
+// readDescriptorSet reads a serialized FileDescriptorSet. The set must
+// include the imported files.
+func readDescriptorSet(path string) (protodesc.Resolver, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
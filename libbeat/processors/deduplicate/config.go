@@ -0,0 +1,84 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deduplicate
+
+import (
+	"errors"
+	"time"
+)
+
+type config struct {
+	// KeyField is the field holding the key of events. If not set, the
+	// key is the fingerprint of Fields.
+	KeyField string `config:"key_field"`
+	// Fields are the fields the fingerprint is computed from. The method
+	// and encoding settings of the fingerprint processor are also used.
+	Fields []string `config:"fields"`
+	// TargetField is the field the key of unique events is written to.
+	TargetField string `config:"target_field"`
+	// TTL is the time during which events with the same key are dropped.
+	TTL time.Duration `config:"ttl" validate:"nonzero,positive"`
+	// IgnoreMissing passes events without a key_field unchanged, and
+	// ignores missing fields when computing the fingerprint.
+	IgnoreMissing bool `config:"ignore_missing"`
+
+	Store storeConfig `config:"backend"`
+}
+
+type storeConfig struct {
+	Memory *memConfig  `config:"memory"`
+	File   *fileConfig `config:"file"`
+
+	// Capacity is the number of keys that may be stored.
+	Capacity int `config:"capacity"`
+}
+
+type memConfig struct {
+	ID string `config:"id" validate:"required"`
+}
+
+type fileConfig struct {
+	ID            string        `config:"id" validate:"required"`
+	WriteOutEvery time.Duration `config:"write_interval"`
+}
+
+func defaultConfig() config {
+	return config{
+		TTL: 10 * time.Minute,
+		Store: storeConfig{
+			Capacity: 100000,
+		},
+	}
+}
+
+func (cfg *config) Validate() error {
+	switch {
+	case cfg.KeyField != "" && len(cfg.Fields) != 0:
+		return errors.New("must specify only one of key_field or fields")
+	case cfg.KeyField == "" && len(cfg.Fields) == 0:
+		return errors.New("must specify one of key_field or fields")
+	}
+	return nil
+}
+
+func (cfg *storeConfig) Validate() error {
+	if cfg.Memory != nil && cfg.File != nil {
+		return errors.New("must specify only one of backend.memory.id or backend.file.id")
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deduplicate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/atomic"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	"github.com/elastic/beats/v7/libbeat/processors/cache"
+	"github.com/elastic/beats/v7/libbeat/processors/fingerprint"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+const (
+	procName = "deduplicate"
+	logName  = "processor." + procName
+)
+
+// instanceID is used to assign each instance a unique monitoring namespace.
+var instanceID atomic.Uint32
+
+func init() {
+	// We cannot use this as a JS plugin as it is stateful and includes a Close method.
+	processors.RegisterPlugin(procName, New)
+}
+
+type metrics struct {
+	dropped *monitoring.Uint
+}
+
+// deduplicate is a processor that drops events with a key that has been
+// seen recently.
+type deduplicate struct {
+	config config
+	key    func(*beat.Event) (string, error)
+	store  cache.Store
+	cancel context.CancelFunc
+
+	log     *logp.Logger
+	metrics metrics
+}
+
+// New constructs a new deduplicate processor. The resulting processor
+// implements Close() to release the store.
+func New(cfg *conf.C) (beat.Processor, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("failed to unpack the %s configuration: %w", procName, err)
+	}
+
+	// Logging and metrics (each processor instance has a unique ID).
+	var (
+		id  = int(instanceID.Inc())
+		log = logp.NewLogger(logName).With("instance_id", id)
+		reg = monitoring.Default.NewRegistry(logName+"."+strconv.Itoa(id), monitoring.DoNotReport)
+	)
+
+	p := &deduplicate{
+		config: config,
+		log:    log,
+		metrics: metrics{
+			dropped: monitoring.NewUint(reg, "dropped"),
+		},
+	}
+
+	if config.KeyField != "" {
+		p.key = p.keyFromField
+	} else {
+		var err error
+		p.key, err = fingerprint.NewFingerprinter(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure the %s fingerprint: %w", procName, err)
+		}
+	}
+
+	opts := cache.StoreOptions{
+		// Without a configured backend, every processor has its own store.
+		ID:       procName + "_" + strconv.Itoa(id),
+		TTL:      config.TTL,
+		Capacity: config.Store.Capacity,
+	}
+	switch {
+	case config.Store.Memory != nil:
+		opts.ID = procName + "_" + config.Store.Memory.ID
+	case config.Store.File != nil:
+		opts.ID = procName + "_" + config.Store.File.ID
+		opts.File = true
+		opts.WriteInterval = config.Store.File.WriteOutEvery
+	}
+	var err error
+	p.store, p.cancel, err = cache.OpenStore(opts, log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the store for %s: %w", procName, err)
+	}
+
+	p.log.Infow("initialized deduplicate processor", "details", p)
+	return p, nil
+}
+
+// Run drops the event if an event with the same key has been seen within the
+// TTL. Otherwise the key is stored, and the event is returned.
+func (p *deduplicate) Run(event *beat.Event) (*beat.Event, error) {
+	key, err := p.key(event)
+	if err != nil {
+		if p.config.IgnoreMissing && errors.Is(err, mapstr.ErrKeyNotFound) {
+			return event, nil
+		}
+		return event, fmt.Errorf("error applying %s processor: %w", procName, err)
+	}
+
+	// Duplicates don't renew the key, so that events are dropped for the TTL
+	// after the first event with the key. The key is checked and stored
+	// atomically, as the store may be shared by processors running
+	// concurrently.
+	stored, err := p.store.PutIfAbsent(key, true)
+	if err != nil {
+		return event, fmt.Errorf("error applying %s processor: %w", procName, err)
+	}
+	if !stored {
+		p.log.Debugw("dropped duplicate event", "key", key)
+		p.metrics.dropped.Inc()
+		return nil, nil
+	}
+
+	if p.config.TargetField != "" {
+		if _, err := event.PutValue(p.config.TargetField, key); err != nil {
+			return event, fmt.Errorf("failed to write %s key to target field '%s': %w", procName, p.config.TargetField, err)
+		}
+	}
+	return event, nil
+}
+
+// keyFromField returns the value of the key_field of the event.
+func (p *deduplicate) keyFromField(event *beat.Event) (string, error) {
+	v, err := event.GetValue(p.config.KeyField)
+	if err != nil {
+		return "", err
+	}
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case []interface{}, map[string]interface{}, mapstr.M:
+		return "", fmt.Errorf("key field '%s' is not a scalar: %T", p.config.KeyField, v)
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+func (p *deduplicate) Close() error {
+	p.cancel()
+	return nil
+}
+
+// String returns the processor representation formatted as a string
+func (p *deduplicate) String() string {
+	return fmt.Sprintf("%s=[store_id=%s, key_field=%s, fields=%v, target_field=%s, ttl=%v, ignore_missing=%t]",
+		procName, p.store, p.config.KeyField, p.config.Fields, p.config.TargetField, p.config.TTL, p.config.IgnoreMissing)
+}
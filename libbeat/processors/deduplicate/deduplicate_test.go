@@ -0,0 +1,243 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deduplicate
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/paths"
+)
+
+// run runs the events through p and returns the ids of the events that
+// were not dropped.
+func run(t *testing.T, p beat.Processor, events ...mapstr.M) []interface{} {
+	t.Helper()
+	var ids []interface{}
+	for _, fields := range events {
+		event, err := p.Run(&beat.Event{Fields: fields.Clone()})
+		require.NoError(t, err)
+		if event != nil {
+			id, _ := event.GetValue("id")
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+var testEvents = []mapstr.M{
+	{"id": 1, "message": "a", "host": "x", "event": mapstr.M{"id": "e1"}},
+	{"id": 2, "message": "b", "host": "x", "event": mapstr.M{"id": "e2"}},
+	{"id": 3, "message": "a", "host": "x", "event": mapstr.M{"id": "e1"}},
+	{"id": 4, "message": "a", "host": "y", "event": mapstr.M{"id": "e3"}},
+	{"id": 5, "message": "b", "host": "x", "event": mapstr.M{"id": "e2"}},
+}
+
+func TestDeduplicate(t *testing.T) {
+	logp.TestingSetup()
+
+	tests := map[string]struct {
+		config  mapstr.M
+		input   []mapstr.M
+		want    []interface{}
+		wantErr error
+	}{
+		"key_field": {
+			config: mapstr.M{"key_field": "event.id"},
+			input:  testEvents,
+			want:   []interface{}{1, 2, 4},
+		},
+		"fields": {
+			config: mapstr.M{"fields": []string{"message", "host"}, "method": "xxhash"},
+			input:  testEvents,
+			want:   []interface{}{1, 2, 4},
+		},
+		"capacity": {
+			config: mapstr.M{"key_field": "event.id", "backend": mapstr.M{"capacity": 2}},
+			// e1 was stored first, so it is evicted when e3 is stored, and
+			// e2 is evicted when e1 is stored again.
+			input: []mapstr.M{testEvents[0], testEvents[1], testEvents[2], testEvents[3], testEvents[4], testEvents[0], testEvents[4]},
+			want:  []interface{}{1, 2, 4, 1, 5},
+		},
+		"missing key": {
+			config:  mapstr.M{"key_field": "event.id"},
+			input:   []mapstr.M{{"id": 1, "message": "a"}},
+			wantErr: mapstr.ErrKeyNotFound,
+		},
+		"ignore missing key": {
+			config: mapstr.M{"key_field": "event.id", "ignore_missing": true},
+			input:  []mapstr.M{{"id": 1, "message": "a"}, {"id": 2, "message": "a"}},
+			want:   []interface{}{1, 2},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := New(conf.MustNewConfigFrom(test.config))
+			require.NoError(t, err)
+			defer processors.Close(p)
+
+			var ids []interface{}
+			for _, fields := range test.input {
+				event, err := p.Run(&beat.Event{Fields: fields.Clone()})
+				if test.wantErr != nil {
+					assert.ErrorIs(t, err, test.wantErr)
+					continue
+				}
+				require.NoError(t, err)
+				if event != nil {
+					id, _ := event.GetValue("id")
+					ids = append(ids, id)
+				}
+			}
+			assert.Equal(t, test.want, ids)
+		})
+	}
+}
+
+func TestDeduplicateTargetField(t *testing.T) {
+	logp.TestingSetup()
+
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{
+		"fields":       []string{"message", "host"},
+		"method":       "xxhash",
+		"target_field": "event.hash",
+	}))
+	require.NoError(t, err)
+	defer processors.Close(p)
+
+	event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "c", "host": "x"}})
+	require.NoError(t, err)
+	hash, err := event.GetValue("event.hash")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, hash)
+}
+
+func TestDeduplicateTTL(t *testing.T) {
+	logp.TestingSetup()
+
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{"key_field": "event.id", "ttl": "200ms"}))
+	require.NoError(t, err)
+	defer processors.Close(p)
+
+	assert.Equal(t, []interface{}{1}, run(t, p, testEvents[0], testEvents[2]))
+	time.Sleep(120 * time.Millisecond)
+	// Duplicates don't renew the TTL of the key.
+	assert.Empty(t, run(t, p, testEvents[2]))
+	time.Sleep(120 * time.Millisecond)
+	assert.Equal(t, []interface{}{3}, run(t, p, testEvents[2]))
+}
+
+func TestDeduplicateSharedStore(t *testing.T) {
+	logp.TestingSetup()
+
+	cfg := mapstr.M{
+		"key_field": "event.id",
+		"backend":   mapstr.M{"memory.id": "shared"},
+	}
+	p1, err := New(conf.MustNewConfigFrom(cfg))
+	require.NoError(t, err)
+	defer processors.Close(p1)
+	p2, err := New(conf.MustNewConfigFrom(cfg))
+	require.NoError(t, err)
+	defer processors.Close(p2)
+
+	assert.Equal(t, []interface{}{1}, run(t, p1, testEvents[0]))
+	assert.Empty(t, run(t, p2, testEvents[2]))
+}
+
+func TestDeduplicateConcurrent(t *testing.T) {
+	logp.TestingSetup()
+
+	cfg := mapstr.M{
+		"key_field": "event.id",
+		"backend":   mapstr.M{"memory.id": "concurrent"},
+	}
+	var passed atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		p, err := New(conf.MustNewConfigFrom(cfg))
+		require.NoError(t, err)
+		defer processors.Close(p)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				event, err := p.Run(&beat.Event{Fields: mapstr.M{"event": mapstr.M{"id": j}}})
+				if err == nil && event != nil {
+					passed.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	// Each key passes exactly once across the processors sharing the store.
+	assert.Equal(t, int64(100), passed.Load())
+}
+
+func TestDeduplicateFileStore(t *testing.T) {
+	logp.TestingSetup()
+
+	defer func(p *paths.Path) { paths.Paths = p }(paths.Paths)
+	dir := t.TempDir()
+	paths.Paths = &paths.Path{Home: dir, Config: dir, Data: dir, Logs: dir}
+
+	cfg := mapstr.M{
+		"key_field": "event.id",
+		"backend":   mapstr.M{"file.id": "restart"},
+	}
+
+	p, err := New(conf.MustNewConfigFrom(cfg))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{1}, run(t, p, mapstr.M{"id": 1, "event": mapstr.M{"id": "e1"}}))
+	require.NoError(t, processors.Close(p))
+
+	// The keys survive a restart.
+	p, err = New(conf.MustNewConfigFrom(cfg))
+	require.NoError(t, err)
+	defer processors.Close(p)
+	assert.Equal(t, []interface{}{3}, run(t, p,
+		mapstr.M{"id": 2, "event": mapstr.M{"id": "e1"}},
+		mapstr.M{"id": 3, "event": mapstr.M{"id": "e2"}},
+	))
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := map[string]mapstr.M{
+		"no key":                 {},
+		"key_field and fields":   {"key_field": "a", "fields": []string{"b"}},
+		"zero ttl":               {"key_field": "a", "ttl": "0s"},
+		"memory and file stores": {"key_field": "a", "backend": mapstr.M{"memory.id": "a", "file.id": "b"}},
+	}
+	for name, cfg := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(conf.MustNewConfigFrom(cfg))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -67,16 +67,26 @@ func New(cfg *config.C) (beat.Processor, error) {
 	return p, nil
 }
 
+// NewFingerprinter returns a function that computes the fingerprint of an
+// event as configured by cfg, without writing it to the event. The
+// target_field setting is not used. The function returns an error if a
+// field holds an object or an array, or is missing and ignore_missing is not
+// set.
+func NewFingerprinter(cfg *config.C) (func(*beat.Event) (string, error), error) {
+	p, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return p.(*fingerprint).compute, nil
+}
+
 // Run enriches the given event with a fingerprint.
 func (p *fingerprint) Run(event *beat.Event) (*beat.Event, error) {
-	hashFn := p.hash()
-
-	if err := p.writeFields(hashFn, event); err != nil {
-		return nil, makeErrComputeFingerprint(err)
+	encodedHash, err := p.compute(event)
+	if err != nil {
+		return nil, err
 	}
 
-	encodedHash := p.config.Encoding.Encode(hashFn.Sum(nil))
-
 	if _, err := event.PutValue(p.config.TargetField, encodedHash); err != nil {
 		return nil, makeErrComputeFingerprint(err)
 	}
@@ -84,6 +94,17 @@ func (p *fingerprint) Run(event *beat.Event) (*beat.Event, error) {
 	return event, nil
 }
 
+// compute returns the encoded fingerprint of the event.
+func (p *fingerprint) compute(event *beat.Event) (string, error) {
+	hashFn := p.hash()
+
+	if err := p.writeFields(hashFn, event); err != nil {
+		return "", makeErrComputeFingerprint(err)
+	}
+
+	return p.config.Encoding.Encode(hashFn.Sum(nil)), nil
+}
+
 func (p *fingerprint) String() string {
 	json, _ := json.Marshal(&p.config)
 	return procName + "=" + string(json)
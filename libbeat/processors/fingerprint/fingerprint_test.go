@@ -155,6 +155,18 @@ func TestHashMethods(t *testing.T) {
 	}
 }
 
+func TestNewHashEncoder(t *testing.T) {
+	hash, err := NewHashEncoder("sha256", "hex")
+	require.NoError(t, err)
+	assert.Equal(t, "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", hash([]byte("foo")))
+	assert.Equal(t, hash([]byte("foo")), hash([]byte("f"), []byte("oo")))
+
+	_, err = NewHashEncoder("sha3", "hex")
+	assert.ErrorContains(t, err, "invalid fingerprinting method")
+	_, err = NewHashEncoder("sha256", "base16")
+	assert.Error(t, err)
+}
+
 func TestSourceFields(t *testing.T) {
 	testFields := mapstr.M{
 		"field1": "foo",
@@ -62,6 +62,28 @@ func (f *namedHashMethod) Unpack(str string) error {
 	return nil
 }
 
+// NewHashEncoder returns a function that hashes the concatenation of data
+// with the named hash method, and returns the hash in the named encoding.
+// It fails if the method or the encoding is unknown.
+func NewHashEncoder(method, encoding string) (func(data ...[]byte) string, error) {
+	var m namedHashMethod
+	if err := m.Unpack(method); err != nil {
+		return nil, err
+	}
+	var e namedEncodingMethod
+	if err := e.Unpack(encoding); err != nil {
+		return nil, err
+	}
+
+	return func(data ...[]byte) string {
+		h := m.Hash()
+		for _, d := range data {
+			h.Write(d)
+		}
+		return e.Encode(h.Sum(nil))
+	}, nil
+}
+
 // newXxHash returns a hash.Hash instead of the *Digest which implements the same
 func newXxHash() hash.Hash {
 	return xxhash.New()
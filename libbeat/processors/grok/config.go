@@ -0,0 +1,79 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grok
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+type config struct {
+	Field              string            `config:"field"`
+	Patterns           []string          `config:"patterns" validate:"required"`
+	PatternDefinitions map[string]string `config:"pattern_definitions"`
+	PatternFiles       []string          `config:"pattern_files"`
+	TargetPrefix       string            `config:"target_prefix"`
+	TraceMatch         bool              `config:"trace_match"`
+	IgnoreMissing      bool              `config:"ignore_missing"`
+	IgnoreFailure      bool              `config:"ignore_failure"`
+	OverwriteKeys      bool              `config:"overwrite_keys"`
+	ID                 string            `config:"id"`
+}
+
+func defaultConfig() config {
+	return config{
+		Field: "message",
+	}
+}
+
+func (c *config) Validate() error {
+	if len(c.Patterns) == 0 {
+		return errors.New("at least one pattern is required")
+	}
+	for _, p := range c.PatternFiles {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern_files entry %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// definitions returns the pattern definitions from the pattern files and
+// pattern_definitions. Definitions in pattern_definitions take precedence.
+func (c *config) definitions() (map[string]string, error) {
+	defs := make(map[string]string)
+	for _, glob := range c.PatternFiles {
+		paths, err := filepath.Glob(glob)
+		if err != nil {
+			return nil, err
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no pattern files found for %s", glob)
+		}
+		for _, path := range paths {
+			if err := LoadPatternFile(path, defs); err != nil {
+				return nil, fmt.Errorf("failed to load pattern file: %w", err)
+			}
+		}
+	}
+	for name, pattern := range c.PatternDefinitions {
+		defs[name] = pattern
+	}
+	return defs, nil
+}
@@ -0,0 +1,179 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grok
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	gogrok "github.com/elastic/go-grok"
+)
+
+// reference matches %{SYNTAX}, %{SYNTAX:ID} and %{SYNTAX:ID:TYPE}.
+var reference = regexp.MustCompile(`%\{(\w+)(?::([^:}]+)(?::(\w+))?)?\}`)
+
+// namedGroup matches named groups in Oniguruma syntax, (?<name>) or
+// (?'name').
+var namedGroup = regexp.MustCompile(`\(\?(?:<([^>=!]+)>|'([^']+)')`)
+
+// Expression is a compiled grok pattern. Expressions are safe for
+// concurrent use.
+type Expression struct {
+	grok *gogrok.Grok
+	// fields maps the capture group names to field names. Capture
+	// names are rewritten to placeholders as field names can contain
+	// characters that are not valid in regular expression group names.
+	fields map[string]string
+	types  map[string]string
+}
+
+// Compile compiles a grok pattern. The pattern can reference the patterns of
+// the standard library and the patterns in definitions. Compiled expressions
+// are not cached: processors compile their patterns once, when they are
+// created, and keep the expressions for their lifetime.
+func Compile(pattern string, definitions map[string]string) (*Expression, error) {
+	names := &names{fields: map[string]string{}, ids: map[string]string{}, types: map[string]string{}}
+	defs := make(map[string]string, len(definitions))
+	for k, v := range definitions {
+		defs[k] = names.rewrite(v)
+	}
+	g, err := gogrok.NewComplete(defs)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.Compile(names.rewrite(pattern), true); err != nil {
+		return nil, err
+	}
+	for id, typ := range names.types {
+		switch typ {
+		case "string", "int", "long", "float", "double", "bool", "boolean":
+		default:
+			return nil, fmt.Errorf("unsupported type %q for field %q", typ, names.fields[id])
+		}
+	}
+	return &Expression{grok: g, fields: names.fields, types: names.types}, nil
+}
+
+// names allocates the placeholders of the capture names.
+type names struct {
+	fields map[string]string
+	ids    map[string]string
+	types  map[string]string
+}
+
+func (n *names) id(field string) string {
+	if id, found := n.ids[field]; found {
+		return id
+	}
+	id := "g" + strconv.Itoa(len(n.ids))
+	n.ids[field] = id
+	n.fields[id] = field
+	return id
+}
+
+func (n *names) rewrite(pattern string) string {
+	pattern = reference.ReplaceAllStringFunc(pattern, func(ref string) string {
+		m := reference.FindStringSubmatch(ref)
+		if m[2] == "" {
+			return ref
+		}
+		id := n.id(m[2])
+		if m[3] != "" {
+			n.types[id] = m[3]
+		}
+		return "%{" + m[1] + ":" + id + "}"
+	})
+	return namedGroup.ReplaceAllStringFunc(pattern, func(group string) string {
+		m := namedGroup.FindStringSubmatch(group)
+		return "(?P<" + n.id(m[1]+m[2]) + ">"
+	})
+}
+
+// Match returns the captures of the expression by field name, or false if
+// s does not match it. Typed captures are converted to int64, float64 or
+// bool.
+func (e *Expression) Match(s string) (map[string]interface{}, bool, error) {
+	captures, err := e.grok.ParseString(s)
+	if err != nil {
+		return nil, false, err
+	}
+	// Captures are empty if s does not match, or if there are no
+	// non-empty captures.
+	if len(captures) == 0 && !e.grok.MatchString(s) {
+		return nil, false, nil
+	}
+	out := make(map[string]interface{}, len(captures))
+	for id, v := range captures {
+		field, found := e.fields[id]
+		if !found {
+			continue
+		}
+		converted, err := convert(v, e.types[id])
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to convert field %q: %w", field, err)
+		}
+		out[field] = converted
+	}
+	return out, true, nil
+}
+
+func convert(v, typ string) (interface{}, error) {
+	switch typ {
+	case "int", "long":
+		return strconv.ParseInt(v, 10, 64)
+	case "float", "double":
+		return strconv.ParseFloat(v, 64)
+	case "bool", "boolean":
+		return strconv.ParseBool(v)
+	}
+	return v, nil
+}
+
+// LoadPatternFile reads pattern definitions from a file in the format used by
+// Logstash, with one NAME and pattern pair separated by whitespace per line.
+// Empty lines and lines starting with # are ignored.
+func LoadPatternFile(path string, definitions map[string]string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, pattern, ok := strings.Cut(line, " ")
+		if !ok {
+			name, pattern, ok = strings.Cut(line, "\t")
+		}
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return fmt.Errorf("%s:%d: expected a pattern name followed by a pattern", path, n)
+		}
+		definitions[name] = pattern
+	}
+	return sc.Err()
+}
@@ -0,0 +1,127 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grok
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpression(t *testing.T) {
+	tests := []struct {
+		name        string
+		pattern     string
+		definitions map[string]string
+		input       string
+		want        map[string]interface{}
+		wantMatch   bool
+	}{
+		{
+			name:      "standard patterns",
+			pattern:   `%{IP:client.ip} %{WORD:http.request.method} %{URIPATHPARAM:url.original} %{NUMBER:http.response.bytes:int} %{NUMBER:event.duration:float}`,
+			input:     "55.3.244.1 GET /index.html 15824 0.043",
+			wantMatch: true,
+			want: map[string]interface{}{
+				"client.ip":           "55.3.244.1",
+				"http.request.method": "GET",
+				"url.original":        "/index.html",
+				"http.response.bytes": int64(15824),
+				"event.duration":      0.043,
+			},
+		},
+		{
+			name:        "custom definitions",
+			pattern:     `%{SESSION:session.id} %{BOOL:session.active:boolean}`,
+			definitions: map[string]string{"SESSION": `[A-F0-9]{8}`, "BOOL": `true|false`},
+			input:       "DEADBEEF true",
+			wantMatch:   true,
+			want:        map[string]interface{}{"session.id": "DEADBEEF", "session.active": true},
+		},
+		{
+			name:      "oniguruma named group",
+			pattern:   `(?<user.name>\w+)@%{HOSTNAME:host.name}`,
+			input:     "alice@example.com",
+			wantMatch: true,
+			want:      map[string]interface{}{"user.name": "alice", "host.name": "example.com"},
+		},
+		{
+			name:      "field names that are not valid group names",
+			pattern:   `%{WORD:@metadata.kind} %{INT:[event][sequence]:long}`,
+			input:     "audit 42",
+			wantMatch: true,
+			want:      map[string]interface{}{"@metadata.kind": "audit", "[event][sequence]": int64(42)},
+		},
+		{
+			name:      "no named captures",
+			pattern:   `^%{WORD}$`,
+			input:     "word",
+			wantMatch: true,
+			want:      map[string]interface{}{},
+		},
+		{
+			name:    "no match",
+			pattern: `^%{INT:n}$`,
+			input:   "word",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := Compile(test.pattern, test.definitions)
+			require.NoError(t, err)
+			got, matched, err := e.Match(test.input)
+			require.NoError(t, err)
+			assert.Equal(t, test.wantMatch, matched)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	_, err := Compile(`%{UNKNOWN_PATTERN:x}`, nil)
+	assert.ErrorContains(t, err, `pattern definition "UNKNOWN_PATTERN" unknown`)
+
+	_, err = Compile(`%{INT:x:date}`, nil)
+	assert.ErrorContains(t, err, `unsupported type "date" for field "x"`)
+
+	_, err = Compile(`%{INT:x}(`, nil)
+	assert.Error(t, err)
+}
+
+func TestLoadPatternFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patterns")
+	require.NoError(t, os.WriteFile(path, []byte(`# Application patterns
+APP_ID [a-z]{3}-\d+
+
+APP_LINE %{APP_ID:app.id}	%{GREEDYDATA:message}
+`), 0o644))
+
+	defs := map[string]string{}
+	require.NoError(t, LoadPatternFile(path, defs))
+	assert.Equal(t, map[string]string{
+		"APP_ID":   `[a-z]{3}-\d+`,
+		"APP_LINE": "%{APP_ID:app.id}\t%{GREEDYDATA:message}",
+	}, defs)
+
+	require.NoError(t, os.WriteFile(path, []byte("APP_ID\n"), 0o644))
+	assert.ErrorContains(t, LoadPatternFile(path, defs), "patterns:1: expected a pattern name followed by a pattern")
+}
@@ -0,0 +1,157 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grok
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	jsprocessor "github.com/elastic/beats/v7/libbeat/processors/script/javascript/module/processor"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const (
+	procName = "grok"
+
+	flagParsingError = "grok_parsing_error"
+
+	// matchIndexField holds the index of the matching pattern when
+	// trace_match is enabled.
+	matchIndexField = "@metadata.grok_match_index"
+)
+
+var errNoMatch = errors.New("provided grok patterns do not match field value")
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+	jsprocessor.RegisterPlugin("Grok", New)
+}
+
+type processor struct {
+	config      config
+	expressions []*Expression
+}
+
+// New constructs a new grok processor.
+func New(cfg *conf.C) (beat.Processor, error) {
+	c := defaultConfig()
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, fmt.Errorf("fail to unpack the %v processor configuration: %w", procName, err)
+	}
+
+	return newGrok(c)
+}
+
+func newGrok(c config) (*processor, error) {
+	cfgwarn.Beta("The " + procName + " processor is beta.")
+
+	defs, err := c.definitions()
+	if err != nil {
+		return nil, err
+	}
+	expressions := make([]*Expression, len(c.Patterns))
+	for i, pattern := range c.Patterns {
+		expressions[i], err = Compile(pattern, defs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile grok pattern %q: %w", pattern, err)
+		}
+	}
+	return &processor{config: c, expressions: expressions}, nil
+}
+
+func (p *processor) String() string {
+	json, _ := json.Marshal(p.config)
+	return procName + "=" + string(json)
+}
+
+// Run matches the configured field against the patterns in order, and adds
+// the captures of the first matching pattern to the event.
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	err := p.run(event)
+	if err == nil {
+		return event, nil
+	}
+	if p.config.IgnoreMissing && errors.Is(err, mapstr.ErrKeyNotFound) {
+		return event, nil
+	}
+	if err := mapstr.AddTagsWithKey(event.Fields, beat.FlagField, []string{flagParsingError}); err != nil {
+		return event, fmt.Errorf("cannot add new flag the event: %w", err)
+	}
+	if p.config.IgnoreFailure {
+		return event, nil
+	}
+	return event, err
+}
+
+func (p *processor) run(event *beat.Event) error {
+	v, err := event.GetValue(p.config.Field)
+	if err != nil {
+		return fmt.Errorf("grok source field [%v] not found: %w", p.config.Field, err)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("grok source field [%v] is not a string: %T", p.config.Field, v)
+	}
+
+	for i, e := range p.expressions {
+		captures, matched, err := e.Match(s)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+		if err := p.put(event, captures); err != nil {
+			return err
+		}
+		if p.config.TraceMatch {
+			if _, err := event.PutValue(matchIndexField, i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return errNoMatch
+}
+
+// put adds the captures to the event.
+func (p *processor) put(event *beat.Event, captures map[string]interface{}) error {
+	prefix := ""
+	if p.config.TargetPrefix != "" {
+		prefix = p.config.TargetPrefix + "."
+	}
+	if !p.config.OverwriteKeys {
+		for k := range captures {
+			if _, err := event.GetValue(prefix + k); err == nil {
+				return fmt.Errorf("cannot override existing key with `%s`", prefix+k)
+			}
+		}
+	}
+
+	for k, v := range captures {
+		if _, err := event.PutValue(prefix+k, v); err != nil {
+			return fmt.Errorf("cannot add capture to `%s`: %w", prefix+k, err)
+		}
+	}
+	return nil
+}
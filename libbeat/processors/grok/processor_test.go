@@ -0,0 +1,207 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package grok
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/processors/dissect"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestProcessor(t *testing.T) {
+	patternDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(patternDir, "app"), []byte("APP_LEVEL (?:DEBUG|INFO|WARN|ERROR)\n"), 0o644))
+
+	tests := []struct {
+		name    string
+		config  mapstr.M
+		fields  mapstr.M
+		meta    mapstr.M
+		want    mapstr.M
+		wantErr string
+	}{
+		{
+			name: "first matching pattern",
+			config: mapstr.M{
+				"patterns": []string{
+					`^%{IP:source.ip} %{WORD:http.request.method} %{NUMBER:http.response.status_code:int}$`,
+					`^%{IP:source.ip} %{GREEDYDATA:error.message}$`,
+				},
+				"trace_match": true,
+			},
+			fields: mapstr.M{"message": "10.0.0.1 connection reset"},
+			meta:   mapstr.M{"grok_match_index": 1},
+			want: mapstr.M{
+				"message": "10.0.0.1 connection reset",
+				"source":  mapstr.M{"ip": "10.0.0.1"},
+				"error":   mapstr.M{"message": "connection reset"},
+			},
+		},
+		{
+			name: "typed captures with target prefix",
+			config: mapstr.M{
+				"patterns":      []string{`%{IP:source.ip} %{WORD:method} %{NUMBER:status:int}`},
+				"target_prefix": "parsed",
+			},
+			fields: mapstr.M{"message": "10.0.0.1 GET 200"},
+			want: mapstr.M{
+				"message": "10.0.0.1 GET 200",
+				"parsed":  mapstr.M{"source": mapstr.M{"ip": "10.0.0.1"}, "method": "GET", "status": int64(200)},
+			},
+		},
+		{
+			name: "pattern files and definitions",
+			config: mapstr.M{
+				"field":               "event.original",
+				"patterns":            []string{`%{APP_LEVEL:log.level} %{APP_COMPONENT:service.name}`},
+				"pattern_files":       []string{filepath.Join(patternDir, "*")},
+				"pattern_definitions": mapstr.M{"APP_COMPONENT": `[a-z]+`},
+			},
+			fields: mapstr.M{"event": mapstr.M{"original": "WARN billing"}},
+			want: mapstr.M{
+				"event":   mapstr.M{"original": "WARN billing"},
+				"log":     mapstr.M{"level": "WARN"},
+				"service": mapstr.M{"name": "billing"},
+			},
+		},
+		{
+			name:    "no match",
+			config:  mapstr.M{"patterns": []string{`^%{INT:n}$`}},
+			fields:  mapstr.M{"message": "text"},
+			want:    mapstr.M{"message": "text", "log": mapstr.M{"flags": []string{flagParsingError}}},
+			wantErr: errNoMatch.Error(),
+		},
+		{
+			name:   "ignore failure",
+			config: mapstr.M{"patterns": []string{`^%{INT:n}$`}, "ignore_failure": true},
+			fields: mapstr.M{"message": "text"},
+			want:   mapstr.M{"message": "text", "log": mapstr.M{"flags": []string{flagParsingError}}},
+		},
+		{
+			name:   "ignore missing",
+			config: mapstr.M{"patterns": []string{`^%{INT:n}$`}, "ignore_missing": true},
+			fields: mapstr.M{"other": "text"},
+			want:   mapstr.M{"other": "text"},
+		},
+		{
+			name:    "existing keys",
+			config:  mapstr.M{"patterns": []string{`%{GREEDYDATA:message}`}},
+			fields:  mapstr.M{"message": "text"},
+			want:    mapstr.M{"message": "text", "log": mapstr.M{"flags": []string{flagParsingError}}},
+			wantErr: "cannot override existing key with `message`",
+		},
+		{
+			name:   "overwrite keys",
+			config: mapstr.M{"patterns": []string{`^<%{INT:priority:int}>%{GREEDYDATA:message}`}, "overwrite_keys": true},
+			fields: mapstr.M{"message": "<13>text"},
+			want:   mapstr.M{"message": "text", "priority": int64(13)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := New(conf.MustNewConfigFrom(test.config))
+			require.NoError(t, err)
+
+			event, err := p.Run(&beat.Event{Fields: test.fields})
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.want, event.Fields)
+			if test.meta != nil {
+				assert.Equal(t, test.meta, event.Meta)
+			}
+		})
+	}
+}
+
+func TestProcessorConfigErrors(t *testing.T) {
+	for name, cfg := range map[string]mapstr.M{
+		"no patterns":       {"patterns": []string{}},
+		"unknown pattern":   {"patterns": []string{`%{NOT_A_PATTERN:x}`}},
+		"missing file":      {"patterns": []string{`%{WORD:x}`}, "pattern_files": []string{filepath.Join(t.TempDir(), "missing")}},
+		"invalid file glob": {"patterns": []string{`%{WORD:x}`}, "pattern_files": []string{"[patterns"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(conf.MustNewConfigFrom(cfg))
+			assert.Error(t, err)
+		})
+	}
+}
+
+// The benchmarks parse the same lines with grok and dissect.
+
+var benchLines = []struct {
+	name    string
+	line    string
+	grok    string
+	dissect string
+}{
+	{
+		name:    "access_log",
+		line:    `10.12.1.101 - alice [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326`,
+		grok:    `^%{IPORHOST:source.address} %{USER:user.id} %{USER:user.name} \[%{HTTPDATE:timestamp}\] "%{WORD:http.request.method} %{NOTSPACE:url.original} HTTP/%{NUMBER:http.version}" %{INT:http.response.status_code:int} %{INT:http.response.body.bytes:int}$`,
+		dissect: `%{source.address} %{user.id} %{user.name} [%{timestamp}] "%{http.request.method} %{url.original} HTTP/%{http.version}" %{http.response.status_code|integer} %{http.response.body.bytes|integer}`,
+	},
+	{
+		name:    "key_values",
+		line:    `level=info component=scheduler msg=started`,
+		grok:    `^level=%{WORD:log.level} component=%{WORD:service.name} msg=%{GREEDYDATA:msg}$`,
+		dissect: `level=%{log.level} component=%{service.name} msg=%{msg}`,
+	},
+}
+
+func BenchmarkGrok(b *testing.B) {
+	for _, bench := range benchLines {
+		b.Run(bench.name, func(b *testing.B) {
+			p, err := New(conf.MustNewConfigFrom(mapstr.M{"patterns": []string{bench.grok}}))
+			require.NoError(b, err)
+			benchmarkProcessor(b, p, bench.line)
+		})
+	}
+}
+
+func BenchmarkDissect(b *testing.B) {
+	for _, bench := range benchLines {
+		b.Run(bench.name, func(b *testing.B) {
+			p, err := dissect.NewProcessor(conf.MustNewConfigFrom(mapstr.M{"tokenizer": bench.dissect, "target_prefix": ""}))
+			require.NoError(b, err)
+			benchmarkProcessor(b, p, bench.line)
+		})
+	}
+}
+
+func benchmarkProcessor(b *testing.B, p beat.Processor, line string) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := p.Run(&beat.Event{Fields: mapstr.M{"message": line}})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package payload implements processors that decode binary payloads, such as
+// Protobuf, MessagePack or CBOR messages, into event fields.
+package payload
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/jsontransform"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// Encodings of string fields holding binary payloads. Byte slices are always
+// decoded as is.
+const (
+	EncodingBase64 = "base64"
+	EncodingHex    = "hex"
+	EncodingNone   = "none"
+)
+
+// Config holds the settings shared by the payload decoding processors.
+type Config struct {
+	Field         string  `config:"field" validate:"required"`
+	Target        *string `config:"target_field"`
+	Encoding      string  `config:"encoding"`
+	OverwriteKeys bool    `config:"overwrite_keys"`
+	IgnoreMissing bool    `config:"ignore_missing"`
+	IgnoreFailure bool    `config:"ignore_failure"`
+	ID            string  `config:"id"`
+}
+
+// DefaultConfig returns the default settings.
+func DefaultConfig() Config {
+	return Config{
+		Field:         "message",
+		Encoding:      EncodingBase64,
+		OverwriteKeys: true,
+	}
+}
+
+func (c *Config) Validate() error {
+	switch c.Encoding {
+	case EncodingBase64, EncodingHex, EncodingNone:
+		return nil
+	}
+	return fmt.Errorf("unsupported encoding %q", c.Encoding)
+}
+
+// DecodeFunc decodes a payload. Maps must be returned as mapstr.M or
+// map[string]interface{}.
+type DecodeFunc func(data []byte) (interface{}, error)
+
+// Processor decodes the payload of a field with a DecodeFunc, and writes the
+// result to the target field.
+type Processor struct {
+	Config
+
+	name   string
+	decode DecodeFunc
+	log    *logp.Logger
+}
+
+// New returns a processor named name that decodes payloads with decode.
+func New(name string, c Config, decode DecodeFunc) *Processor {
+	// Default target to overwriting field.
+	if c.Target == nil {
+		c.Target = &c.Field
+	}
+
+	log := logp.NewLogger("processor." + name)
+	if c.ID != "" {
+		log = log.With("instance_id", c.ID)
+	}
+	return &Processor{Config: c, name: name, decode: decode, log: log}
+}
+
+func (p *Processor) Run(event *beat.Event) (*beat.Event, error) {
+	if err := p.run(event); err != nil && !p.IgnoreFailure {
+		err = fmt.Errorf("failed in %s on the %q field: %w", p.name, p.Field, err)
+		_, _ = event.PutValue("error.message", err.Error())
+		return event, err
+	}
+	return event, nil
+}
+
+func (p *Processor) run(event *beat.Event) error {
+	v, err := event.GetValue(p.Field)
+	if err != nil {
+		if p.IgnoreMissing && errors.Is(err, mapstr.ErrKeyNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	data, err := p.bytes(v)
+	if err != nil {
+		return err
+	}
+	out, err := p.decode(data)
+	if err != nil {
+		return fmt.Errorf("error decoding payload: %w", err)
+	}
+
+	if *p.Target != "" {
+		if _, err = event.PutValue(*p.Target, out); err != nil {
+			return fmt.Errorf("failed to put decoded payload into field %q: %w", *p.Target, err)
+		}
+		return nil
+	}
+	m, ok := out.(mapstr.M)
+	if !ok {
+		return fmt.Errorf("decoded payload of type %T can't be written to the root of the event", out)
+	}
+	jsontransform.WriteJSONKeys(event, m, false, p.OverwriteKeys, !p.IgnoreFailure)
+	return nil
+}
+
+// bytes returns the payload held by the value of the field.
+func (p *Processor) bytes(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		switch p.Encoding {
+		case EncodingBase64:
+			// Accept payloads with and without padding.
+			return base64.RawStdEncoding.DecodeString(strings.TrimRight(v, "="))
+		case EncodingHex:
+			return hex.DecodeString(v)
+		default:
+			return []byte(v), nil
+		}
+	default:
+		return nil, fmt.Errorf("field value of type %T is not a string or bytes", v)
+	}
+}
+
+func (p *Processor) String() string {
+	json, _ := json.Marshal(p.Config)
+	return p.name + "=" + string(json)
+}
+
+// Normalize converts decoded values to types events can hold: maps become
+// mapstr.M with string keys, and byte slices become base64 strings.
+func Normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(mapstr.M, len(v))
+		for k, e := range v {
+			if s, ok := k.(string); ok {
+				m[s] = Normalize(e)
+			} else {
+				m[fmt.Sprint(k)] = Normalize(e)
+			}
+		}
+		return m
+	case map[string]interface{}:
+		m := make(mapstr.M, len(v))
+		for k, e := range v {
+			m[k] = Normalize(e)
+		}
+		return m
+	case mapstr.M:
+		return Normalize(map[string]interface{}(v))
+	case []interface{}:
+		for i, e := range v {
+			v[i] = Normalize(e)
+		}
+		return v
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	case time.Time:
+		return v.UTC()
+	default:
+		return v
+	}
+}
@@ -0,0 +1,164 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package payload
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// decodeJSON stands in for a binary decoder.
+func decodeJSON(data []byte) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return Normalize(v), nil
+}
+
+func TestProcessor(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		fields mapstr.M
+		want   mapstr.M
+		err    bool
+	}{
+		{
+			name:   "base64",
+			fields: mapstr.M{"message": "eyJhIjoxfQ=="},
+			want:   mapstr.M{"message": mapstr.M{"a": float64(1)}},
+		},
+		{
+			name:   "base64 without padding",
+			fields: mapstr.M{"message": "eyJhIjoxfQ"},
+			want:   mapstr.M{"message": mapstr.M{"a": float64(1)}},
+		},
+		{
+			name:   "hex",
+			config: map[string]interface{}{"encoding": "hex", "target_field": "decoded"},
+			fields: mapstr.M{"message": "7b2261223a317d"},
+			want:   mapstr.M{"message": "7b2261223a317d", "decoded": mapstr.M{"a": float64(1)}},
+		},
+		{
+			name:   "none",
+			config: map[string]interface{}{"encoding": "none"},
+			fields: mapstr.M{"message": `{"a":1}`},
+			want:   mapstr.M{"message": mapstr.M{"a": float64(1)}},
+		},
+		{
+			name:   "bytes",
+			fields: mapstr.M{"message": []byte(`[1,2]`)},
+			want:   mapstr.M{"message": []interface{}{float64(1), float64(2)}},
+		},
+		{
+			name:   "root",
+			config: map[string]interface{}{"target_field": ""},
+			fields: mapstr.M{"message": "eyJhIjoxfQ==", "a": 2},
+			want:   mapstr.M{"message": "eyJhIjoxfQ==", "a": float64(1)},
+		},
+		{
+			name:   "root without overwrite",
+			config: map[string]interface{}{"target_field": "", "overwrite_keys": false},
+			fields: mapstr.M{"message": "eyJhIjoxfQ==", "a": 2},
+			want:   mapstr.M{"message": "eyJhIjoxfQ==", "a": 2},
+		},
+		{
+			name:   "root not an object",
+			config: map[string]interface{}{"target_field": "", "encoding": "none"},
+			fields: mapstr.M{"message": "[1]"},
+			err:    true,
+		},
+		{
+			name:   "invalid base64",
+			fields: mapstr.M{"message": "!"},
+			err:    true,
+		},
+		{
+			name:   "invalid payload",
+			config: map[string]interface{}{"encoding": "none"},
+			fields: mapstr.M{"message": "{"},
+			err:    true,
+		},
+		{
+			name:   "not a string",
+			fields: mapstr.M{"message": 1},
+			err:    true,
+		},
+		{
+			name:   "missing",
+			fields: mapstr.M{},
+			err:    true,
+		},
+		{
+			name:   "ignore missing",
+			config: map[string]interface{}{"ignore_missing": true},
+			fields: mapstr.M{},
+			want:   mapstr.M{},
+		},
+		{
+			name:   "ignore failure",
+			config: map[string]interface{}{"ignore_failure": true},
+			fields: mapstr.M{"message": "!"},
+			want:   mapstr.M{"message": "!"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := DefaultConfig()
+			require.NoError(t, conf.MustNewConfigFrom(tc.config).Unpack(&c))
+			p := New("test", c, decodeJSON)
+
+			event, err := p.Run(&beat.Event{Fields: tc.fields})
+			if tc.err {
+				assert.Error(t, err)
+				assert.Contains(t, event.Fields, "error")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, event.Fields)
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	c := DefaultConfig()
+	assert.Error(t, conf.MustNewConfigFrom(map[string]interface{}{"encoding": "base32"}).Unpack(&c))
+}
+
+func TestNormalize(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+	got := Normalize(map[interface{}]interface{}{
+		"a":      []byte{0x01, 0x02},
+		int64(1): []interface{}{map[string]interface{}{"b": "c"}},
+		"ts":     ts,
+	})
+	assert.Equal(t, mapstr.M{
+		"a":  "AQI=",
+		"1":  []interface{}{mapstr.M{"b": "c"}},
+		"ts": ts.UTC(),
+	}, got)
+}
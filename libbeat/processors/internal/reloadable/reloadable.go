@@ -0,0 +1,158 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package reloadable keeps values loaded from files up to date, for
+// processors that reload their files when they change.
+package reloadable
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// ErrFileChanged is returned when a file changes while it is loaded.
+var ErrFileChanged = errors.New("file changed while it was loaded")
+
+// Config configures a File.
+type Config[T any] struct {
+	// Name describes the contents of the file in log messages, e.g.
+	// "lookup table".
+	Name string
+	// Path is the path of the file.
+	Path string
+	// ReloadPeriod is the interval at which the file is checked for
+	// changes. Zero disables reloading.
+	ReloadPeriod time.Duration
+	// Load loads the value from the file.
+	Load func(path string) (T, error)
+	// LogFields returns additional fields logged when the value is loaded.
+	// It is optional.
+	LogFields func(T) []interface{}
+}
+
+// File is a value loaded from a file. Values must be immutable, as they are
+// used concurrently.
+type File[T any] struct {
+	Config[T]
+	log *logp.Logger
+
+	current atomic.Pointer[version[T]]
+	// nextCheck is the time, in Unix nanoseconds, after which the file
+	// is checked for changes.
+	nextCheck atomic.Int64
+	// reloading is set while the file is checked in the background.
+	reloading atomic.Bool
+}
+
+// version is a value with the state of the file it was loaded from.
+type version[T any] struct {
+	value   T
+	modTime time.Time
+	size    int64
+}
+
+// changed returns whether the file described by info differs from the file
+// the value was loaded from.
+func (v *version[T]) changed(info os.FileInfo) bool {
+	return !info.ModTime().Equal(v.modTime) || info.Size() != v.size
+}
+
+// New loads the file described by c.
+func New[T any](c Config[T], log *logp.Logger) (*File[T], error) {
+	f := &File[T]{Config: c, log: log}
+	v, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	f.current.Store(v)
+	f.nextCheck.Store(time.Now().Add(c.ReloadPeriod).UnixNano())
+	log.Infow("Loaded "+c.Name, f.logFields(v.value, "path", c.Path)...)
+	return f, nil
+}
+
+// Get returns the current value. When the reload period has passed, the
+// file is checked for changes in the background, and reloaded if it changed.
+// The previous value is returned until the reload has completed, and kept if
+// it fails.
+func (f *File[T]) Get() T {
+	v := f.current.Load()
+	if f.ReloadPeriod <= 0 {
+		return v.value
+	}
+	now := time.Now()
+	if now.UnixNano() >= f.nextCheck.Load() && f.reloading.CompareAndSwap(false, true) {
+		f.nextCheck.Store(now.Add(f.ReloadPeriod).UnixNano())
+		go f.reload(v)
+	}
+	return v.value
+}
+
+func (f *File[T]) reload(old *version[T]) {
+	defer f.reloading.Store(false)
+
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		f.log.Warnw("Failed to check "+f.Name+" for changes", "path", f.Path, "error", err)
+		return
+	}
+	if !old.changed(info) {
+		return
+	}
+
+	start := time.Now()
+	v, err := f.load()
+	if err != nil {
+		f.log.Errorw("Failed to reload "+f.Name+", keeping the previous version", "path", f.Path, "error", err)
+		return
+	}
+	f.current.Store(v)
+	f.log.Infow("Reloaded "+f.Name, f.logFields(v.value, "path", f.Path, "duration", time.Since(start))...)
+}
+
+// load loads the file, and fails if it changes while it is loaded.
+func (f *File[T]) load() (*version[T], error) {
+	before, err := os.Stat(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	value, err := f.Load(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	after, err := os.Stat(f.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &version[T]{value: value, modTime: before.ModTime(), size: before.Size()}
+	if v.changed(after) {
+		return nil, fmt.Errorf("failed to load %s: %w", f.Path, ErrFileChanged)
+	}
+	return v, nil
+}
+
+func (f *File[T]) logFields(value T, fields ...interface{}) []interface{} {
+	if f.LogFields != nil {
+		fields = append(fields, f.LogFields(value)...)
+	}
+	return fields
+}
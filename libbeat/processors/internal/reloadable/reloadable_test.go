@@ -0,0 +1,104 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package reloadable
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// loadWord loads a file holding a single word, and fails on empty files.
+func loadWord(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	word := strings.TrimSpace(string(data))
+	if word == "" {
+		return "", errors.New("empty file")
+	}
+	return word, nil
+}
+
+// update replaces the contents of path, and moves its modification time
+// forward so the change is detected on file systems with coarse
+// modification times.
+func update(t *testing.T, path, content string, mtime time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+}
+
+func TestFileReload(t *testing.T) {
+	logp.TestingSetup()
+
+	for name, test := range map[string]struct {
+		period time.Duration
+		want   string
+	}{
+		"reload":    {period: 10 * time.Millisecond, want: "second"},
+		"no reload": {period: 0, want: "first"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "word.txt")
+			require.NoError(t, os.WriteFile(path, []byte("first"), 0o644))
+
+			f, err := New(Config[string]{Name: "word", Path: path, ReloadPeriod: test.period, Load: loadWord}, logp.NewLogger("test"))
+			require.NoError(t, err)
+			assert.Equal(t, "first", f.Get())
+
+			later := time.Now().Add(time.Second)
+			update(t, path, "second", later)
+			if test.period > 0 {
+				assert.Eventually(t, func() bool { return f.Get() == test.want }, 5*time.Second, 20*time.Millisecond)
+			} else {
+				time.Sleep(100 * time.Millisecond)
+				assert.Equal(t, test.want, f.Get())
+			}
+
+			// A broken file keeps the previous value.
+			update(t, path, "", later.Add(time.Second))
+			time.Sleep(100 * time.Millisecond)
+			assert.Equal(t, test.want, f.Get())
+		})
+	}
+}
+
+func TestFileChangedWhileLoading(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "word.txt")
+	require.NoError(t, os.WriteFile(path, []byte("first"), 0o644))
+
+	_, err := New(Config[string]{
+		Name: "word",
+		Path: path,
+		Load: func(path string) (string, error) {
+			update(t, path, "second", time.Now().Add(time.Second))
+			return loadWord(path)
+		},
+	}, logp.NewLogger("test"))
+	assert.ErrorIs(t, err, ErrFileChanged)
+}
@@ -0,0 +1,150 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lookup
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Table formats.
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+	formatMMDB   = "mmdb"
+)
+
+// Match modes.
+const (
+	matchExact  = "exact"
+	matchCIDR   = "cidr"
+	matchPrefix = "prefix"
+)
+
+type config struct {
+	Field         string        `config:"field"  validate:"required"`
+	Table         tableConfig   `config:"table"`
+	Fields        []fieldConfig `config:"fields"`
+	TargetField   string        `config:"target_field"`
+	OverwriteKeys bool          `config:"overwrite_keys"`
+	IgnoreMissing bool          `config:"ignore_missing"`
+	IgnoreFailure bool          `config:"ignore_failure"`
+	ID            string        `config:"id"`
+}
+
+type tableConfig struct {
+	// Path is the path of the table file.
+	Path string `config:"path" validate:"required"`
+	// Format is csv, ndjson or mmdb. If not set, it is derived from the
+	// extension of the file.
+	Format string `config:"format"`
+	// Key is the column, or the field for NDJSON tables, that is matched
+	// against the value of the source field. It is not used by mmdb tables.
+	Key string `config:"key"`
+	// Match is exact, cidr or prefix.
+	Match string `config:"match"`
+	// Separator is the field separator of CSV tables.
+	Separator string `config:"separator"`
+	// ReloadPeriod is the interval at which the file is checked for
+	// changes. Zero disables reloading.
+	ReloadPeriod time.Duration `config:"reload_period" validate:"min=0"`
+}
+
+// fieldConfig copies a value of a matching row to the event.
+type fieldConfig struct {
+	From string `config:"from" validate:"required"`
+	To   string `config:"to"   validate:"required"`
+}
+
+func defaultConfig() config {
+	return config{
+		Table: tableConfig{
+			Separator:    ",",
+			ReloadPeriod: time.Minute,
+		},
+	}
+}
+
+func (c *config) Validate() error {
+	if len(c.Fields) == 0 && c.TargetField == "" {
+		return fmt.Errorf("either fields or target_field must be set")
+	}
+	if len(c.Fields) != 0 && c.TargetField != "" {
+		return fmt.Errorf("fields and target_field can not be used together")
+	}
+	return nil
+}
+
+func (c *tableConfig) Validate() error {
+	format := c.format()
+	switch format {
+	case formatCSV, formatNDJSON:
+		if c.Key == "" {
+			return fmt.Errorf("table.key is required for %s tables", format)
+		}
+	case formatMMDB:
+		if c.match() != matchCIDR {
+			return fmt.Errorf("mmdb tables only support cidr matching: %s", c.Match)
+		}
+	case "":
+		return fmt.Errorf("can not determine the format of %s, set table.format", c.Path)
+	default:
+		return fmt.Errorf("unsupported table.format: %s", c.Format)
+	}
+	switch c.match() {
+	case matchExact, matchCIDR, matchPrefix:
+	default:
+		return fmt.Errorf("unsupported table.match: %s", c.Match)
+	}
+	if format == formatCSV && utf8.RuneCountInString(c.Separator) != 1 {
+		return fmt.Errorf("table.separator must be a single character: %q", c.Separator)
+	}
+	return nil
+}
+
+// format returns the configured format, or the format for the extension of
+// the file.
+func (c *tableConfig) format() string {
+	if c.Format != "" {
+		return strings.ToLower(c.Format)
+	}
+	switch strings.ToLower(filepath.Ext(c.Path)) {
+	case ".csv":
+		return formatCSV
+	case ".ndjson", ".jsonl", ".json":
+		return formatNDJSON
+	case ".mmdb":
+		return formatMMDB
+	}
+	return ""
+}
+
+// match returns the match mode, which defaults to cidr for mmdb tables and
+// exact otherwise.
+func (c *tableConfig) match() string {
+	if c.Match != "" {
+		return strings.ToLower(c.Match)
+	}
+	if c.format() == formatMMDB {
+		return matchCIDR
+	}
+	return matchExact
+}
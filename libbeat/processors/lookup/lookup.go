@@ -0,0 +1,138 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lookup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	"github.com/elastic/beats/v7/libbeat/processors/internal/reloadable"
+	jsprocessor "github.com/elastic/beats/v7/libbeat/processors/script/javascript/module/processor"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const (
+	procName = "lookup"
+	logName  = "processor." + procName
+)
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+	jsprocessor.RegisterPlugin("Lookup", New)
+}
+
+type processor struct {
+	config
+	table *reloadable.File[table]
+}
+
+// New constructs a new lookup processor built from ucfg config.
+func New(cfg *conf.C) (beat.Processor, error) {
+	c := defaultConfig()
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, fmt.Errorf("fail to unpack the %v processor configuration: %w", procName, err)
+	}
+
+	return newLookup(c)
+}
+
+func newLookup(c config) (*processor, error) {
+	cfgwarn.Beta("The " + procName + " processor is beta.")
+
+	log := logp.NewLogger(logName)
+	if c.ID != "" {
+		log = log.With("instance_id", c.ID)
+	}
+
+	t, err := reloadable.New(reloadable.Config[table]{
+		Name:         "lookup table",
+		Path:         c.Table.Path,
+		ReloadPeriod: c.Table.ReloadPeriod,
+		Load:         func(string) (table, error) { return loadTable(c.Table) },
+		LogFields:    func(t table) []interface{} { return []interface{}{"entries", t.len()} },
+	}, log)
+	if err != nil {
+		return nil, err
+	}
+
+	return &processor{config: c, table: t}, nil
+}
+
+func (p *processor) String() string {
+	json, _ := json.Marshal(p.config)
+	return procName + "=" + string(json)
+}
+
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	err := p.enrich(event)
+	if err == nil || p.IgnoreFailure || (p.IgnoreMissing && errors.Is(err, mapstr.ErrKeyNotFound)) {
+		return event, nil
+	}
+	return event, err
+}
+
+func (p *processor) enrich(event *beat.Event) error {
+	v, err := event.GetValue(p.Field)
+	if err != nil {
+		return fmt.Errorf("lookup source field [%v] not found: %w", p.Field, err)
+	}
+	key, ok := keyString(v)
+	if !ok {
+		return fmt.Errorf("lookup source field [%v] has unsupported type %T", p.Field, v)
+	}
+
+	r, err := p.table.Get().lookup(key)
+	if err != nil {
+		return fmt.Errorf("failed to look up source field [%v]: %w", p.Field, err)
+	}
+	if r == nil {
+		return nil
+	}
+
+	if p.TargetField != "" {
+		return p.put(event, p.TargetField, r.fields())
+	}
+	for _, f := range p.Fields {
+		v, ok := r.get(f.From)
+		if !ok {
+			continue
+		}
+		if err := p.put(event, f.To, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *processor) put(event *beat.Event, key string, value interface{}) error {
+	if !p.OverwriteKeys {
+		if _, err := event.GetValue(key); err == nil {
+			return fmt.Errorf("lookup target field [%v] already exists", key)
+		}
+	}
+	if _, err := event.PutValue(key, value); err != nil {
+		return fmt.Errorf("failed to write lookup result to target field [%v]: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,318 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lookup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestLookup(t *testing.T) {
+	logp.TestingSetup()
+
+	tests := map[string]struct {
+		config  mapstr.M
+		input   mapstr.M
+		want    mapstr.M
+		wantErr string
+	}{
+		"cidr most specific network": {
+			config: mapstr.M{
+				"field": "source.ip",
+				"table": mapstr.M{"path": "testdata/assets.csv", "key": "ip", "match": "cidr"},
+				"fields": []mapstr.M{
+					{"from": "owner", "to": "source.owner"},
+					{"from": "team", "to": "source.team"},
+				},
+			},
+			input: mapstr.M{"source": mapstr.M{"ip": "10.1.200.1"}},
+			want:  mapstr.M{"source": mapstr.M{"ip": "10.1.200.1", "owner": "payments", "team": "pay-ops"}},
+		},
+		"cidr single address": {
+			config: mapstr.M{
+				"field":        "source.ip",
+				"table":        mapstr.M{"path": "testdata/assets.csv", "key": "ip", "match": "cidr"},
+				"target_field": "source.asset",
+			},
+			input: mapstr.M{"source": mapstr.M{"ip": "10.1.2.3"}},
+			want: mapstr.M{"source": mapstr.M{"ip": "10.1.2.3", "asset": mapstr.M{
+				"ip": "10.1.2.3", "owner": "payments-db", "team": "pay-ops", "criticality": "critical",
+			}}},
+		},
+		"cidr empty values are not copied": {
+			config: mapstr.M{
+				"field": "source.ip",
+				"table": mapstr.M{"path": "testdata/assets.csv", "key": "ip", "match": "cidr"},
+				"fields": []mapstr.M{
+					{"from": "owner", "to": "source.owner"},
+					{"from": "team", "to": "source.team"},
+				},
+			},
+			input: mapstr.M{"source": mapstr.M{"ip": "2001:db8::1"}},
+			want:  mapstr.M{"source": mapstr.M{"ip": "2001:db8::1", "owner": "lab"}},
+		},
+		"no match": {
+			config: mapstr.M{
+				"field":        "source.ip",
+				"table":        mapstr.M{"path": "testdata/assets.csv", "key": "ip", "match": "cidr"},
+				"target_field": "source.asset",
+			},
+			input: mapstr.M{"source": mapstr.M{"ip": "192.168.1.1"}},
+			want:  mapstr.M{"source": mapstr.M{"ip": "192.168.1.1"}},
+		},
+		"invalid ip": {
+			config: mapstr.M{
+				"field":        "source.ip",
+				"table":        mapstr.M{"path": "testdata/assets.csv", "key": "ip", "match": "cidr"},
+				"target_field": "source.asset",
+			},
+			input:   mapstr.M{"source": mapstr.M{"ip": "not-an-ip"}},
+			want:    mapstr.M{"source": mapstr.M{"ip": "not-an-ip"}},
+			wantErr: `failed to look up source field [source.ip]: invalid IP address "not-an-ip"`,
+		},
+		"exact": {
+			config: mapstr.M{
+				"field": "user.id",
+				"table": mapstr.M{"path": "testdata/users.ndjson", "key": "id"},
+				"fields": []mapstr.M{
+					{"from": "user.full_name", "to": "user.full_name"},
+					{"from": "user.roles", "to": "user.roles"},
+					{"from": "uid", "to": "user.uid"},
+				},
+			},
+			input: mapstr.M{"user": mapstr.M{"id": "alice"}},
+			want: mapstr.M{"user": mapstr.M{
+				"id": "alice", "full_name": "Alice Smith", "roles": []interface{}{"admin"}, "uid": int64(1001),
+			}},
+		},
+		"exact numeric key": {
+			config: mapstr.M{
+				"field": "user.id",
+				"table": mapstr.M{"path": "testdata/users.ndjson", "key": "id"},
+				"fields": []mapstr.M{
+					{"from": "user.full_name", "to": "user.full_name"},
+				},
+			},
+			input: mapstr.M{"user": mapstr.M{"id": 1003}},
+			want:  mapstr.M{"user": mapstr.M{"id": 1003, "full_name": "Service Account"}},
+		},
+		"prefix": {
+			config: mapstr.M{
+				"field": "phone",
+				"table": mapstr.M{"path": "testdata/prefixes.csv", "key": "prefix", "match": "prefix", "separator": ";"},
+				"fields": []mapstr.M{
+					{"from": "carrier", "to": "carrier"},
+				},
+			},
+			input: mapstr.M{"phone": "+442071234567"},
+			want:  mapstr.M{"phone": "+442071234567", "carrier": "london"},
+		},
+		"target exists": {
+			config: mapstr.M{
+				"field": "user.id",
+				"table": mapstr.M{"path": "testdata/users.ndjson", "key": "id"},
+				"fields": []mapstr.M{
+					{"from": "user.full_name", "to": "user.full_name"},
+				},
+			},
+			input:   mapstr.M{"user": mapstr.M{"id": "bob", "full_name": "Robert"}},
+			want:    mapstr.M{"user": mapstr.M{"id": "bob", "full_name": "Robert"}},
+			wantErr: "lookup target field [user.full_name] already exists",
+		},
+		"overwrite keys": {
+			config: mapstr.M{
+				"field":          "user.id",
+				"table":          mapstr.M{"path": "testdata/users.ndjson", "key": "id"},
+				"overwrite_keys": true,
+				"fields": []mapstr.M{
+					{"from": "user.full_name", "to": "user.full_name"},
+				},
+			},
+			input: mapstr.M{"user": mapstr.M{"id": "bob", "full_name": "Robert"}},
+			want:  mapstr.M{"user": mapstr.M{"id": "bob", "full_name": "Bob Jones"}},
+		},
+		"missing field": {
+			config: mapstr.M{
+				"field":        "user.id",
+				"table":        mapstr.M{"path": "testdata/users.ndjson", "key": "id"},
+				"target_field": "user.details",
+			},
+			input:   mapstr.M{"message": "hello"},
+			want:    mapstr.M{"message": "hello"},
+			wantErr: "lookup source field [user.id] not found: key not found",
+		},
+		"ignore missing field": {
+			config: mapstr.M{
+				"field":          "user.id",
+				"table":          mapstr.M{"path": "testdata/users.ndjson", "key": "id"},
+				"target_field":   "user.details",
+				"ignore_missing": true,
+			},
+			input: mapstr.M{"message": "hello"},
+			want:  mapstr.M{"message": "hello"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := New(conf.MustNewConfigFrom(test.config))
+			require.NoError(t, err)
+
+			event, err := p.Run(&beat.Event{Fields: test.input})
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.want, event.Fields)
+		})
+	}
+}
+
+func TestLookupSharedRows(t *testing.T) {
+	logp.TestingSetup()
+
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{
+		"field":        "user.id",
+		"table":        mapstr.M{"path": "testdata/users.ndjson", "key": "id"},
+		"target_field": "user.details",
+	}))
+	require.NoError(t, err)
+
+	first, err := p.Run(&beat.Event{Fields: mapstr.M{"user": mapstr.M{"id": "alice"}}})
+	require.NoError(t, err)
+	_, err = first.PutValue("user.details.user.full_name", "changed")
+	require.NoError(t, err)
+
+	second, err := p.Run(&beat.Event{Fields: mapstr.M{"user": mapstr.M{"id": "alice"}}})
+	require.NoError(t, err)
+	name, err := second.GetValue("user.details.user.full_name")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice Smith", name)
+}
+
+func TestLookupMMDB(t *testing.T) {
+	logp.TestingSetup()
+
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{
+		"field": "source.ip",
+		"table": mapstr.M{"path": "../../../testing/environments/GeoLite2-ASN.mmdb"},
+		"fields": []mapstr.M{
+			{"from": "autonomous_system_number", "to": "source.as.number"},
+			{"from": "autonomous_system_organization", "to": "source.as.organization.name"},
+		},
+	}))
+	require.NoError(t, err)
+
+	event, err := p.Run(&beat.Event{Fields: mapstr.M{"source": mapstr.M{"ip": "1.128.0.1"}}})
+	require.NoError(t, err)
+	assert.Equal(t, mapstr.M{"source": mapstr.M{
+		"ip": "1.128.0.1",
+		"as": mapstr.M{"number": uint64(1221), "organization": mapstr.M{"name": "Telstra Pty Ltd"}},
+	}}, event.Fields)
+
+	event, err = p.Run(&beat.Event{Fields: mapstr.M{"source": mapstr.M{"ip": "127.0.0.1"}}})
+	require.NoError(t, err)
+	assert.Equal(t, mapstr.M{"source": mapstr.M{"ip": "127.0.0.1"}}, event.Fields)
+}
+
+func TestLookupReload(t *testing.T) {
+	logp.TestingSetup()
+
+	path := filepath.Join(t.TempDir(), "hosts.csv")
+	require.NoError(t, os.WriteFile(path, []byte("host,env\nweb-1,staging\n"), 0o644))
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{
+		"field":          "host.name",
+		"table":          mapstr.M{"path": path, "key": "host", "reload_period": "10ms"},
+		"fields":         []mapstr.M{{"from": "env", "to": "host.env"}},
+		"overwrite_keys": true,
+	}))
+	require.NoError(t, err)
+
+	env := func() interface{} {
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{"host": mapstr.M{"name": "web-1"}}})
+		require.NoError(t, err)
+		v, _ := event.GetValue("host.env")
+		return v
+	}
+	assert.Equal(t, "staging", env())
+
+	require.NoError(t, os.WriteFile(path, []byte("host,env\nweb-1,production\n"), 0o644))
+	// Make sure the change is detected on file systems with coarse
+	// modification times.
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(path, later, later))
+	assert.Eventually(t, func() bool { return env() == "production" }, 5*time.Second, 20*time.Millisecond)
+
+	// A broken file keeps the previous table.
+	require.NoError(t, os.WriteFile(path, []byte("name,env\nweb-1,development\n"), 0o644))
+	later = later.Add(time.Second)
+	require.NoError(t, os.Chtimes(path, later, later))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, "production", env())
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		config  mapstr.M
+		wantErr string
+	}{
+		"no output": {
+			config:  mapstr.M{"field": "a", "table": mapstr.M{"path": "t.csv", "key": "k"}},
+			wantErr: "either fields or target_field must be set",
+		},
+		"unknown format": {
+			config:  mapstr.M{"field": "a", "target_field": "b", "table": mapstr.M{"path": "t.txt", "key": "k"}},
+			wantErr: "can not determine the format of t.txt, set table.format",
+		},
+		"missing key": {
+			config:  mapstr.M{"field": "a", "target_field": "b", "table": mapstr.M{"path": "t.csv"}},
+			wantErr: "table.key is required for csv tables",
+		},
+		"mmdb exact": {
+			config:  mapstr.M{"field": "a", "target_field": "b", "table": mapstr.M{"path": "t.mmdb", "match": "exact"}},
+			wantErr: "mmdb tables only support cidr matching: exact",
+		},
+		"valid": {
+			config: mapstr.M{"field": "a", "target_field": "b", "table": mapstr.M{"path": "t.jsonl", "key": "k", "match": "prefix"}},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := defaultConfig()
+			err := conf.MustNewConfigFrom(test.config).Unpack(&c)
+			if test.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), test.wantErr)
+			}
+		})
+	}
+}
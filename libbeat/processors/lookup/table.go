@@ -0,0 +1,435 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lookup
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/elastic/beats/v7/libbeat/common/jsontransform"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// table is a loaded lookup table. Tables are immutable and can be used
+// concurrently.
+type table interface {
+	// lookup returns the row matching key, or nil if there is none.
+	lookup(key string) (row, error)
+	// len returns the number of keys in the table, or the number of nodes
+	// of the search tree for mmdb tables.
+	len() int
+}
+
+// row is a row of a table.
+type row interface {
+	// get returns the value of the named column of the row.
+	get(name string) (interface{}, bool)
+	// fields returns all the values of the row.
+	fields() mapstr.M
+}
+
+func loadTable(c tableConfig) (table, error) {
+	var (
+		t   table
+		err error
+	)
+	switch c.format() {
+	case formatCSV:
+		t, err = loadCSV(c)
+	case formatNDJSON:
+		t, err = loadNDJSON(c)
+	case formatMMDB:
+		t, err = loadMMDB(c)
+	default:
+		err = fmt.Errorf("unsupported table format: %s", c.format())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", c.Path, err)
+	}
+	return t, nil
+}
+
+// csvRow is a row of a CSV table.
+type csvRow struct {
+	header map[string]int
+	values []string
+}
+
+func (r csvRow) get(name string) (interface{}, bool) {
+	i, ok := r.header[name]
+	if !ok || i >= len(r.values) || r.values[i] == "" {
+		return nil, false
+	}
+	return r.values[i], true
+}
+
+func (r csvRow) fields() mapstr.M {
+	m := make(mapstr.M, len(r.header))
+	for name, i := range r.header {
+		if i < len(r.values) && r.values[i] != "" {
+			m[name] = r.values[i]
+		}
+	}
+	return m
+}
+
+func loadCSV(c tableConfig) (table, error) {
+	f, err := os.Open(c.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(bufio.NewReader(f))
+	r.Comma, _ = utf8.DecodeRuneInString(c.Separator)
+	r.FieldsPerRecord = -1
+
+	names, err := r.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("missing header")
+		}
+		return nil, err
+	}
+	header := make(map[string]int, len(names))
+	for i, name := range names {
+		header[name] = i
+	}
+	keyIdx, ok := header[c.Key]
+	if !ok {
+		return nil, fmt.Errorf("key column %q not found in header", c.Key)
+	}
+
+	var (
+		keys []string
+		rows []row
+	)
+	for {
+		values, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if keyIdx >= len(values) || values[keyIdx] == "" {
+			continue
+		}
+		keys = append(keys, values[keyIdx])
+		rows = append(rows, csvRow{header: header, values: values})
+	}
+	return newIndex(c.match(), keys, rows)
+}
+
+// mapRow is a row of an NDJSON or mmdb table.
+type mapRow struct {
+	m mapstr.M
+	// shared is set when the row is returned for more than one lookup,
+	// in which case returned values must be copied.
+	shared bool
+}
+
+func (r mapRow) get(name string) (interface{}, bool) {
+	v, err := r.m.GetValue(name)
+	if err != nil {
+		return nil, false
+	}
+	if r.shared {
+		v = deepCopy(v)
+	}
+	return v, true
+}
+
+func (r mapRow) fields() mapstr.M {
+	if r.shared {
+		return deepCopy(r.m).(mapstr.M)
+	}
+	return r.m
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch v := v.(type) {
+	case mapstr.M:
+		m := make(mapstr.M, len(v))
+		for k, e := range v {
+			m[k] = deepCopy(e)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = deepCopy(e)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = deepCopy(e)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+func loadNDJSON(c tableConfig) (table, error) {
+	f, err := os.Open(c.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		keys []string
+		rows []row
+	)
+	dec := json.NewDecoder(bufio.NewReader(f))
+	dec.UseNumber()
+	for line := 1; ; line++ {
+		var m mapstr.M
+		err := dec.Decode(&m)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode object %d: %w", line, err)
+		}
+		jsontransform.TransformNumbers(m)
+		v, err := m.GetValue(c.Key)
+		if err != nil {
+			continue
+		}
+		key, ok := keyString(v)
+		if !ok {
+			return nil, fmt.Errorf("unsupported type %T of key in object %d", v, line)
+		}
+		keys = append(keys, key)
+		rows = append(rows, mapRow{m: m, shared: true})
+	}
+	return newIndex(c.match(), keys, rows)
+}
+
+// keyString returns the string used to look up a value.
+func keyString(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case json.Number:
+		return v.String(), true
+	case net.IP:
+		return v.String(), true
+	}
+	return "", false
+}
+
+// newIndex returns an in-memory index of rows. Rows with the same key replace
+// earlier rows.
+func newIndex(match string, keys []string, rows []row) (table, error) {
+	switch match {
+	case matchExact:
+		return newExactIndex(keys, rows), nil
+	case matchPrefix:
+		idx := &prefixIndex{exactIndex: newExactIndex(keys, rows)}
+		seen := make(map[int]bool)
+		for k := range idx.keys {
+			if !seen[len(k)] {
+				seen[len(k)] = true
+				idx.lens = append(idx.lens, len(k))
+			}
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(idx.lens)))
+		return idx, nil
+	case matchCIDR:
+		return newCIDRIndex(keys, rows)
+	}
+	return nil, fmt.Errorf("unsupported match: %s", match)
+}
+
+// exactIndex matches keys that are equal to the looked up value.
+type exactIndex struct {
+	keys map[string]int32
+	rows []row
+}
+
+func newExactIndex(keys []string, rows []row) *exactIndex {
+	idx := &exactIndex{keys: make(map[string]int32, len(keys)), rows: rows}
+	for i, k := range keys {
+		idx.keys[k] = int32(i)
+	}
+	return idx
+}
+
+func (idx *exactIndex) lookup(key string) (row, error) {
+	i, ok := idx.keys[key]
+	if !ok {
+		return nil, nil
+	}
+	return idx.rows[i], nil
+}
+
+func (idx *exactIndex) len() int { return len(idx.keys) }
+
+// prefixIndex matches the longest key that is a prefix of the looked up
+// value.
+type prefixIndex struct {
+	*exactIndex
+	// lens are the distinct lengths of the keys, longest first.
+	lens []int
+}
+
+func (idx *prefixIndex) lookup(key string) (row, error) {
+	for _, n := range idx.lens {
+		if n > len(key) {
+			continue
+		}
+		if i, ok := idx.keys[key[:n]]; ok {
+			return idx.rows[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// cidrIndex matches the most specific network containing the looked up IP
+// address. Keys are networks in CIDR notation or IP addresses.
+type cidrIndex struct {
+	nets map[netip.Prefix]int32
+	rows []row
+	// bits4 and bits6 are the distinct prefix lengths of the IPv4 and
+	// IPv6 networks, longest first.
+	bits4, bits6 []int
+}
+
+func newCIDRIndex(keys []string, rows []row) (*cidrIndex, error) {
+	idx := &cidrIndex{nets: make(map[netip.Prefix]int32, len(keys)), rows: rows}
+	seen4 := make(map[int]bool)
+	seen6 := make(map[int]bool)
+	for i, k := range keys {
+		p, err := parsePrefix(k)
+		if err != nil {
+			return nil, err
+		}
+		idx.nets[p] = int32(i)
+		bits, seen := &idx.bits4, seen4
+		if p.Addr().Is6() {
+			bits, seen = &idx.bits6, seen6
+		}
+		if !seen[p.Bits()] {
+			seen[p.Bits()] = true
+			*bits = append(*bits, p.Bits())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(idx.bits4)))
+	sort.Sort(sort.Reverse(sort.IntSlice(idx.bits6)))
+	return idx, nil
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	if p, err := netip.ParsePrefix(s); err == nil {
+		if p.Addr().Is4In6() && p.Bits() >= 96 {
+			p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+		}
+		return p.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid network %q", s)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func (idx *cidrIndex) lookup(key string) (row, error) {
+	addr, err := netip.ParseAddr(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP address %q", key)
+	}
+	addr = addr.Unmap()
+	bits := idx.bits4
+	if addr.Is6() {
+		bits = idx.bits6
+	}
+	for _, n := range bits {
+		p, err := addr.Prefix(n)
+		if err != nil {
+			continue
+		}
+		if i, ok := idx.nets[p]; ok {
+			return idx.rows[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (idx *cidrIndex) len() int { return len(idx.nets) }
+
+// mmdbTable looks up IP addresses in a MaxMind DB file, such as the GeoIP2
+// and GeoLite2 databases.
+type mmdbTable struct {
+	reader *maxminddb.Reader
+}
+
+func loadMMDB(c tableConfig) (table, error) {
+	// The database is read into memory rather than mapped, so that it is
+	// safe to replace it while lookups are in progress.
+	b, err := os.ReadFile(c.Path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := maxminddb.FromBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	return mmdbTable{reader: r}, nil
+}
+
+func (t mmdbTable) lookup(key string) (row, error) {
+	ip := net.ParseIP(key)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address %q", key)
+	}
+	var rec map[string]interface{}
+	if err := t.reader.Lookup(ip, &rec); err != nil {
+		return nil, err
+	}
+	if len(rec) == 0 {
+		return nil, nil
+	}
+	return mapRow{m: rec}, nil
+}
+
+func (t mmdbTable) len() int { return int(t.reader.Metadata.NodeCount) }
@@ -176,6 +176,92 @@ func (procs *Processors) Run(event *beat.Event) (*beat.Event, error) {
 	return event, nil
 }
 
+// RunMulti executes all processors serially, passing every event returned by
+// a processor to the next one. Processors implementing beat.MultiProcessor
+// can turn an event into zero or more events. If all events have been
+// dropped then an empty result is returned.
+func (procs *Processors) RunMulti(event *beat.Event) ([]*beat.Event, error) {
+	events := []*beat.Event{event}
+	for _, p := range procs.List {
+		out := make([]*beat.Event, 0, len(events))
+		for i, event := range events {
+			results, err := RunMulti(p, event)
+			out = append(out, results...)
+			if err != nil {
+				return append(out, events[i+1:]...), fmt.Errorf("failed applying processor %v: %w", p, err)
+			}
+		}
+		if len(out) == 0 {
+			// Drop.
+			return nil, nil
+		}
+		events = out
+	}
+	return events, nil
+}
+
+// RunMulti runs the processor on the event. If the processor implements
+// beat.MultiProcessor all resulting events are returned, otherwise the result
+// holds at most the one event returned by Run.
+func RunMulti(p beat.Processor, event *beat.Event) ([]*beat.Event, error) {
+	if m, ok := p.(beat.MultiProcessor); ok {
+		return m.RunMulti(event)
+	}
+	event, err := p.Run(event)
+	if event == nil {
+		return nil, err
+	}
+	return []*beat.Event{event}, err
+}
+
+// IsMulti reports whether the processor, or any processor it wraps, can
+// return more than one event. Lists and conditionals implement
+// beat.MultiProcessor in order to pass events through, but only need to be
+// run with RunMulti if they contain a multi-event processor.
+func IsMulti(p beat.Processor) bool {
+	switch p := p.(type) {
+	case *SafeProcessor:
+		return IsMulti(p.Processor)
+	case *WhenProcessor:
+		return IsMulti(p.p)
+	case *IfThenElseProcessor:
+		return IsMulti(p.then) || (p.els != nil && IsMulti(p.els))
+	case beat.ProcessorList:
+		for _, sub := range p.All() {
+			if IsMulti(sub) {
+				return true
+			}
+		}
+		return false
+	case beat.MultiProcessor:
+		return true
+	}
+	return false
+}
+
+// SetPipeline passes the pipeline and the configuration of the client using
+// the processor to the processor, and to any processor it wraps, that
+// implements beat.PublishingProcessor.
+func SetPipeline(p beat.Processor, pipeline beat.Pipeline, cfg beat.ClientConfig) {
+	switch p := p.(type) {
+	case *SafeProcessor:
+		SetPipeline(p.Processor, pipeline, cfg)
+	case *WhenProcessor:
+		SetPipeline(p.p, pipeline, cfg)
+	case *IfThenElseProcessor:
+		SetPipeline(p.then, pipeline, cfg)
+		if p.els != nil {
+			SetPipeline(p.els, pipeline, cfg)
+		}
+	case beat.ProcessorList:
+		for _, sub := range p.All() {
+			SetPipeline(sub, pipeline, cfg)
+		}
+	case beat.PublishingProcessor:
+		p.SetPipeline(pipeline, cfg)
+	}
+}
+
 func (procs Processors) String() string {
 	var s []string
 	for _, p := range procs.List {
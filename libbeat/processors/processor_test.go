@@ -34,6 +34,7 @@ import (
 	_ "github.com/elastic/beats/v7/libbeat/processors/decode_csv_fields"
 	_ "github.com/elastic/beats/v7/libbeat/processors/dissect"
 	_ "github.com/elastic/beats/v7/libbeat/processors/extract_array"
+	_ "github.com/elastic/beats/v7/libbeat/processors/split"
 	_ "github.com/elastic/beats/v7/libbeat/processors/urldecode"
 	conf "github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
@@ -580,6 +581,58 @@ const (
 	depth      = 3
 )
 
+func TestRunMulti(t *testing.T) {
+	logp.TestingSetup()
+
+	assert.False(t, processors.IsMulti(GetProcessors(t, []map[string]interface{}{
+		{"add_fields": map[string]interface{}{"fields": map[string]interface{}{"a": 1}}},
+	})))
+
+	t.Run("when", func(t *testing.T) {
+		procs := GetProcessors(t, []map[string]interface{}{
+			{
+				"split": map[string]interface{}{
+					"field": "list",
+					"when":  map[string]interface{}{"has_fields": []string{"list"}},
+				},
+			},
+			{"add_fields": map[string]interface{}{"target": "", "fields": map[string]interface{}{"a": 1}}},
+		})
+		require.True(t, processors.IsMulti(procs))
+
+		events, err := procs.RunMulti(&beat.Event{Fields: mapstr.M{"list": []interface{}{"x", "y"}}})
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, mapstr.M{"list": "x", "a": uint64(1)}, events[0].Fields)
+		assert.Equal(t, mapstr.M{"list": "y", "a": uint64(1)}, events[1].Fields)
+
+		events, err = procs.RunMulti(&beat.Event{Fields: mapstr.M{"message": "x"}})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, mapstr.M{"message": "x", "a": uint64(1)}, events[0].Fields)
+	})
+
+	t.Run("if then", func(t *testing.T) {
+		procs := GetProcessors(t, []map[string]interface{}{
+			{
+				"if":   map[string]interface{}{"has_fields": []string{"list"}},
+				"then": []map[string]interface{}{{"split": map[string]interface{}{"field": "list"}}},
+			},
+			{
+				"drop_event": map[string]interface{}{
+					"when": map[string]interface{}{"equals": map[string]interface{}{"list": "x"}},
+				},
+			},
+		})
+		require.True(t, processors.IsMulti(procs))
+
+		events, err := procs.RunMulti(&beat.Event{Fields: mapstr.M{"list": []interface{}{"x", "y"}}})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, mapstr.M{"list": "y"}, events[0].Fields)
+	})
+}
+
 func BenchmarkEventBackups(b *testing.B) {
 	// listing all the processors that revert changes in case of an error
 	yml := []map[string]interface{}{
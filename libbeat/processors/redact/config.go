@@ -0,0 +1,99 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Replacement strategies.
+const (
+	strategyMask = "mask"
+	strategyHash = "hash"
+	strategyDrop = "drop"
+)
+
+type config struct {
+	// Fields are the fields to scan. If empty, all fields of the event are
+	// scanned.
+	Fields []string `config:"fields"`
+	// Detectors are the names of the built-in detectors to use.
+	Detectors []string `config:"detectors"`
+	// Patterns are custom detectors.
+	Patterns []patternConfig `config:"patterns"`
+	// Strategy is mask, hash or drop.
+	Strategy string `config:"strategy"`
+	// Mask replaces detected values with the mask strategy.
+	Mask string     `config:"mask"`
+	Hash hashConfig `config:"hash"`
+	ID   string     `config:"id"`
+}
+
+// patternConfig is a custom detector.
+type patternConfig struct {
+	Name    string `config:"name"    validate:"required"`
+	Pattern string `config:"pattern" validate:"required"`
+}
+
+// hashConfig configures how values are hashed with the hash strategy.
+type hashConfig struct {
+	Method   string `config:"method"`
+	Encoding string `config:"encoding"`
+	Salt     string `config:"salt"`
+}
+
+func defaultConfig() config {
+	return config{
+		Strategy: strategyMask,
+		Mask:     "[REDACTED]",
+		Hash: hashConfig{
+			Method:   "sha256",
+			Encoding: "hex",
+		},
+	}
+}
+
+func (c *config) Validate() error {
+	switch strings.ToLower(c.Strategy) {
+	case strategyMask, strategyHash, strategyDrop:
+	default:
+		return fmt.Errorf("unsupported strategy: %s", c.Strategy)
+	}
+	for _, name := range c.Detectors {
+		if _, ok := builtinDetectors[name]; !ok {
+			return fmt.Errorf("unknown detector: %s", name)
+		}
+	}
+	seen := map[string]bool{}
+	for _, p := range c.Patterns {
+		if _, ok := builtinDetectors[p.Name]; ok || seen[p.Name] {
+			return fmt.Errorf("duplicate detector name: %s", p.Name)
+		}
+		seen[p.Name] = true
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return fmt.Errorf("failed to compile pattern %s: %w", p.Name, err)
+		}
+		if re.NumSubexp() > 1 {
+			return fmt.Errorf("pattern %s must not have more than one capture group", p.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package redact
+
+import (
+	"regexp"
+	"strings"
+)
+
+// detector finds sensitive values in strings.
+type detector struct {
+	name string
+	// re matches candidate values. If it has a capture group, only the group
+	// is redacted.
+	re *regexp.Regexp
+	// valid, if set, validates candidate values, e.g. with a checksum.
+	valid func(string) bool
+	// retry retries invalid candidates without their last space or dash
+	// separated group, and from the start of their next group, as the
+	// groups might belong to the text around the value.
+	retry bool
+}
+
+var builtinDetectors = map[string]detector{
+	"credit_card": {
+		name:   "credit_card",
+		re:     regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		valid:  validLuhn,
+		retry:  true,
+	},
+	"email": {
+		name: "email",
+		re:   regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`),
+	},
+	"iban": {
+		name:   "iban",
+		re:     regexp.MustCompile(`\b[A-Z]{2}[0-9]{2}(?: ?[A-Z0-9]){11,30}\b`),
+		valid:  validIBAN,
+		retry:  true,
+	},
+	"bearer_token": {
+		name: "bearer_token",
+		re:   regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]+=*)`),
+	},
+}
+
+// builtinNames is the order in which the built-in detectors are applied.
+var builtinNames = []string{"credit_card", "iban", "email", "bearer_token"}
+
+// find returns the start and end offsets of the values to redact in s.
+func (d *detector) find(s string) [][2]int {
+	if d.retry {
+		return d.rescan(s)
+	}
+	var found [][2]int
+	for _, m := range d.re.FindAllStringSubmatchIndex(s, -1) {
+		start, end := m[0], m[1]
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		if start == end {
+			continue
+		}
+		if d.valid != nil {
+			var ok bool
+			if end, ok = d.validate(s, start, end); !ok {
+				continue
+			}
+		}
+		found = append(found, [2]int{start, end})
+	}
+	return found
+}
+
+// rescan is find for detectors that retry invalid candidates. When a
+// candidate is invalid, s is scanned again from the start of its second
+// group, so that a value preceded by other groups, like the quantity in
+// "qty 12 4111 1111 1111 1111", is still found.
+func (d *detector) rescan(s string) [][2]int {
+	var found [][2]int
+	for pos := 0; pos < len(s); {
+		m := d.re.FindStringIndex(s[pos:])
+		if m == nil || m[0] == m[1] {
+			break
+		}
+		start, end := pos+m[0], pos+m[1]
+		if end, ok := d.validate(s, start, end); ok {
+			found = append(found, [2]int{start, end})
+			pos = end
+			continue
+		}
+		i := strings.IndexAny(s[start:end], " -")
+		if i < 0 {
+			pos = end
+			continue
+		}
+		pos = start + i + 1
+	}
+	return found
+}
+
+// validate validates the candidate s[start:end], and returns the end of the
+// valid value.
+func (d *detector) validate(s string, start, end int) (int, bool) {
+	for {
+		if d.valid(s[start:end]) {
+			return end, true
+		}
+		if !d.retry {
+			return 0, false
+		}
+		i := strings.LastIndexAny(s[start:end], " -")
+		if i <= 0 {
+			return 0, false
+		}
+		end = start + i
+	}
+}
+
+// digits returns the digits of s, ignoring spaces and dashes.
+func digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// validLuhn reports whether s is a 13 to 19 digit number with a valid Luhn
+// checksum.
+func validLuhn(s string) bool {
+	s = digits(s)
+	if len(s) < 13 || len(s) > 19 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		d := int(s[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// validIBAN reports whether s is an IBAN with a valid ISO 7064 mod 97-10
+// checksum.
+func validIBAN(s string) bool {
+	s = strings.ReplaceAll(s, " ", "")
+	if len(s) < 15 || len(s) > 34 {
+		return false
+	}
+	// Move the country code and check digits to the end, and convert
+	// letters to numbers (A=10, ..., Z=35).
+	s = s[4:] + s[:4]
+	rem := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			rem = (rem*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			rem = (rem*100 + int(c-'A') + 10) % 97
+		default:
+			return false
+		}
+	}
+	return rem == 1
+}
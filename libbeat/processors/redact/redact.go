@@ -0,0 +1,225 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package redact
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/atomic"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	"github.com/elastic/beats/v7/libbeat/processors/fingerprint"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+const (
+	procName = "redact"
+	logName  = "processor." + procName
+)
+
+// instanceID is used to assign each instance a unique monitoring namespace.
+var instanceID atomic.Uint32
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+}
+
+type processor struct {
+	config
+	log *logp.Logger
+
+	detectors []detector
+	hash      func(data ...[]byte) string
+
+	// redactions counts the redacted values per detector.
+	redactions map[string]*monitoring.Uint
+}
+
+// New constructs a new redact processor built from ucfg config.
+func New(cfg *conf.C) (beat.Processor, error) {
+	c := defaultConfig()
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, fmt.Errorf("fail to unpack the %v processor configuration: %w", procName, err)
+	}
+	c.Strategy = strings.ToLower(c.Strategy)
+
+	// Logging and metrics (each processor instance has a unique ID).
+	var (
+		id  = int(instanceID.Inc())
+		log = logp.NewLogger(logName).With("instance_id", id)
+		reg = monitoring.Default.NewRegistry(logName+"."+strconv.Itoa(id), monitoring.DoNotReport)
+	)
+	if c.ID != "" {
+		log = log.With("id", c.ID)
+	}
+
+	p := &processor{
+		config:     c,
+		log:        log,
+		redactions: map[string]*monitoring.Uint{},
+	}
+
+	names := c.Detectors
+	if len(names) == 0 && len(c.Patterns) == 0 {
+		names = builtinNames
+	}
+	for _, name := range names {
+		p.detectors = append(p.detectors, builtinDetectors[name])
+	}
+	for _, pat := range c.Patterns {
+		// The pattern has been validated with the config.
+		p.detectors = append(p.detectors, detector{name: pat.Name, re: regexp.MustCompile(pat.Pattern)})
+	}
+
+	counters := reg.NewRegistry("redactions")
+	for _, d := range p.detectors {
+		p.redactions[d.name] = monitoring.NewUint(counters, d.name)
+	}
+
+	if c.Strategy == strategyHash {
+		var err error
+		p.hash, err = fingerprint.NewHashEncoder(c.Hash.Method, c.Hash.Encoding)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure the %v processor hash: %w", procName, err)
+		}
+	}
+
+	return p, nil
+}
+
+func (p *processor) String() string {
+	cfg := p.config
+	cfg.Hash.Salt = ""
+	json, _ := json.Marshal(cfg)
+	return procName + "=" + string(json)
+}
+
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	if len(p.Fields) == 0 {
+		for k, v := range event.Fields {
+			if v, drop := p.redact(v); drop {
+				delete(event.Fields, k)
+			} else {
+				event.Fields[k] = v
+			}
+		}
+		return event, nil
+	}
+
+	for _, field := range p.Fields {
+		v, err := event.GetValue(field)
+		if err != nil {
+			// Nothing to redact.
+			continue
+		}
+		v, drop := p.redact(v)
+		if drop {
+			err = event.Delete(field)
+		} else {
+			_, err = event.PutValue(field, v)
+		}
+		if err != nil && !errors.Is(err, mapstr.ErrKeyNotFound) {
+			return event, fmt.Errorf("failed to redact field [%v]: %w", field, err)
+		}
+	}
+	return event, nil
+}
+
+// redact returns the value with all detected values redacted. If the drop
+// strategy is used and a value has been detected, drop is true.
+func (p *processor) redact(v interface{}) (_ interface{}, drop bool) {
+	switch v := v.(type) {
+	case string:
+		return p.redactString(v)
+	case []string:
+		// Arrays might be shared with other events, copy them.
+		out := make([]string, len(v))
+		for i, s := range v {
+			if out[i], drop = p.redactString(s); drop {
+				return nil, true
+			}
+		}
+		return out, false
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			if out[i], drop = p.redact(elem); drop {
+				return nil, true
+			}
+		}
+		return out, false
+	case mapstr.M:
+		return v, p.redactMap(v)
+	case map[string]interface{}:
+		return v, p.redactMap(v)
+	}
+	return v, false
+}
+
+// redactMap redacts the values of the map in place. Values of the map are
+// dropped by the drop strategy, so drop is always false.
+func (p *processor) redactMap(m map[string]interface{}) bool {
+	for k, v := range m {
+		if v, drop := p.redact(v); drop {
+			delete(m, k)
+		} else {
+			m[k] = v
+		}
+	}
+	return false
+}
+
+func (p *processor) redactString(s string) (_ string, drop bool) {
+	for i := range p.detectors {
+		d := &p.detectors[i]
+		found := d.find(s)
+		if len(found) == 0 {
+			continue
+		}
+		p.redactions[d.name].Add(uint64(len(found)))
+		if p.Strategy == strategyDrop {
+			return "", true
+		}
+
+		var b strings.Builder
+		last := 0
+		for _, f := range found {
+			b.WriteString(s[last:f[0]])
+			b.WriteString(p.replacement(s[f[0]:f[1]]))
+			last = f[1]
+		}
+		b.WriteString(s[last:])
+		s = b.String()
+	}
+	return s, false
+}
+
+func (p *processor) replacement(value string) string {
+	if p.Strategy == strategyHash {
+		return p.hash([]byte(p.Hash.Salt), []byte(value))
+	}
+	return p.Mask
+}
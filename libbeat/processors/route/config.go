@@ -0,0 +1,85 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package route
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/common/fmtstr"
+	"github.com/elastic/beats/v7/libbeat/conditions"
+)
+
+// Match modes.
+const (
+	matchFirst = "first"
+	matchAll   = "all"
+)
+
+type config struct {
+	// Path is the path of the rules file.
+	Path string `config:"path" validate:"required"`
+	// Match is first, to apply the first matching rule only, or all, to
+	// apply all matching rules in order.
+	Match string `config:"match"`
+	// ReloadPeriod is the interval at which the file is checked for
+	// changes. Zero disables reloading.
+	ReloadPeriod  time.Duration `config:"reload_period" validate:"min=0"`
+	IgnoreFailure bool          `config:"ignore_failure"`
+	ID            string        `config:"id"`
+}
+
+func defaultConfig() config {
+	return config{
+		Match:        matchFirst,
+		ReloadPeriod: time.Minute,
+	}
+}
+
+func (c *config) Validate() error {
+	switch c.Match {
+	case matchFirst, matchAll:
+		return nil
+	}
+	return fmt.Errorf("unsupported match mode %q", c.Match)
+}
+
+// rulesFile is the content of the rules file.
+type rulesFile struct {
+	Rules []ruleConfig `config:"rules"`
+}
+
+type ruleConfig struct {
+	Name string `config:"name" validate:"required"`
+	// When is the condition of the rule. Rules without a condition match
+	// all events.
+	When *conditions.Config `config:"when"`
+	// Metadata are the @metadata fields set by the rule.
+	Metadata map[string]*fmtstr.EventFormatString `config:"metadata" validate:"required"`
+}
+
+func (f *rulesFile) Validate() error {
+	names := make(map[string]bool, len(f.Rules))
+	for _, r := range f.Rules {
+		if names[r.Name] {
+			return fmt.Errorf("duplicate rule name %q", r.Name)
+		}
+		names[r.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	"github.com/elastic/beats/v7/libbeat/processors/internal/reloadable"
+	jsprocessor "github.com/elastic/beats/v7/libbeat/processors/script/javascript/module/processor"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+const (
+	procName = "route"
+	logName  = "processor." + procName
+)
+
+// instanceID is used to assign each instance a unique monitoring namespace.
+var instanceID atomic.Uint32
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+	jsprocessor.RegisterPlugin("Route", New)
+}
+
+type processor struct {
+	config
+	reg *monitoring.Registry
+
+	unmatched *monitoring.Uint
+
+	rules *reloadable.File[*ruleSet]
+}
+
+// New constructs a new route processor built from ucfg config.
+func New(cfg *conf.C) (beat.Processor, error) {
+	c := defaultConfig()
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, fmt.Errorf("fail to unpack the %v processor configuration: %w", procName, err)
+	}
+
+	return newRoute(c)
+}
+
+func newRoute(c config) (*processor, error) {
+	cfgwarn.Beta("The " + procName + " processor is beta.")
+
+	// Logging and metrics (each processor instance has a unique ID).
+	var (
+		id  = int(instanceID.Add(1))
+		log = logp.NewLogger(logName).With("instance_id", id)
+		reg = monitoring.Default.NewRegistry(logName+"."+strconv.Itoa(id), monitoring.DoNotReport)
+	)
+	if c.ID != "" {
+		log = log.With("id", c.ID)
+	}
+
+	rules, err := reloadable.New(reloadable.Config[*ruleSet]{
+		Name:         "routing rules",
+		Path:         c.Path,
+		ReloadPeriod: c.ReloadPeriod,
+		Load:         func(path string) (*ruleSet, error) { return loadRules(path, reg) },
+		LogFields:    func(s *ruleSet) []interface{} { return []interface{}{"rules", len(s.rules)} },
+	}, log)
+	if err != nil {
+		return nil, err
+	}
+
+	return &processor{
+		config:    c,
+		reg:       reg,
+		unmatched: monitoring.NewUint(reg, "unmatched"),
+		rules:     rules,
+	}, nil
+}
+
+func (p *processor) String() string {
+	json, _ := json.Marshal(p.config)
+	return procName + "=" + string(json)
+}
+
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	err := p.route(event)
+	if err == nil || p.IgnoreFailure {
+		return event, nil
+	}
+	return event, err
+}
+
+// route applies the matching rules to the event. In first mode only the
+// first matching rule is applied. In all mode every matching rule is
+// applied in order, so later rules override the fields set by earlier ones.
+func (p *processor) route(event *beat.Event) error {
+	matched := false
+	for _, r := range p.rules.Get().rules {
+		if !r.matches(event) {
+			continue
+		}
+		matched = true
+		r.hits.Inc()
+		if err := r.apply(event); err != nil {
+			return err
+		}
+		if p.Match == matchFirst {
+			return nil
+		}
+	}
+	if !matched {
+		p.unmatched.Inc()
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package route
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+func TestRoute(t *testing.T) {
+	logp.TestingSetup()
+
+	tests := map[string]struct {
+		config  mapstr.M
+		input   mapstr.M
+		want    mapstr.M
+		wantErr string
+	}{
+		"first match": {
+			config: mapstr.M{"path": "testdata/rules.yml", "match": "first"},
+			input:  mapstr.M{"service": mapstr.M{"name": "payments", "environment": "prod"}, "http": mapstr.M{"response": mapstr.M{"status_code": 503}}},
+			want:   mapstr.M{"raw_index": "payments-errors", "topic": "alerts"},
+		},
+		"first match with format string": {
+			config: mapstr.M{"path": "testdata/rules.yml", "match": "first"},
+			input:  mapstr.M{"service": mapstr.M{"name": "payments", "environment": "prod"}},
+			want:   mapstr.M{"raw_index": "payments-prod", "pipeline": "payments"},
+		},
+		"all matches": {
+			config: mapstr.M{"path": "testdata/rules.yml", "match": "all"},
+			input:  mapstr.M{"service": mapstr.M{"name": "payments", "environment": "prod"}, "http": mapstr.M{"response": mapstr.M{"status_code": 503}}},
+			want:   mapstr.M{"raw_index": "payments-prod", "pipeline": "payments", "topic": "logs"},
+		},
+		"rule without condition": {
+			config: mapstr.M{"path": "testdata/rules.yml", "match": "first"},
+			input:  mapstr.M{"service": mapstr.M{"name": "web"}},
+			want:   mapstr.M{"topic": "logs"},
+		},
+		"missing format field": {
+			config:  mapstr.M{"path": "testdata/rules.yml", "match": "first"},
+			input:   mapstr.M{"service": mapstr.M{"name": "payments"}},
+			wantErr: "failed to format metadata field raw_index of rule payments",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := New(conf.MustNewConfigFrom(test.config))
+			require.NoError(t, err)
+
+			event, err := p.Run(&beat.Event{Fields: test.input.Clone()})
+			if test.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), test.wantErr)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, event.Meta)
+		})
+	}
+}
+
+func TestRouteMetrics(t *testing.T) {
+	logp.TestingSetup()
+
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{"path": "testdata/errors.yml"}))
+	require.NoError(t, err)
+	for _, level := range []string{"error", "info", "error"} {
+		_, err := p.Run(&beat.Event{Fields: mapstr.M{"log": mapstr.M{"level": level}}})
+		require.NoError(t, err)
+	}
+
+	snapshot := mapstr.M(monitoring.CollectStructSnapshot(p.(*processor).reg, monitoring.Full, false))
+	hits, _ := snapshot.GetValue("rules.errors.hits")
+	assert.EqualValues(t, 2, hits)
+	assert.EqualValues(t, 1, snapshot["unmatched"])
+}
+
+func TestRouteReload(t *testing.T) {
+	logp.TestingSetup()
+
+	path := filepath.Join(t.TempDir(), "rules.yml")
+	require.NoError(t, os.WriteFile(path, []byte("rules:\n  - name: all\n    metadata.raw_index: old\n"), 0o644))
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{"path": path, "reload_period": "10ms"}))
+	require.NoError(t, err)
+
+	index := func() interface{} {
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{}})
+		require.NoError(t, err)
+		return event.Meta["raw_index"]
+	}
+	assert.Equal(t, "old", index())
+
+	// Files are replaced rather than rewritten, as an empty file holds
+	// valid rules. The modification time is moved forward so the change is
+	// detected on file systems with coarse modification times.
+	replace := func(content string, mtime time.Time) {
+		tmp := path + ".tmp"
+		require.NoError(t, os.WriteFile(tmp, []byte(content), 0o644))
+		require.NoError(t, os.Chtimes(tmp, mtime, mtime))
+		require.NoError(t, os.Rename(tmp, path))
+	}
+	later := time.Now().Add(time.Second)
+	replace("rules:\n  - name: all\n    metadata.raw_index: new\n", later)
+	assert.Eventually(t, func() bool { return index() == "new" }, 5*time.Second, 20*time.Millisecond)
+
+	// A broken file keeps the previous rules.
+	replace("rules:\n  - name: all\n    when.nope: {}\n    metadata.raw_index: broken\n", later.Add(time.Second))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, "new", index())
+}
+
+func TestLoadRulesErrors(t *testing.T) {
+	tests := map[string]struct {
+		rules   string
+		wantErr string
+	}{
+		"duplicate name": {
+			rules:   "rules:\n  - {name: a, metadata.topic: x}\n  - {name: a, metadata.topic: y}\n",
+			wantErr: `duplicate rule name "a"`,
+		},
+		"missing metadata": {
+			rules:   "rules:\n  - name: a\n",
+			wantErr: "missing required field",
+		},
+		"invalid condition": {
+			rules:   "rules:\n  - {name: a, when.nope: {}, metadata.topic: x}\n",
+			wantErr: "invalid condition of rule a",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rules.yml")
+			require.NoError(t, os.WriteFile(path, []byte(test.rules), 0o644))
+
+			_, err := New(conf.MustNewConfigFrom(mapstr.M{"path": path}))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), test.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	c := defaultConfig()
+	err := conf.MustNewConfigFrom(mapstr.M{"path": "rules.yml", "match": "some"}).Unpack(&c)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `unsupported match mode "some"`)
+	}
+}
@@ -0,0 +1,96 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package route
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/fmtstr"
+	"github.com/elastic/beats/v7/libbeat/conditions"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+// rule sets @metadata fields of the events matching its condition.
+type rule struct {
+	name      string
+	condition conditions.Condition
+	metadata  map[string]*fmtstr.EventFormatString
+	hits      *monitoring.Uint
+}
+
+func (r *rule) matches(event *beat.Event) bool {
+	return r.condition == nil || r.condition.Check(event)
+}
+
+// apply sets the @metadata fields of the rule.
+func (r *rule) apply(event *beat.Event) error {
+	for k, fs := range r.metadata {
+		v, err := fs.Run(event)
+		if err != nil {
+			return fmt.Errorf("failed to format metadata field %s of rule %s: %w", k, r.name, err)
+		}
+		if _, err := event.PutValue("@metadata."+k, v); err != nil {
+			return fmt.Errorf("failed to set metadata field %s of rule %s: %w", k, r.name, err)
+		}
+	}
+	return nil
+}
+
+// ruleSet is an ordered list of rules. Rule sets are immutable and can be
+// used concurrently.
+type ruleSet struct {
+	rules []*rule
+}
+
+// loadRules loads the rules file. The hit counters of the rules are created
+// in reg, or reused if they exist.
+func loadRules(path string, reg *monitoring.Registry) (*ruleSet, error) {
+	var f rulesFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := conf.NewConfigWithYAML(data, path)
+	if err == nil {
+		err = cfg.Unpack(&f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+
+	set := &ruleSet{}
+	for _, c := range f.Rules {
+		r := &rule{
+			name:     c.Name,
+			metadata: c.Metadata,
+			// Dots would nest the counters of rules.
+			hits: monitoring.NewUint(reg, "rules."+strings.ReplaceAll(c.Name, ".", "_")+".hits"),
+		}
+		if c.When != nil {
+			if r.condition, err = conditions.NewCondition(c.When); err != nil {
+				return nil, fmt.Errorf("failed to load %s: invalid condition of rule %s: %w", path, c.Name, err)
+			}
+		}
+		set.rules = append(set.rules, r)
+	}
+	return set, nil
+}
@@ -0,0 +1,186 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package split
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const procName = "split"
+
+// errSingleEvent is returned when the processor is run by a caller that does
+// not support multiple events.
+var errSingleEvent = errors.New("the split processor can only be used where processors can publish multiple events")
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+}
+
+type processor struct {
+	config
+}
+
+// New constructs a new split processor built from ucfg config.
+func New(cfg *conf.C) (beat.Processor, error) {
+	var c config
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, fmt.Errorf("fail to unpack the %v processor configuration: %w", procName, err)
+	}
+
+	cfgwarn.Beta("The " + procName + " processor is beta.")
+	return &processor{config: c}, nil
+}
+
+func (p *processor) String() string {
+	json, _ := json.Marshal(p.config)
+	return procName + "=" + string(json)
+}
+
+// Run returns the event unchanged. Splitting requires the processor to be
+// run with RunMulti.
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	return event, errSingleEvent
+}
+
+// RunMulti publishes one event per element of the source field. The
+// original event is returned unchanged if splitting fails.
+func (p *processor) RunMulti(event *beat.Event) ([]*beat.Event, error) {
+	events, err := p.split(event)
+	if err == nil {
+		return events, nil
+	}
+	if p.IgnoreFailure || (p.IgnoreMissing && errors.Is(err, mapstr.ErrKeyNotFound)) {
+		return []*beat.Event{event}, nil
+	}
+	return []*beat.Event{event}, err
+}
+
+func (p *processor) split(event *beat.Event) ([]*beat.Event, error) {
+	v, err := event.GetValue(p.Field)
+	if err != nil {
+		return nil, fmt.Errorf("split source field [%v] not found: %w", p.Field, err)
+	}
+	elems, err := p.elements(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(elems) == 0 {
+		// Nothing to split, keep the event as is.
+		return []*beat.Event{event}, nil
+	}
+
+	target := p.target()
+	if target != p.Field && !p.OverwriteKeys {
+		if _, err := event.GetValue(target); err == nil {
+			return nil, fmt.Errorf("split target field [%v] already exists", target)
+		}
+	}
+
+	// Remove the source field before copying the event, such that the
+	// elements are not copied into every event.
+	if err := event.Delete(p.Field); err != nil {
+		return nil, fmt.Errorf("failed to remove split source field [%v]: %w", p.Field, err)
+	}
+
+	events := make([]*beat.Event, len(elems))
+	for i, elem := range elems {
+		e := event
+		if i < len(elems)-1 {
+			e = event.Clone()
+		}
+		if err := p.put(e, target, elem, i); err != nil {
+			// Restore the original event.
+			_, _ = event.PutValue(p.Field, v)
+			return nil, err
+		}
+		events[i] = e
+	}
+	return events, nil
+}
+
+func (p *processor) put(event *beat.Event, target string, elem interface{}, index int) error {
+	if _, err := event.PutValue(target, elem); err != nil {
+		return fmt.Errorf("failed to write split element to target field [%v]: %w", target, err)
+	}
+	if p.IndexField != "" {
+		if _, err := event.PutValue(p.IndexField, index); err != nil {
+			return fmt.Errorf("failed to write split index to field [%v]: %w", p.IndexField, err)
+		}
+	}
+	return nil
+}
+
+// elements returns the elements of an array, or the parts of a string split
+// on the configured separator.
+func (p *processor) elements(v interface{}) ([]interface{}, error) {
+	var elems []interface{}
+	switch v := v.(type) {
+	case string:
+		if p.Separator == "" {
+			return nil, fmt.Errorf("split source field [%v] is a string, but no separator is configured", p.Field)
+		}
+		for _, s := range strings.Split(v, p.Separator) {
+			if s == "" && !p.KeepEmpty {
+				continue
+			}
+			elems = append(elems, s)
+		}
+		return elems, nil
+	case []interface{}:
+		elems = v
+	default:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("split source field [%v] has unsupported type %T", p.Field, v)
+		}
+		elems = make([]interface{}, rv.Len())
+		for i := range elems {
+			elems[i] = rv.Index(i).Interface()
+		}
+	}
+
+	if p.KeepEmpty {
+		return elems, nil
+	}
+	nonEmpty := elems[:0:0]
+	for _, elem := range elems {
+		if !isEmpty(elem) {
+			nonEmpty = append(nonEmpty, elem)
+		}
+	}
+	return nonEmpty, nil
+}
+
+// isEmpty reports whether an array element is nil or an empty string.
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	s, ok := v.(string)
+	return ok && s == ""
+}
@@ -0,0 +1,182 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package split
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestSplit(t *testing.T) {
+	tests := map[string]struct {
+		config   mapstr.M
+		fields   mapstr.M
+		expected []mapstr.M
+		err      string
+	}{
+		"array": {
+			config: mapstr.M{"field": "json.Records"},
+			fields: mapstr.M{
+				"json": mapstr.M{"Records": []interface{}{
+					mapstr.M{"eventName": "a"},
+					mapstr.M{"eventName": "b"},
+				}},
+				"cloud": "aws",
+			},
+			expected: []mapstr.M{
+				{"json": mapstr.M{"Records": mapstr.M{"eventName": "a"}}, "cloud": "aws"},
+				{"json": mapstr.M{"Records": mapstr.M{"eventName": "b"}}, "cloud": "aws"},
+			},
+		},
+		"target field and index": {
+			config: mapstr.M{"field": "json.Records", "target_field": "aws.cloudtrail", "index_field": "aws.record_index"},
+			fields: mapstr.M{
+				"json": mapstr.M{"Records": []interface{}{
+					mapstr.M{"eventName": "a"},
+					mapstr.M{"eventName": "b"},
+				}},
+			},
+			expected: []mapstr.M{
+				{"json": mapstr.M{}, "aws": mapstr.M{"cloudtrail": mapstr.M{"eventName": "a"}, "record_index": 0}},
+				{"json": mapstr.M{}, "aws": mapstr.M{"cloudtrail": mapstr.M{"eventName": "b"}, "record_index": 1}},
+			},
+		},
+		"typed array": {
+			config: mapstr.M{"field": "tags"},
+			fields: mapstr.M{"tags": []string{"a", "", "b"}},
+			expected: []mapstr.M{
+				{"tags": "a"},
+				{"tags": "b"},
+			},
+		},
+		"separator": {
+			config: mapstr.M{"field": "message", "separator": "\n"},
+			fields: mapstr.M{"message": "line 1\nline 2\n", "log": mapstr.M{"offset": 10}},
+			expected: []mapstr.M{
+				{"message": "line 1", "log": mapstr.M{"offset": 10}},
+				{"message": "line 2", "log": mapstr.M{"offset": 10}},
+			},
+		},
+		"keep empty": {
+			config: mapstr.M{"field": "message", "separator": ",", "keep_empty": true},
+			fields: mapstr.M{"message": "a,,b"},
+			expected: []mapstr.M{
+				{"message": "a"},
+				{"message": ""},
+				{"message": "b"},
+			},
+		},
+		"empty array": {
+			config:   mapstr.M{"field": "list"},
+			fields:   mapstr.M{"list": []interface{}{}},
+			expected: []mapstr.M{{"list": []interface{}{}}},
+		},
+		"string without separator": {
+			config:   mapstr.M{"field": "message"},
+			fields:   mapstr.M{"message": "a,b"},
+			expected: []mapstr.M{{"message": "a,b"}},
+			err:      "no separator is configured",
+		},
+		"missing": {
+			config:   mapstr.M{"field": "list"},
+			fields:   mapstr.M{"message": "a"},
+			expected: []mapstr.M{{"message": "a"}},
+			err:      "not found",
+		},
+		"ignore missing": {
+			config:   mapstr.M{"field": "list", "ignore_missing": true},
+			fields:   mapstr.M{"message": "a"},
+			expected: []mapstr.M{{"message": "a"}},
+		},
+		"target exists": {
+			config:   mapstr.M{"field": "list", "target_field": "item"},
+			fields:   mapstr.M{"list": []interface{}{1, 2}, "item": 0},
+			expected: []mapstr.M{{"list": []interface{}{1, 2}, "item": 0}},
+			err:      "already exists",
+		},
+		"overwrite keys": {
+			config: mapstr.M{"field": "list", "target_field": "item", "overwrite_keys": true},
+			fields: mapstr.M{"list": []interface{}{1, 2}, "item": 0},
+			expected: []mapstr.M{
+				{"item": 1},
+				{"item": 2},
+			},
+		},
+		"ignore failure": {
+			config:   mapstr.M{"field": "list", "ignore_failure": true},
+			fields:   mapstr.M{"list": 1},
+			expected: []mapstr.M{{"list": 1}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := New(conf.MustNewConfigFrom(test.config))
+			require.NoError(t, err)
+
+			events, err := p.(*processor).RunMulti(&beat.Event{Fields: test.fields})
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			var fields []mapstr.M
+			for _, e := range events {
+				fields = append(fields, e.Fields)
+			}
+			assert.Equal(t, test.expected, fields)
+		})
+	}
+}
+
+func TestSplitKeepsMetadata(t *testing.T) {
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{"field": "list"}))
+	require.NoError(t, err)
+
+	event := &beat.Event{
+		Meta:    mapstr.M{"_id": "abc"},
+		Fields:  mapstr.M{"list": []interface{}{"a", "b"}},
+		Private: 42,
+	}
+	events, err := p.(*processor).RunMulti(event)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	events[0].Meta["_id"] = "changed"
+	assert.Equal(t, "abc", events[1].Meta["_id"])
+	for _, e := range events {
+		assert.Equal(t, 42, e.Private)
+	}
+}
+
+func TestSplitRequiresMultiEvents(t *testing.T) {
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{"field": "list"}))
+	require.NoError(t, err)
+
+	event := &beat.Event{Fields: mapstr.M{"list": []interface{}{"a", "b"}}}
+	out, err := p.Run(event)
+	assert.ErrorIs(t, err, errSingleEvent)
+	assert.Same(t, event, out)
+}
@@ -0,0 +1,45 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package user_agent
+
+import "time"
+
+type config struct {
+	Field       string `config:"field"        validate:"required"`
+	TargetField string `config:"target_field" validate:"required"`
+	// RegexFile is the path of a file in the uap-core regexes.yaml format
+	// that replaces the embedded definitions.
+	RegexFile string `config:"regex_file"`
+	// ReloadPeriod is the interval at which the regex file is checked for
+	// changes. Zero disables reloading.
+	ReloadPeriod time.Duration `config:"reload_period" validate:"min=0"`
+	// CacheSize is the number of parsed user agents that are cached. Zero
+	// disables the cache.
+	CacheSize     int    `config:"cache_size" validate:"min=0"`
+	IgnoreMissing bool   `config:"ignore_missing"`
+	IgnoreFailure bool   `config:"ignore_failure"`
+	ID            string `config:"id"`
+}
+
+func defaultConfig() config {
+	return config{
+		Field:       "user_agent.original",
+		TargetField: "user_agent",
+		CacheSize:   1000,
+	}
+}
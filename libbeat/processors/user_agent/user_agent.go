@@ -0,0 +1,193 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package user_agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/libbeat/common/useragent"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	"github.com/elastic/beats/v7/libbeat/processors/internal/reloadable"
+	jsprocessor "github.com/elastic/beats/v7/libbeat/processors/script/javascript/module/processor"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const (
+	procName = "user_agent"
+	logName  = "processor." + procName
+)
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+	jsprocessor.RegisterPlugin("UserAgent", New)
+}
+
+type processor struct {
+	config
+
+	// parser uses the embedded definitions. It is nil if a regex file is
+	// configured.
+	parser *loadedParser
+	// regexFile is the parser of the regex file, if one is configured.
+	regexFile *reloadable.File[*loadedParser]
+}
+
+// loadedParser is a parser with its cache. The cache is replaced with the
+// parser, so results of previous definitions are never used.
+type loadedParser struct {
+	*useragent.Parser
+	cache *lru.Cache
+}
+
+// New constructs a new user_agent processor built from ucfg config.
+func New(cfg *conf.C) (beat.Processor, error) {
+	c := defaultConfig()
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, fmt.Errorf("fail to unpack the %v processor configuration: %w", procName, err)
+	}
+
+	return newUserAgent(c)
+}
+
+func newUserAgent(c config) (*processor, error) {
+	cfgwarn.Beta("The " + procName + " processor is beta.")
+
+	log := logp.NewLogger(logName)
+	if c.ID != "" {
+		log = log.With("instance_id", c.ID)
+	}
+
+	p := &processor{config: c}
+	if c.RegexFile == "" {
+		var err error
+		if p.parser, err = newParser(useragent.Default(), c.CacheSize); err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+
+	var err error
+	p.regexFile, err = reloadable.New(reloadable.Config[*loadedParser]{
+		Name:         "user agent definitions",
+		Path:         c.RegexFile,
+		ReloadPeriod: c.ReloadPeriod,
+		Load: func(path string) (*loadedParser, error) {
+			parser, err := useragent.Load(path)
+			if err != nil {
+				return nil, err
+			}
+			return newParser(parser, c.CacheSize)
+		},
+	}, log)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newParser returns parser with a cache of size entries, or without cache
+// if size is zero.
+func newParser(parser *useragent.Parser, size int) (*loadedParser, error) {
+	lp := &loadedParser{Parser: parser}
+	if size > 0 {
+		var err error
+		if lp.cache, err = lru.New(size); err != nil {
+			return nil, err
+		}
+	}
+	return lp, nil
+}
+
+func (p *processor) String() string {
+	json, _ := json.Marshal(p.config)
+	return procName + "=" + string(json)
+}
+
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	err := p.parse(event)
+	if err == nil || p.IgnoreFailure || (p.IgnoreMissing && errors.Is(err, mapstr.ErrKeyNotFound)) {
+		return event, nil
+	}
+	return event, err
+}
+
+func (p *processor) parse(event *beat.Event) error {
+	v, err := event.GetValue(p.Field)
+	if err != nil {
+		return fmt.Errorf("user_agent source field [%v] not found: %w", p.Field, err)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("user_agent source field [%v] is not a string", p.Field)
+	}
+
+	for k, v := range p.current().parse(s) {
+		if _, err := event.PutValue(p.TargetField+"."+k, v); err != nil {
+			return fmt.Errorf("failed to write user agent to target field [%v]: %w", p.TargetField, err)
+		}
+	}
+	return nil
+}
+
+// parse returns the ECS fields of the user agent s. The returned map must
+// not be modified, as it is shared through the cache.
+func (lp *loadedParser) parse(s string) map[string]interface{} {
+	if lp.cache != nil {
+		if v, ok := lp.cache.Get(s); ok {
+			return v.(map[string]interface{})
+		}
+	}
+
+	ua := lp.Parse(s)
+	fields := map[string]interface{}{
+		"name":        ua.Name,
+		"device.name": ua.Device,
+	}
+	if ua.Version != "" {
+		fields["version"] = ua.Version
+	}
+	if ua.OS.Name != "" {
+		fields["os.name"] = ua.OS.Name
+		fields["os.full"] = ua.OS.Full
+		if ua.OS.Version != "" {
+			fields["os.version"] = ua.OS.Version
+		}
+	}
+
+	if lp.cache != nil {
+		lp.cache.Add(s, fields)
+	}
+	return fields
+}
+
+// current returns the parser of the regex file, which is reloaded when it
+// changes, or the parser of the embedded definitions.
+func (p *processor) current() *loadedParser {
+	if p.regexFile != nil {
+		return p.regexFile.Get()
+	}
+	return p.parser
+}
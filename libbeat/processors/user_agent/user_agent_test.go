@@ -0,0 +1,170 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package user_agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const chromeWindows = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.109 Safari/537.36"
+
+func TestUserAgent(t *testing.T) {
+	logp.TestingSetup()
+
+	tests := map[string]struct {
+		config mapstr.M
+		input  mapstr.M
+		want   mapstr.M
+		err    bool
+	}{
+		"defaults": {
+			config: mapstr.M{},
+			input:  mapstr.M{"user_agent": mapstr.M{"original": chromeWindows}},
+			want: mapstr.M{"user_agent": mapstr.M{
+				"original": chromeWindows,
+				"name":     "Chrome",
+				"version":  "120.0.6099",
+				"os":       mapstr.M{"name": "Windows", "version": "10", "full": "Windows 10"},
+				"device":   mapstr.M{"name": "Other"},
+			}},
+		},
+		"no version or os": {
+			config: mapstr.M{"field": "ua", "target_field": "client.ua"},
+			input:  mapstr.M{"ua": "unknown"},
+			want: mapstr.M{
+				"ua":     "unknown",
+				"client": mapstr.M{"ua": mapstr.M{"name": "Other", "device": mapstr.M{"name": "Other"}}},
+			},
+		},
+		"regex file": {
+			config: mapstr.M{"field": "ua", "target_field": "ua_info", "regex_file": "testdata/regexes.yaml"},
+			input:  mapstr.M{"ua": "MyAgent/1.2"},
+			want: mapstr.M{
+				"ua":      "MyAgent/1.2",
+				"ua_info": mapstr.M{"name": "My Agent", "version": "1.2", "device": mapstr.M{"name": "Other"}},
+			},
+		},
+		"missing field": {
+			config: mapstr.M{},
+			input:  mapstr.M{"message": "hello"},
+			err:    true,
+		},
+		"ignore missing": {
+			config: mapstr.M{"ignore_missing": true},
+			input:  mapstr.M{"message": "hello"},
+			want:   mapstr.M{"message": "hello"},
+		},
+		"not a string": {
+			config: mapstr.M{"ignore_missing": true},
+			input:  mapstr.M{"user_agent": mapstr.M{"original": 1}},
+			err:    true,
+		},
+		"ignore failure": {
+			config: mapstr.M{"ignore_failure": true},
+			input:  mapstr.M{"user_agent": mapstr.M{"original": 1}},
+			want:   mapstr.M{"user_agent": mapstr.M{"original": 1}},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := New(conf.MustNewConfigFrom(test.config))
+			require.NoError(t, err)
+
+			event, err := p.Run(&beat.Event{Fields: test.input.Clone()})
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, event.Fields)
+		})
+	}
+}
+
+func TestUserAgentInvalidRegexFile(t *testing.T) {
+	_, err := New(conf.MustNewConfigFrom(mapstr.M{"regex_file": "testdata/invalid_regexes.yaml"}))
+	assert.Error(t, err)
+}
+
+func TestUserAgentCache(t *testing.T) {
+	logp.TestingSetup()
+
+	for _, size := range []int{0, 1} {
+		p, err := New(conf.MustNewConfigFrom(mapstr.M{"cache_size": size}))
+		require.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			for _, ua := range []string{chromeWindows, "curl/8.4.0"} {
+				event, err := p.Run(&beat.Event{Fields: mapstr.M{"user_agent": mapstr.M{"original": ua}}})
+				require.NoError(t, err)
+				// Events must not share the cached fields.
+				_, err = event.PutValue("user_agent.os.name", "changed")
+				require.NoError(t, err)
+			}
+		}
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{"user_agent": mapstr.M{"original": chromeWindows}}})
+		require.NoError(t, err)
+		v, _ := event.GetValue("user_agent.os.name")
+		assert.Equal(t, "Windows", v, "cache_size=%d", size)
+	}
+}
+
+func TestUserAgentReload(t *testing.T) {
+	logp.TestingSetup()
+
+	path := filepath.Join(t.TempDir(), "regexes.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("user_agent_parsers: [{regex: '(MyAgent)', family_replacement: 'Old'}]\n"), 0o644))
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{"field": "ua", "target_field": "ua_info", "regex_file": path, "reload_period": "10ms"}))
+	require.NoError(t, err)
+
+	name := func() interface{} {
+		event, err := p.Run(&beat.Event{Fields: mapstr.M{"ua": "MyAgent"}})
+		require.NoError(t, err)
+		v, _ := event.GetValue("ua_info.name")
+		return v
+	}
+	assert.Equal(t, "Old", name())
+
+	// Files are replaced rather than rewritten, as an empty file holds
+	// valid definitions. The modification time is moved forward so the
+	// change is detected on file systems with coarse modification times.
+	replace := func(content string, mtime time.Time) {
+		tmp := path + ".tmp"
+		require.NoError(t, os.WriteFile(tmp, []byte(content), 0o644))
+		require.NoError(t, os.Chtimes(tmp, mtime, mtime))
+		require.NoError(t, os.Rename(tmp, path))
+	}
+	later := time.Now().Add(time.Second)
+	replace("user_agent_parsers: [{regex: '(MyAgent)', family_replacement: 'New'}]\n", later)
+	assert.Eventually(t, func() bool { return name() == "New" }, 5*time.Second, 20*time.Millisecond)
+
+	// Broken definitions keep the previous parser.
+	replace("user_agent_parsers: [{regex: '(?<=a)b'}]\n", later.Add(time.Second))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, "New", name())
+}
@@ -0,0 +1,95 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package validate_schema
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Actions taken on invalid events.
+const (
+	actionCoerce     = "coerce"
+	actionTag        = "tag"
+	actionDeadLetter = "dead_letter"
+	actionDrop       = "drop"
+)
+
+type config struct {
+	// Fields selects a fields.yml schema. It is used when JSONSchema is not
+	// set.
+	Fields fieldsConfig `config:"fields"`
+	// JSONSchema selects a JSON Schema.
+	JSONSchema jsonSchemaConfig `config:"json_schema"`
+	// IgnoreUnexpected accepts fields that are not defined in a fields.yml
+	// schema.
+	IgnoreUnexpected bool `config:"ignore_unexpected_fields"`
+	// Action is the action taken on invalid events.
+	Action string `config:"action"`
+	// TargetField is the field the violations of invalid events are
+	// written to.
+	TargetField string `config:"target_field"`
+	// DeadLetterIndex is the index invalid events are sent to with the
+	// dead_letter action.
+	DeadLetterIndex string `config:"dead_letter_index"`
+	IgnoreFailure   bool   `config:"ignore_failure"`
+	ID              string `config:"id"`
+}
+
+type fieldsConfig struct {
+	// Path is the path of a fields.yml file.
+	Path string `config:"path"`
+	// Beat is the name of the Beat whose fields are used when no path is
+	// set. It defaults to the running Beat.
+	Beat string `config:"beat"`
+}
+
+type jsonSchemaConfig struct {
+	// Path is the path of a JSON Schema file.
+	Path string `config:"path"`
+}
+
+func defaultConfig() config {
+	return config{
+		Action:      actionTag,
+		TargetField: "event.validation_errors",
+	}
+}
+
+func (c *config) Validate() error {
+	switch c.Action {
+	case actionCoerce:
+		if c.JSONSchema.Path != "" {
+			return errors.New("the coerce action is not supported with json_schema")
+		}
+	case actionDeadLetter:
+		if c.DeadLetterIndex == "" {
+			return errors.New("dead_letter_index is required for the dead_letter action")
+		}
+	case actionTag, actionDrop:
+	default:
+		return fmt.Errorf("unsupported action %q", c.Action)
+	}
+	if c.JSONSchema.Path != "" && (c.Fields.Path != "" || c.Fields.Beat != "") {
+		return errors.New("fields and json_schema can not be used together")
+	}
+	if c.Action != actionDrop && c.TargetField == "" {
+		return errors.New("target_field is required")
+	}
+	return nil
+}
@@ -0,0 +1,335 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package validate_schema
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/asset"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/mapping"
+	"github.com/elastic/beats/v7/libbeat/processors/convert"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// fieldsSchema validates events against fields.yml definitions.
+type fieldsSchema struct {
+	// fields are the fields and groups by their full name.
+	fields map[string]*mapping.Field
+	// wildcards are the fields whose name contains wildcards.
+	wildcards []wildcardField
+
+	ignoreUnexpected bool
+}
+
+type wildcardField struct {
+	pattern string
+	field   *mapping.Field
+}
+
+// loadFields loads the fields.yml file of c, or the fields registered by a
+// Beat.
+func loadFields(c fieldsConfig) (mapping.Fields, error) {
+	if c.Path != "" {
+		return mapping.LoadFieldsYaml(c.Path)
+	}
+
+	name := c.Beat
+	if name == "" {
+		// Beats only register their own fields.
+		if len(asset.FieldsRegistry) != 1 {
+			return nil, errors.New("can not determine the fields of the running Beat, set fields.path or fields.beat")
+		}
+		for name = range asset.FieldsRegistry {
+		}
+	}
+	raw, err := asset.GetFields(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the fields of %s: %w", name, err)
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("no fields are registered for %s", name)
+	}
+	return mapping.LoadFields(raw)
+}
+
+func newFieldsSchema(fields mapping.Fields, ignoreUnexpected bool) *fieldsSchema {
+	s := &fieldsSchema{
+		fields:           map[string]*mapping.Field{},
+		ignoreUnexpected: ignoreUnexpected,
+	}
+	s.index("", fields)
+	return s
+}
+
+func (s *fieldsSchema) index(prefix string, fields mapping.Fields) {
+	for i := range fields {
+		f := &fields[i]
+		name := f.Name
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		if strings.Contains(name, "*") {
+			s.wildcards = append(s.wildcards, wildcardField{pattern: name, field: f})
+		} else {
+			s.fields[name] = f
+		}
+		s.index(name, f.Fields)
+	}
+}
+
+func (s *fieldsSchema) validate(event *beat.Event, coerce bool) []violation {
+	flat := event.Fields.Flatten()
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var violations []violation
+	for _, k := range keys {
+		v := flat[k]
+		typ, ok := s.lookup(k)
+		if !ok {
+			if !s.ignoreUnexpected {
+				violations = append(violations, violation{field: k, message: "unexpected field"})
+			}
+			continue
+		}
+		if typ == "alias" {
+			violations = append(violations, violation{field: k, message: "alias fields can not be written"})
+			continue
+		}
+		if matchesType(typ, v) {
+			continue
+		}
+		if coerce {
+			if c, err := coerceValue(typ, v); err == nil {
+				if _, err := event.PutValue(k, c); err == nil {
+					continue
+				}
+			}
+		}
+		violations = append(violations, violation{
+			field:   k,
+			message: fmt.Sprintf("expected %s, got %s", typ, jsonType(v)),
+		})
+	}
+	return violations
+}
+
+// lookup returns the type of the values of a flattened key. Keys under
+// objects without defined sub-fields have the object type of the object.
+func (s *fieldsSchema) lookup(key string) (string, bool) {
+	if f, ok := s.fields[key]; ok {
+		return fieldType(f), true
+	}
+	for _, w := range s.wildcards {
+		if ok, _ := path.Match(w.pattern, key); ok {
+			return fieldType(w.field), true
+		}
+	}
+	for i := strings.LastIndexByte(key, '.'); i > 0; i = strings.LastIndexByte(key[:i], '.') {
+		f, ok := s.fields[key[:i]]
+		if !ok {
+			continue
+		}
+		if isOpaque(f) {
+			return f.ObjectType, true
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// fieldType returns the type of a field, defaulting to keyword as in
+// templates.
+func fieldType(f *mapping.Field) string {
+	switch {
+	case f.Type != "":
+		return f.Type
+	case len(f.Fields) > 0:
+		return "group"
+	default:
+		return "keyword"
+	}
+}
+
+// isOpaque returns whether the sub-fields of f are not defined by the
+// schema.
+func isOpaque(f *mapping.Field) bool {
+	if f.Enabled != nil && !*f.Enabled {
+		return true
+	}
+	switch fieldType(f) {
+	case "object", "flattened", "nested", "histogram", "aggregate_metric_double", "geo_point", "geo_shape":
+		return len(f.Fields) == 0
+	}
+	return false
+}
+
+// matchesType returns whether v can be indexed as a field of type typ. Each
+// element of arrays must match. An empty type matches all values.
+func matchesType(typ string, v interface{}) bool {
+	if v == nil || typ == "" {
+		return true
+	}
+	if rv := reflect.ValueOf(v); isArray(rv) {
+		for i := 0; i < rv.Len(); i++ {
+			if !matchesType(typ, rv.Index(i).Interface()) {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch typ {
+	case "keyword", "constant_keyword", "wildcard", "text", "match_only_text", "version", "binary":
+		_, ok := v.(string)
+		return ok
+	case "long", "integer", "short", "byte", "unsigned_long":
+		return isInteger(v)
+	case "float", "half_float", "double", "scaled_float":
+		return isNumber(v)
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "date", "date_nanos":
+		switch v.(type) {
+		case string, time.Time, common.Time:
+			return true
+		}
+		return isNumber(v)
+	case "ip":
+		switch v := v.(type) {
+		case net.IP:
+			return true
+		case string:
+			return net.ParseIP(v) != nil
+		}
+		return false
+	case "group", "object", "nested", "flattened":
+		_, ok := tryToMap(v)
+		return ok
+	}
+	return true
+}
+
+// isArray returns whether rv is an array of values. Byte slices are single
+// values.
+func isArray(rv reflect.Value) bool {
+	return rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8
+}
+
+func isInteger(v interface{}) bool {
+	switch v := v.(type) {
+	case float32:
+		return float32(int64(v)) == v
+	case float64:
+		return float64(int64(v)) == v
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func isNumber(v interface{}) bool {
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Float32, reflect.Float64:
+		return true
+	}
+	return isInteger(v)
+}
+
+func tryToMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case mapstr.M:
+		return m, true
+	case map[string]interface{}:
+		return m, true
+	}
+	return nil, false
+}
+
+// coerceValue converts v, or each element of v, to the type typ.
+func coerceValue(typ string, v interface{}) (interface{}, error) {
+	var name string
+	switch typ {
+	case "keyword", "constant_keyword", "wildcard", "text", "match_only_text", "version":
+		name = "string"
+	case "long", "short", "byte", "unsigned_long":
+		name = "long"
+	case "integer":
+		name = "integer"
+	case "float", "half_float":
+		name = "float"
+	case "double", "scaled_float":
+		name = "double"
+	case "boolean":
+		name = "boolean"
+	default:
+		return nil, fmt.Errorf("values can not be converted to %s", typ)
+	}
+	transform, err := convert.NewTransform(name)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := reflect.ValueOf(v)
+	if !isArray(rv) {
+		return transform(v)
+	}
+	out := make([]interface{}, rv.Len())
+	for i := range out {
+		if out[i], err = transform(rv.Index(i).Interface()); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// jsonType returns the JSON type of v for messages.
+func jsonType(v interface{}) string {
+	if _, ok := tryToMap(v); ok {
+		return "object"
+	}
+	switch v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	if isArray(reflect.ValueOf(v)) {
+		return "array"
+	}
+	if isNumber(v) {
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
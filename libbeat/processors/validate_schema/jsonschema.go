@@ -0,0 +1,99 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package validate_schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+)
+
+// jsonSchema validates events against a JSON Schema.
+type jsonSchema struct {
+	schema *jsonschema.Schema
+}
+
+func loadJSONSchema(c jsonSchemaConfig) (*jsonSchema, error) {
+	schema, err := jsonschema.Compile(c.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile JSON Schema %s: %w", c.Path, err)
+	}
+	return &jsonSchema{schema: schema}, nil
+}
+
+func (s *jsonSchema) validate(event *beat.Event, _ bool) []violation {
+	// The schema only accepts JSON types, so the fields are encoded
+	// and decoded.
+	data, err := json.Marshal(event.Fields)
+	if err != nil {
+		return []violation{{message: fmt.Sprintf("failed to encode fields: %v", err)}}
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return []violation{{message: fmt.Sprintf("failed to decode fields: %v", err)}}
+	}
+
+	err = s.schema.Validate(doc)
+	if err == nil {
+		return nil
+	}
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return []violation{{message: err.Error()}}
+	}
+	var violations []violation
+	collectViolations(verr, &violations)
+	return violations
+}
+
+// collectViolations adds the leaf errors of err to violations.
+func collectViolations(err *jsonschema.ValidationError, violations *[]violation) {
+	if len(err.Causes) == 0 {
+		*violations = append(*violations, violation{
+			field:   pointerToField(err.InstanceLocation),
+			message: err.Message,
+		})
+		return
+	}
+	for _, c := range err.Causes {
+		collectViolations(c, violations)
+	}
+}
+
+var unescapePointer = strings.NewReplacer("~1", "/", "~0", "~")
+
+// pointerToField converts a JSON pointer to a dotted field name.
+func pointerToField(ptr string) string {
+	ptr = strings.TrimPrefix(ptr, "/")
+	if ptr == "" {
+		return ""
+	}
+	tokens := strings.Split(ptr, "/")
+	for i, t := range tokens {
+		tokens[i] = unescapePointer.Replace(t)
+	}
+	return strings.Join(tokens, ".")
+}
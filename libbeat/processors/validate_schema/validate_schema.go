@@ -0,0 +1,223 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package validate_schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/beat/events"
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	jsprocessor "github.com/elastic/beats/v7/libbeat/processors/script/javascript/module/processor"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+const (
+	procName = "validate_schema"
+	logName  = "processor." + procName
+
+	// maxFieldMetrics is the maximum number of fields with violation
+	// counters. Violations of other fields are counted together.
+	maxFieldMetrics = 1000
+)
+
+// instanceID is used to assign each instance a unique monitoring namespace.
+var instanceID atomic.Uint32
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+	jsprocessor.RegisterPlugin("ValidateSchema", New)
+}
+
+// schema validates events.
+type schema interface {
+	// validate returns the violations of the event. When coerce is set,
+	// values of the wrong type are converted if possible, and are not
+	// reported.
+	validate(event *beat.Event, coerce bool) []violation
+}
+
+// violation is a schema violation of an event.
+type violation struct {
+	// field is the name of the invalid field. It is empty for violations
+	// of the whole event.
+	field   string
+	message string
+}
+
+func (v violation) String() string {
+	if v.field == "" {
+		return v.message
+	}
+	return v.field + ": " + v.message
+}
+
+type metrics struct {
+	reg *monitoring.Registry
+
+	valid   *monitoring.Uint
+	invalid *monitoring.Uint
+	dropped *monitoring.Uint
+	// root counts the violations of whole events, other the violations
+	// of fields beyond maxFieldMetrics.
+	root  *monitoring.Uint
+	other *monitoring.Uint
+
+	mu     sync.Mutex
+	fields map[string]*monitoring.Uint
+}
+
+// countViolation increments the violation counter of field.
+func (m *metrics) countViolation(field string) {
+	if field == "" {
+		m.root.Inc()
+		return
+	}
+
+	m.mu.Lock()
+	c, ok := m.fields[field]
+	if !ok && len(m.fields) < maxFieldMetrics {
+		// Dots would nest the counters of fields.
+		c = monitoring.NewUint(m.reg, "violations.fields."+strings.ReplaceAll(field, ".", "_"))
+		m.fields[field] = c
+	}
+	m.mu.Unlock()
+
+	if c == nil {
+		m.other.Inc()
+		return
+	}
+	c.Inc()
+}
+
+type processor struct {
+	config
+	log    *logp.Logger
+	schema schema
+	metrics
+}
+
+// New constructs a new validate_schema processor built from ucfg config.
+func New(cfg *conf.C) (beat.Processor, error) {
+	c := defaultConfig()
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, fmt.Errorf("fail to unpack the %v processor configuration: %w", procName, err)
+	}
+
+	return newValidateSchema(c)
+}
+
+func newValidateSchema(c config) (*processor, error) {
+	cfgwarn.Beta("The " + procName + " processor is beta.")
+
+	var s schema
+	if c.JSONSchema.Path != "" {
+		js, err := loadJSONSchema(c.JSONSchema)
+		if err != nil {
+			return nil, err
+		}
+		s = js
+	} else {
+		fields, err := loadFields(c.Fields)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the fields of the %v processor: %w", procName, err)
+		}
+		s = newFieldsSchema(fields, c.IgnoreUnexpected)
+	}
+
+	// Logging and metrics (each processor instance has a unique ID).
+	var (
+		id  = int(instanceID.Add(1))
+		log = logp.NewLogger(logName).With("instance_id", id)
+		reg = monitoring.Default.NewRegistry(logName+"."+strconv.Itoa(id), monitoring.DoNotReport)
+	)
+	if c.ID != "" {
+		log = log.With("id", c.ID)
+	}
+
+	return &processor{
+		config: c,
+		log:    log,
+		schema: s,
+		metrics: metrics{
+			reg:     reg,
+			valid:   monitoring.NewUint(reg, "events.valid"),
+			invalid: monitoring.NewUint(reg, "events.invalid"),
+			dropped: monitoring.NewUint(reg, "events.dropped"),
+			root:    monitoring.NewUint(reg, "violations.root"),
+			other:   monitoring.NewUint(reg, "violations.other"),
+			fields:  map[string]*monitoring.Uint{},
+		},
+	}, nil
+}
+
+func (p *processor) String() string {
+	json, _ := json.Marshal(p.config)
+	return procName + "=" + string(json)
+}
+
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	violations := p.schema.validate(event, p.Action == actionCoerce)
+	if len(violations) == 0 {
+		p.valid.Inc()
+		return event, nil
+	}
+
+	p.invalid.Inc()
+	for _, v := range violations {
+		p.countViolation(v.field)
+	}
+	if p.Action == actionDrop {
+		p.dropped.Inc()
+		p.log.Debugw("Dropped invalid event", "violations", len(violations))
+		return nil, nil
+	}
+
+	err := p.tag(event, violations)
+	if err == nil || p.IgnoreFailure {
+		return event, nil
+	}
+	return event, err
+}
+
+// tag writes the violations to the target field, and routes the event to the
+// dead letter index with the dead_letter action.
+func (p *processor) tag(event *beat.Event, violations []violation) error {
+	if p.Action == actionDeadLetter {
+		if _, err := event.PutValue("@metadata."+events.FieldMetaRawIndex, p.DeadLetterIndex); err != nil {
+			return fmt.Errorf("failed to set the dead letter index: %w", err)
+		}
+	}
+
+	msgs := make([]string, len(violations))
+	for i, v := range violations {
+		msgs[i] = v.String()
+	}
+	if _, err := event.PutValue(p.TargetField, msgs); err != nil {
+		return fmt.Errorf("failed to write validation errors to target field [%v]: %w", p.TargetField, err)
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package validate_schema
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/asset"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+func TestValidateFields(t *testing.T) {
+	logp.TestingSetup()
+
+	tests := map[string]struct {
+		config   mapstr.M
+		input    mapstr.M
+		want     mapstr.M
+		wantMeta mapstr.M
+	}{
+		"valid": {
+			config: mapstr.M{"fields.path": "testdata/fields.yml"},
+			input: mapstr.M{
+				"http":    mapstr.M{"response": mapstr.M{"status_code": 200}, "request": mapstr.M{"method": "GET"}},
+				"source":  mapstr.M{"ip": "10.1.2.3"},
+				"message": "hello",
+				"tags":    []string{"a", "b"},
+				"labels":  mapstr.M{"env": "prod"},
+				"metrics": mapstr.M{"cpu": 0.5, "load": mapstr.M{"1m": 2}},
+				"raw":     mapstr.M{"anything": mapstr.M{"goes": true}},
+				"success": false,
+			},
+			want: mapstr.M{
+				"http":    mapstr.M{"response": mapstr.M{"status_code": 200}, "request": mapstr.M{"method": "GET"}},
+				"source":  mapstr.M{"ip": "10.1.2.3"},
+				"message": "hello",
+				"tags":    []string{"a", "b"},
+				"labels":  mapstr.M{"env": "prod"},
+				"metrics": mapstr.M{"cpu": 0.5, "load": mapstr.M{"1m": 2}},
+				"raw":     mapstr.M{"anything": mapstr.M{"goes": true}},
+				"success": false,
+			},
+		},
+		"tag": {
+			config: mapstr.M{"fields.path": "testdata/fields.yml"},
+			input: mapstr.M{
+				"http":        mapstr.M{"response": mapstr.M{"status_code": "200"}},
+				"source":      mapstr.M{"ip": "not-an-ip"},
+				"labels":      mapstr.M{"count": 3},
+				"tags":        []interface{}{"a", 1},
+				"unknown":     "x",
+				"status_code": 200,
+			},
+			want: mapstr.M{
+				"http":        mapstr.M{"response": mapstr.M{"status_code": "200"}},
+				"source":      mapstr.M{"ip": "not-an-ip"},
+				"labels":      mapstr.M{"count": 3},
+				"tags":        []interface{}{"a", 1},
+				"unknown":     "x",
+				"status_code": 200,
+				"event": mapstr.M{"validation_errors": []string{
+					"http.response.status_code: expected long, got string",
+					"labels.count: expected keyword, got number",
+					"source.ip: expected ip, got string",
+					"status_code: alias fields can not be written",
+					"tags: expected keyword, got array",
+					"unknown: unexpected field",
+				}},
+			},
+		},
+		"ignore unexpected fields": {
+			config: mapstr.M{"fields.path": "testdata/fields.yml", "ignore_unexpected_fields": true},
+			input:  mapstr.M{"unknown": "x", "http": mapstr.M{"unknown": "y"}},
+			want:   mapstr.M{"unknown": "x", "http": mapstr.M{"unknown": "y"}},
+		},
+		"group value": {
+			config: mapstr.M{"fields.path": "testdata/fields.yml"},
+			input:  mapstr.M{"http": "x"},
+			want: mapstr.M{
+				"http":  "x",
+				"event": mapstr.M{"validation_errors": []string{"http: expected group, got string"}},
+			},
+		},
+		"coerce": {
+			config: mapstr.M{"fields.path": "testdata/fields.yml", "action": "coerce"},
+			input: mapstr.M{
+				"http":    mapstr.M{"response": mapstr.M{"status_code": "200"}},
+				"tags":    []interface{}{"a", 1},
+				"success": "true",
+				"source":  mapstr.M{"ip": "not-an-ip"},
+			},
+			want: mapstr.M{
+				"http":    mapstr.M{"response": mapstr.M{"status_code": int64(200)}},
+				"tags":    []interface{}{"a", "1"},
+				"success": true,
+				"source":  mapstr.M{"ip": "not-an-ip"},
+				"event":   mapstr.M{"validation_errors": []string{"source.ip: expected ip, got string"}},
+			},
+		},
+		"dead letter": {
+			config: mapstr.M{"fields.path": "testdata/fields.yml", "action": "dead_letter", "dead_letter_index": "dead-letter", "target_field": "error.message"},
+			input:  mapstr.M{"unknown": "x"},
+			want: mapstr.M{
+				"unknown": "x",
+				"error":   mapstr.M{"message": []string{"unknown: unexpected field"}},
+			},
+			wantMeta: mapstr.M{"raw_index": "dead-letter"},
+		},
+		"drop": {
+			config: mapstr.M{"fields.path": "testdata/fields.yml", "action": "drop"},
+			input:  mapstr.M{"unknown": "x"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := New(conf.MustNewConfigFrom(test.config))
+			require.NoError(t, err)
+
+			event, err := p.Run(&beat.Event{Fields: test.input})
+			require.NoError(t, err)
+			if test.want == nil {
+				assert.Nil(t, event)
+				return
+			}
+			assert.Equal(t, test.want, event.Fields)
+			assert.Equal(t, test.wantMeta, event.Meta)
+		})
+	}
+}
+
+func TestValidateJSONSchema(t *testing.T) {
+	logp.TestingSetup()
+
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{"json_schema.path": "testdata/schema.json"}))
+	require.NoError(t, err)
+
+	event, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "hello", "http": mapstr.M{"response": mapstr.M{"status_code": 200}}}})
+	require.NoError(t, err)
+	assert.NotContains(t, event.Fields, "event")
+
+	event, err = p.Run(&beat.Event{Fields: mapstr.M{"http": mapstr.M{"response": mapstr.M{"status_code": "200"}}, "unknown": 1}})
+	require.NoError(t, err)
+	errs, err := event.GetValue("event.validation_errors")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"missing properties: 'message'",
+		"additionalProperties 'unknown' not allowed",
+		"http.response.status_code: expected integer, but got string",
+	}, errs)
+}
+
+func TestValidateBeatFields(t *testing.T) {
+	logp.TestingSetup()
+
+	fields, err := os.ReadFile("testdata/fields.yml")
+	require.NoError(t, err)
+	data, err := asset.EncodeData(string(fields))
+	require.NoError(t, err)
+	require.NoError(t, asset.SetFields("testbeat", "test", asset.BeatFieldsPri, func() string { return data }))
+	t.Cleanup(func() { delete(asset.FieldsRegistry, "testbeat") })
+
+	for name, cfg := range map[string]mapstr.M{
+		"default beat": {},
+		"named beat":   {"fields.beat": "testbeat"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p, err := New(conf.MustNewConfigFrom(cfg))
+			require.NoError(t, err)
+
+			event, err := p.Run(&beat.Event{Fields: mapstr.M{"success": "yes"}})
+			require.NoError(t, err)
+			errs, _ := event.GetValue("event.validation_errors")
+			assert.Equal(t, []string{"success: expected boolean, got string"}, errs)
+		})
+	}
+
+	_, err = New(conf.MustNewConfigFrom(mapstr.M{"fields.beat": "otherbeat"}))
+	assert.ErrorContains(t, err, "no fields are registered for otherbeat")
+}
+
+func TestValidateMetrics(t *testing.T) {
+	logp.TestingSetup()
+
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{"fields.path": "testdata/fields.yml"}))
+	require.NoError(t, err)
+	for _, fields := range []mapstr.M{
+		{"success": true},
+		{"success": "yes", "http": mapstr.M{"response": mapstr.M{"status_code": "x"}}},
+		{"success": "no"},
+	} {
+		_, err := p.Run(&beat.Event{Fields: fields})
+		require.NoError(t, err)
+	}
+
+	snapshot := mapstr.M(monitoring.CollectStructSnapshot(p.(*processor).reg, monitoring.Full, false))
+	for k, want := range map[string]int64{
+		"events.valid":                                1,
+		"events.invalid":                              2,
+		"violations.fields.success":                   2,
+		"violations.fields.http_response_status_code": 1,
+		"violations.root":                             0,
+	} {
+		got, err := snapshot.GetValue(k)
+		if assert.NoError(t, err, k) {
+			assert.EqualValues(t, want, got, k)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		config  mapstr.M
+		wantErr string
+	}{
+		"unknown action": {
+			config:  mapstr.M{"action": "fix"},
+			wantErr: `unsupported action "fix"`,
+		},
+		"coerce with json schema": {
+			config:  mapstr.M{"action": "coerce", "json_schema.path": "schema.json"},
+			wantErr: "the coerce action is not supported with json_schema",
+		},
+		"dead letter without index": {
+			config:  mapstr.M{"action": "dead_letter"},
+			wantErr: "dead_letter_index is required for the dead_letter action",
+		},
+		"fields and json schema": {
+			config:  mapstr.M{"fields.path": "fields.yml", "json_schema.path": "schema.json"},
+			wantErr: "fields and json_schema can not be used together",
+		},
+		"valid": {
+			config: mapstr.M{"action": "dead_letter", "dead_letter_index": "dead-letter"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := defaultConfig()
+			err := conf.MustNewConfigFrom(test.config).Unpack(&c)
+			if test.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), test.wantErr)
+			}
+		})
+	}
+}
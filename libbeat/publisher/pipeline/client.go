@@ -33,6 +33,8 @@ import (
 type client struct {
 	logger     *logp.Logger
 	processors beat.Processor
+	multi      beat.MultiProcessor // set if processors can emit multiple events
+	fanOut     *fanOutACKer
 	producer   queue.Producer
 	mutex      sync.Mutex
 	waiter     *clientCloseWaiter
@@ -88,6 +90,11 @@ func (c *client) publish(e beat.Event) {
 		return
 	}
 
+	if c.multi != nil {
+		c.publishMulti(e)
+		return
+	}
+
 	if c.processors != nil {
 		var err error
 
@@ -110,7 +117,41 @@ func (c *client) publish(e beat.Event) {
 		return
 	}
 
-	e = *event
+	c.publishEvent(*event)
+}
+
+// publishMulti runs processors that can turn the event into multiple events.
+// All resulting events are pushed to the queue, but the event listener only
+// sees the original event, which is ACKed once all resulting events have been
+// ACKed.
+func (c *client) publishMulti(e beat.Event) {
+	events, err := c.multi.RunMulti(&e)
+	if err != nil {
+		c.logger.Errorf("Failed to publish event: %v", err)
+	}
+
+	if len(events) == 0 {
+		c.eventListener.AddEvent(e, false)
+		c.onFilteredOut(e)
+		return
+	}
+
+	c.eventListener.AddEvent(*events[0], true)
+	for range events[1:] {
+		c.onNewEvent()
+	}
+
+	entry := c.fanOut.add(len(events))
+	failed := 0
+	for _, event := range events {
+		if !c.publishEvent(*event) {
+			failed++
+		}
+	}
+	c.fanOut.done(entry, failed)
+}
+
+func (c *client) publishEvent(e beat.Event) bool {
 	pubEvent := publisher.Event{
 		Content: e,
 		Flags:   c.eventFlags,
@@ -128,6 +169,22 @@ func (c *client) publish(e beat.Event) {
 	} else {
 		c.onDroppedOnPublish(e)
 	}
+	return published
+}
+
+// multiProcessor returns the client processors as beat.MultiProcessor if they
+// contain processors that can turn an event into multiple events.
+func multiProcessor(p beat.Processor) beat.MultiProcessor {
+	if multi, ok := p.(beat.MultiProcessor); ok && processors.IsMulti(p) {
+		return multi
+	}
+	return nil
+}
+
+// setPipeline passes the pipeline and the client configuration to processors
+// that publish events of their own.
+func setPipeline(p beat.Processor, pipeline beat.Pipeline, cfg beat.ClientConfig) {
+	processors.SetPipeline(p, pipeline, cfg)
 }
 
 func (c *client) Close() error {
@@ -28,6 +28,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/acker"
 	"github.com/elastic/beats/v7/libbeat/outputs"
 	"github.com/elastic/beats/v7/libbeat/processors"
 	"github.com/elastic/beats/v7/libbeat/publisher"
@@ -183,6 +184,67 @@ func TestClient(t *testing.T) {
 	})
 }
 
+func TestClientMultiEvents(t *testing.T) {
+	logp.TestingSetup()
+
+	q := memqueue.NewQueue(logp.L(), nil, memqueue.Settings{
+		Events:        10,
+		MaxGetRequest: 2,
+		FlushTimeout:  time.Millisecond,
+	}, 10, nil)
+
+	pipeline := makePipeline(t, Settings{
+		Processors: testProcessorSupporter{Processor: testMultiProcessor{}},
+	}, q)
+	defer pipeline.Close()
+
+	var (
+		mu    sync.Mutex
+		acked []interface{}
+	)
+	client, err := pipeline.ConnectWith(beat.ClientConfig{
+		EventListener: acker.EventPrivateReporter(func(_ int, data []interface{}) {
+			mu.Lock()
+			defer mu.Unlock()
+			acked = append(acked, data...)
+		}),
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	var received []beat.Event
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for len(received) < 6 {
+			batch, err := q.Get(2)
+			if err != nil {
+				return
+			}
+			for i := 0; i < batch.Count(); i++ {
+				received = append(received, batch.Entry(i).(publisher.Event).Content)
+			}
+			batch.Done()
+		}
+	}()
+
+	client.PublishAll([]beat.Event{
+		{Fields: mapstr.M{"count": 3}, Private: 1},
+		{Fields: mapstr.M{"count": 0}, Private: 2},
+		{Fields: mapstr.M{"count": 1}, Private: 3},
+		{Fields: mapstr.M{"count": 2}, Private: 4},
+	})
+
+	<-done
+	require.Len(t, received, 6)
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(acked) == 4
+	}, 10*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []interface{}{1, 2, 3, 4}, acked)
+}
+
 func TestClientWaitClose(t *testing.T) {
 	makePipeline := func(settings Settings, qu queue.Queue) *Pipeline {
 		p, err := New(beat.Info{},
@@ -337,6 +399,29 @@ func (p *testProcessor) ErrorSwitch() {
 	p.error = !p.error
 }
 
+// testMultiProcessor publishes the event as many times as the value of the
+// count field.
+type testMultiProcessor struct{}
+
+func (testMultiProcessor) String() string {
+	return "testMultiProcessor"
+}
+
+func (testMultiProcessor) Run(in *beat.Event) (*beat.Event, error) {
+	return in, nil
+}
+
+func (testMultiProcessor) RunMulti(in *beat.Event) ([]*beat.Event, error) {
+	n, _ := in.Fields["count"].(int)
+	events := make([]*beat.Event, n)
+	for i := range events {
+		e := in.Clone()
+		e.Fields["index"] = i
+		events[i] = e
+	}
+	return events, nil
+}
+
 type testProcessorSupporter struct {
 	beat.Processor
 }
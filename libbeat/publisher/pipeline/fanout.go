@@ -0,0 +1,104 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pipeline
+
+import "sync"
+
+// fanOutACKer maps ACKs from the queue back to the events published by a
+// client, when processors can turn one event into multiple events. The
+// queue ACKs events in publishing order, so it is sufficient to keep track of
+// the number of outstanding queue events per published event.
+type fanOutACKer struct {
+	mu      sync.Mutex
+	pending []*fanOutEntry
+	onACK   func(n int)
+}
+
+type fanOutEntry struct {
+	remaining int  // number of queue events not ACKed yet
+	published int  // number of queue events successfully published
+	open      bool // set while the client is still publishing the events
+}
+
+func newFanOutACKer(onACK func(n int)) *fanOutACKer {
+	return &fanOutACKer{onACK: onACK}
+}
+
+// add registers an event that is published as n queue events. It must be
+// called before the events are passed to the queue.
+func (f *fanOutACKer) add(n int) *fanOutEntry {
+	entry := &fanOutEntry{remaining: n, published: n, open: true}
+
+	f.mu.Lock()
+	f.pending = append(f.pending, entry)
+	f.mu.Unlock()
+	return entry
+}
+
+// done marks all queue events of the entry as passed to the queue. Events
+// that could not be published will not be ACKed by the queue. If none of the
+// events have been published, the original event is not ACKed either.
+func (f *fanOutACKer) done(entry *fanOutEntry, failed int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entry.open = false
+	entry.remaining -= failed
+	entry.published -= failed
+	if entry.published == 0 {
+		// Entries are added by the publishing client only, so the entry must
+		// still be the last one.
+		f.pending = f.pending[:len(f.pending)-1]
+		return
+	}
+	f.collect()
+}
+
+// ack handles n events being ACKed by the queue.
+func (f *fanOutACKer) ack(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, entry := range f.pending {
+		if n == 0 {
+			break
+		}
+		k := min(n, entry.remaining)
+		entry.remaining -= k
+		n -= k
+	}
+	f.collect()
+}
+
+// collect removes all fully ACKed entries from the front of the queue and
+// reports them as ACKed. Must be called with the mutex held.
+func (f *fanOutACKer) collect() {
+	acked := 0
+	for len(f.pending) > 0 {
+		entry := f.pending[0]
+		if entry.open || entry.remaining > 0 {
+			break
+		}
+		f.pending[0] = nil
+		f.pending = f.pending[1:]
+		acked++
+	}
+	if acked > 0 {
+		f.onACK(acked)
+	}
+}
@@ -0,0 +1,55 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFanOutACKer(t *testing.T) {
+	var acked []int
+	f := newFanOutACKer(func(n int) { acked = append(acked, n) })
+
+	// first event published as 3 queue events
+	e1 := f.add(3)
+	f.ack(1) // queue ACKs before the client finished publishing
+	f.done(e1, 0)
+	assert.Empty(t, acked)
+
+	// second event published as 1 queue event
+	e2 := f.add(1)
+	f.done(e2, 0)
+
+	// third event could not be published at all
+	e3 := f.add(2)
+	f.done(e3, 2)
+
+	// fourth event partially published
+	e4 := f.add(2)
+	f.done(e4, 1)
+
+	f.ack(1)
+	assert.Empty(t, acked)
+	f.ack(2)
+	assert.Equal(t, []int{2}, acked)
+	f.ack(1)
+	assert.Equal(t, []int{2, 1}, acked)
+	assert.Empty(t, f.pending)
+}
@@ -209,6 +209,8 @@ func (p *Pipeline) ConnectWith(cfg beat.ClientConfig) (beat.Client, error) {
 		return nil, err
 	}
 
+	setPipeline(processors, p, cfg)
+
 	client := &client{
 		logger:         p.monitors.Logger,
 		isOpen:         atomic.MakeBool(true),
@@ -231,11 +233,25 @@ func (p *Pipeline) ConnectWith(cfg beat.ClientConfig) (beat.Client, error) {
 		}
 	}
 
+	ackEvents := func(count int) {
+		if ackHandler != nil {
+			ackHandler.ACKEvents(count)
+		}
+	}
+	if multi := multiProcessor(processors); multi != nil {
+		// Processors can publish multiple events per event. ACK the event
+		// only once all of them have been ACKed by the queue.
+		client.multi = multi
+		client.fanOut = newFanOutACKer(ackEvents)
+	}
+
 	producerCfg := queue.ProducerConfig{
 		ACK: func(count int) {
 			client.observer.eventsACKed(count)
-			if ackHandler != nil {
-				ackHandler.ACKEvents(count)
+			if client.fanOut != nil {
+				client.fanOut.ack(count)
+			} else {
+				ackEvents(count)
 			}
 		},
 	}
@@ -284,8 +284,9 @@ func (b *builder) Processors() []string {
 //  7. (P) add builtins
 //  8. (P) pipeline processors list
 //  9. (P) timeseries mangling
-//  10. (P) (if publish/debug enabled) log event
-//  11. (P) (if output disabled) dropEvent
+//  10. (C) client post processor
+//  11. (P) (if publish/debug enabled) log event
+//  12. (P) (if output disabled) dropEvent
 func (b *builder) Create(cfg beat.ProcessingConfig, drop bool) (beat.Processor, error) {
 	var (
 		// pipeline processors
@@ -296,7 +297,7 @@ func (b *builder) Create(cfg beat.ProcessingConfig, drop bool) (beat.Processor,
 		localProcessors = makeClientProcessors(b.log, cfg)
 	)
 
-	needsCopy := b.alwaysCopy || localProcessors != nil || b.processors != nil
+	needsCopy := b.alwaysCopy || localProcessors != nil || b.processors != nil || cfg.PostProcessor != nil
 
 	builtin := b.builtinMeta
 	if cfg.DisableHost {
@@ -378,8 +379,21 @@ func (b *builder) Create(cfg beat.ProcessingConfig, drop bool) (beat.Processor,
 
 	// setup 8: pipeline processors list
 	if b.processors != nil {
+		// The global processors are shared by all clients, so they don't get
+		// the configuration of the client.
+		setPipeline := func(pipeline beat.Pipeline, _ beat.ClientConfig) {
+			b.processors.setPipeline(pipeline, beat.ClientConfig{})
+		}
 		// Add the global pipeline as a function processor, so clients cannot close it
-		processors.add(newProcessor(b.processors.title, b.processors.Run))
+		if b.processors.isMulti() {
+			fn := newMultiProcessor(b.processors.title, b.processors.Run, b.processors.RunMulti)
+			fn.pipeline = setPipeline
+			processors.add(fn)
+		} else {
+			fn := newProcessor(b.processors.title, b.processors.Run)
+			fn.pipeline = setPipeline
+			processors.add(fn)
+		}
 	}
 
 	// setup 9: time series metadata
@@ -387,12 +401,15 @@ func (b *builder) Create(cfg beat.ProcessingConfig, drop bool) (beat.Processor,
 		processors.add(timeseries.NewTimeSeriesProcessor(b.timeseriesFields))
 	}
 
-	// setup 10: debug print final event (P)
+	// setup 10: client post processor
+	processors.add(cfg.PostProcessor)
+
+	// setup 11: debug print final event (P)
 	if b.log.IsDebug() || management.UnderAgent() {
 		processors.add(debugPrintProcessor(b.info, b.log))
 	}
 
-	// setup 11: drop all events if outputs are disabled (P)
+	// setup 12: drop all events if outputs are disabled (P)
 	if drop {
 		processors.add(dropDisabledProcessor)
 	}
@@ -400,6 +417,18 @@ func (b *builder) Create(cfg beat.ProcessingConfig, drop bool) (beat.Processor,
 	return processors, nil
 }
 
+// WrapProcessors replaces each global processor with the result of wrap.
+// It must be called before Create. Tools use it to observe the global
+// processors of the event processing pipeline.
+func (b *builder) WrapProcessors(wrap func(beat.Processor) beat.Processor) {
+	if b.processors == nil {
+		return
+	}
+	for i, p := range b.processors.list {
+		b.processors.list[i] = wrap(p)
+	}
+}
+
 func (b *builder) Close() error {
 	if b.processors != nil {
 		return b.processors.Close()
@@ -38,6 +38,7 @@ import (
 	_ "github.com/elastic/beats/v7/libbeat/processors/add_docker_metadata"
 	_ "github.com/elastic/beats/v7/libbeat/processors/add_host_metadata"
 	_ "github.com/elastic/beats/v7/libbeat/processors/add_kubernetes_metadata"
+	_ "github.com/elastic/beats/v7/libbeat/processors/split"
 )
 
 func TestGenerateProcessorList(t *testing.T) {
@@ -437,6 +438,103 @@ func TestDynamicFields(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestMultiEventProcessing(t *testing.T) {
+	factory, err := MakeDefaultSupport(true, nil)(beat.Info{}, logp.L(), config.MustNewConfigFrom(mapstr.M{
+		"processors": []mapstr.M{
+			{"split": mapstr.M{"field": "message", "separator": "\n"}},
+		},
+	}))
+	require.NoError(t, err)
+	defer factory.Close()
+
+	prog, err := factory.Create(beat.ProcessingConfig{
+		Fields: mapstr.M{"client": "value"},
+	}, false)
+	require.NoError(t, err)
+	require.True(t, processors.IsMulti(prog))
+
+	multi, ok := prog.(beat.MultiProcessor)
+	require.True(t, ok)
+	events, err := multi.RunMulti(&beat.Event{Fields: mapstr.M{"message": "a\nb"}})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, mapstr.M{"message": "a", "client": "value"}, events[0].Fields)
+	assert.Equal(t, mapstr.M{"message": "b", "client": "value"}, events[1].Fields)
+
+	// Processing without multi-event processors does not need RunMulti.
+	plain, err := MakeDefaultSupport(true, nil)(beat.Info{}, logp.L(), config.NewConfig())
+	require.NoError(t, err)
+	defer plain.Close()
+	prog, err = plain.Create(beat.ProcessingConfig{}, false)
+	require.NoError(t, err)
+	assert.False(t, processors.IsMulti(prog))
+}
+
+func TestPostProcessor(t *testing.T) {
+	factory, err := MakeDefaultSupport(true, nil, WithAgentMeta())(beat.Info{Beat: "test"}, logp.L(), config.MustNewConfigFrom(mapstr.M{
+		"processors": []mapstr.M{
+			{"add_fields": mapstr.M{"target": "", "fields": mapstr.M{"global": "value"}}},
+		},
+	}))
+	require.NoError(t, err)
+	defer factory.Close()
+
+	var seen mapstr.M
+	prog, err := factory.Create(beat.ProcessingConfig{
+		PostProcessor: newProcessor("post", func(event *beat.Event) (*beat.Event, error) {
+			seen = event.Fields.Clone()
+			event.Fields.Put("agent.type", "changed")
+			return event, nil
+		}),
+	}, false)
+	require.NoError(t, err)
+
+	actual, err := prog.Run(&beat.Event{Fields: mapstr.M{"hello": "world"}})
+	require.NoError(t, err)
+
+	// The post processor runs after the global processors and builtin metadata.
+	assert.Equal(t, "value", seen["global"])
+	agentType, err := seen.GetValue("agent.type")
+	require.NoError(t, err)
+	assert.Equal(t, "test", agentType)
+	agentType, err = actual.Fields.GetValue("agent.type")
+	require.NoError(t, err)
+	assert.Equal(t, "changed", agentType)
+
+	// Builtin metadata shared between events is not modified.
+	_, err = prog.Run(&beat.Event{Fields: mapstr.M{"hello": "world"}})
+	require.NoError(t, err)
+	agentType, err = seen.GetValue("agent.type")
+	require.NoError(t, err)
+	assert.Equal(t, "test", agentType)
+}
+
+func TestWrapProcessors(t *testing.T) {
+	factory, err := MakeDefaultSupport(true, nil)(beat.Info{}, logp.L(), config.MustNewConfigFrom(mapstr.M{
+		"processors": []mapstr.M{
+			{"add_fields": mapstr.M{"target": "", "fields": mapstr.M{"global": "value"}}},
+		},
+	}))
+	require.NoError(t, err)
+	defer factory.Close()
+
+	var seen []string
+	factory.(*builder).WrapProcessors(func(p beat.Processor) beat.Processor {
+		return newProcessor("wrapped", func(event *beat.Event) (*beat.Event, error) {
+			seen = append(seen, p.String())
+			return p.Run(event)
+		})
+	})
+
+	prog, err := factory.Create(beat.ProcessingConfig{}, false)
+	require.NoError(t, err)
+	actual, err := prog.Run(&beat.Event{Fields: mapstr.M{"hello": "world"}})
+	require.NoError(t, err)
+	assert.Equal(t, "value", actual.Fields["global"])
+	require.Len(t, seen, 1)
+	assert.Contains(t, seen[0], "add_fields=")
+}
+
 func TestProcessingClose(t *testing.T) {
 	factory, err := MakeDefaultSupport(true, nil)(beat.Info{}, logp.L(), config.NewConfig())
 	require.NoError(t, err)
@@ -480,6 +578,39 @@ func TestProcessingClose(t *testing.T) {
 	assert.True(t, factoryProcessor.closed)
 }
 
+func TestProcessingSetPipeline(t *testing.T) {
+	factory, err := MakeDefaultSupport(true, nil)(beat.Info{}, logp.L(), config.NewConfig())
+	require.NoError(t, err)
+	defer factory.Close()
+
+	factoryProcessor := &publishingProcessor{}
+	b := factory.(*builder)
+	if b.processors == nil {
+		b.processors = newGroup("global", logp.L())
+	}
+	b.processors.add(factoryProcessor)
+
+	clientProcessor := &publishingProcessor{}
+	g := newGroup("test", logp.L())
+	g.add(clientProcessor)
+
+	clientConfig := beat.ClientConfig{
+		PublishMode: beat.GuaranteedSend,
+		Processing:  beat.ProcessingConfig{Processor: g},
+	}
+	prog, err := factory.Create(clientConfig.Processing, false)
+	require.NoError(t, err)
+
+	// Both the global and the client processors get the pipeline, only the
+	// client processors get the client configuration.
+	pipeline := &fakePipeline{}
+	processors.SetPipeline(prog, pipeline, clientConfig)
+	assert.Same(t, pipeline, factoryProcessor.pipeline)
+	assert.Equal(t, beat.ClientConfig{}, factoryProcessor.config)
+	assert.Same(t, pipeline, clientProcessor.pipeline)
+	assert.Equal(t, clientConfig, clientProcessor.config)
+}
+
 func TestProcessingDiagnostics(t *testing.T) {
 	factory, err := MakeDefaultSupport(true, nil)(beat.Info{}, logp.L(), config.NewConfig())
 	require.NoError(t, err)
@@ -515,3 +646,20 @@ func (p *processorWithClose) Close() error {
 func (p *processorWithClose) String() string {
 	return "processorWithClose"
 }
+
+type publishingProcessor struct {
+	pipeline beat.Pipeline
+	config   beat.ClientConfig
+}
+
+func (p *publishingProcessor) Run(e *beat.Event) (*beat.Event, error) { return e, nil }
+func (p *publishingProcessor) String() string                         { return "publishingProcessor" }
+
+func (p *publishingProcessor) SetPipeline(pipeline beat.Pipeline, cfg beat.ClientConfig) {
+	p.pipeline = pipeline
+	p.config = cfg
+}
+
+type fakePipeline struct {
+	beat.Pipeline
+}
@@ -42,6 +42,13 @@ type group struct {
 type processorFn struct {
 	name string
 	fn   func(event *beat.Event) (*beat.Event, error)
+	// pipeline, if set, passes the pipeline to the wrapped processors.
+	pipeline func(beat.Pipeline, beat.ClientConfig)
+}
+
+type multiProcessorFn struct {
+	processorFn
+	multi func(event *beat.Event) ([]*beat.Event, error)
 }
 
 func newGeneralizeProcessor(keepNull bool) *processorFn {
@@ -113,6 +120,18 @@ func (p *group) String() string {
 	return fmt.Sprintf("%v{%v}", p.title, str)
 }
 
+// isMulti reports whether the group contains processors that can turn an
+// event into multiple events.
+func (p *group) isMulti() bool {
+	return processors.IsMulti(p)
+}
+
+// setPipeline passes the pipeline and the client configuration to processors
+// in the group that publish events of their own.
+func (p *group) setPipeline(pipeline beat.Pipeline, cfg beat.ClientConfig) {
+	processors.SetPipeline(p, pipeline, cfg)
+}
+
 func (p *group) All() []beat.Processor {
 	return p.list
 }
@@ -143,6 +162,40 @@ func (p *group) Run(event *beat.Event) (*beat.Event, error) {
 	return event, nil
 }
 
+// RunMulti is like Run, but allows processors in the group to turn an event
+// into multiple events. Every event returned by a processor is passed to the
+// next processor in the group.
+func (p *group) RunMulti(event *beat.Event) ([]*beat.Event, error) {
+	events := []*beat.Event{event}
+	if p == nil || len(p.list) == 0 {
+		return events, nil
+	}
+
+	for _, sub := range p.list {
+		var (
+			out     = make([]*beat.Event, 0, len(events))
+			lastErr error
+		)
+
+		for _, event := range events {
+			results, err := processors.RunMulti(sub, event)
+			if err != nil {
+				// Same as in Run: errors do not drop the event.
+				p.log.Debugf("Fail to apply processor %s: %s", p, err)
+				lastErr = err
+			}
+			out = append(out, results...)
+		}
+
+		if len(out) == 0 {
+			return nil, lastErr
+		}
+		events = out
+	}
+
+	return events, nil
+}
+
 func newProcessor(name string, fn func(*beat.Event) (*beat.Event, error)) *processorFn {
 	return &processorFn{name: name, fn: fn}
 }
@@ -157,6 +210,22 @@ func newAnnotateProcessor(name string, fn func(*beat.Event)) *processorFn {
 func (p *processorFn) String() string                         { return p.name }
 func (p *processorFn) Run(e *beat.Event) (*beat.Event, error) { return p.fn(e) }
 
+func (p *processorFn) SetPipeline(pipeline beat.Pipeline, cfg beat.ClientConfig) {
+	if p.pipeline != nil {
+		p.pipeline(pipeline, cfg)
+	}
+}
+
+func newMultiProcessor(
+	name string,
+	fn func(*beat.Event) (*beat.Event, error),
+	multi func(*beat.Event) ([]*beat.Event, error),
+) *multiProcessorFn {
+	return &multiProcessorFn{processorFn: processorFn{name: name, fn: fn}, multi: multi}
+}
+
+func (p *multiProcessorFn) RunMulti(e *beat.Event) ([]*beat.Event, error) { return p.multi(e) }
+
 func clientEventMeta(meta mapstr.M, needsCopy bool) *processorFn {
 	fn := func(event *beat.Event) { addMeta(event, meta) }
 	if needsCopy {
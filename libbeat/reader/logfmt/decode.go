@@ -0,0 +1,181 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logfmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// Decode decodes the key=value pairs of data. Keys without a value are
+// decoded to an empty string and later values of repeated keys overwrite
+// earlier ones. When data spans several lines, the pairs of all lines are
+// decoded. The pairs decoded before a syntax error are returned with the
+// error.
+//
+// The syntax is the one of github.com/go-logfmt/logfmt, but lines are
+// tokenized in place, so that their length is only limited by the
+// message_max_bytes setting of the input.
+func Decode(data []byte) (mapstr.M, error) {
+	fields := mapstr.M{}
+	for lineNum := 1; len(data) > 0; lineNum++ {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line, data = data[:i], data[i+1:]
+		} else {
+			data = nil
+		}
+		d := decoder{line: bytes.TrimSuffix(line, []byte{'\r'}), lineNum: lineNum}
+		for d.scan() {
+			fields[string(d.key)] = string(d.value)
+		}
+		if d.err != nil {
+			return fields, d.err
+		}
+	}
+	return fields, nil
+}
+
+// SyntaxError is a syntax error in logfmt data.
+type SyntaxError struct {
+	Msg  string
+	Line int
+	Pos  int
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("logfmt syntax error at pos %d on line %d: %s", e.Pos, e.Line, e.Msg)
+}
+
+// decoder tokenizes the key=value pairs of a line.
+type decoder struct {
+	line       []byte
+	lineNum    int
+	pos        int
+	key, value []byte
+	err        error
+}
+
+// scan advances to the next pair of the line. It returns false at the end
+// of the line or on a syntax error.
+func (d *decoder) scan() bool {
+	d.key, d.value = nil, nil
+	if d.err != nil {
+		return false
+	}
+
+	// Skip the spaces and control characters before the key.
+	for d.pos < len(d.line) && d.line[d.pos] <= ' ' {
+		d.pos++
+	}
+	if d.pos == len(d.line) {
+		return false
+	}
+
+	start, multibyte := d.pos, false
+	for ; d.pos < len(d.line); d.pos++ {
+		c := d.line[d.pos]
+		if c == '=' || c <= ' ' {
+			break
+		}
+		if c == '"' {
+			d.unexpectedByte(c)
+			return false
+		}
+		if c >= utf8.RuneSelf {
+			multibyte = true
+		}
+	}
+	if d.pos > start {
+		d.key = d.line[start:d.pos]
+		if multibyte && bytes.ContainsRune(d.key, utf8.RuneError) {
+			d.syntaxError("invalid key")
+			return false
+		}
+	}
+	if d.pos == len(d.line) || d.line[d.pos] != '=' {
+		return true
+	}
+	if d.key == nil {
+		d.unexpectedByte('=')
+		return false
+	}
+
+	d.pos++
+	if d.pos == len(d.line) || d.line[d.pos] <= ' ' {
+		return true
+	}
+	if d.line[d.pos] == '"' {
+		return d.scanQuoted()
+	}
+
+	start = d.pos
+	for ; d.pos < len(d.line); d.pos++ {
+		c := d.line[d.pos]
+		if c <= ' ' {
+			break
+		}
+		if c == '=' || c == '"' {
+			d.unexpectedByte(c)
+			return false
+		}
+	}
+	d.value = d.line[start:d.pos]
+	return true
+}
+
+// scanQuoted reads a quoted value. Escape sequences are the ones of JSON
+// strings.
+func (d *decoder) scanQuoted() bool {
+	start, escaped, hasEscape := d.pos, false, false
+	for d.pos++; d.pos < len(d.line); d.pos++ {
+		switch c := d.line[d.pos]; {
+		case escaped:
+			escaped = false
+		case c == '\\':
+			escaped, hasEscape = true, true
+		case c == '"':
+			d.pos++
+			if !hasEscape {
+				d.value = d.line[start+1 : d.pos-1]
+				return true
+			}
+			var s string
+			if err := json.Unmarshal(d.line[start:d.pos], &s); err != nil {
+				d.syntaxError("invalid quoted value")
+				return false
+			}
+			d.value = []byte(s)
+			return true
+		}
+	}
+	d.syntaxError("unterminated quoted value")
+	return false
+}
+
+func (d *decoder) syntaxError(msg string) {
+	d.err = &SyntaxError{Msg: msg, Line: d.lineNum, Pos: d.pos + 1}
+}
+
+func (d *decoder) unexpectedByte(c byte) {
+	d.syntaxError(fmt.Sprintf("unexpected %q", c))
+}
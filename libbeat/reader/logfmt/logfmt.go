@@ -0,0 +1,149 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logfmt
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/reader"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// Config stores the configuration for the Parser.
+type Config struct {
+	// The field the decoded keys are written to. If empty, the keys are
+	// written to the root of the event.
+	Target string `config:"target"`
+	// The key whose value replaces the message content.
+	MessageKey string `config:"message_key"`
+	// The key holding the event timestamp.
+	TimestampKey string `config:"timestamp_key"`
+	// The layouts tried in order when parsing the timestamp.
+	TimestampLayouts []string `config:"timestamp_layouts"`
+	// If true, errors will be logged.
+	LogErrors bool `config:"log_errors"`
+	// If true, errors will be added to the message fields under the error.message field.
+	AddErrorKey bool `config:"add_error_key"`
+}
+
+// DefaultConfig will return a Config with default values.
+func DefaultConfig() Config {
+	return Config{
+		Target:           "logfmt",
+		TimestampLayouts: []string{time.RFC3339Nano},
+		AddErrorKey:      true,
+	}
+}
+
+// Validate validates the Config.
+func (c *Config) Validate() error {
+	if c.TimestampKey != "" && len(c.TimestampLayouts) == 0 {
+		return errors.New("timestamp_layouts must not be empty when timestamp_key is set")
+	}
+	return nil
+}
+
+// Parser is a logfmt parser that implements parser.Parser.
+type Parser struct {
+	cfg    *Config
+	reader reader.Reader
+	logger *logp.Logger
+}
+
+// NewParser creates a new logfmt parser.
+func NewParser(r reader.Reader, cfg *Config) *Parser {
+	return &Parser{
+		cfg:    cfg,
+		reader: r,
+		logger: logp.NewLogger("reader_logfmt"),
+	}
+}
+
+// Close closes this Parser.
+func (p *Parser) Close() error {
+	return p.reader.Close()
+}
+
+// Next reads the next message and decodes its key=value pairs.
+func (p *Parser) Next() (reader.Message, error) {
+	msg, err := p.reader.Next()
+	if err != nil || len(msg.Content) == 0 {
+		return msg, err
+	}
+
+	fields, err := Decode(msg.Content)
+	if err != nil {
+		p.handleError(&msg, fmt.Errorf("error decoding logfmt message: %w", err))
+	}
+	if len(fields) == 0 {
+		return msg, nil
+	}
+
+	if key := p.cfg.MessageKey; key != "" {
+		if text, ok := fields[key].(string); ok {
+			msg.Content = []byte(text)
+			delete(fields, key)
+		}
+	}
+
+	if key := p.cfg.TimestampKey; key != "" {
+		if value, ok := fields[key].(string); ok {
+			ts, err := parseTimestamp(value, p.cfg.TimestampLayouts)
+			if err != nil {
+				p.handleError(&msg, fmt.Errorf("error parsing timestamp key %q: %w", key, err))
+			} else {
+				msg.Ts = ts
+				delete(fields, key)
+			}
+		}
+	}
+
+	if p.cfg.Target == "" {
+		msg.AddFields(fields)
+	} else {
+		out := mapstr.M{}
+		_, _ = out.Put(p.cfg.Target, fields)
+		msg.AddFields(out)
+	}
+
+	return msg, nil
+}
+
+func (p *Parser) handleError(msg *reader.Message, err error) {
+	if p.cfg.LogErrors {
+		p.logger.Errorf("%v", err)
+	}
+	if p.cfg.AddErrorKey {
+		msg.AddFields(mapstr.M{"error": mapstr.M{"message": err.Error(), "type": "logfmt"}})
+	}
+}
+
+func parseTimestamp(value string, layouts []string) (time.Time, error) {
+	var err error
+	for _, layout := range layouts {
+		var ts time.Time
+		ts, err = time.Parse(layout, value)
+		if err == nil {
+			return ts, nil
+		}
+	}
+	return time.Time{}, err
+}
@@ -0,0 +1,222 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logfmt
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/reader"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestDecode(t *testing.T) {
+	tests := map[string]struct {
+		in      string
+		want    mapstr.M
+		wantErr bool
+	}{
+		"simple": {
+			in:   `level=info msg="user logged in" user=alice`,
+			want: mapstr.M{"level": "info", "msg": "user logged in", "user": "alice"},
+		},
+		"escaped quotes": {
+			in:   `msg="say \"hi\"" n=1`,
+			want: mapstr.M{"msg": `say "hi"`, "n": "1"},
+		},
+		"key without value": {
+			in:   `debug level=warn`,
+			want: mapstr.M{"debug": "", "level": "warn"},
+		},
+		"repeated key": {
+			in:   `a=1 a=2`,
+			want: mapstr.M{"a": "2"},
+		},
+		"multiple lines": {
+			in:   "a=1\nb=2",
+			want: mapstr.M{"a": "1", "b": "2"},
+		},
+		"unterminated quote": {
+			in:      `a=1 msg="oops`,
+			want:    mapstr.M{"a": "1"},
+			wantErr: true,
+		},
+		"escape sequences": {
+			in:   `msg="tab\there \u00e9\\" empty=""`,
+			want: mapstr.M{"msg": "tab\there \u00e9\\", "empty": ""},
+		},
+		"invalid escape": {
+			in:      `a=1 msg="\x41" b=2`,
+			want:    mapstr.M{"a": "1"},
+			wantErr: true,
+		},
+		"crlf lines": {
+			in:   "a=1\r\nb=2\r\n",
+			want: mapstr.M{"a": "1", "b": "2"},
+		},
+		"unexpected equal sign": {
+			in:      `a=1 =2`,
+			want:    mapstr.M{"a": "1"},
+			wantErr: true,
+		},
+		"quote in value": {
+			in:      `a=1 b=x"y`,
+			want:    mapstr.M{"a": "1"},
+			wantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := Decode([]byte(test.in))
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestDecodeLongLine(t *testing.T) {
+	// Lines are not limited to the 64 KiB tokens of bufio.Scanner.
+	long := strings.Repeat("x", 100*1024)
+	got, err := Decode([]byte(`level=info msg="` + long + `" id=` + long + "\nnext=1"))
+	require.NoError(t, err)
+	assert.Equal(t, mapstr.M{"level": "info", "msg": long, "id": long, "next": "1"}, got)
+}
+
+func TestParser(t *testing.T) {
+	ts := time.Date(2023, 6, 1, 12, 30, 0, 0, time.UTC)
+	readTs := time.Date(2023, 6, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		config      map[string]interface{}
+		content     string
+		wantContent string
+		wantFields  mapstr.M
+		wantTs      time.Time
+	}{
+		"default target": {
+			content:     `level=info msg=started`,
+			wantContent: `level=info msg=started`,
+			wantFields:  mapstr.M{"logfmt": mapstr.M{"level": "info", "msg": "started"}},
+			wantTs:      readTs,
+		},
+		"root target with message and timestamp keys": {
+			config: map[string]interface{}{
+				"target":        "",
+				"message_key":   "msg",
+				"timestamp_key": "ts",
+			},
+			content:     `ts=2023-06-01T12:30:00Z level=info msg="request served"`,
+			wantContent: "request served",
+			wantFields:  mapstr.M{"level": "info"},
+			wantTs:      ts,
+		},
+		"custom timestamp layout": {
+			config: map[string]interface{}{
+				"timestamp_key":     "time",
+				"timestamp_layouts": []string{time.RFC3339, "2006-01-02 15:04:05"},
+			},
+			content:     `time="2023-06-01 12:30:00" level=debug`,
+			wantContent: `time="2023-06-01 12:30:00" level=debug`,
+			wantFields:  mapstr.M{"logfmt": mapstr.M{"level": "debug"}},
+			wantTs:      ts,
+		},
+		"invalid timestamp": {
+			config: map[string]interface{}{
+				"timestamp_key": "ts",
+			},
+			content:     `ts=yesterday level=info`,
+			wantContent: `ts=yesterday level=info`,
+			wantFields: mapstr.M{
+				"logfmt": mapstr.M{"ts": "yesterday", "level": "info"},
+				"error": mapstr.M{
+					"message": `error parsing timestamp key "ts": parsing time "yesterday" as "2006-01-02T15:04:05.999999999Z07:00": cannot parse "yesterday" as "2006"`,
+					"type":    "logfmt",
+				},
+			},
+			wantTs: readTs,
+		},
+		"syntax error": {
+			content:     `a=1 b="oops`,
+			wantContent: `a=1 b="oops`,
+			wantFields: mapstr.M{
+				"logfmt": mapstr.M{"a": "1"},
+				"error": mapstr.M{
+					"message": "error decoding logfmt message: logfmt syntax error at pos 12 on line 1: unterminated quoted value",
+					"type":    "logfmt",
+				},
+			},
+			wantTs: readTs,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := DefaultConfig()
+			require.NoError(t, conf.MustNewConfigFrom(test.config).Unpack(&config))
+
+			p := NewParser(&testReader{messages: []reader.Message{{
+				Ts:      readTs,
+				Content: []byte(test.content),
+				Bytes:   len(test.content),
+			}}}, &config)
+
+			msg, err := p.Next()
+			require.NoError(t, err)
+			assert.Equal(t, test.wantContent, string(msg.Content))
+			assert.Equal(t, test.wantFields, msg.Fields)
+			assert.Equal(t, test.wantTs, msg.Ts)
+			assert.Equal(t, len(test.content), msg.Bytes)
+
+			_, err = p.Next()
+			assert.ErrorIs(t, err, io.EOF)
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	config := DefaultConfig()
+	config.TimestampKey = "ts"
+	config.TimestampLayouts = nil
+	assert.EqualError(t, config.Validate(), "timestamp_layouts must not be empty when timestamp_key is set")
+}
+
+type testReader struct {
+	messages []reader.Message
+}
+
+func (r *testReader) Next() (reader.Message, error) {
+	if len(r.messages) == 0 {
+		return reader.Message{}, io.EOF
+	}
+	m := r.messages[0]
+	r.messages = r.messages[1:]
+	return m, nil
+}
+
+func (r *testReader) Close() error { return nil }
@@ -115,6 +115,12 @@ func (b *messageBuffer) addLine(m reader.Message) {
 	b.last = m.Content
 	b.message.Bytes += m.Bytes
 	b.message.AddFields(m.Fields)
+	// The private data of the last line is kept, so that inputs that
+	// acknowledge their source cumulatively, like amqp, acknowledge all the
+	// lines of the message.
+	if m.Private != nil {
+		b.message.Private = m.Private
+	}
 }
 
 // finalize writes the existing content into the returned message and resets all reader variables.
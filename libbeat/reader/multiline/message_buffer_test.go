@@ -135,6 +135,28 @@ func TestFinalizeMessage(t *testing.T) {
 
 }
 
+func TestFinalizeMessageKeepsLastPrivate(t *testing.T) {
+	buf := getTestMessageBuffer(1024, false, []reader.Message{
+		{Content: []byte("line1"), Bytes: 5, Private: 1},
+		{Content: []byte("line2"), Bytes: 5, Private: 2},
+		{Content: []byte("line3"), Bytes: 5},
+	})
+	actualMsg := buf.finalize()
+
+	assert.Equal(t, []byte("line1\nline2\nline3"), actualMsg.Content)
+	assert.Equal(t, 2, actualMsg.Private)
+
+	// Readers that don't set private data, like filestream, are not affected.
+	buf = getTestMessageBuffer(1024, false, []reader.Message{
+		{Content: []byte("line1"), Bytes: 5},
+		{Content: []byte("line2"), Bytes: 5},
+	})
+	actualMsg = buf.finalize()
+
+	assert.Equal(t, []byte("line1\nline2"), actualMsg.Content)
+	assert.Nil(t, actualMsg.Private)
+}
+
 func getTestMessageBuffer(maxBytes int, skipNewline bool, messages []reader.Message) *messageBuffer {
 	buf := newMessageBuffer(maxBytes, 5, []byte("\n"), skipNewline)
 	buf.clear()
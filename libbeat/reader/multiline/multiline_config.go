@@ -31,10 +31,12 @@ const (
 	patternMode multilineType = iota
 	countMode
 	whilePatternMode
+	stacktraceMode
 
 	patternStr      = "pattern"
 	countStr        = "count"
 	whilePatternStr = "while_pattern"
+	stacktraceStr   = "stacktrace"
 )
 
 var (
@@ -42,6 +44,7 @@ var (
 		patternStr:      patternMode,
 		countStr:        countMode,
 		whilePatternStr: whilePatternMode,
+		stacktraceStr:   stacktraceMode,
 	}
 
 	ErrMissingPattern = errors.New("multiline.pattern cannot be empty when pattern based matching is selected")
@@ -80,6 +83,8 @@ func (c *Config) Validate() error {
 		if c.Pattern == nil {
 			return ErrMissingPattern
 		}
+	} else if c.Type == stacktraceMode {
+		return nil
 	} else {
 		return fmt.Errorf("unknown multiline type %d", c.Type)
 	}
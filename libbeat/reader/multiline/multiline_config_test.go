@@ -92,6 +92,11 @@ func TestValidConfiguration(t *testing.T) {
 				"count_lines": 5,
 			},
 		},
+		"stacktrace based multiline": {
+			config: map[string]interface{}{
+				"type": "stacktrace",
+			},
+		},
 	}
 
 	for name, test := range testcases {
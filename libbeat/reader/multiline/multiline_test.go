@@ -284,6 +284,95 @@ func TestMultilineWhilePattern(t *testing.T) {
 	)
 }
 
+func TestMultilineStacktrace(t *testing.T) {
+	// java, including cause chain and exception without stack trace
+	testMultilineOK(t,
+		Config{Type: stacktraceMode},
+		4,
+		"2024-01-01 10:00:00 ERROR request failed\n"+
+			"java.lang.IllegalStateException: boom\n"+
+			"\tat com.example.Foo.bar(Foo.java:10)\n"+
+			"\tat com.example.Main.main(Main.java:5)\n"+
+			"Caused by: java.io.IOException: closed\n"+
+			"\tat com.example.Io.read(Io.java:42)\n"+
+			"\t... 2 more\n",
+		"2024-01-01 10:00:01 INFO next\n",
+		"java.lang.RuntimeException: no trace\n",
+		"2024-01-01 10:00:02 INFO last\n",
+	)
+	// python, including chained exceptions
+	testMultilineOK(t,
+		Config{Type: stacktraceMode},
+		2,
+		"Traceback (most recent call last):\n"+
+			"  File \"a.py\", line 2, in <module>\n"+
+			"    f()\n"+
+			"KeyError: 'x'\n"+
+			"\n"+
+			"During handling of the above exception, another exception occurred:\n"+
+			"\n"+
+			"Traceback (most recent call last):\n"+
+			"  File \"a.py\", line 4, in <module>\n"+
+			"    g()\n"+
+			"ValueError: bad\n",
+		"INFO done\n",
+	)
+	// go panic with multiple goroutines
+	testMultilineOK(t,
+		Config{Type: stacktraceMode},
+		2,
+		"panic: runtime error: index out of range [1] with length 1\n"+
+			"\n"+
+			"goroutine 1 [running]:\n"+
+			"main.main()\n"+
+			"\t/tmp/main.go:8 +0x1d\n"+
+			"\n"+
+			"goroutine 6 [sleep]:\n"+
+			"time.Sleep(0x3b9aca00)\n"+
+			"\t/usr/local/go/src/runtime/time.go:195 +0x10d\n"+
+			"created by main.main in goroutine 1\n"+
+			"\t/tmp/main.go:6 +0x1a\n"+
+			"exit status 2\n",
+		"INFO restarted\n",
+	)
+	// .NET and node.js
+	testMultilineOK(t,
+		Config{Type: stacktraceMode},
+		2,
+		"Unhandled exception. System.InvalidOperationException: outer\n"+
+			" ---> System.NullReferenceException: inner\n"+
+			"   at App.Run() in /src/App.cs:line 12\n"+
+			"   --- End of inner exception stack trace ---\n"+
+			"   at App.Main() in /src/App.cs:line 5\n",
+		"listening on :8080\n"+
+			"TypeError: Cannot read properties of undefined (reading 'x')\n"+
+			"    at f (/app/index.js:3:15)\n"+
+			"    at Object.<anonymous> (/app/index.js:5:1)\n",
+	)
+	// empty lines not followed by a trace are separate events
+	testMultilineOK(t,
+		Config{Type: stacktraceMode},
+		3,
+		"line1\n",
+		"\n",
+		"line2\n",
+	)
+	// truncated
+	maxLines := 2
+	testMultilineTruncated(t,
+		Config{
+			Type:     stacktraceMode,
+			MaxLines: &maxLines,
+		},
+		1,
+		true,
+		[]string{
+			"java.lang.Error: x\n\tat A.a(A.java:1)\n\tat B.b(B.java:2)\n"},
+		[]string{
+			"java.lang.Error: x\n\tat A.a(A.java:1)\n"},
+	)
+}
+
 func testMultilineOK(t *testing.T, cfg Config, events int, expected ...string) {
 	_, buf := createLineBuffer(expected...)
 	r := createMultilineTestReader(t, buf, cfg)
@@ -0,0 +1,252 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package multiline
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/elastic/beats/v7/libbeat/common/match"
+	"github.com/elastic/beats/v7/libbeat/reader"
+	"github.com/elastic/beats/v7/libbeat/reader/readfile"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// traceKind is the kind of stack trace being collected, for shapes that
+// are only continuations inside of a trace of that language.
+type traceKind uint8
+
+const (
+	traceNone traceKind = iota
+	tracePython
+	traceGo
+)
+
+var (
+	// stackFrameLine matches lines that continue an event in any context:
+	// Java, .NET and Node.js frames, Java cause chains and Python frames.
+	stackFrameLine = match.MustCompile(`^(\s+at\s|\s+\.\.\. \d+ (more|common frames omitted)|\s*Caused by: |\s+Suppressed: |\s*--- End of |\s*---> |\s+File ".*", line \d+)`)
+
+	// exceptionLine matches the first line of a Java, .NET or Node.js
+	// exception. It continues an event only if it is followed by a frame.
+	exceptionLine = match.MustCompile(`^(Exception in thread "[^"]*" |Unhandled [Ee]xception[.:] )?([a-zA-Z_$][\w$]*\.)*([a-zA-Z_$][\w$]*)?(Exception|Error|Throwable)(: .*)?$`)
+
+	pythonTracebackLine = match.MustCompile(`^(Traceback \(most recent call last\):|During handling of the above exception, another exception occurred:|The above exception was the direct cause of the following exception:)$`)
+	pythonCodeLine      = match.MustCompile(`^\s`)
+	pythonExceptionLine = match.MustCompile(`^[a-zA-Z_][\w.]*(: .*)?$`)
+
+	goPanicLine     = match.MustCompile(`^(panic|fatal error): `)
+	goGoroutineLine = match.MustCompile(`^goroutine \d+ \[.*\]:$`)
+	goTraceLine     = match.MustCompile(`^(\t|created by |\[signal |exit status \d+$|\S+\(.*\)$)`)
+)
+
+// stacktraceReader combines stack traces and exceptions of common languages
+// with the line logged before them.
+//
+// Lines that can't be classified on their own, like the first line of a
+// Java exception or an empty line within a trace, are decided by looking
+// ahead one line.
+type stacktraceReader struct {
+	reader    reader.Reader
+	logger    *logp.Logger
+	msgBuffer *messageBuffer
+	trace     traceKind
+	ahead     []lookaheadLine
+	err       error // error to return after the buffered event
+}
+
+type lookaheadLine struct {
+	message reader.Message
+	err     error
+}
+
+func newMultilineStacktraceReader(
+	r reader.Reader,
+	separator string,
+	maxBytes int,
+	config *Config,
+) (reader.Reader, error) {
+	maxLines := defaultMaxLines
+	if config.MaxLines != nil {
+		maxLines = *config.MaxLines
+	}
+
+	tout := defaultMultilineTimeout
+	if config.Timeout != nil {
+		tout = *config.Timeout
+	}
+
+	if tout > 0 {
+		r = readfile.NewTimeoutReader(r, sigMultilineTimeout, tout)
+	}
+
+	return &stacktraceReader{
+		reader:    r,
+		logger:    logp.NewLogger("reader_multiline"),
+		msgBuffer: newMessageBuffer(maxBytes, maxLines, []byte(separator), config.SkipNewLine),
+	}, nil
+}
+
+// Next returns next multi-line event.
+func (sr *stacktraceReader) Next() (reader.Message, error) {
+	if sr.err != nil {
+		err := sr.err
+		sr.err = nil
+		return reader.Message{}, err
+	}
+
+	for {
+		message, err := sr.read()
+		if err != nil {
+			if err == sigMultilineTimeout {
+				// no lines buffered -> ignore timeout
+				if sr.msgBuffer.isEmpty() {
+					continue
+				}
+				sr.logger.Debug("Multiline event flushed because timeout reached.")
+				return sr.finalize(), nil
+			}
+
+			// no lines buffered -> return error
+			if sr.msgBuffer.isEmpty() {
+				return message, err
+			}
+
+			// lines buffered, return multiline and error on next read
+			if message.Bytes > 0 {
+				if !sr.continues(message) {
+					msg := sr.finalize()
+					sr.ahead = append([]lookaheadLine{{message, err}}, sr.ahead...)
+					return msg, nil
+				}
+				sr.msgBuffer.addLine(message)
+			}
+			sr.err = err
+			return sr.finalize(), nil
+		}
+
+		if message.Bytes == 0 {
+			continue
+		}
+
+		if sr.msgBuffer.isEmpty() {
+			sr.start(message)
+			continue
+		}
+
+		if sr.continues(message) {
+			sr.msgBuffer.addLine(message)
+			continue
+		}
+
+		msg := sr.finalize()
+		sr.start(message)
+		return msg, nil
+	}
+}
+
+// read returns the next line, taking the lines read ahead first.
+func (sr *stacktraceReader) read() (reader.Message, error) {
+	if len(sr.ahead) > 0 {
+		l := sr.ahead[0]
+		sr.ahead = sr.ahead[1:]
+		return l.message, l.err
+	}
+	return sr.reader.Next()
+}
+
+// peek returns the next line without consuming it.
+func (sr *stacktraceReader) peek() (reader.Message, bool) {
+	if len(sr.ahead) == 0 {
+		message, err := sr.reader.Next()
+		sr.ahead = append(sr.ahead, lookaheadLine{message, err})
+	}
+	l := sr.ahead[0]
+	return l.message, l.err == nil
+}
+
+func (sr *stacktraceReader) start(message reader.Message) {
+	sr.msgBuffer.startNewMessage(message)
+	switch {
+	case pythonTracebackLine.Match(message.Content):
+		sr.trace = tracePython
+	case goPanicLine.Match(message.Content):
+		sr.trace = traceGo
+	default:
+		sr.trace = traceNone
+	}
+}
+
+func (sr *stacktraceReader) finalize() reader.Message {
+	sr.trace = traceNone
+	return sr.msgBuffer.finalize()
+}
+
+// continues reports whether a line continues the buffered event.
+func (sr *stacktraceReader) continues(message reader.Message) bool {
+	line := message.Content
+	switch {
+	case stackFrameLine.Match(line):
+		return true
+	case pythonTracebackLine.Match(line):
+		sr.trace = tracePython
+		return true
+	case len(bytes.TrimSpace(line)) == 0:
+		// Empty lines separate chained Python exceptions and goroutines.
+		next, ok := sr.peek()
+		return ok && sr.continuesAfterEmptyLine(next.Content)
+	}
+
+	switch sr.trace {
+	case tracePython:
+		if pythonCodeLine.Match(line) {
+			return true
+		}
+		if pythonExceptionLine.Match(line) {
+			// The exception ends the traceback.
+			sr.trace = traceNone
+			return true
+		}
+	case traceGo:
+		if goGoroutineLine.Match(line) || goTraceLine.Match(line) {
+			return true
+		}
+	}
+
+	if exceptionLine.Match(line) {
+		next, ok := sr.peek()
+		return ok && stackFrameLine.Match(next.Content)
+	}
+	return false
+}
+
+func (sr *stacktraceReader) continuesAfterEmptyLine(next []byte) bool {
+	switch {
+	case pythonTracebackLine.Match(next):
+		return true
+	case sr.trace == traceGo:
+		return goGoroutineLine.Match(next) || goTraceLine.Match(next)
+	}
+	return false
+}
+
+func (sr *stacktraceReader) Close() error {
+	sr.ahead = nil
+	sr.err = io.EOF
+	return sr.reader.Close()
+}
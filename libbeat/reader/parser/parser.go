@@ -27,7 +27,9 @@ import (
 	"github.com/elastic/beats/v7/libbeat/common/cfgtype"
 	"github.com/elastic/beats/v7/libbeat/reader"
 	"github.com/elastic/beats/v7/libbeat/reader/filter"
+	"github.com/elastic/beats/v7/libbeat/reader/logfmt"
 	"github.com/elastic/beats/v7/libbeat/reader/multiline"
+	"github.com/elastic/beats/v7/libbeat/reader/readcsv"
 	"github.com/elastic/beats/v7/libbeat/reader/readfile"
 	"github.com/elastic/beats/v7/libbeat/reader/readjson"
 	"github.com/elastic/beats/v7/libbeat/reader/syslog"
@@ -38,6 +40,9 @@ var (
 	ErrNoSuchParser = errors.New("no such parser")
 )
 
+// maxCSVHeaders is the number of file headers remembered by each csv parser.
+const maxCSVHeaders = 1024
+
 // parser transforms or translates the Content attribute of a Message.
 // They are able to aggregate two or more Messages into a single one.
 type Parser interface {
@@ -55,6 +60,9 @@ type Config struct {
 
 	pCfg    CommonConfig
 	parsers []config.Namespace
+
+	// csvHeaders holds the file headers of the csv parsers, by parser index.
+	csvHeaders map[int]*readcsv.Headers
 }
 
 func (c *Config) Unpack(cc *config.C) error {
@@ -84,7 +92,8 @@ func (c *Config) Unpack(cc *config.C) error {
 
 func NewConfig(pCfg CommonConfig, parsers []config.Namespace) (*Config, error) {
 	var suffix string
-	for _, ns := range parsers {
+	csvHeaders := map[int]*readcsv.Headers{}
+	for i, ns := range parsers {
 		name := ns.Name()
 		switch name {
 		case "multiline":
@@ -128,22 +137,38 @@ func NewConfig(pCfg CommonConfig, parsers []config.Namespace) (*Config, error) {
 			if err != nil {
 				return nil, fmt.Errorf("error while parsing include_message parser config: %w", err)
 			}
+		case "logfmt":
+			config := logfmt.DefaultConfig()
+			cfg := ns.Config()
+			err := cfg.Unpack(&config)
+			if err != nil {
+				return nil, fmt.Errorf("error while parsing logfmt parser config: %w", err)
+			}
+		case "csv":
+			config := readcsv.DefaultConfig()
+			cfg := ns.Config()
+			err := cfg.Unpack(&config)
+			if err != nil {
+				return nil, fmt.Errorf("error while parsing csv parser config: %w", err)
+			}
+			csvHeaders[i] = readcsv.NewHeaders(maxCSVHeaders)
 		default:
 			return nil, fmt.Errorf("%s: %w", name, ErrNoSuchParser)
 		}
 	}
 
 	return &Config{
-		Suffix:  suffix,
-		pCfg:    pCfg,
-		parsers: parsers,
+		Suffix:     suffix,
+		pCfg:       pCfg,
+		parsers:    parsers,
+		csvHeaders: csvHeaders,
 	}, nil
 
 }
 
 func (c *Config) Create(in reader.Reader) Parser {
 	p := in
-	for _, ns := range c.parsers {
+	for i, ns := range c.parsers {
 		name := ns.Name()
 		switch name {
 		case "multiline":
@@ -189,6 +214,22 @@ func (c *Config) Create(in reader.Reader) Parser {
 				return p
 			}
 			p = filter.NewParser(p, &config)
+		case "logfmt":
+			config := logfmt.DefaultConfig()
+			cfg := ns.Config()
+			err := cfg.Unpack(&config)
+			if err != nil {
+				return p
+			}
+			p = logfmt.NewParser(p, &config)
+		case "csv":
+			config := readcsv.DefaultConfig()
+			cfg := ns.Config()
+			err := cfg.Unpack(&config)
+			if err != nil {
+				return p
+			}
+			p = readcsv.NewParser(p, &config, c.csvHeaders[i])
 		default:
 			return p
 		}
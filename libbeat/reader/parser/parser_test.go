@@ -768,6 +768,73 @@ func TestParserIncludeMessages(t *testing.T) {
 	require.Equal(t, expectedMessages, readMsgs, "fii")
 }
 
+func TestParsersCSVAndLogfmt(t *testing.T) {
+	tests := map[string]struct {
+		lines          string
+		parsers        map[string]interface{}
+		expectedFields []mapstr.M
+	}{
+		"multiline csv with header": {
+			lines: "id,msg\n1,\"first\nsecond\"\n2,third\n",
+			parsers: map[string]interface{}{
+				"parsers": []map[string]interface{}{
+					{
+						"multiline": map[string]interface{}{
+							"match":   "after",
+							"negate":  true,
+							"pattern": "^[0-9]+,",
+						},
+					},
+					{
+						"csv": map[string]interface{}{
+							"header": true,
+						},
+					},
+				},
+			},
+			expectedFields: []mapstr.M{
+				{"csv": mapstr.M{"id": "1", "msg": "first\n\nsecond"}, "log": mapstr.M{"flags": []string{"multiline"}}},
+				{"csv": mapstr.M{"id": "2", "msg": "third"}},
+			},
+		},
+		"logfmt": {
+			lines: "level=info msg=\"hello world\"\nlevel=warn msg=bye\n",
+			parsers: map[string]interface{}{
+				"parsers": []map[string]interface{}{
+					{
+						"logfmt": map[string]interface{}{
+							"target": "",
+						},
+					},
+				},
+			},
+			expectedFields: []mapstr.M{
+				{"level": "info", "msg": "hello world"},
+				{"level": "warn", "msg": "bye"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			cfg := config.MustNewConfigFrom(test.parsers)
+			var c inputParsersConfig
+			require.NoError(t, cfg.Unpack(&c))
+
+			p := c.Parsers.Create(testReader(test.lines))
+
+			var fields []mapstr.M
+			msg, err := p.Next()
+			for err == nil {
+				fields = append(fields, msg.Fields)
+				msg, err = p.Next()
+			}
+			require.Equal(t, test.expectedFields, fields)
+		})
+	}
+}
+
 type testParsersConfig struct {
 	Parsers []config.Namespace `struct:"parsers"`
 }
@@ -0,0 +1,256 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package readcsv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/elastic/beats/v7/libbeat/reader"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// Config stores the configuration for the Parser.
+type Config struct {
+	// The character separating the values of a record.
+	Separator string `config:"separator"`
+	// If true, leading white space in a value is ignored.
+	TrimLeadingSpace bool `config:"trim_leading_space"`
+	// The names of the columns. They take precedence over the header line.
+	Columns []string `config:"columns"`
+	// If true, the first line of each file is used as the column names.
+	Header bool `config:"header"`
+	// The field the decoded values are written to. If empty, the values are
+	// written to the root of the event.
+	Target string `config:"target"`
+	// If true, errors will be logged.
+	LogErrors bool `config:"log_errors"`
+	// If true, errors will be added to the message fields under the error.message field.
+	AddErrorKey bool `config:"add_error_key"`
+}
+
+// DefaultConfig will return a Config with default values.
+func DefaultConfig() Config {
+	return Config{
+		Separator:   ",",
+		Target:      "csv",
+		AddErrorKey: true,
+	}
+}
+
+// Validate validates the Config.
+func (c *Config) Validate() error {
+	if utf8.RuneCountInString(c.Separator) != 1 {
+		return fmt.Errorf("separator must be a single character, got %q", c.Separator)
+	}
+	sep, _ := utf8.DecodeRuneInString(c.Separator)
+	if sep == '"' || sep == '\r' || sep == '\n' || sep == utf8.RuneError {
+		return fmt.Errorf("invalid separator %q", c.Separator)
+	}
+	if c.Target == "" && !c.Header && len(c.Columns) == 0 {
+		return errors.New("columns or header must be set when target is empty")
+	}
+	return nil
+}
+
+// Parser is a CSV parser that implements parser.Parser.
+type Parser struct {
+	cfg     *Config
+	sep     rune
+	reader  reader.Reader
+	headers *Headers
+	logger  *logp.Logger
+
+	seen   bool     // true once the first message has been read.
+	header []string // the header of the file, if known.
+}
+
+// NewParser creates a new CSV parser. The header of each file is stored in
+// headers, so that a parser resuming in the middle of a file can name its
+// columns. headers can be nil.
+func NewParser(r reader.Reader, cfg *Config, headers *Headers) *Parser {
+	sep, _ := utf8.DecodeRuneInString(cfg.Separator)
+	return &Parser{
+		cfg:     cfg,
+		sep:     sep,
+		reader:  r,
+		headers: headers,
+		logger:  logp.NewLogger("reader_csv"),
+	}
+}
+
+// Close closes this Parser.
+func (p *Parser) Close() error {
+	return p.reader.Close()
+}
+
+// Next reads the next message and decodes its values. Header lines are
+// dropped.
+func (p *Parser) Next() (message reader.Message, err error) {
+	// discardedOffset accounts for the bytes of dropped header lines, so
+	// that inputs can correctly track the file offset.
+	var discardedOffset int
+	defer func() {
+		message.Offset += discardedOffset
+	}()
+
+	for {
+		message, err = p.reader.Next()
+		if err != nil || len(message.Content) == 0 {
+			return message, err
+		}
+
+		record, err := p.decode(message.Content)
+		if err != nil {
+			p.handleError(&message, fmt.Errorf("error decoding CSV message: %w", err))
+			return message, nil
+		}
+
+		key := fileKey(message.Fields)
+		if !p.seen {
+			p.header, _ = p.headers.Get(key)
+		}
+		first := !p.seen
+		p.seen = true
+
+		if p.isHeader(message.Fields, first, record) {
+			p.header = record
+			p.headers.Set(key, record)
+			discardedOffset += message.Offset + message.Bytes
+			continue
+		}
+		if first && p.cfg.Header && p.header == nil && len(p.cfg.Columns) == 0 {
+			path, _ := message.Fields.GetValue("log.file.path")
+			p.logger.Warnf("The CSV header of file %v is unknown, as reading resumed after the header line. Its values are named column<N>.", path)
+		}
+
+		p.addFields(&message, record)
+		return message, nil
+	}
+}
+
+func (p *Parser) decode(content []byte) ([]string, error) {
+	r := csv.NewReader(bytes.NewReader(content))
+	r.Comma = p.sep
+	r.TrimLeadingSpace = p.cfg.TrimLeadingSpace
+	r.FieldsPerRecord = -1
+	return r.Read()
+}
+
+// isHeader reports whether record is a header line. Lines read from the
+// start of a file, or the first message of inputs that don't report file
+// offsets, are headers. Lines repeating the known header are also treated
+// as headers, as happens when files are concatenated.
+func (p *Parser) isHeader(fields mapstr.M, first bool, record []string) bool {
+	if !p.cfg.Header {
+		return false
+	}
+	if p.header != nil && equal(record, p.header) {
+		return true
+	}
+	offset, err := fields.GetValue("log.offset")
+	if err != nil {
+		return first
+	}
+	switch offset := offset.(type) {
+	case int64:
+		return offset == 0
+	case int:
+		return offset == 0
+	}
+	return false
+}
+
+func (p *Parser) addFields(message *reader.Message, record []string) {
+	names := p.cfg.Columns
+	if len(names) == 0 {
+		names = p.header
+	}
+	values := make(mapstr.M, len(record))
+	for i, v := range record {
+		if i < len(names) && names[i] != "" {
+			values[names[i]] = v
+		} else {
+			values["column"+strconv.Itoa(i+1)] = v
+		}
+	}
+
+	if p.cfg.Target == "" {
+		message.AddFields(values)
+		return
+	}
+	fields := mapstr.M{}
+	_, _ = fields.Put(p.cfg.Target, values)
+	message.AddFields(fields)
+}
+
+func (p *Parser) handleError(message *reader.Message, err error) {
+	if p.cfg.LogErrors {
+		p.logger.Errorf("%v", err)
+	}
+	if p.cfg.AddErrorKey {
+		message.AddFields(mapstr.M{"error": mapstr.M{"message": err.Error(), "type": "csv"}})
+	}
+}
+
+// fileIdentityKeys lists the fields identifying the file of a message, in
+// order of preference. The path is only used when no other identity is
+// available, as it changes when files are rotated.
+var fileIdentityKeys = [][]string{
+	{"log.file.fingerprint"},
+	{"log.file.device_id", "log.file.inode"},
+	{"log.file.vol", "log.file.idxhi", "log.file.idxlo"},
+	{"log.file.path"},
+}
+
+// fileKey returns the key identifying the file of a message in Headers, or
+// an empty string if the message doesn't come from a file.
+func fileKey(fields mapstr.M) string {
+	for _, keys := range fileIdentityKeys {
+		parts := make([]string, 0, len(keys))
+		for _, k := range keys {
+			v, err := fields.GetValue(k)
+			if err != nil {
+				break
+			}
+			parts = append(parts, fmt.Sprint(v))
+		}
+		if len(parts) == len(keys) {
+			return keys[0] + "=" + strings.Join(parts, "-")
+		}
+	}
+	return ""
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
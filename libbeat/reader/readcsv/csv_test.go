@@ -0,0 +1,272 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package readcsv
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/reader"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// fileLines returns the messages a file reader produces for lines, starting
+// at offset.
+func fileLines(path string, offset int64, lines ...string) []reader.Message {
+	msgs := make([]reader.Message, len(lines))
+	for i, l := range lines {
+		msgs[i] = reader.Message{
+			Content: []byte(l),
+			Bytes:   len(l) + 1,
+			Fields: mapstr.M{
+				"log": mapstr.M{
+					"offset": offset,
+					"file":   mapstr.M{"path": path, "device_id": "1", "inode": "42"},
+				},
+			},
+		}
+		offset += int64(len(l) + 1)
+	}
+	return msgs
+}
+
+func readAll(t *testing.T, p reader.Reader) []reader.Message {
+	t.Helper()
+	var msgs []reader.Message
+	for {
+		msg, err := p.Next()
+		if err == io.EOF {
+			return msgs
+		}
+		require.NoError(t, err)
+		msgs = append(msgs, msg)
+	}
+}
+
+func csvFields(msgs []reader.Message) []interface{} {
+	out := make([]interface{}, len(msgs))
+	for i, m := range msgs {
+		out[i], _ = m.Fields.GetValue("csv")
+	}
+	return out
+}
+
+func newTestParser(t *testing.T, config map[string]interface{}, headers *Headers, msgs []reader.Message) *Parser {
+	t.Helper()
+	c := DefaultConfig()
+	require.NoError(t, conf.MustNewConfigFrom(config).Unpack(&c))
+	return NewParser(&testReader{messages: msgs}, &c, headers)
+}
+
+func TestParserHeader(t *testing.T) {
+	headers := NewHeaders(10)
+	lines := []string{"id,name,level", "1,alpha,info", `2,"beta, gamma",warn`}
+
+	p := newTestParser(t, map[string]interface{}{"header": true}, headers, fileLines("/var/log/a.csv", 0, lines...))
+	msgs := readAll(t, p)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, []interface{}{
+		mapstr.M{"id": "1", "name": "alpha", "level": "info"},
+		mapstr.M{"id": "2", "name": "beta, gamma", "level": "warn"},
+	}, csvFields(msgs))
+	// The header line bytes are reported as discarded.
+	assert.Equal(t, len(lines[0])+1, msgs[0].Offset)
+	assert.Equal(t, 0, msgs[1].Offset)
+	assert.Equal(t, "1,alpha,info", string(msgs[0].Content))
+
+	t.Run("resume in the middle of the file", func(t *testing.T) {
+		// A new parser sharing the headers names the columns of a file
+		// it reads from the middle, even after the file was renamed.
+		p := newTestParser(t, map[string]interface{}{"header": true}, headers, fileLines("/var/log/a.csv.1", 40, "3,delta,error"))
+		msgs := readAll(t, p)
+		require.Len(t, msgs, 1)
+		assert.Equal(t, []interface{}{
+			mapstr.M{"id": "3", "name": "delta", "level": "error"},
+		}, csvFields(msgs))
+	})
+
+	t.Run("unknown header", func(t *testing.T) {
+		require.NoError(t, logp.DevelopmentSetup(logp.ToObserverOutput()))
+		msgs := fileLines("/var/log/b.csv", 40, "3,delta,error", "4,epsilon,info")
+		msgs[0].Fields.Put("log.file.inode", "43")
+		msgs[1].Fields.Put("log.file.inode", "43")
+		p := newTestParser(t, map[string]interface{}{"header": true}, headers, msgs)
+		msgs = readAll(t, p)
+		require.Len(t, msgs, 2)
+		assert.Equal(t, []interface{}{
+			mapstr.M{"column1": "3", "column2": "delta", "column3": "error"},
+			mapstr.M{"column1": "4", "column2": "epsilon", "column3": "info"},
+		}, csvFields(msgs))
+		logs := logp.ObserverLogs().FilterMessageSnippet("CSV header of file /var/log/b.csv is unknown").TakeAll()
+		assert.Len(t, logs, 1)
+	})
+}
+
+func TestParserRepeatedHeader(t *testing.T) {
+	p := newTestParser(t, map[string]interface{}{"header": true}, nil,
+		fileLines("/var/log/a.csv", 0, "a,b", "1,2", "a,b", "3,4"))
+	msgs := readAll(t, p)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, []interface{}{
+		mapstr.M{"a": "1", "b": "2"},
+		mapstr.M{"a": "3", "b": "4"},
+	}, csvFields(msgs))
+	assert.Equal(t, 4, msgs[1].Offset)
+}
+
+func TestParserWithoutOffsets(t *testing.T) {
+	// Inputs that don't read files use the first message as the header.
+	p := newTestParser(t, map[string]interface{}{"header": true, "separator": ";"}, NewHeaders(10), []reader.Message{
+		{Content: []byte("a;b"), Bytes: 3},
+		{Content: []byte("1;2"), Bytes: 3},
+	})
+	msgs := readAll(t, p)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, []interface{}{mapstr.M{"a": "1", "b": "2"}}, csvFields(msgs))
+}
+
+func TestParserColumns(t *testing.T) {
+	tests := map[string]struct {
+		config  map[string]interface{}
+		line    string
+		want    mapstr.M
+		wantErr string
+	}{
+		"no column names": {
+			line: "1,2",
+			want: mapstr.M{"csv": mapstr.M{"column1": "1", "column2": "2"}},
+		},
+		"more values than columns": {
+			config: map[string]interface{}{"columns": []string{"a", "b"}},
+			line:   "1,2,3",
+			want:   mapstr.M{"csv": mapstr.M{"a": "1", "b": "2", "column3": "3"}},
+		},
+		"fewer values than columns": {
+			config: map[string]interface{}{"columns": []string{"a", "b", "c"}},
+			line:   "1,2",
+			want:   mapstr.M{"csv": mapstr.M{"a": "1", "b": "2"}},
+		},
+		"root target": {
+			config: map[string]interface{}{"columns": []string{"a", "b"}, "target": ""},
+			line:   "1,2",
+			want:   mapstr.M{"a": "1", "b": "2"},
+		},
+		"tab separator and leading space": {
+			config: map[string]interface{}{"columns": []string{"a", "b"}, "separator": "\t", "trim_leading_space": true},
+			line:   "1\t  2",
+			want:   mapstr.M{"csv": mapstr.M{"a": "1", "b": "2"}},
+		},
+		"invalid quotes": {
+			config:  map[string]interface{}{"columns": []string{"a", "b"}},
+			line:    `1,"2`,
+			wantErr: `error decoding CSV message: parse error on line 1`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := newTestParser(t, test.config, nil, []reader.Message{{Content: []byte(test.line), Bytes: len(test.line)}})
+			msgs := readAll(t, p)
+			require.Len(t, msgs, 1)
+			if test.wantErr != "" {
+				errType, _ := msgs[0].Fields.GetValue("error.type")
+				assert.Equal(t, "csv", errType)
+				errMsg, _ := msgs[0].Fields.GetValue("error.message")
+				assert.Contains(t, errMsg, test.wantErr)
+			} else {
+				assert.Equal(t, test.want, msgs[0].Fields)
+			}
+			assert.Equal(t, test.line, string(msgs[0].Content))
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := map[string]struct {
+		config  map[string]interface{}
+		wantErr string
+	}{
+		"default": {},
+		"long separator": {
+			config:  map[string]interface{}{"separator": "::"},
+			wantErr: `separator must be a single character, got "::" accessing config`,
+		},
+		"quote separator": {
+			config:  map[string]interface{}{"separator": `"`},
+			wantErr: `invalid separator "\"" accessing config`,
+		},
+		"root target without names": {
+			config:  map[string]interface{}{"target": ""},
+			wantErr: "columns or header must be set when target is empty accessing config",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := DefaultConfig()
+			err := conf.MustNewConfigFrom(test.config).Unpack(&c)
+			if test.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestHeadersEviction(t *testing.T) {
+	h := NewHeaders(2)
+	h.Set("a", []string{"a"})
+	h.Set("b", []string{"b"})
+	h.Set("a", []string{"a2"})
+	h.Set("c", []string{"c"})
+
+	_, ok := h.Get("a")
+	assert.False(t, ok)
+	got, ok := h.Get("b")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"b"}, got)
+	got, ok = h.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"c"}, got)
+
+	var nilHeaders *Headers
+	nilHeaders.Set("a", []string{"a"})
+	_, ok = nilHeaders.Get("a")
+	assert.False(t, ok)
+}
+
+type testReader struct {
+	messages []reader.Message
+}
+
+func (r *testReader) Next() (reader.Message, error) {
+	if len(r.messages) == 0 {
+		return reader.Message{}, io.EOF
+	}
+	m := r.messages[0]
+	r.messages = r.messages[1:]
+	return m, nil
+}
+
+func (r *testReader) Close() error { return nil }
@@ -0,0 +1,67 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package readcsv
+
+import "sync"
+
+// Headers remembers the header line of files. It is shared by the parsers
+// created from the same configuration, so that a parser reading a file
+// from the middle, for example after its harvester was restarted, can name
+// the columns. The oldest headers are evicted once max files are stored.
+type Headers struct {
+	mu      sync.Mutex
+	max     int
+	headers map[string][]string
+	order   []string
+}
+
+// NewHeaders creates a Headers storing the header of up to max files.
+func NewHeaders(max int) *Headers {
+	return &Headers{
+		max:     max,
+		headers: map[string][]string{},
+	}
+}
+
+// Get returns the header of the file identified by key.
+func (h *Headers) Get(key string) ([]string, bool) {
+	if h == nil || key == "" {
+		return nil, false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	header, ok := h.headers[key]
+	return header, ok
+}
+
+// Set stores the header of the file identified by key.
+func (h *Headers) Set(key string, header []string) {
+	if h == nil || key == "" {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.headers[key]; !ok {
+		h.order = append(h.order, key)
+	}
+	h.headers[key] = header
+	for len(h.order) > h.max {
+		delete(h.headers, h.order[0])
+		h.order = h.order[1:]
+	}
+}
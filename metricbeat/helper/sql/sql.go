@@ -166,6 +166,12 @@ func ReplaceUnderscores(ms mapstr.M) mapstr.M {
 	return dotMap
 }
 
+// ConvertValue converts a value scanned from a row as the fetch functions do:
+// numbers are returned as float64 and timestamps as RFC3339 strings.
+func ConvertValue(v interface{}) interface{} {
+	return getValue(&v)
+}
+
 func getValue(pval *interface{}) interface{} {
 	switch v := (*pval).(type) {
 	case nil, bool:
@@ -203,6 +209,8 @@ func SwitchDriverName(d string) string {
 		return "postgres"
 	case "postgresql":
 		return "postgres"
+	case "sqlite3":
+		return "sqlite"
 	}
 
 	return d
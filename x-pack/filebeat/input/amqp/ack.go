@@ -0,0 +1,66 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package amqp
+
+import (
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/acker"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// ackHandle is stored in the Private field of published events and
+// identifies the last delivery that contributed to the event.
+type ackHandle struct {
+	acker amqp.Acknowledger
+	tag   uint64
+}
+
+// ack acknowledges the delivery and all the previous unacknowledged
+// deliveries on the same channel.
+func (h *ackHandle) ack() error {
+	return h.acker.Ack(h.tag, true)
+}
+
+// newEventACKHandler returns a beat ACKer that acknowledges deliveries to the
+// broker once their events have been ACKed by the pipeline.
+//
+// Events are ACKed in publishing order and each queue has its own channel and
+// pipeline client, so acknowledging the last delivery tag of a batch with
+// multiple set also covers the deliveries that were merged by parsers or
+// whose events were dropped by processors.
+func newEventACKHandler(log *logp.Logger, metrics *inputMetrics) beat.EventListener {
+	return acker.ConnectionOnly(
+		acker.EventPrivateReporter(func(acked int, privates []interface{}) {
+			metrics.eventsACKedTotal.Add(uint64(acked))
+
+			var last *ackHandle
+			for _, private := range privates {
+				h, ok := private.(*ackHandle)
+				if !ok {
+					continue
+				}
+				// Events published before a reconnect belong to a closed
+				// channel. Acknowledge them separately, the broker will
+				// redeliver them if the channel is gone.
+				if last != nil && last.acker != h.acker {
+					ackDelivery(log, metrics, last)
+				}
+				last = h
+			}
+			if last != nil {
+				ackDelivery(log, metrics, last)
+			}
+		}),
+	)
+}
+
+func ackDelivery(log *logp.Logger, metrics *inputMetrics, h *ackHandle) {
+	if err := h.ack(); err != nil {
+		metrics.ackErrorsTotal.Inc()
+		log.Warnw("Failed to acknowledge delivery, it will be redelivered by the broker", "delivery_tag", h.tag, "error", err)
+	}
+}
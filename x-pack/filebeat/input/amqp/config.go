@@ -0,0 +1,119 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package amqp
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/elastic/beats/v7/libbeat/reader/parser"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+type config struct {
+	URLs        []string          `config:"urls" validate:"required"`    // Broker URLs (amqp:// or amqps://). Tried in order on (re)connect.
+	TLS         *tlscommon.Config `config:"ssl"`                         // TLS options for amqps:// URLs.
+	ConsumerTag string            `config:"consumer_tag"`                // Consumer tag prefix. The queue name is appended.
+	Prefetch    int               `config:"prefetch" validate:"min=1"`   // Maximum number of unacknowledged deliveries per queue.
+	Heartbeat   time.Duration     `config:"heartbeat" validate:"min=0"`  // Heartbeat interval negotiated with the broker.
+	Backoff     backoffConfig     `config:"backoff"`                     // Reconnection backoff.
+	WaitClose   time.Duration     `config:"wait_close" validate:"min=0"` // Time to wait for pending ACKs on shutdown.
+	Exchanges   []exchangeConfig  `config:"exchanges"`                   // Exchanges to declare on connect.
+	Queues      []queueConfig     `config:"queues" validate:"required"`  // Queues to consume from.
+	Parsers     parser.Config     `config:",inline"`
+}
+
+type backoffConfig struct {
+	Init time.Duration `config:"init" validate:"nonzero"`
+	Max  time.Duration `config:"max" validate:"nonzero"`
+}
+
+type exchangeConfig struct {
+	Name       string `config:"name" validate:"required"`
+	Type       string `config:"type"`
+	Durable    bool   `config:"durable"`
+	AutoDelete bool   `config:"auto_delete"`
+	Internal   bool   `config:"internal"`
+}
+
+type queueConfig struct {
+	Name       string          `config:"name" validate:"required"`
+	Declare    bool            `config:"declare"`     // Declare the queue on connect.
+	Durable    bool            `config:"durable"`     // Only used when declaring the queue.
+	AutoDelete bool            `config:"auto_delete"` // Only used when declaring the queue.
+	Exclusive  bool            `config:"exclusive"`   // Only used when declaring the queue.
+	Bindings   []bindingConfig `config:"bindings"`    // Bindings created on connect.
+}
+
+type bindingConfig struct {
+	Exchange   string `config:"exchange" validate:"required"`
+	RoutingKey string `config:"routing_key"`
+}
+
+func defaultConfig() config {
+	return config{
+		ConsumerTag: "filebeat",
+		Prefetch:    100,
+		Heartbeat:   10 * time.Second,
+		Backoff: backoffConfig{
+			Init: time.Second,
+			Max:  time.Minute,
+		},
+		WaitClose: 2 * time.Second,
+	}
+}
+
+func (e *exchangeConfig) InitDefaults() {
+	e.Type = "direct"
+	e.Durable = true
+}
+
+func (q *queueConfig) InitDefaults() {
+	q.Durable = true
+}
+
+func (c *config) Validate() error {
+	for i, u := range c.URLs {
+		// The parse error is not wrapped as it may contain credentials.
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return fmt.Errorf("invalid url at index %d", i)
+		}
+		switch parsed.Scheme {
+		case "amqp", "amqps":
+		default:
+			return fmt.Errorf("invalid url scheme %q: allowed values are amqp and amqps", parsed.Scheme)
+		}
+	}
+	if c.Backoff.Max < c.Backoff.Init {
+		return errors.New("backoff.max must be greater than or equal to backoff.init")
+	}
+	seen := make(map[string]bool, len(c.Queues))
+	for _, q := range c.Queues {
+		if seen[q.Name] {
+			return fmt.Errorf("queue %q configured more than once", q.Name)
+		}
+		seen[q.Name] = true
+	}
+	for _, e := range c.Exchanges {
+		switch e.Type {
+		case "direct", "fanout", "topic", "headers":
+		default:
+			return fmt.Errorf("invalid type %q for exchange %q: allowed values are direct, fanout, topic and headers", e.Type, e.Name)
+		}
+	}
+	return nil
+}
+
+// redactURL removes the password from an AMQP URL so that it can be logged.
+func redactURL(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "<invalid url>"
+	}
+	return parsed.Redacted()
+}
@@ -0,0 +1,137 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package amqp
+
+import (
+	"errors"
+	"io"
+	"math"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/reader"
+	"github.com/elastic/beats/v7/libbeat/reader/parser"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+// consumer publishes the deliveries of a single queue.
+type consumer struct {
+	queue      string
+	deliveries <-chan amqp.Delivery
+	client     beat.Client
+	parsers    parser.Config
+	metrics    *inputMetrics
+	log        *logp.Logger
+}
+
+// run publishes deliveries until the delivery channel is closed.
+func (c *consumer) run() {
+	r := &deliveryReader{queue: c.queue, deliveries: c.deliveries, metrics: c.metrics}
+	p := c.parsers.Create(r)
+	defer p.Close()
+
+	for {
+		msg, err := p.Next()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				c.log.Errorw("Failed to read delivery", "error", err)
+			}
+			return
+		}
+		c.client.Publish(msg.ToEvent())
+	}
+}
+
+// deliveryReader is a reader.Reader that returns one message per delivery.
+type deliveryReader struct {
+	queue      string
+	deliveries <-chan amqp.Delivery
+	metrics    *inputMetrics
+}
+
+var _ reader.Reader = (*deliveryReader)(nil)
+
+func (r *deliveryReader) Next() (reader.Message, error) {
+	d, ok := <-r.deliveries
+	if !ok {
+		return reader.Message{}, io.EOF
+	}
+	r.metrics.messagesReceivedTotal.Inc()
+	r.metrics.bytesReceivedTotal.Add(uint64(len(d.Body)))
+	return newMessage(r.queue, d), nil
+}
+
+func (r *deliveryReader) Close() error {
+	return nil
+}
+
+func newMessage(queue string, d amqp.Delivery) reader.Message {
+	ts := d.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	return reader.Message{
+		Ts:      ts.UTC(),
+		Content: d.Body,
+		Bytes:   len(d.Body),
+		Fields:  mapstr.M{"amqp": deliveryFields(queue, d)},
+		Private: &ackHandle{acker: d.Acknowledger, tag: d.DeliveryTag},
+	}
+}
+
+func deliveryFields(queue string, d amqp.Delivery) mapstr.M {
+	fields := mapstr.M{
+		"queue":       queue,
+		"routing_key": d.RoutingKey,
+		"redelivered": d.Redelivered,
+	}
+	putNonEmpty := func(key, value string) {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+	putNonEmpty("exchange", d.Exchange)
+	putNonEmpty("message_id", d.MessageId)
+	putNonEmpty("correlation_id", d.CorrelationId)
+	putNonEmpty("content_type", d.ContentType)
+	putNonEmpty("content_encoding", d.ContentEncoding)
+	putNonEmpty("app_id", d.AppId)
+	putNonEmpty("type", d.Type)
+	putNonEmpty("user_id", d.UserId)
+	if len(d.Headers) != 0 {
+		fields["headers"] = headerValue(d.Headers)
+	}
+	return fields
+}
+
+// headerValue converts AMQP table values into types that can be encoded in
+// an event.
+func headerValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case amqp.Table:
+		m := make(mapstr.M, len(v))
+		for k, val := range v {
+			m[k] = headerValue(val)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, val := range v {
+			s[i] = headerValue(val)
+		}
+		return s
+	case amqp.Decimal:
+		return float64(v.Value) / math.Pow10(int(v.Scale))
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.UTC()
+	default:
+		return v
+	}
+}
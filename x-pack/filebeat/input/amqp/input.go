@@ -0,0 +1,257 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package amqp
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/backoff"
+	"github.com/elastic/beats/v7/libbeat/feature"
+	"github.com/elastic/beats/v7/libbeat/management/status"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+const inputName = "amqp"
+
+func Plugin() v2.Plugin {
+	return v2.Plugin{
+		Name:       inputName,
+		Stability:  feature.Experimental,
+		Deprecated: false,
+		Info:       "AMQP 0-9-1 (RabbitMQ) consumer",
+		Doc:        "The AMQP input consumes messages from AMQP 0-9-1 queues and acknowledges them once they have been published",
+		Manager:    v2.ConfigureWith(configure),
+	}
+}
+
+func configure(cfg *conf.C) (v2.Input, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+	return newInput(config)
+}
+
+type amqpInput struct {
+	config config
+	tls    *tlscommon.TLSConfig
+}
+
+var _ v2.Input = (*amqpInput)(nil)
+
+func newInput(config config) (*amqpInput, error) {
+	tls, err := tlscommon.LoadTLSConfig(config.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS configuration: %w", err)
+	}
+	return &amqpInput{config: config, tls: tls}, nil
+}
+
+func (in *amqpInput) Name() string { return inputName }
+
+func (in *amqpInput) Test(ctx v2.TestContext) error {
+	conn, _, err := in.dial(ctx.Logger, 0)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (in *amqpInput) Run(ctx v2.Context, pipeline beat.Pipeline) error {
+	log := ctx.Logger.With("queues", queueNames(in.config.Queues))
+	log.Info("Starting " + inputName + " input")
+	defer log.Info(inputName + " input stopped")
+	ctx.UpdateStatus(status.Starting, "")
+
+	metrics := newInputMetrics(ctx.ID, nil)
+	defer metrics.Close()
+
+	// Each queue is consumed on its own channel and published through its
+	// own pipeline client, so that delivery tags are ACKed in the order in
+	// which they were published.
+	clients := make([]beat.Client, len(in.config.Queues))
+	for i := range in.config.Queues {
+		client, err := pipeline.ConnectWith(beat.ClientConfig{
+			EventListener: newEventACKHandler(log, metrics),
+			WaitClose:     in.config.WaitClose,
+		})
+		if err != nil {
+			ctx.UpdateStatus(status.Failed, "failed to connect to pipeline: "+err.Error())
+			return fmt.Errorf("failed to create pipeline client: %w", err)
+		}
+		defer client.Close()
+		clients[i] = client
+	}
+
+	connectDelay := backoff.NewEqualJitterBackoff(
+		ctx.Cancelation.Done(),
+		in.config.Backoff.Init,
+		in.config.Backoff.Max,
+	)
+	urlIdx := 0
+	for ctx.Cancelation.Err() == nil {
+		conn, connURL, err := in.dial(log, urlIdx)
+		urlIdx++
+		if err != nil {
+			log.Errorw("Failed to connect to AMQP broker", "error", err)
+			ctx.UpdateStatus(status.Degraded, "failed to connect to broker: "+err.Error())
+			metrics.connectErrorsTotal.Inc()
+			connectDelay.Wait()
+			continue
+		}
+		// We've successfully connected, reset the backoff timer.
+		connectDelay.Reset()
+
+		err = in.runSession(ctx, log.With("url", connURL), conn, clients, metrics)
+		if ctx.Cancelation.Err() != nil {
+			break
+		}
+		log.Errorw("AMQP session closed, reconnecting", "error", err)
+		ctx.UpdateStatus(status.Degraded, "session closed: "+err.Error())
+		metrics.reconnectsTotal.Inc()
+		connectDelay.Wait()
+	}
+
+	ctx.UpdateStatus(status.Stopped, "")
+	return nil
+}
+
+// dial connects to the broker at the given index, modulo the number of
+// configured URLs. It returns the connection and the redacted URL.
+func (in *amqpInput) dial(log *logp.Logger, idx int) (*amqp.Connection, string, error) {
+	u := in.config.URLs[idx%len(in.config.URLs)]
+	redacted := redactURL(u)
+
+	cfg := amqp.Config{
+		Heartbeat:  in.config.Heartbeat,
+		Properties: amqp.NewConnectionProperties(),
+	}
+	cfg.Properties.SetClientConnectionName(in.config.ConsumerTag)
+	if in.tls != nil {
+		parsed, err := amqp.ParseURI(u)
+		if err != nil {
+			return nil, redacted, fmt.Errorf("invalid url %s", redacted)
+		}
+		cfg.TLSClientConfig = in.tls.BuildModuleClientConfig(parsed.Host)
+	}
+
+	log.Debugw("Connecting to AMQP broker", "url", redacted)
+	conn, err := amqp.DialConfig(u, cfg)
+	if err != nil {
+		return nil, redacted, fmt.Errorf("failed to connect to %s: %w", redacted, err)
+	}
+	return conn, redacted, nil
+}
+
+// runSession declares the configured topology and consumes all queues until
+// the connection is closed or the input is cancelled.
+func (in *amqpInput) runSession(ctx v2.Context, log *logp.Logger, conn *amqp.Connection, clients []beat.Client, metrics *inputMetrics) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	// Closing the connection closes all delivery channels, which stops
+	// the consumers.
+	defer conn.Close()
+
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+
+	if err := declare(conn, in.config); err != nil {
+		return err
+	}
+
+	for i, q := range in.config.Queues {
+		deliveries, err := consume(conn, q, in.config)
+		if err != nil {
+			return err
+		}
+		c := &consumer{
+			queue:      q.Name,
+			deliveries: deliveries,
+			client:     clients[i],
+			parsers:    in.config.Parsers,
+			metrics:    metrics,
+			log:        log.With("queue", q.Name),
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.run()
+		}()
+	}
+
+	log.Info("Consuming from AMQP broker")
+	ctx.UpdateStatus(status.Running, "")
+
+	select {
+	case <-ctx.Cancelation.Done():
+		return nil
+	case err, ok := <-closed:
+		if !ok || err == nil {
+			return errors.New("connection closed")
+		}
+		return err
+	}
+}
+
+// declare declares the configured exchanges, queues and bindings.
+func declare(conn *amqp.Connection, config config) error {
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open channel: %w", err)
+	}
+	defer ch.Close()
+
+	for _, e := range config.Exchanges {
+		err := ch.ExchangeDeclare(e.Name, e.Type, e.Durable, e.AutoDelete, e.Internal, false, nil)
+		if err != nil {
+			return fmt.Errorf("failed to declare exchange %q: %w", e.Name, err)
+		}
+	}
+	for _, q := range config.Queues {
+		if q.Declare {
+			if _, err := ch.QueueDeclare(q.Name, q.Durable, q.AutoDelete, q.Exclusive, false, nil); err != nil {
+				return fmt.Errorf("failed to declare queue %q: %w", q.Name, err)
+			}
+		}
+		for _, b := range q.Bindings {
+			if err := ch.QueueBind(q.Name, b.RoutingKey, b.Exchange, false, nil); err != nil {
+				return fmt.Errorf("failed to bind queue %q to exchange %q: %w", q.Name, b.Exchange, err)
+			}
+		}
+	}
+	return nil
+}
+
+// consume opens a channel for the queue and starts consuming from it with
+// manual acknowledgement.
+func consume(conn *amqp.Connection, q queueConfig, config config) (<-chan amqp.Delivery, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open channel for queue %q: %w", q.Name, err)
+	}
+	if err = ch.Qos(config.Prefetch, 0, false); err != nil {
+		return nil, fmt.Errorf("failed to set prefetch for queue %q: %w", q.Name, err)
+	}
+	deliveries, err := ch.Consume(q.Name, config.ConsumerTag+"-"+q.Name, false, false, false, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume from queue %q: %w", q.Name, err)
+	}
+	return deliveries, nil
+}
+
+func queueNames(queues []queueConfig) []string {
+	names := make([]string, len(queues))
+	for i, q := range queues {
+		names[i] = q.Name
+	}
+	return names
+}
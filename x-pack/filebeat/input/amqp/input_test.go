@@ -0,0 +1,166 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package amqp
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/reader/parser"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+type ackCall struct {
+	tag      uint64
+	multiple bool
+}
+
+type fakeAcknowledger struct {
+	acks []ackCall
+	err  error
+}
+
+func (a *fakeAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.acks = append(a.acks, ackCall{tag, multiple})
+	return a.err
+}
+
+func (a *fakeAcknowledger) Nack(uint64, bool, bool) error { return nil }
+func (a *fakeAcknowledger) Reject(uint64, bool) error     { return nil }
+
+func TestEventACKHandler(t *testing.T) {
+	metrics := newInputMetrics("test", monitoring.NewRegistry())
+	defer metrics.Close()
+
+	oldChannel := &fakeAcknowledger{err: errors.New("channel closed")}
+	newChannel := &fakeAcknowledger{}
+
+	listener := newEventACKHandler(logp.NewLogger(inputName), metrics)
+	events := []beat.Event{
+		{Private: &ackHandle{acker: oldChannel, tag: 7}},
+		{Private: &ackHandle{acker: oldChannel, tag: 8}},
+		{Private: &ackHandle{acker: newChannel, tag: 1}},
+		{Private: &ackHandle{acker: newChannel, tag: 3}},
+	}
+	for _, e := range events {
+		listener.AddEvent(e, true)
+	}
+	listener.ACKEvents(len(events))
+
+	assert.Equal(t, []ackCall{{8, true}}, oldChannel.acks)
+	assert.Equal(t, []ackCall{{3, true}}, newChannel.acks)
+	assert.Equal(t, uint64(4), metrics.eventsACKedTotal.Get())
+	assert.Equal(t, uint64(1), metrics.ackErrorsTotal.Get())
+}
+
+func TestConsumer(t *testing.T) {
+	metrics := newInputMetrics("test", monitoring.NewRegistry())
+	defer metrics.Close()
+
+	ts := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	acker := &fakeAcknowledger{}
+	deliveries := make(chan amqp.Delivery, 3)
+	deliveries <- amqp.Delivery{Acknowledger: acker, DeliveryTag: 1, RoutingKey: "app", Body: []byte("first line")}
+	deliveries <- amqp.Delivery{Acknowledger: acker, DeliveryTag: 2, RoutingKey: "app", Body: []byte("  continued")}
+	deliveries <- amqp.Delivery{
+		Acknowledger: acker,
+		DeliveryTag:  3,
+		Exchange:     "logs",
+		RoutingKey:   "app.error",
+		MessageId:    "m1",
+		Redelivered:  true,
+		Timestamp:    ts,
+		Headers: amqp.Table{
+			"retries": int32(2),
+			"nested":  amqp.Table{"price": amqp.Decimal{Scale: 2, Value: 1050}},
+		},
+		Body: []byte("second"),
+	}
+	close(deliveries)
+
+	var parsers parser.Config
+	require.NoError(t, parsers.Unpack(conf.MustNewConfigFrom(map[string]interface{}{
+		"parsers": []map[string]interface{}{{
+			"multiline": map[string]interface{}{
+				"type":    "pattern",
+				"pattern": `^\s`,
+				"match":   "after",
+			},
+		}},
+	})))
+
+	client := &fakeClient{}
+	c := &consumer{
+		queue:      "q",
+		deliveries: deliveries,
+		client:     client,
+		parsers:    parsers,
+		metrics:    metrics,
+		log:        logp.NewLogger(inputName),
+	}
+	c.run()
+
+	require.Len(t, client.events, 2)
+
+	// Merged deliveries are acknowledged with the tag of the last one.
+	first := client.events[0]
+	assert.Equal(t, &ackHandle{acker: acker, tag: 2}, first.Private)
+	assert.Equal(t, "first line\n  continued", mustGetValue(t, first.Fields, "message"))
+	assert.Equal(t, "app", mustGetValue(t, first.Fields, "amqp.routing_key"))
+
+	second := client.events[1]
+	assert.Equal(t, ts, second.Timestamp)
+	assert.Equal(t, &ackHandle{acker: acker, tag: 3}, second.Private)
+	assert.Equal(t, "second", mustGetValue(t, second.Fields, "message"))
+	assert.Equal(t, "q", mustGetValue(t, second.Fields, "amqp.queue"))
+	assert.Equal(t, "logs", mustGetValue(t, second.Fields, "amqp.exchange"))
+	assert.Equal(t, "app.error", mustGetValue(t, second.Fields, "amqp.routing_key"))
+	assert.Equal(t, "m1", mustGetValue(t, second.Fields, "amqp.message_id"))
+	assert.Equal(t, true, mustGetValue(t, second.Fields, "amqp.redelivered"))
+	assert.Equal(t, int32(2), mustGetValue(t, second.Fields, "amqp.headers.retries"))
+	assert.Equal(t, 10.5, mustGetValue(t, second.Fields, "amqp.headers.nested.price"))
+
+	assert.Equal(t, uint64(3), metrics.messagesReceivedTotal.Get())
+}
+
+func TestDeliveryReaderEOF(t *testing.T) {
+	metrics := newInputMetrics("test", monitoring.NewRegistry())
+	defer metrics.Close()
+
+	deliveries := make(chan amqp.Delivery)
+	close(deliveries)
+	r := &deliveryReader{queue: "q", deliveries: deliveries, metrics: metrics}
+	_, err := r.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+type fakeClient struct {
+	events []beat.Event
+}
+
+func (c *fakeClient) Publish(e beat.Event) { c.events = append(c.events, e) }
+func (c *fakeClient) PublishAll(es []beat.Event) {
+	for _, e := range es {
+		c.Publish(e)
+	}
+}
+func (c *fakeClient) Close() error { return nil }
+
+func mustGetValue(t *testing.T, m mapstr.M, key string) interface{} {
+	t.Helper()
+	v, err := m.GetValue(key)
+	require.NoError(t, err, key)
+	return v
+}
@@ -0,0 +1,40 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package amqp
+
+import (
+	"github.com/elastic/beats/v7/libbeat/monitoring/inputmon"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+type inputMetrics struct {
+	unregister func()
+
+	messagesReceivedTotal *monitoring.Uint // Number of AMQP deliveries received (not necessarily processed fully).
+	bytesReceivedTotal    *monitoring.Uint // Number of AMQP delivery body bytes received.
+	eventsACKedTotal      *monitoring.Uint // Number of events ACKed by the pipeline.
+	ackErrorsTotal        *monitoring.Uint // Number of failed attempts to acknowledge deliveries to the broker.
+	connectErrorsTotal    *monitoring.Uint // Number of failed connection attempts.
+	reconnectsTotal       *monitoring.Uint // Number of times an established session was lost.
+}
+
+// Close removes the metrics from the registry.
+func (m *inputMetrics) Close() {
+	m.unregister()
+}
+
+func newInputMetrics(id string, optionalParent *monitoring.Registry) *inputMetrics {
+	reg, unreg := inputmon.NewInputRegistry(inputName, id, optionalParent)
+
+	return &inputMetrics{
+		unregister:            unreg,
+		messagesReceivedTotal: monitoring.NewUint(reg, "messages_received_total"),
+		bytesReceivedTotal:    monitoring.NewUint(reg, "bytes_received_total"),
+		eventsACKedTotal:      monitoring.NewUint(reg, "events_acked_total"),
+		ackErrorsTotal:        monitoring.NewUint(reg, "ack_errors_total"),
+		connectErrorsTotal:    monitoring.NewUint(reg, "connect_errors_total"),
+		reconnectsTotal:       monitoring.NewUint(reg, "reconnects_total"),
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/elastic/beats/v7/filebeat/beater"
 	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
 	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/amqp"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/awscloudwatch"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/awss3"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/azureblobstorage"
@@ -24,7 +25,9 @@ import (
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/lumberjack"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/o365audit"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/redisstreams"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/salesforce"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/sql"
 	"github.com/elastic/beats/v7/x-pack/filebeat/input/streaming"
 	"github.com/elastic/elastic-agent-libs/logp"
 )
@@ -47,6 +50,9 @@ func xpackInputs(info beat.Info, log *logp.Logger, store beater.StateStore) []v2
 		streaming.Plugin(log, store),
 		streaming.PluginWebsocketAlias(log, store),
 		netflow.Plugin(log),
+		amqp.Plugin(),
+		redisstreams.Plugin(),
+		sql.Plugin(log, store),
 		benchmark.Plugin(),
 	}
 }
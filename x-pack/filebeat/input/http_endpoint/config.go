@@ -47,6 +47,8 @@ type config struct {
 	CRCProvider           string                  `config:"crc.provider"`
 	CRCSecret             string                  `config:"crc.secret"`
 	IncludeHeaders        []string                `config:"include_headers"`
+	IncludeClientIdentity bool                    `config:"include_client_identity"`
+	JWT                   *jwtConfig              `config:"jwt"`
 	PreserveOriginalEvent bool                    `config:"preserve_original_event"`
 	Tracer                *tracerConfig           `config:"tracer"`
 }
@@ -113,6 +115,16 @@ func (c *config) Validate() error {
 		return errors.New("crc.provider is required when crc.secret is defined")
 	}
 
+	if c.JWT != nil {
+		if err := c.JWT.validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.IncludeClientIdentity && (c.TLS == nil || !c.TLS.IsEnabled()) {
+		return errors.New("include_client_identity requires ssl to be enabled")
+	}
+
 	return nil
 }
 
@@ -46,6 +46,36 @@ func Test_validateConfig(t *testing.T) {
 			},
 			wantError: "response_body must be valid JSON",
 		},
+		{
+			name: "jwt without JWKS",
+			config: config{
+				URL:          "/",
+				ResponseBody: `{"message": "success"}`,
+				Method:       http.MethodPost,
+				JWT:          &jwtConfig{Issuer: "https://issuer.example.com"},
+			},
+			wantError: "one of jwt.jwks_file or jwt.jwks_url is required",
+		},
+		{
+			name: "jwt invalid algorithm",
+			config: config{
+				URL:          "/",
+				ResponseBody: `{"message": "success"}`,
+				Method:       http.MethodPost,
+				JWT:          &jwtConfig{JWKSURL: "https://issuer.example.com/jwks", Algorithms: []string{"none"}},
+			},
+			wantError: `unsupported jwt.algorithms value: "none"`,
+		},
+		{
+			name: "client identity without TLS",
+			config: config{
+				URL:                   "/",
+				ResponseBody:          `{"message": "success"}`,
+				Method:                http.MethodPost,
+				IncludeClientIdentity: true,
+			},
+			wantError: "include_client_identity requires ssl to be enabled",
+		},
 	}
 
 	for _, tc := range testCases {
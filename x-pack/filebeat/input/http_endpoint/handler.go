@@ -7,6 +7,8 @@ package http_endpoint
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -53,6 +55,7 @@ type handler struct {
 	publish     func(beat.Event)
 	log         *logp.Logger
 	validator   apiValidator
+	jwt         *jwtValidator
 	txBaseID    string        // Random value to make transaction IDs unique.
 	txIDCounter atomic.Uint64 // Transaction ID counter that is incremented for each request.
 
@@ -64,6 +67,7 @@ type handler struct {
 	responseCode          int
 	responseBody          string
 	includeHeaders        []string
+	includeClientIdentity bool
 	preserveOriginalEvent bool
 	crc                   *crcValidator
 }
@@ -77,6 +81,29 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var identity mapstr.M
+	if h.jwt != nil {
+		claims, status, err := h.jwt.validate(r)
+		if err != nil {
+			if status == http.StatusUnauthorized {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			}
+			h.sendAPIErrorResponse(txID, w, r, h.log, status, err)
+			return
+		}
+		if len(claims) != 0 {
+			identity = mapstr.M{"jwt": mapstr.M{"claims": claims}}
+		}
+	}
+	if h.includeClientIdentity {
+		if client := getClientIdentity(r); client != nil {
+			if identity == nil {
+				identity = mapstr.M{}
+			}
+			identity["tls"] = mapstr.M{"client": client}
+		}
+	}
+
 	wait, err := getTimeoutWait(r.URL, h.log)
 	if err != nil {
 		h.sendAPIErrorResponse(txID, w, r, h.log, http.StatusBadRequest, err)
@@ -152,7 +179,7 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 
 		acker.Add()
-		if err = h.publishEvent(obj, headers, acker); err != nil {
+		if err = h.publishEvent(obj, headers, identity, acker); err != nil {
 			h.metrics.apiErrors.Add(1)
 			h.sendAPIErrorResponse(txID, w, r, h.log, http.StatusInternalServerError, err)
 			return
@@ -308,7 +335,7 @@ func (h *handler) sendResponse(w http.ResponseWriter, status int, message string
 	}
 }
 
-func (h *handler) publishEvent(obj, headers mapstr.M, acker *batchACKTracker) error {
+func (h *handler) publishEvent(obj, headers, identity mapstr.M, acker *batchACKTracker) error {
 	event := beat.Event{
 		Timestamp: time.Now().UTC(),
 		Private:   acker,
@@ -328,6 +355,9 @@ func (h *handler) publishEvent(obj, headers mapstr.M, acker *batchACKTracker) er
 	if len(headers) > 0 {
 		event.Fields["headers"] = headers
 	}
+	if len(identity) > 0 {
+		event.Fields.DeepUpdate(identity.Clone())
+	}
 
 	h.publish(event)
 	return nil
@@ -552,6 +582,45 @@ func getIncludedHeaders(r *http.Request, headerConf []string) (includedHeaders m
 	return includedHeaders
 }
 
+// getClientIdentity returns the ECS tls.client fields for the certificate
+// presented by the client of a mutual TLS connection.
+func getClientIdentity(r *http.Request) mapstr.M {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	cert := r.TLS.PeerCertificates[0]
+	sum := sha256.Sum256(cert.Raw)
+	x509 := mapstr.M{
+		"serial_number": fmt.Sprintf("%X", cert.SerialNumber),
+	}
+	if cert.Subject.CommonName != "" {
+		x509["subject"] = mapstr.M{"common_name": []string{cert.Subject.CommonName}}
+	}
+	if cert.Issuer.CommonName != "" {
+		x509["issuer"] = mapstr.M{"common_name": []string{cert.Issuer.CommonName}}
+	}
+	var names []string
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	for _, u := range cert.URIs {
+		names = append(names, u.String())
+	}
+	if len(names) != 0 {
+		x509["alternative_names"] = names
+	}
+	return mapstr.M{
+		"subject":    cert.Subject.String(),
+		"issuer":     cert.Issuer.String(),
+		"not_before": cert.NotBefore.UTC(),
+		"not_after":  cert.NotAfter.UTC(),
+		"hash":       mapstr.M{"sha256": strings.ToUpper(hex.EncodeToString(sum[:]))},
+		"x509":       x509,
+	}
+}
+
 func newJSONDecoder(r io.Reader) *json.Decoder {
 	dec := json.NewDecoder(r)
 	dec.UseNumber()
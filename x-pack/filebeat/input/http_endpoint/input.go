@@ -347,11 +347,13 @@ func newHandler(ctx context.Context, c config, prg *program, pub func(beat.Event
 			hmacType:     c.HMACType,
 			hmacPrefix:   c.HMACPrefix,
 		},
+		jwt:                   newJWTValidator(c.JWT),
 		program:               prg,
 		messageField:          c.Prefix,
 		responseCode:          c.ResponseCode,
 		responseBody:          htmlEscape(c.ResponseBody),
 		includeHeaders:        canonicalizeHeaders(c.IncludeHeaders),
+		includeClientIdentity: c.IncludeClientIdentity,
 		preserveOriginalEvent: c.PreserveOriginalEvent,
 		crc:                   newCRC(c.CRCProvider, c.CRCSecret),
 	}
@@ -0,0 +1,485 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package http_endpoint
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/elastic/beats/v7/libbeat/common"
+	"github.com/elastic/beats/v7/libbeat/common/jsontransform"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+var (
+	errMissingBearerToken = errors.New("missing bearer token")
+	errJWKSUnavailable    = errors.New("JWKS unavailable")
+	errNoMatchingKey      = errors.New("no matching key in JWKS")
+)
+
+// jwtAlgorithms are the supported JWT signing algorithms.
+var jwtAlgorithms = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+	"HS256", "HS384", "HS512",
+}
+
+// defaultJWTAlgorithms are the algorithms accepted when none are configured.
+// HMAC algorithms must be enabled explicitly.
+var defaultJWTAlgorithms = jwtAlgorithms[:10]
+
+const (
+	// minJWKSRefresh is the minimum time between reloads of the JWKS
+	// that are triggered by unknown key IDs or failed loads.
+	minJWKSRefresh = 10 * time.Second
+
+	// jwksFetchTimeout is the timeout for fetching a JWKS from a URL.
+	jwksFetchTimeout = 30 * time.Second
+
+	// maxJWKSSize is the maximum accepted size of a JWKS document.
+	maxJWKSSize = 1 << 20
+)
+
+type jwtConfig struct {
+	JWKSFile      string        `config:"jwks_file"`
+	JWKSURL       string        `config:"jwks_url"`
+	JWKSCacheTTL  time.Duration `config:"jwks_cache_ttl"`
+	Issuer        string        `config:"issuer"`
+	Audience      []string      `config:"audience"`
+	Algorithms    []string      `config:"algorithms"`
+	Leeway        time.Duration `config:"leeway"`
+	IncludeClaims []string      `config:"include_claims"`
+}
+
+func (c *jwtConfig) validate() error {
+	switch {
+	case c.JWKSFile == "" && c.JWKSURL == "":
+		return errors.New("one of jwt.jwks_file or jwt.jwks_url is required")
+	case c.JWKSFile != "" && c.JWKSURL != "":
+		return errors.New("only one of jwt.jwks_file or jwt.jwks_url may be set")
+	}
+	if c.JWKSURL != "" {
+		u, err := url.Parse(c.JWKSURL)
+		if err != nil {
+			return fmt.Errorf("invalid jwt.jwks_url: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("jwt.jwks_url must be an http or https URL: %s", c.JWKSURL)
+		}
+	}
+	if c.JWKSFile != "" {
+		b, err := os.ReadFile(c.JWKSFile)
+		if err != nil {
+			return fmt.Errorf("failed to read jwt.jwks_file: %w", err)
+		}
+		_, err = parseJWKS(b)
+		if err != nil {
+			return fmt.Errorf("invalid jwt.jwks_file: %w", err)
+		}
+	}
+	if c.JWKSCacheTTL < 0 {
+		return errors.New("jwt.jwks_cache_ttl must not be negative")
+	}
+	if c.Leeway < 0 {
+		return errors.New("jwt.leeway must not be negative")
+	}
+	for _, alg := range c.Algorithms {
+		if !isValidJWTAlgorithm(alg) {
+			return fmt.Errorf("unsupported jwt.algorithms value: %q", alg)
+		}
+	}
+	return nil
+}
+
+func isValidJWTAlgorithm(alg string) bool {
+	for _, a := range jwtAlgorithms {
+		if alg == a {
+			return true
+		}
+	}
+	return false
+}
+
+// jwtValidator validates bearer JWTs against a cached JWKS.
+type jwtValidator struct {
+	keys          *jwksCache
+	parser        *jwt.Parser
+	audience      []string
+	includeClaims []string
+}
+
+func newJWTValidator(c *jwtConfig) *jwtValidator {
+	if c == nil {
+		return nil
+	}
+	algs := c.Algorithms
+	if len(algs) == 0 {
+		algs = defaultJWTAlgorithms
+	}
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(algs),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(c.Leeway),
+		jwt.WithJSONNumber(),
+	}
+	if c.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(c.Issuer))
+	}
+	return &jwtValidator{
+		keys: &jwksCache{
+			path:   c.JWKSFile,
+			url:    c.JWKSURL,
+			ttl:    c.JWKSCacheTTL,
+			client: &http.Client{Timeout: jwksFetchTimeout},
+		},
+		parser:        jwt.NewParser(opts...),
+		audience:      c.Audience,
+		includeClaims: c.IncludeClaims,
+	}
+}
+
+// validate checks the bearer token in the Authorization header of r and
+// returns the claims selected by include_claims.
+func (v *jwtValidator) validate(r *http.Request) (claims mapstr.M, status int, err error) {
+	auth := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(auth, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, http.StatusUnauthorized, errMissingBearerToken
+	}
+
+	var mc jwt.MapClaims
+	_, err = v.parser.ParseWithClaims(token, &mc, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		keys, err := v.keys.get(r.Context(), kid)
+		if err != nil {
+			return nil, err
+		}
+		var set jwt.VerificationKeySet
+		for _, k := range keys {
+			if kid != "" && k.kid != kid {
+				continue
+			}
+			if k.accepts(t.Method.Alg()) {
+				set.Keys = append(set.Keys, k.key)
+			}
+		}
+		if len(set.Keys) == 0 {
+			return nil, errNoMatchingKey
+		}
+		return set, nil
+	})
+	if err != nil {
+		if errors.Is(err, errJWKSUnavailable) {
+			return nil, http.StatusServiceUnavailable, fmt.Errorf("invalid bearer token: %w", err)
+		}
+		return nil, http.StatusUnauthorized, fmt.Errorf("invalid bearer token: %w", err)
+	}
+	if len(v.audience) != 0 && !hasAudience(mc, v.audience) {
+		return nil, http.StatusUnauthorized, fmt.Errorf("invalid bearer token: %w", jwt.ErrTokenInvalidAudience)
+	}
+
+	if len(v.includeClaims) == 0 {
+		return nil, http.StatusOK, nil
+	}
+	claims = mapstr.M{}
+	for _, name := range v.includeClaims {
+		if c, ok := mc[name]; ok {
+			claims[common.DeDot(name)] = c
+		}
+	}
+	jsontransform.TransformNumbers(claims)
+	return claims, http.StatusOK, nil
+}
+
+// hasAudience returns whether any of the token's audiences is in want.
+func hasAudience(claims jwt.MapClaims, want []string) bool {
+	aud, err := claims.GetAudience()
+	if err != nil {
+		return false
+	}
+	for _, a := range aud {
+		for _, w := range want {
+			if a == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jwksCache holds the keys of a JWKS loaded from a file or a URL. The keys
+// are reloaded when they are older than the TTL or when a token refers to
+// an unknown key ID, so that key rotations are picked up.
+//
+// A single reload runs at a time, without holding the lock. Requests keep
+// using the cached keys while it runs, except when they need a key that is
+// not cached, in which case they wait for the reload.
+type jwksCache struct {
+	path   string
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	mu        sync.Mutex
+	keys      []jsonWebKey
+	loaded    time.Time     // Time of the last successful load.
+	attempted time.Time     // Time of the last load attempt.
+	err       error         // Error of the last load attempt.
+	loading   chan struct{} // Closed when the running reload completes.
+}
+
+func (c *jwksCache) get(ctx context.Context, kid string) ([]jsonWebKey, error) {
+	c.mu.Lock()
+	now := time.Now()
+	missing := c.keys == nil || (kid != "" && !c.hasKey(kid))
+	reload := missing || (c.ttl > 0 && now.Sub(c.loaded) >= c.ttl)
+	if reload && c.loading == nil && (c.attempted.IsZero() || now.Sub(c.attempted) >= minJWKSRefresh) {
+		c.attempted = now
+		c.loading = make(chan struct{})
+		go c.reload(c.loading)
+	}
+	loading := c.loading
+	c.mu.Unlock()
+
+	if missing && loading != nil {
+		select {
+		case <-loading:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %v", errJWKSUnavailable, ctx.Err())
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.keys == nil {
+		return nil, fmt.Errorf("%w: %v", errJWKSUnavailable, c.err)
+	}
+	// Keep using the previous keys if a reload failed.
+	return c.keys, nil
+}
+
+// reload loads the keys and closes done. It doesn't use the context of the
+// request that started it, so that other requests waiting for the reload are
+// not affected if that request is cancelled. The HTTP client timeout bounds
+// the reload.
+func (c *jwksCache) reload(done chan struct{}) {
+	keys, err := c.load(context.Background())
+
+	c.mu.Lock()
+	c.err = err
+	if err == nil {
+		c.keys = keys
+		c.loaded = time.Now()
+	}
+	c.loading = nil
+	c.mu.Unlock()
+	close(done)
+}
+
+func (c *jwksCache) hasKey(kid string) bool {
+	for _, k := range c.keys {
+		if k.kid == kid {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *jwksCache) load(ctx context.Context) ([]jsonWebKey, error) {
+	if c.path != "" {
+		b, err := os.ReadFile(c.path)
+		if err != nil {
+			return nil, err
+		}
+		return parseJWKS(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: %s", resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	return parseJWKS(b)
+}
+
+// jsonWebKey is a public or symmetric verification key from a JWKS.
+type jsonWebKey struct {
+	kid string
+	alg string
+	key interface{}
+}
+
+// accepts returns whether the key can verify tokens signed with alg.
+func (k jsonWebKey) accepts(alg string) bool {
+	if k.alg != "" && k.alg != alg {
+		return false
+	}
+	switch k.key.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case *ecdsa.PublicKey:
+		return strings.HasPrefix(alg, "ES")
+	case ed25519.PublicKey:
+		return alg == "EdDSA"
+	case []byte:
+		return strings.HasPrefix(alg, "HS")
+	default:
+		return false
+	}
+}
+
+// parseJWKS parses the signing keys in a JSON Web Key Set as described in
+// RFC 7517. Keys for other uses and of unsupported types are ignored.
+func parseJWKS(b []byte) ([]jsonWebKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			Alg string `json:"alg"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+			K   string `json:"k"`
+		} `json:"keys"`
+	}
+	err := json.Unmarshal(b, &set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+	var keys []jsonWebKey
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var key interface{}
+		switch k.Kty {
+		case "RSA":
+			key, err = rsaKey(k.N, k.E)
+		case "EC":
+			key, err = ecKey(k.Crv, k.X, k.Y)
+		case "OKP":
+			if k.Crv != "Ed25519" {
+				continue
+			}
+			key, err = ed25519Key(k.X)
+		case "oct":
+			key, err = base64.RawURLEncoding.DecodeString(k.K)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s key %d (kid=%q): %w", k.Kty, i, k.Kid, err)
+		}
+		keys = append(keys, jsonWebKey{kid: k.Kid, alg: k.Alg, key: key})
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys in JWKS")
+	}
+	return keys, nil
+}
+
+func rsaKey(n, e string) (*rsa.PublicKey, error) {
+	nb, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	eb, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+	if len(nb) == 0 || len(eb) == 0 || len(eb) > 4 {
+		return nil, errors.New("invalid modulus or exponent")
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nb),
+		E: int(new(big.Int).SetBytes(eb).Int64()),
+	}, nil
+}
+
+func ecKey(crv, x, y string) (*ecdsa.PublicKey, error) {
+	var (
+		curve elliptic.Curve
+		check ecdh.Curve
+	)
+	switch crv {
+	case "P-256":
+		curve, check = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, check = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, check = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve: %q", crv)
+	}
+	xb, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x coordinate: %w", err)
+	}
+	yb, err := base64.RawURLEncoding.DecodeString(y)
+	if err != nil {
+		return nil, fmt.Errorf("invalid y coordinate: %w", err)
+	}
+	size := (curve.Params().BitSize + 7) / 8
+	if len(xb) != size || len(yb) != size {
+		return nil, errors.New("invalid coordinate length")
+	}
+	// Check that the point is on the curve.
+	point := append(append([]byte{4}, xb...), yb...)
+	_, err = check.NewPublicKey(point)
+	if err != nil {
+		return nil, err
+	}
+	return &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(xb),
+		Y:     new(big.Int).SetBytes(yb),
+	}, nil
+}
+
+func ed25519Key(x string) (ed25519.PublicKey, error) {
+	b, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid key length")
+	}
+	return ed25519.PublicKey(b), nil
+}
@@ -0,0 +1,450 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package http_endpoint
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestParseJWKS(t *testing.T) {
+	rsaPriv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecPriv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	b := jwks(t,
+		rsaJWK("rsa", &rsaPriv.PublicKey),
+		ecJWK("ec", &ecPriv.PublicKey),
+		map[string]string{"kty": "OKP", "crv": "Ed25519", "kid": "ed", "x": b64(edPub)},
+		map[string]string{"kty": "oct", "kid": "hmac", "k": b64([]byte("secret"))},
+		map[string]string{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+		map[string]string{"kty": "OKP", "crv": "X25519", "kid": "x", "x": b64(edPub)},
+	)
+	keys, err := parseJWKS(b)
+	require.NoError(t, err)
+	require.Len(t, keys, 4)
+	assert.Equal(t, &rsaPriv.PublicKey, keys[0].key)
+	assert.True(t, ecPriv.PublicKey.Equal(keys[1].key))
+	assert.Equal(t, edPub, keys[2].key)
+	assert.Equal(t, []byte("secret"), keys[3].key)
+
+	assert.True(t, keys[0].accepts("RS256"))
+	assert.True(t, keys[0].accepts("PS512"))
+	assert.False(t, keys[0].accepts("ES256"))
+	assert.True(t, keys[1].accepts("ES256"))
+	assert.True(t, keys[2].accepts("EdDSA"))
+	assert.False(t, keys[3].accepts("RS256"))
+
+	_, err = parseJWKS(jwks(t, map[string]string{"kty": "EC", "crv": "P-256", "x": b64(make([]byte, 32)), "y": b64(make([]byte, 32))}))
+	assert.Error(t, err, "point not on curve")
+	_, err = parseJWKS(jwks(t))
+	assert.EqualError(t, err, "no signing keys in JWKS")
+	_, err = parseJWKS([]byte("{"))
+	assert.Error(t, err)
+}
+
+func TestJWTValidator(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	err = os.WriteFile(path, jwks(t, rsaJWK("key1", &key.PublicKey)), 0o600)
+	require.NoError(t, err)
+
+	cfg := &jwtConfig{
+		JWKSFile:      path,
+		Issuer:        "https://issuer.example.com",
+		Audience:      []string{"webhooks", "other"},
+		IncludeClaims: []string{"sub", "https://example.com/tenant", "n", "missing"},
+	}
+	require.NoError(t, cfg.validate())
+	v := newJWTValidator(cfg)
+
+	valid := jwt.MapClaims{
+		"iss":                        "https://issuer.example.com",
+		"aud":                        []string{"webhooks"},
+		"exp":                        time.Now().Add(time.Hour).Unix(),
+		"sub":                        "client-1",
+		"https://example.com/tenant": "acme",
+		"n":                          42,
+	}
+	with := func(k string, val interface{}) jwt.MapClaims {
+		c := jwt.MapClaims{}
+		for k, v := range valid {
+			c[k] = v
+		}
+		if val == nil {
+			delete(c, k)
+		} else {
+			c[k] = val
+		}
+		return c
+	}
+
+	testCases := []struct {
+		name       string
+		auth       string
+		wantClaims mapstr.M
+		wantStatus int
+		wantErr    string
+	}{
+		{
+			name: "valid",
+			auth: "Bearer " + sign(t, jwt.SigningMethodRS256, "key1", key, valid),
+			wantClaims: mapstr.M{
+				"sub":                        "client-1",
+				"https://example_com/tenant": "acme",
+				"n":                          int64(42),
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "no_kid",
+			auth:       "bearer " + sign(t, jwt.SigningMethodRS256, "", key, valid),
+			wantClaims: mapstr.M{"sub": "client-1", "https://example_com/tenant": "acme", "n": int64(42)},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "missing_token",
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    "missing bearer token",
+		},
+		{
+			name:       "basic_auth",
+			auth:       "Basic dXNlcjpwYXNz",
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    "missing bearer token",
+		},
+		{
+			name:       "wrong_key",
+			auth:       "Bearer " + sign(t, jwt.SigningMethodRS256, "key1", other, valid),
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    "invalid bearer token: token signature is invalid",
+		},
+		{
+			name:       "unknown_kid",
+			auth:       "Bearer " + sign(t, jwt.SigningMethodRS256, "key2", key, valid),
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    "no matching key in JWKS",
+		},
+		{
+			name:       "disallowed_algorithm",
+			auth:       "Bearer " + sign(t, jwt.SigningMethodHS256, "key1", []byte("secret"), valid),
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    "signing method HS256 is invalid",
+		},
+		{
+			name:       "expired",
+			auth:       "Bearer " + sign(t, jwt.SigningMethodRS256, "key1", key, with("exp", time.Now().Add(-time.Minute).Unix())),
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    "token is expired",
+		},
+		{
+			name:       "no_expiry",
+			auth:       "Bearer " + sign(t, jwt.SigningMethodRS256, "key1", key, with("exp", nil)),
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    "token is missing required claim: exp claim is required",
+		},
+		{
+			name:       "wrong_issuer",
+			auth:       "Bearer " + sign(t, jwt.SigningMethodRS256, "key1", key, with("iss", "https://evil.example.com")),
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    "token has invalid issuer",
+		},
+		{
+			name:       "wrong_audience",
+			auth:       "Bearer " + sign(t, jwt.SigningMethodRS256, "key1", key, with("aud", "someone-else")),
+			wantStatus: http.StatusUnauthorized,
+			wantErr:    "invalid bearer token: token has invalid audience",
+		},
+		{
+			name:       "second_audience",
+			auth:       "Bearer " + sign(t, jwt.SigningMethodRS256, "key1", key, with("aud", []string{"x", "other"})),
+			wantClaims: mapstr.M{"sub": "client-1", "https://example_com/tenant": "acme", "n": int64(42)},
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tc.auth != "" {
+				r.Header.Set("Authorization", tc.auth)
+			}
+			claims, status, err := v.validate(r)
+			assert.Equal(t, tc.wantStatus, status)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantClaims, claims)
+		})
+	}
+}
+
+func TestJWKSRotation(t *testing.T) {
+	key1, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key2, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var (
+		current atomic.Value
+		fetches atomic.Int32
+		fail    atomic.Bool
+	)
+	current.Store(jwks(t, rsaJWK("key1", &key1.PublicKey)))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if fail.Load() {
+			http.Error(w, "unavailable", http.StatusInternalServerError)
+			return
+		}
+		w.Write(current.Load().([]byte))
+	}))
+	defer srv.Close()
+
+	v := newJWTValidator(&jwtConfig{JWKSURL: srv.URL, JWKSCacheTTL: time.Hour})
+	claims := jwt.MapClaims{"exp": time.Now().Add(time.Hour).Unix()}
+	validate := func(kid string, key *rsa.PrivateKey) (int, error) {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+sign(t, jwt.SigningMethodRS256, kid, key, claims))
+		_, status, err := v.validate(r)
+		return status, err
+	}
+
+	status, err := validate("key1", key1)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	_, err = validate("key1", key1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), fetches.Load(), "keys should be cached")
+
+	// Rotate the keys. The unknown key ID causes a reload, but not
+	// before the minimum refresh interval has passed.
+	current.Store(jwks(t, rsaJWK("key2", &key2.PublicKey)))
+	_, err = validate("key2", key2)
+	assert.ErrorIs(t, err, errNoMatchingKey)
+	assert.Equal(t, int32(1), fetches.Load())
+
+	v.keys.attempted = v.keys.attempted.Add(-minJWKSRefresh)
+	_, err = validate("key2", key2)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), fetches.Load())
+
+	// A failed reload keeps the previous keys.
+	fail.Store(true)
+	v.keys.loaded = v.keys.loaded.Add(-time.Hour)
+	v.keys.attempted = v.keys.attempted.Add(-time.Hour)
+	_, err = validate("key2", key2)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return fetches.Load() == 3 }, time.Second, 10*time.Millisecond)
+	_, err = validate("key2", key2)
+	require.NoError(t, err)
+
+	// Without any keys, requests are rejected as unavailable.
+	v = newJWTValidator(&jwtConfig{JWKSURL: srv.URL})
+	status, err = validate("key2", key2)
+	assert.ErrorIs(t, err, errJWKSUnavailable)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+}
+
+func TestJWKSReloadDoesNotBlock(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) > 1 {
+			<-release
+		}
+		w.Write(jwks(t, rsaJWK("key1", &key.PublicKey)))
+	}))
+	defer srv.Close()
+
+	c := &jwksCache{url: srv.URL, ttl: time.Hour, client: srv.Client()}
+	ctx := context.Background()
+	keys, err := c.get(ctx, "key1")
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+
+	// While a reload is blocked, concurrent requests are served from the
+	// cache and don't start other reloads.
+	c.mu.Lock()
+	c.loaded = c.loaded.Add(-time.Hour)
+	c.attempted = c.attempted.Add(-time.Hour)
+	c.mu.Unlock()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			keys, err := c.get(ctx, "key1")
+			assert.NoError(t, err)
+			assert.Len(t, keys, 1)
+		}()
+	}
+	wg.Wait()
+	assert.Eventually(t, func() bool { return fetches.Load() == 2 }, time.Second, 10*time.Millisecond)
+
+	// Requests for an unknown key wait for the running reload, until their
+	// context is done.
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = c.get(timeout, "key2")
+	assert.ErrorIs(t, err, errJWKSUnavailable)
+
+	close(release)
+	require.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.loading == nil
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestHandlerIdentity(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	err = os.WriteFile(path, jwks(t, ecJWK("key1", &key.PublicKey)), 0o600)
+	require.NoError(t, err)
+
+	cert := &x509.Certificate{
+		SerialNumber: big.NewInt(0xabcdef),
+		Subject:      pkix.Name{CommonName: "client.example.com", Organization: []string{"Acme"}},
+		Issuer:       pkix.Name{CommonName: "Acme CA"},
+		NotBefore:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		DNSNames:     []string{"client.example.com"},
+		Raw:          []byte("certificate"),
+	}
+
+	c := defaultConfig()
+	c.JWT = &jwtConfig{JWKSFile: path, IncludeClaims: []string{"sub"}}
+	c.IncludeClientIdentity = true
+	pub := new(publisher)
+	metrics := newInputMetrics("")
+	defer metrics.Close()
+	h := newHandler(context.Background(), c, nil, pub.Publish, logp.NewLogger("http_endpoint.test"), metrics)
+
+	req := func(auth string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"id":0}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Authorization", auth)
+		r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+		return r
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req("Bearer invalid"))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `Bearer error="invalid_token"`, rec.Header().Get("WWW-Authenticate"))
+	assert.Empty(t, pub.events)
+
+	token := sign(t, jwt.SigningMethodES256, "key1", key, jwt.MapClaims{
+		"sub": "client-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req("Bearer "+token))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.Len(t, pub.events, 1)
+	assert.Equal(t, mapstr.M{
+		"json": mapstr.M{"id": int64(0)},
+		"jwt":  mapstr.M{"claims": mapstr.M{"sub": "client-1"}},
+		"tls": mapstr.M{"client": mapstr.M{
+			"subject":    "CN=client.example.com,O=Acme",
+			"issuer":     "CN=Acme CA",
+			"not_before": cert.NotBefore,
+			"not_after":  cert.NotAfter,
+			"hash":       mapstr.M{"sha256": "03D66DD08835C1CA3F128CCEACD1F31AC94163096B20F445AE84285BC0832D72"},
+			"x509": mapstr.M{
+				"serial_number":     "ABCDEF",
+				"subject":           mapstr.M{"common_name": []string{"client.example.com"}},
+				"issuer":            mapstr.M{"common_name": []string{"Acme CA"}},
+				"alternative_names": []string{"client.example.com"},
+			},
+		}},
+	}, pub.events[0].Fields)
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	s, err := tok.SignedString(key)
+	require.NoError(t, err)
+	return s
+}
+
+func jwks(t *testing.T, keys ...map[string]string) []byte {
+	t.Helper()
+	if keys == nil {
+		keys = []map[string]string{}
+	}
+	b, err := json.Marshal(map[string]interface{}{"keys": keys})
+	require.NoError(t, err)
+	return b
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   b64(key.N.Bytes()),
+		"e":   b64(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": "P-256",
+		"x":   b64(key.X.FillBytes(make([]byte, 32))),
+		"y":   b64(key.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
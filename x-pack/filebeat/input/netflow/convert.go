@@ -61,9 +61,10 @@ func normaliseIPFields(fields mapstr.M) {
 
 func toBeatEventCommon(flow record.Record) beat.Event {
 	const (
-		flowType    = "netflow_flow"
-		optionsType = "netflow_options"
-		unknownType = "netflow_unknown"
+		flowType     = "netflow_flow"
+		optionsType  = "netflow_options"
+		countersType = "netflow_counters"
+		unknownType  = "netflow_unknown"
 	)
 
 	// replace net.HardwareAddress with its String() representation
@@ -77,6 +78,8 @@ func toBeatEventCommon(flow record.Record) beat.Event {
 		flow.Fields["type"] = flowType
 	case record.Options:
 		flow.Fields["type"] = optionsType
+	case record.Counters:
+		flow.Fields["type"] = countersType
 	default:
 		flow.Fields["type"] = unknownType
 	}
@@ -88,8 +91,11 @@ func toBeatEventCommon(flow record.Record) beat.Event {
 		"category": []string{"network"},
 		"action":   flow.Fields["type"],
 	}
-	if ecsEvent["action"] == flowType {
+	switch ecsEvent["action"] {
+	case flowType:
 		ecsEvent["type"] = []string{"connection"}
+	case countersType:
+		ecsEvent["kind"] = "metric"
 	}
 	// ECS Fields -- device
 	ecsDevice := mapstr.M{}
@@ -83,6 +83,8 @@ func (p *Decoder) Read(buf *bytes.Buffer, source net.Addr) (records []record.Rec
 	if buf.Len() < 2 {
 		return nil, io.EOF
 	}
+	// sFlow datagrams start with a 32-bit version, so their 16-bit prefix
+	// is always zero. See sflow.ProtocolID.
 	version := binary.BigEndian.Uint16(buf.Bytes()[:2])
 
 	handler, exists := p.protos[version]
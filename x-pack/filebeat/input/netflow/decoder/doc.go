@@ -16,6 +16,15 @@
 //
 // # Status
 //
+// sFlow 5
+//
+//   - Flow samples and expanded flow samples. Raw packet headers (Ethernet,
+//     IPv4 and IPv6), Ethernet frame data, sampled IPv4/IPv6 and extended
+//     switch, router and gateway records are mapped to IPFIX fields.
+//   - Counter samples and expanded counter samples with generic and Ethernet
+//     interface counters.
+//   - Missing: Vendor (non-zero enterprise) structures are skipped.
+//
 // IPFIX
 //
 //   - Working implementation as of rfc7011.
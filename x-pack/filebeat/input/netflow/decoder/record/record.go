@@ -19,6 +19,10 @@ const (
 	// Options enumeration value identifies exported options records, as defined
 	// in NetFlowV9 and IPFIX.
 	Options
+
+	// Counters enumeration value identifies interface counter records, as
+	// exported in sFlow counter samples.
+	Counters
 )
 
 // Map type is a regular map with string keys and interface{} values. The valid
@@ -96,6 +100,15 @@ type Record struct {
 	// +--------------+-----------+------------------------------------------------------------------+
 	// | sourceId     |   uint64  | Exporter observation domain ID.                                  |
 	// +--------------+-----------+------------------------------------------------------------------+
+	//
+	// sFlow only:
+	// +----------------+-----------+----------------------------------------------------------------+
+	// | agentAddress   |   net.IP  | IP address of the sFlow agent, as reported in the datagram.    |
+	// +----------------+-----------+----------------------------------------------------------------+
+	// | subAgentId     |   uint64  | ID of the sub-agent that sent the datagram.                    |
+	// +----------------+-----------+----------------------------------------------------------------+
+	// | sequenceNumber |   uint64  | Datagram sequence number for this agent and sub-agent.         |
+	// +----------------+-----------+----------------------------------------------------------------+
 	Exporter Map
 
 	// Type is the type of this record, either Flow or Options.
@@ -0,0 +1,104 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sflow
+
+import (
+	"encoding/binary"
+	"net"
+
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/record"
+)
+
+const (
+	etherTypeIPv4  = 0x0800
+	etherTypeIPv6  = 0x86dd
+	etherTypeVLAN  = 0x8100
+	etherTypeQinQ  = 0x88a8
+	ipProtocolTCP  = 6
+	ipProtocolUDP  = 17
+	ipProtocolSCTP = 132
+)
+
+// decodeEthernet extracts flow fields from a sampled Ethernet header. Sampled
+// headers are usually truncated, so decoding stops silently at the first
+// layer that is incomplete.
+func decodeEthernet(b []byte, fields record.Map) {
+	if len(b) < 14 {
+		return
+	}
+	fields["destinationMacAddress"] = net.HardwareAddr(append([]byte(nil), b[0:6]...))
+	fields["sourceMacAddress"] = net.HardwareAddr(append([]byte(nil), b[6:12]...))
+	etherType := binary.BigEndian.Uint16(b[12:14])
+	b = b[14:]
+	for etherType == etherTypeVLAN || etherType == etherTypeQinQ {
+		if len(b) < 4 {
+			return
+		}
+		if _, found := fields["vlanId"]; !found {
+			fields["vlanId"] = uint64(binary.BigEndian.Uint16(b[0:2]) & 0x0fff)
+		}
+		etherType = binary.BigEndian.Uint16(b[2:4])
+		b = b[4:]
+	}
+	fields["ethernetType"] = uint64(etherType)
+	if etherType == etherTypeIPv4 || etherType == etherTypeIPv6 {
+		decodeIP(b, fields)
+	}
+}
+
+// decodeIP extracts flow fields from a sampled IPv4 or IPv6 header.
+func decodeIP(b []byte, fields record.Map) {
+	if len(b) < 1 {
+		return
+	}
+	var (
+		proto   uint8
+		payload []byte
+	)
+	switch b[0] >> 4 {
+	case 4:
+		ihl := int(b[0]&0x0f) * 4
+		if len(b) < 20 || ihl < 20 {
+			return
+		}
+		fields["ipVersion"] = uint64(4)
+		fields["ipClassOfService"] = uint64(b[1])
+		fields["ipTTL"] = uint64(b[8])
+		proto = b[9]
+		setIPAddresses(fields, net.IP(append([]byte(nil), b[12:16]...)), net.IP(append([]byte(nil), b[16:20]...)))
+		// Only the first fragment carries the transport header.
+		if binary.BigEndian.Uint16(b[6:8])&0x1fff != 0 || len(b) < ihl {
+			fields["protocolIdentifier"] = uint64(proto)
+			return
+		}
+		payload = b[ihl:]
+	case 6:
+		if len(b) < 40 {
+			return
+		}
+		fields["ipVersion"] = uint64(6)
+		fields["ipClassOfService"] = uint64(binary.BigEndian.Uint16(b[0:2]) >> 4 & 0xff)
+		fields["flowLabelIPv6"] = uint64(binary.BigEndian.Uint32(b[0:4]) & 0x000fffff)
+		fields["ipTTL"] = uint64(b[7])
+		proto = b[6]
+		setIPAddresses(fields, net.IP(append([]byte(nil), b[8:24]...)), net.IP(append([]byte(nil), b[24:40]...)))
+		payload = b[40:]
+	default:
+		return
+	}
+	fields["protocolIdentifier"] = uint64(proto)
+
+	switch proto {
+	case ipProtocolTCP, ipProtocolUDP, ipProtocolSCTP:
+		if len(payload) < 4 {
+			return
+		}
+		fields["sourceTransportPort"] = uint64(binary.BigEndian.Uint16(payload[0:2]))
+		fields["destinationTransportPort"] = uint64(binary.BigEndian.Uint16(payload[2:4]))
+		if proto == ipProtocolTCP && len(payload) >= 14 {
+			fields["tcpControlBits"] = uint64(payload[13])
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sflow
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+)
+
+// reader decodes XDR-encoded sFlow structures from a byte slice. All reads
+// fail with io.ErrUnexpectedEOF when the data is shorter than required.
+type reader struct {
+	data []byte
+}
+
+func newReader(data []byte) *reader {
+	return &reader{data: data}
+}
+
+func (r *reader) next(n int) ([]byte, error) {
+	if n < 0 || n > len(r.data) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b, nil
+}
+
+func (r *reader) skip(n int) error {
+	_, err := r.next(n)
+	return err
+}
+
+func (r *reader) uint32() (uint32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+func (r *reader) uint64() (uint64, error) {
+	b, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// opaque reads variable-length opaque data. The length is encoded as a
+// 32-bit prefix and the data is padded to a multiple of four bytes.
+func (r *reader) opaque() ([]byte, error) {
+	length, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(length) > uint64(len(r.data)) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b, err := r.next(int(length))
+	if err != nil {
+		return nil, err
+	}
+	if pad := (4 - length%4) % 4; pad != 0 {
+		if err = r.skip(int(pad)); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func (r *reader) ip(length int) (net.IP, error) {
+	b, err := r.next(length)
+	if err != nil {
+		return nil, err
+	}
+	ip := make(net.IP, length)
+	copy(ip, b)
+	return ip, nil
+}
+
+// address reads an sFlow address structure. It returns a nil IP for
+// addresses of unknown type.
+func (r *reader) address() (net.IP, error) {
+	addrType, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	switch addrType {
+	case addressUnknown:
+		return nil, nil
+	case addressIPv4:
+		return r.ip(net.IPv4len)
+	case addressIPv6:
+		return r.ip(net.IPv6len)
+	}
+	return nil, fmt.Errorf("%w: %d", errUnknownAddressType, addrType)
+}
+
+// mac reads a MAC address, which is encoded as 6 bytes padded to 8.
+func (r *reader) mac() (net.HardwareAddr, error) {
+	b, err := r.next(8)
+	if err != nil {
+		return nil, err
+	}
+	mac := make(net.HardwareAddr, 6)
+	copy(mac, b)
+	return mac, nil
+}
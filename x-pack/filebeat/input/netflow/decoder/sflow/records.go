@@ -0,0 +1,366 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sflow
+
+import (
+	"net"
+
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/record"
+)
+
+// Flow record formats for enterprise 0.
+const (
+	flowRawPacketHeader uint32 = 1
+	flowEthernetFrame   uint32 = 2
+	flowSampledIPv4     uint32 = 3
+	flowSampledIPv6     uint32 = 4
+	flowExtendedSwitch  uint32 = 1001
+	flowExtendedRouter  uint32 = 1002
+	flowExtendedGateway uint32 = 1003
+)
+
+// Header protocols of sampled raw packet headers.
+const (
+	headerProtocolEthernet uint32 = 1
+	headerProtocolIPv4     uint32 = 11
+	headerProtocolIPv6     uint32 = 12
+)
+
+// Counter record formats for enterprise 0.
+const (
+	counterGenericInterface  uint32 = 1
+	counterEthernetInterface uint32 = 2
+)
+
+// decodeFlowRecord decodes a single flow record into fields using the
+// NetFlow V9/IPFIX equivalent names. Unsupported formats are ignored.
+func decodeFlowRecord(format uint32, r *reader, fields record.Map) error {
+	switch format {
+	case flowRawPacketHeader:
+		return decodeRawPacketHeader(r, fields)
+	case flowEthernetFrame:
+		return decodeEthernetFrame(r, fields)
+	case flowSampledIPv4:
+		return decodeSampledIP(r, fields, net.IPv4len)
+	case flowSampledIPv6:
+		return decodeSampledIP(r, fields, net.IPv6len)
+	case flowExtendedSwitch:
+		return decodeExtendedSwitch(r, fields)
+	case flowExtendedRouter:
+		return decodeExtendedRouter(r, fields)
+	case flowExtendedGateway:
+		return decodeExtendedGateway(r, fields)
+	}
+	return nil
+}
+
+func decodeRawPacketHeader(r *reader, fields record.Map) error {
+	headerProtocol, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	frameLength, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	// Bytes stripped from the sampled header, such as the FCS.
+	if err = r.skip(4); err != nil {
+		return err
+	}
+	header, err := r.opaque()
+	if err != nil {
+		return err
+	}
+	fields["octetDeltaCount"] = uint64(frameLength)
+	switch headerProtocol {
+	case headerProtocolEthernet:
+		decodeEthernet(header, fields)
+	case headerProtocolIPv4, headerProtocolIPv6:
+		decodeIP(header, fields)
+	}
+	return nil
+}
+
+func decodeEthernetFrame(r *reader, fields record.Map) error {
+	length, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	src, err := r.mac()
+	if err != nil {
+		return err
+	}
+	dst, err := r.mac()
+	if err != nil {
+		return err
+	}
+	etherType, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	if _, found := fields["octetDeltaCount"]; !found {
+		fields["octetDeltaCount"] = uint64(length)
+	}
+	fields["sourceMacAddress"] = src
+	fields["destinationMacAddress"] = dst
+	fields["ethernetType"] = uint64(etherType)
+	return nil
+}
+
+// decodeSampledIP decodes the sampled_ipv4 and sampled_ipv6 structures. Both
+// share the same layout except for the address length.
+func decodeSampledIP(r *reader, fields record.Map, addrLen int) error {
+	length, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	proto, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	src, err := r.ip(addrLen)
+	if err != nil {
+		return err
+	}
+	dst, err := r.ip(addrLen)
+	if err != nil {
+		return err
+	}
+	srcPort, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	dstPort, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	tcpFlags, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	tos, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	if _, found := fields["octetDeltaCount"]; !found {
+		fields["octetDeltaCount"] = uint64(length)
+	}
+	setIPAddresses(fields, src, dst)
+	fields["protocolIdentifier"] = uint64(proto)
+	fields["sourceTransportPort"] = uint64(srcPort)
+	fields["destinationTransportPort"] = uint64(dstPort)
+	fields["tcpControlBits"] = uint64(tcpFlags)
+	fields["ipClassOfService"] = uint64(tos)
+	return nil
+}
+
+func decodeExtendedSwitch(r *reader, fields record.Map) error {
+	var values [4]uint32
+	for i := range values {
+		v, err := r.uint32()
+		if err != nil {
+			return err
+		}
+		values[i] = v
+	}
+	// values[3] holds the outgoing 802.1p priority, which has no
+	// IPFIX equivalent.
+	fields["vlanId"] = uint64(values[0])
+	fields["dot1qPriority"] = uint64(values[1])
+	fields["postVlanId"] = uint64(values[2])
+	return nil
+}
+
+func decodeExtendedRouter(r *reader, fields record.Map) error {
+	nextHop, err := r.address()
+	if err != nil {
+		return err
+	}
+	srcMask, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	dstMask, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	if ip4 := nextHop.To4(); ip4 != nil {
+		fields["ipNextHopIPv4Address"] = ip4
+		fields["sourceIPv4PrefixLength"] = uint64(srcMask)
+		fields["destinationIPv4PrefixLength"] = uint64(dstMask)
+	} else if nextHop != nil {
+		fields["ipNextHopIPv6Address"] = nextHop
+		fields["sourceIPv6PrefixLength"] = uint64(srcMask)
+		fields["destinationIPv6PrefixLength"] = uint64(dstMask)
+	}
+	return nil
+}
+
+func decodeExtendedGateway(r *reader, fields record.Map) error {
+	nextHop, err := r.address()
+	if err != nil {
+		return err
+	}
+	as, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	srcAS, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	srcPeerAS, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	numSegments, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	var path []uint32
+	for i := uint32(0); i < numSegments; i++ {
+		if _, err = r.uint32(); err != nil { // segment type
+			return err
+		}
+		length, err := r.uint32()
+		if err != nil {
+			return err
+		}
+		for j := uint32(0); j < length; j++ {
+			hop, err := r.uint32()
+			if err != nil {
+				return err
+			}
+			path = append(path, hop)
+		}
+	}
+
+	if ip4 := nextHop.To4(); ip4 != nil {
+		fields["bgpNextHopIPv4Address"] = ip4
+	} else if nextHop != nil {
+		fields["bgpNextHopIPv6Address"] = nextHop
+	}
+	fields["bgpSourceAsNumber"] = uint64(srcAS)
+	fields["bgpPrevAdjacentAsNumber"] = uint64(srcPeerAS)
+	if len(path) > 0 {
+		fields["bgpNextAdjacentAsNumber"] = uint64(path[0])
+		fields["bgpDestinationAsNumber"] = uint64(path[len(path)-1])
+	} else {
+		// The destination is in the router's own AS.
+		fields["bgpDestinationAsNumber"] = uint64(as)
+	}
+	return nil
+}
+
+// decodeCounterRecord decodes a single counter record into fields.
+// Unsupported formats are ignored.
+func decodeCounterRecord(format uint32, r *reader, fields record.Map) error {
+	switch format {
+	case counterGenericInterface:
+		return decodeGenericInterfaceCounters(r, fields)
+	case counterEthernetInterface:
+		return decodeNamedUint32s(r, fields, ethernetCounterNames)
+	}
+	return nil
+}
+
+func decodeGenericInterfaceCounters(r *reader, fields record.Map) error {
+	ifIndex, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	ifType, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	ifSpeed, err := r.uint64()
+	if err != nil {
+		return err
+	}
+	ifDirection, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	ifStatus, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	fields["ifIndex"] = uint64(ifIndex)
+	fields["ifType"] = uint64(ifType)
+	fields["ifSpeed"] = ifSpeed
+	fields["ifDirection"] = uint64(ifDirection)
+	fields["ifAdminStatus"] = uint64(ifStatus & 1)
+	fields["ifOperStatus"] = uint64((ifStatus >> 1) & 1)
+
+	inOctets, err := r.uint64()
+	if err != nil {
+		return err
+	}
+	fields["ifInOctets"] = inOctets
+	if err = decodeNamedUint32s(r, fields, genericInCounterNames); err != nil {
+		return err
+	}
+	outOctets, err := r.uint64()
+	if err != nil {
+		return err
+	}
+	fields["ifOutOctets"] = outOctets
+	return decodeNamedUint32s(r, fields, genericOutCounterNames)
+}
+
+var genericInCounterNames = []string{
+	"ifInUcastPkts",
+	"ifInMulticastPkts",
+	"ifInBroadcastPkts",
+	"ifInDiscards",
+	"ifInErrors",
+	"ifInUnknownProtos",
+}
+
+var genericOutCounterNames = []string{
+	"ifOutUcastPkts",
+	"ifOutMulticastPkts",
+	"ifOutBroadcastPkts",
+	"ifOutDiscards",
+	"ifOutErrors",
+	"ifPromiscuousMode",
+}
+
+var ethernetCounterNames = []string{
+	"dot3StatsAlignmentErrors",
+	"dot3StatsFcsErrors",
+	"dot3StatsSingleCollisionFrames",
+	"dot3StatsMultipleCollisionFrames",
+	"dot3StatsSqeTestErrors",
+	"dot3StatsDeferredTransmissions",
+	"dot3StatsLateCollisions",
+	"dot3StatsExcessiveCollisions",
+	"dot3StatsInternalMacTransmitErrors",
+	"dot3StatsCarrierSenseErrors",
+	"dot3StatsFrameTooLongs",
+	"dot3StatsInternalMacReceiveErrors",
+	"dot3StatsSymbolErrors",
+}
+
+func decodeNamedUint32s(r *reader, fields record.Map, names []string) error {
+	for _, name := range names {
+		v, err := r.uint32()
+		if err != nil {
+			return err
+		}
+		fields[name] = uint64(v)
+	}
+	return nil
+}
+
+func setIPAddresses(fields record.Map, src, dst net.IP) {
+	if src4, dst4 := src.To4(), dst.To4(); src4 != nil && dst4 != nil {
+		fields["sourceIPv4Address"] = src4
+		fields["destinationIPv4Address"] = dst4
+		return
+	}
+	fields["sourceIPv6Address"] = src
+	fields["destinationIPv6Address"] = dst
+}
@@ -0,0 +1,319 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sflow
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/config"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/protocol"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/record"
+)
+
+const (
+	ProtocolName = "sflow"
+	LogPrefix    = "[sflow] "
+
+	// ProtocolID is the value of the first 16 bits of an sFlow datagram.
+	// sFlow encodes its version as a 32-bit integer, so the 16-bit prefix
+	// used to select NetFlow and IPFIX parsers is always zero.
+	ProtocolID uint16 = 0
+
+	// Version is the sFlow datagram version supported by this protocol.
+	Version uint32 = 5
+)
+
+// Sample formats for enterprise 0 as defined in sFlow v5.
+const (
+	formatFlowSample            uint32 = 1
+	formatCounterSample         uint32 = 2
+	formatExpandedFlowSample    uint32 = 3
+	formatExpandedCounterSample uint32 = 4
+)
+
+// Address types used in sFlow address structures.
+const (
+	addressUnknown uint32 = 0
+	addressIPv4    uint32 = 1
+	addressIPv6    uint32 = 2
+)
+
+var errUnknownAddressType = errors.New("unknown address type")
+
+type SFlowProtocol struct {
+	logger *log.Logger
+}
+
+var _ protocol.Protocol = (*SFlowProtocol)(nil)
+
+func init() {
+	protocol.Registry.Register(ProtocolName, New)
+}
+
+func New(config config.Config) protocol.Protocol {
+	return &SFlowProtocol{
+		logger: log.New(config.LogOutput(), LogPrefix, 0),
+	}
+}
+
+func (*SFlowProtocol) Version() uint16 {
+	return ProtocolID
+}
+
+func (*SFlowProtocol) Start() error {
+	return nil
+}
+
+func (*SFlowProtocol) Stop() error {
+	return nil
+}
+
+// PacketHeader is the header of an sFlow v5 datagram.
+type PacketHeader struct {
+	Version        uint32
+	AgentAddress   net.IP
+	SubAgentID     uint32
+	SequenceNumber uint32
+	SysUptime      uint32 // 32 bit milliseconds
+	NumSamples     uint32
+}
+
+func (p *SFlowProtocol) OnPacket(buf *bytes.Buffer, source net.Addr) (flows []record.Record, err error) {
+	r := newReader(buf.Bytes())
+	buf.Reset()
+
+	header, err := readPacketHeader(r)
+	if err != nil {
+		p.logger.Printf("Failed parsing packet: %v", err)
+		return nil, fmt.Errorf("error reading sflow header: %w", err)
+	}
+
+	// sFlow datagrams don't carry an export time.
+	timestamp := time.Now().UTC()
+	metadata := record.Map{
+		"version":        uint64(header.Version),
+		"timestamp":      timestamp,
+		"uptimeMillis":   uint64(header.SysUptime),
+		"address":        source.String(),
+		"subAgentId":     uint64(header.SubAgentID),
+		"sequenceNumber": uint64(header.SequenceNumber),
+	}
+	if header.AgentAddress != nil {
+		metadata["agentAddress"] = header.AgentAddress
+	}
+
+	for i := uint32(0); i < header.NumSamples; i++ {
+		dataFormat, err := r.uint32()
+		if err != nil {
+			return nil, fmt.Errorf("error reading sample %d format: %w", i, err)
+		}
+		sample, err := r.opaque()
+		if err != nil {
+			return nil, fmt.Errorf("error reading sample %d: %w", i, err)
+		}
+		enterprise, format := dataFormat>>12, dataFormat&0xfff
+		if enterprise != 0 {
+			p.logger.Printf("Skipping sample with unsupported enterprise %d (format %d)", enterprise, format)
+			continue
+		}
+		var rec record.Record
+		switch format {
+		case formatFlowSample:
+			rec, err = decodeFlowSample(newReader(sample), false)
+		case formatExpandedFlowSample:
+			rec, err = decodeFlowSample(newReader(sample), true)
+		case formatCounterSample:
+			rec, err = decodeCounterSample(newReader(sample), false)
+		case formatExpandedCounterSample:
+			rec, err = decodeCounterSample(newReader(sample), true)
+		default:
+			p.logger.Printf("Skipping sample with unsupported format %d", format)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing sample %d (format %d): %w", i, format, err)
+		}
+		rec.Timestamp = timestamp
+		rec.Exporter = metadata
+		flows = append(flows, rec)
+	}
+	return flows, nil
+}
+
+func readPacketHeader(r *reader) (header PacketHeader, err error) {
+	if header.Version, err = r.uint32(); err != nil {
+		return header, err
+	}
+	if header.Version != Version {
+		return header, fmt.Errorf("unsupported sflow version %d", header.Version)
+	}
+	if header.AgentAddress, err = r.address(); err != nil {
+		return header, err
+	}
+	if header.SubAgentID, err = r.uint32(); err != nil {
+		return header, err
+	}
+	if header.SequenceNumber, err = r.uint32(); err != nil {
+		return header, err
+	}
+	if header.SysUptime, err = r.uint32(); err != nil {
+		return header, err
+	}
+	if header.NumSamples, err = r.uint32(); err != nil {
+		return header, err
+	}
+	return header, nil
+}
+
+// decodeFlowSample decodes a flow_sample or, when expanded is set, an
+// expanded_flow_sample structure.
+func decodeFlowSample(r *reader, expanded bool) (rec record.Record, err error) {
+	var (
+		samplingRate, samplePool, drops uint32
+		inFormat, inValue               uint32
+		outFormat, outValue             uint32
+	)
+	// Skip the sequence number and the source ID, which is split into
+	// source_id_type and source_id_index for expanded samples.
+	skip := 8
+	if expanded {
+		skip = 12
+	}
+	if err = r.skip(skip); err != nil {
+		return rec, err
+	}
+	if samplingRate, err = r.uint32(); err != nil {
+		return rec, err
+	}
+	if samplePool, err = r.uint32(); err != nil {
+		return rec, err
+	}
+	if drops, err = r.uint32(); err != nil {
+		return rec, err
+	}
+	if expanded {
+		if inFormat, err = r.uint32(); err != nil {
+			return rec, err
+		}
+		if inValue, err = r.uint32(); err != nil {
+			return rec, err
+		}
+		if outFormat, err = r.uint32(); err != nil {
+			return rec, err
+		}
+		if outValue, err = r.uint32(); err != nil {
+			return rec, err
+		}
+	} else {
+		var in, out uint32
+		if in, err = r.uint32(); err != nil {
+			return rec, err
+		}
+		if out, err = r.uint32(); err != nil {
+			return rec, err
+		}
+		inFormat, inValue = in>>30, in&0x3fffffff
+		outFormat, outValue = out>>30, out&0x3fffffff
+	}
+
+	fields := record.Map{
+		"samplingPacketInterval":  uint64(samplingRate),
+		"samplingPopulation":      uint64(samplePool),
+		"droppedPacketTotalCount": uint64(drops),
+		"packetDeltaCount":        uint64(1),
+	}
+	// Only format 0 holds an ifIndex. Other formats signal discarded
+	// packets or multiple output interfaces.
+	if inFormat == 0 && inValue != 0 && inValue != 0x3fffffff {
+		fields["ingressInterface"] = uint64(inValue)
+	}
+	if outFormat == 0 && outValue != 0 && outValue != 0x3fffffff {
+		fields["egressInterface"] = uint64(outValue)
+	}
+
+	numRecords, err := r.uint32()
+	if err != nil {
+		return rec, err
+	}
+	for i := uint32(0); i < numRecords; i++ {
+		dataFormat, err := r.uint32()
+		if err != nil {
+			return rec, err
+		}
+		data, err := r.opaque()
+		if err != nil {
+			return rec, err
+		}
+		if dataFormat>>12 != 0 {
+			continue
+		}
+		if err = decodeFlowRecord(dataFormat&0xfff, newReader(data), fields); err != nil {
+			return rec, fmt.Errorf("flow record %d: %w", dataFormat&0xfff, err)
+		}
+	}
+	return record.Record{
+		Type:   record.Flow,
+		Fields: fields,
+	}, nil
+}
+
+// decodeCounterSample decodes a counters_sample or, when expanded is set, an
+// expanded_counters_sample structure.
+func decodeCounterSample(r *reader, expanded bool) (rec record.Record, err error) {
+	var sourceType, sourceIndex uint32
+	// Sequence number.
+	if err = r.skip(4); err != nil {
+		return rec, err
+	}
+	if expanded {
+		if sourceType, err = r.uint32(); err != nil {
+			return rec, err
+		}
+		if sourceIndex, err = r.uint32(); err != nil {
+			return rec, err
+		}
+	} else {
+		var sourceID uint32
+		if sourceID, err = r.uint32(); err != nil {
+			return rec, err
+		}
+		sourceType, sourceIndex = sourceID>>24, sourceID&0xffffff
+	}
+
+	fields := record.Map{
+		"sourceIdType":  uint64(sourceType),
+		"sourceIdIndex": uint64(sourceIndex),
+	}
+
+	numRecords, err := r.uint32()
+	if err != nil {
+		return rec, err
+	}
+	for i := uint32(0); i < numRecords; i++ {
+		dataFormat, err := r.uint32()
+		if err != nil {
+			return rec, err
+		}
+		data, err := r.opaque()
+		if err != nil {
+			return rec, err
+		}
+		if dataFormat>>12 != 0 {
+			continue
+		}
+		if err = decodeCounterRecord(dataFormat&0xfff, newReader(data), fields); err != nil {
+			return rec, fmt.Errorf("counter record %d: %w", dataFormat&0xfff, err)
+		}
+	}
+	return record.Record{
+		Type:   record.Counters,
+		Fields: fields,
+	}, nil
+}
@@ -0,0 +1,291 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sflow
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/config"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/record"
+	"github.com/elastic/beats/v7/x-pack/filebeat/input/netflow/decoder/test"
+)
+
+// xdr builds XDR-encoded test datagrams.
+type xdr []byte
+
+func (x xdr) u32(values ...uint32) xdr {
+	for _, v := range values {
+		x = binary.BigEndian.AppendUint32(x, v)
+	}
+	return x
+}
+
+func (x xdr) u64(v uint64) xdr {
+	return binary.BigEndian.AppendUint64(x, v)
+}
+
+func (x xdr) raw(b []byte) xdr {
+	return append(x, b...)
+}
+
+func (x xdr) opaque(b []byte) xdr {
+	x = x.u32(uint32(len(b))).raw(b)
+	return append(x, make([]byte, (4-len(b)%4)%4)...)
+}
+
+func datagram(samples ...xdr) xdr {
+	d := xdr{}.u32(Version, addressIPv4).raw([]byte{10, 0, 0, 1}).u32(3, 42, 123456, uint32(len(samples)))
+	for _, s := range samples {
+		d = d.raw(s)
+	}
+	return d
+}
+
+func sample(format uint32, body xdr) xdr {
+	return xdr{}.u32(format).opaque(body)
+}
+
+func tcpPacket() []byte {
+	eth := []byte{
+		0x00, 0x11, 0x22, 0x33, 0x44, 0x55, // dst
+		0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, // src
+		0x81, 0x00, 0x00, 0x64, // 802.1Q, VLAN 100
+		0x08, 0x00, // IPv4
+	}
+	ip := []byte{
+		0x45, 0x10, 0x00, 0x3c, 0x00, 0x00, 0x40, 0x00, 0x40, 0x06, 0x00, 0x00,
+		192, 168, 1, 10,
+		172, 16, 0, 1,
+	}
+	tcp := []byte{
+		0xc3, 0x50, 0x01, 0xbb, // 50000 -> 443
+		0, 0, 0, 0, 0, 0, 0, 0, 0x50, 0x12,
+	}
+	return append(append(eth, ip...), tcp...)
+}
+
+func TestSFlowProtocol_New(t *testing.T) {
+	proto := New(config.Defaults())
+
+	assert.Nil(t, proto.Start())
+	assert.Equal(t, uint16(0), proto.Version())
+	assert.Nil(t, proto.Stop())
+}
+
+func TestSFlowProtocol_FlowSample(t *testing.T) {
+	rawHeader := xdr{}.u32(headerProtocolEthernet, 1514, 4).opaque(tcpPacket())
+	switchRecord := xdr{}.u32(100, 3, 200, 0)
+	body := xdr{}.u32(
+		7,          // sequence number
+		0x00000005, // source id
+		512,        // sampling rate
+		51200,      // sample pool
+		2,          // drops
+		5,          // input
+		6,          // output
+		2,          // number of records
+	).u32(flowRawPacketHeader).opaque(rawHeader).
+		u32(flowExtendedSwitch).opaque(switchRecord)
+
+	proto := New(config.Defaults())
+	source := test.MakeAddress(t, "127.0.0.1:6343")
+	flows, err := proto.OnPacket(bytes.NewBuffer(datagram(sample(formatFlowSample, body))), source)
+	require.NoError(t, err)
+	require.Len(t, flows, 1)
+
+	flow := flows[0]
+	assert.Equal(t, record.Flow, flow.Type)
+	test.AssertMapEqual(t, record.Map{
+		"version":        uint64(5),
+		"address":        "127.0.0.1:6343",
+		"agentAddress":   net.IP{10, 0, 0, 1},
+		"subAgentId":     uint64(3),
+		"sequenceNumber": uint64(42),
+		"uptimeMillis":   uint64(123456),
+		"timestamp":      flow.Timestamp,
+	}, flow.Exporter)
+	test.AssertMapEqual(t, record.Map{
+		"samplingPacketInterval":   uint64(512),
+		"samplingPopulation":       uint64(51200),
+		"droppedPacketTotalCount":  uint64(2),
+		"ingressInterface":         uint64(5),
+		"egressInterface":          uint64(6),
+		"packetDeltaCount":         uint64(1),
+		"octetDeltaCount":          uint64(1514),
+		"sourceMacAddress":         net.HardwareAddr{0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb},
+		"destinationMacAddress":    net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55},
+		"vlanId":                   uint64(100),
+		"postVlanId":               uint64(200),
+		"dot1qPriority":            uint64(3),
+		"ethernetType":             uint64(0x0800),
+		"ipVersion":                uint64(4),
+		"ipClassOfService":         uint64(0x10),
+		"ipTTL":                    uint64(64),
+		"sourceIPv4Address":        net.IP{192, 168, 1, 10},
+		"destinationIPv4Address":   net.IP{172, 16, 0, 1},
+		"protocolIdentifier":       uint64(6),
+		"sourceTransportPort":      uint64(50000),
+		"destinationTransportPort": uint64(443),
+		"tcpControlBits":           uint64(0x12),
+	}, flow.Fields)
+}
+
+func TestSFlowProtocol_ExpandedFlowSample(t *testing.T) {
+	sampledIPv6 := xdr{}.u32(1280, ipProtocolUDP).
+		raw(net.ParseIP("2001:db8::1")).
+		raw(net.ParseIP("2001:db8::2")).
+		u32(53, 40000, 0, 0)
+	router := xdr{}.u32(addressIPv6).raw(net.ParseIP("2001:db8::fe")).u32(48, 64)
+	gateway := xdr{}.u32(addressIPv4).raw([]byte{10, 1, 1, 1}).
+		u32(65000, 65001, 65002).
+		u32(1, 2, 3, 65010, 65020, 65030).
+		u32(0, 0) // communities, localpref
+	body := xdr{}.u32(
+		1,     // sequence number
+		0, 10, // source id type and index
+		1024,  // sampling rate
+		4096,  // sample pool
+		0,     // drops
+		0, 10, // input
+		1, 0x102, // output (discarded)
+		3, // number of records
+	).u32(flowSampledIPv6).opaque(sampledIPv6).
+		u32(flowExtendedRouter).opaque(router).
+		u32(flowExtendedGateway).opaque(gateway)
+
+	proto := New(config.Defaults())
+	flows, err := proto.OnPacket(bytes.NewBuffer(datagram(sample(formatExpandedFlowSample, body))), test.MakeAddress(t, "127.0.0.1:6343"))
+	require.NoError(t, err)
+	require.Len(t, flows, 1)
+
+	fields := flows[0].Fields
+	test.AssertMapEqual(t, record.Map{
+		"samplingPacketInterval":      uint64(1024),
+		"samplingPopulation":          uint64(4096),
+		"droppedPacketTotalCount":     uint64(0),
+		"packetDeltaCount":            uint64(1),
+		"ingressInterface":            uint64(10),
+		"tcpControlBits":              uint64(0),
+		"ipClassOfService":            uint64(0),
+		"octetDeltaCount":             uint64(1280),
+		"sourceIPv6Address":           net.ParseIP("2001:db8::1"),
+		"destinationIPv6Address":      net.ParseIP("2001:db8::2"),
+		"protocolIdentifier":          uint64(ipProtocolUDP),
+		"sourceTransportPort":         uint64(53),
+		"destinationTransportPort":    uint64(40000),
+		"ipNextHopIPv6Address":        net.ParseIP("2001:db8::fe"),
+		"sourceIPv6PrefixLength":      uint64(48),
+		"destinationIPv6PrefixLength": uint64(64),
+		"bgpNextHopIPv4Address":       net.IP{10, 1, 1, 1},
+		"bgpSourceAsNumber":           uint64(65001),
+		"bgpPrevAdjacentAsNumber":     uint64(65002),
+		"bgpNextAdjacentAsNumber":     uint64(65010),
+		"bgpDestinationAsNumber":      uint64(65030),
+	}, fields)
+	assert.NotContains(t, fields, "egressInterface")
+}
+
+func TestSFlowProtocol_CounterSample(t *testing.T) {
+	generic := xdr{}.u32(7, 6).u64(10000000000).u32(1, 3).
+		u64(1<<40).u32(1, 2, 3, 4, 5, 6).
+		u64(1<<41).u32(7, 8, 9, 10, 11, 0)
+	ethernet := xdr{}.u32(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13)
+	body := xdr{}.u32(
+		9,          // sequence number
+		0x00000007, // source id
+		3,          // number of records
+	).u32(counterGenericInterface).opaque(generic).
+		u32(counterEthernetInterface).opaque(ethernet).
+		u32(4<<12 | 1).opaque(xdr{}.u32(1, 2, 3)) // vendor record, skipped
+
+	proto := New(config.Defaults())
+	flows, err := proto.OnPacket(bytes.NewBuffer(datagram(sample(formatCounterSample, body))), test.MakeAddress(t, "127.0.0.1:6343"))
+	require.NoError(t, err)
+	require.Len(t, flows, 1)
+
+	assert.Equal(t, record.Counters, flows[0].Type)
+	test.AssertMapEqual(t, record.Map{
+		"sourceIdType":                       uint64(0),
+		"sourceIdIndex":                      uint64(7),
+		"ifIndex":                            uint64(7),
+		"ifType":                             uint64(6),
+		"ifSpeed":                            uint64(10000000000),
+		"ifDirection":                        uint64(1),
+		"ifAdminStatus":                      uint64(1),
+		"ifOperStatus":                       uint64(1),
+		"ifInOctets":                         uint64(1 << 40),
+		"ifInUcastPkts":                      uint64(1),
+		"ifInMulticastPkts":                  uint64(2),
+		"ifInBroadcastPkts":                  uint64(3),
+		"ifInDiscards":                       uint64(4),
+		"ifInErrors":                         uint64(5),
+		"ifInUnknownProtos":                  uint64(6),
+		"ifOutOctets":                        uint64(1 << 41),
+		"ifOutUcastPkts":                     uint64(7),
+		"ifOutMulticastPkts":                 uint64(8),
+		"ifOutBroadcastPkts":                 uint64(9),
+		"ifOutDiscards":                      uint64(10),
+		"ifOutErrors":                        uint64(11),
+		"ifPromiscuousMode":                  uint64(0),
+		"dot3StatsAlignmentErrors":           uint64(1),
+		"dot3StatsFcsErrors":                 uint64(2),
+		"dot3StatsSingleCollisionFrames":     uint64(3),
+		"dot3StatsMultipleCollisionFrames":   uint64(4),
+		"dot3StatsSqeTestErrors":             uint64(5),
+		"dot3StatsDeferredTransmissions":     uint64(6),
+		"dot3StatsLateCollisions":            uint64(7),
+		"dot3StatsExcessiveCollisions":       uint64(8),
+		"dot3StatsInternalMacTransmitErrors": uint64(9),
+		"dot3StatsCarrierSenseErrors":        uint64(10),
+		"dot3StatsFrameTooLongs":             uint64(11),
+		"dot3StatsInternalMacReceiveErrors":  uint64(12),
+		"dot3StatsSymbolErrors":              uint64(13),
+	}, flows[0].Fields)
+}
+
+func TestSFlowProtocol_SkipsUnknownSamples(t *testing.T) {
+	proto := New(config.Defaults())
+	packet := datagram(
+		sample(5<<12|1, xdr{}.u32(1, 2)),
+		sample(42, xdr{}.u32(1)),
+	)
+	flows, err := proto.OnPacket(bytes.NewBuffer(packet), test.MakeAddress(t, "127.0.0.1:6343"))
+	assert.NoError(t, err)
+	assert.Empty(t, flows)
+}
+
+func TestSFlowProtocol_BadPackets(t *testing.T) {
+	proto := New(config.Defaults())
+	source := test.MakeAddress(t, "127.0.0.1:6343")
+
+	t.Run("unsupported version", func(t *testing.T) {
+		packet := datagram()
+		binary.BigEndian.PutUint32(packet, 4)
+		_, err := proto.OnPacket(bytes.NewBuffer(packet), source)
+		assert.ErrorContains(t, err, "unsupported sflow version 4")
+	})
+	t.Run("truncated header", func(t *testing.T) {
+		packet := datagram()
+		_, err := proto.OnPacket(bytes.NewBuffer(packet[:10]), source)
+		assert.Error(t, err)
+	})
+	t.Run("truncated sample", func(t *testing.T) {
+		packet := datagram(sample(formatFlowSample, xdr{}.u32(1, 2, 3)))
+		_, err := proto.OnPacket(bytes.NewBuffer(packet), source)
+		assert.Error(t, err)
+	})
+	t.Run("sample length overflow", func(t *testing.T) {
+		packet := datagram().u32(formatFlowSample, 1<<31)
+		binary.BigEndian.PutUint32(packet[len(packet)-12:], 1)
+		_, err := proto.OnPacket(bytes.NewBuffer(packet), source)
+		assert.Error(t, err)
+	})
+}
@@ -19,5 +19,5 @@ func init() {
 // AssetNetflow returns asset data.
 // This is the base64 encoded zlib format compressed contents of input/netflow.
 func AssetNetflow() string {
-	return "eJysXc2S2ziSvvspFDOHvWx32K6y2+7DnmYndg67O4c57A0BkSkKXSTAAkCp1E+/AfBHpAhQyAR96Imx9X34YSIBZCYy/5r459NfD/86C3M4iRoOwhwqkKC5hfLXw9/UQSp7aFQpTrdfPyUz/nJ4g9vvBwn2VKvrp8PBClvD74e//A/Yv9fq+pdPh0MJptCitULJ3w//8elwOBz+LqAuzeGkVXMYfnngsjz8459//8f/HRyV+fXT4XDyP/vdQ345SN7AvCn3x95a+P1QadW1w98EWnva4q/Dz+btzdt0rUx/OTb6Brer0uXs7yNNuz//OoOHHdRpal5DoXQ5oI5QHo63g3XfBy4g7a+fVt2Aj1ZpC3rVlfn4n3Tkv8Hyklt+0FC7T3+w6mDPMHEfSriIAg72zO1dQPp+9R3+dcb3OGHz3vKy1GDM4t/ic/ek2+7Pfw5d/DfjhOCq9NvYxkHIwz/++bv758NJ6YbPZ2/eJ6M6XQATjy33vaqVrHBd+t+jAX3h7p8PpWq468ff3JRez6I4z2ftcARHbyIds6IBY3nTBjtWcgu4jv1LNODl20Gd0PXfN9J617r2WSPqWoQ/GH5q/ktdPWopXa1WhftgZ24ORwB50J2UQlb/7j5h3z4USpaxebqANkLJYB+FtFAtVkdCN8fFOBAfOgNlYOnxo1F1Z4GB1mq9AEvVHWsIwHr5ZK1SNTuL6szsWYM5q7pccfgp3mao1TWDQFvW8LYVssrtyoxpty61oFlnQBP7dipYq5VVhapXkFEwNlHM/VVUya+hUslbI/70a5+dal4ZRLsLsIXiLMV7t249TtC2tSh6+LEzQoIxv2io4cJlseaJzNmMpOAWKqVv2FmYUcwWGo3A72IZHThb27JOC2Yst8JYUaw/iTkrbVNoDOhfeAXSUijEWmwTUPSRm+64xxe0mp9OovilqLlZT11EiLRlRS1AWjZsyqzfS/iHaLoml0XIHVgMgaHlxRtY1CyoTlrmDlVMg2mVNICHS7iyQkkJhfsieDy95QnJzsJYVWnesGPnJuHLjlxfd+R62ZHrdUeubztyfd+R6zcCl9VcmkYYQ5JGj+ZoUc5UJJkaJEN1jHNP7fkDHt31JR7Xd3eRAZ0790EW9DhCLKTR5H6QMA11PPmfB78rWWV5nT0PQRb0NIRYcLPQM8zUSt6A1kTEMa2IEMMyBppjDSU7aV417vDilWcqvCvFgAgYhiKYC2heAXPXC821Fhc3BBE4Nobxx6p1p30rZH9u5IbJrjkmt+/wEj4s4+UfvHAjJjOcVctEe3lla2vTcIVqn6O/o9Gthkte7wdLFAV64bUohb35e876m0VuGkdxcnf1Uuj+bJmOkyVhih3Krwp/yXf/Sb/d+vtH6BIVnpP+57hrj8eI03jzAVkJCYh7W6HqGhaK4266WnF4812MQmlWgLZ9X5K/5h2M/jQLKE7071Dyp1VNoyRrtWrdoAHzmZU8iRJkAayGC6zNPDEjnLtPUaZJyoxhjlc4txOwstPDRT0iIdERjyzzDQYzY9JqXrylQ0puOTveLKA2IY+qhXxze5jb/XAqZgUXfyJsYY/ooLNmG92b6A3TUAt+FLWwtxXDUakauAwwQG0588oVNWezDRQtnCtwq+EkPnKwrAZZ2XPyN1uy4PTICkzv/vf87je8iPY+uoHMCahaohQVGMvO3Jzdph4wAEdER7i2i/GWrU5sOSnR0dDoNmSTSBiXllTC0ZGXP9aJaYdh3rnQI5SG8c6elRaWW3FJPgg4YIE7+zhIYEOIi6k0rPlg8FGcuayQDTUffoGChpBjYrNNaUrkqc6jPljvicXMYGs1pa13AkRjNygH6kDfposzZmBaozdjaZhRnMFHK3SyRhpAuPvziNJw0mDWuvsZyur1Nr2NMaAFX58cN0ENQTCM4poC0xfsFjKitHCqYz0fT5GW6woILV5BVGckzlrM3NsPy9zxDDGHyn55Z0VnrGpAsxIE4hj3iM09ICz5oh8otgyX8GFn2aMnl5oH7xDxL+fxhMmkjdmAvgi3i0pjuXyM1tkUmjB+p25YXuEY8BOtVdtCyWp+A/2VqcKCZf31AnWzCNH0dkoKTW43ctvvbd4ZHRgICD0wdvSJMyFLWF9QwjiofHjLUSteFtxYeg8GJm+oPfHkyI5HWPjIsYltzzcjCl5T2+6k2GXoF31KXv8gC31rLZR9SI2qVbVe9FE1ibYBDoDg3MYg9gxagmVn4CVo5KV1Qrf8VitexuBRBTMR9J+DDkedYPuTJNG/0UcO4qWwh03BXeu4we2Z7uGmsC0zVgNvUIp8gM/sArHQtM323ZzV6jqYpyhraKRpwBheQQ4FVY2PoZ8UK/eERRvI5kicbWpCUq06PYEPaeyDXdMPMPBhQUtejx1mGni9vk1FZuskNFx5XTMfP45AGcv8YZ5JJRk0rb1Nm+7oLUqOpOvpVkQ4k25PMkCPXMqAfyyqx30sJuOyZIY3be1kIHX+/WrjhRWX3jutOsR378HWanHsbCAAaxuI9sv1qNGx0IhCK5x34ZFgw4G1QQCyHI5m1C44hk1s2H02w6JdbxNWckltVgM3yK/lYMTWzM2wrsU46T0UJ/mirKly/wY3d0R22j0QHr/RZs2PUHstjUH5le1UrHe++sPBJdmuE2AwLS+EXN/vtgighmLaovG3kyUJ9ZK1ZCFflWY0SjNeV+7CfF7vPNtCYCzXNlMb9BxUfTCiaRqhRxN1Qg+mA2kL3PJjDexUd+bcb/v4z95TtMDfcFilr1yXbgkZy22XfkIYrwHhRxPPUKIEaafIjdTOjmh1OhmMtfN0ZceaF2+q8982WZR9FPdJVJ27+GB8ew5pDTO6YKZK/hbX4eOHAyNSQIit4srgw0YOmVsTOaEYrzEjm2AlmAJxDryyM69PTLUgcZI9B55FleqYWOB06JoTxTX8gxlARVmfrkx3NcLBcLoy0zUN1zfWviH1xJX9qSSwlgvMaXqOCjpDwriqVsfZHSjrzVqlwZ1NEn/tPfGDV151tg0cg6Imb4/tzwJh92N0aXikkMIK7rYtjdIXPbidTEIRBZcGDt7SNqD9HDHNZeWDcOnYwHxtYKcDD6nlR3R629a2Tq9bp4yCNrDo4vPQ0QoTM0Zto/sLB2vPmgd8wM+w750PNVFmLRtp0AbsWa2XXRo44mTcBvfHClaoEmE6mF7vsfDrvWiTomha35Y3NqUeSxao9RVmC+V+sdFgdIRr8LrdBDB6lCMqfZTVgEoHSOXOS7OYPILBcWQZ3F9DUGAuDdX8OdLk4sl+FCH7jAg+zLkGfwr2E4z6MiESynvfEBHGsh7EB11zKIrg6QQ1iH4vOUKVvJvEWUCmHm9CHAYaLjGPn0MknRSBZ0Ybc7qby3Okwnp8VjiM03MEN11tM/2WIxXZgToSZHtQRyKMC3U4hjJbtLj7eQ+0SveaLvUWc4fhnrbdvzJRDY1w7Mq3oBsoBbeAd+94NNm9I1rWv3RRpzE4BQEtxamH9aeGVol0F5Foaf7qO270AuFcHCLnkZfIeeM1uxNFhhz5wnccecithgLKYLhsHGSgYKZdR2hFu7kZARBHBQL44p1COt39JxZnRAuXV6ZazOtyD9Gqs6BZwJYUFoXLdwYfFqTx2UG8QCe3N9tSkof1IbjbSK8m7LpMgSG1moP2eb+wDQ4oSnvSMC7NFePanYBeEQZ8rM+RPpSagBuvIx+YK6UHm/6BKGGGjGWFsNiMLnesOyvoW/gCnQ4ntl5zK2wXaPlUKx4VJwdUsqIhNVQ+lxFtvANa4tFD3BkrRHtGi/MIfoMbOiBrQYAzSHmoN5aclUFfgCZwp9d7TgqMYqBxaNEYwUx37FMmYue6/o3xtkUc2GYgwgzV3IIsUo3PHqLhQvYFTwRkN7BnMLogKiyHpCosj6UrrDmc2DpJYXkgSWE5JF1hzdCE4dqzBm4jdsfQGWgJCtgbn4BwuqnmuoJRijHyuzD54cU/02KYGbYfgJuu8be9947r5JRemQbLADynGwaqmJFvC7e8PYWfAUXOpUECrN8/SIILYqyFBFZwXSLGriqm2uSBqitoVghWi0asxxZLh9Bw/ZbYn4Z/sKM4MpBWi+Qv71ADYsrqiYEOsdeoSBoHzIkmfMCj44cWeHwE0QJOgA7hLW6+pQ/J0TDkFUo9fTgaUqzuLHSBICf3oxwCJZquYTQbxogetFMGA8IU0gCXQyR/elSIBw1fBAXrfcxN+Y0VZyjeQpmoov3ssaZQ6a6hBixoUrR7A1YrBpciBIkeDu4ojAOrEUdW8NZ2esw7hvWSeAYlrQ9Hwj4VmoNx5zWH9A4udHitQzaq7GqsecYB1fEPKIg+vhl+DNYLbAAbMzWAyb02N2kDISEpUB96w44h59fzDvdgXC62FbziXSDHQiJ4nQL9mfZeMcQzaoQO+St8f240VofCqVOnUOFW1gLrV7cVxVvyhvLI0EkjKgnJakwcfQLmDVmPASXxsCOG7YQcLL1iwB94lgyEI8+SAA3OOQQM6IxDwMCAOQSoo6jBG7AQmqkHNUaYEqOBlRRW+V15fBtxP9hipzrANZMaLFtbm+HNh7G8eGMltOmz/gjGfbNHNNI1F8N/+ZzLsE6KjSRYZ8JGEiRHgsUI1jmvkQTJUWUxgnV2ayTBj1yCnygCkn95gaR5mD2FVe0wAvhItjc8INEe+TUe55R/wJPyvj1w0BXBnQOzBzwgMRcYB720svdesz73WNUJE/I4RTlsMR3vMLeXFh0O1EPGaCIfb2jgvfPZO1EJb3ui8dI9WhORJgrPoaEAcQHNrHqD1CC9e0CYhnuhjBMv0u9hkscey0TmzgGENKIEZi4FJjpyQCLT5jiU0qLy6Y5kRYtO8iSdpfbalzdC9dgjcFdEB3vvlOUMPgqAEsrIh4m36g76kcvNZrPTuxV0ixglIcGagrtTZsHHSijoxwYjiWacc2+8Rc7yHY92m66xOIdtAC9kwfWQyAuleZZcFprW13bJGVAnhc0d1b3ojzuEd+30hH8XMsT70ztRwYszMA2l0KPUzVIXF0pjlFEiKykh8oy8J9Q2eSneobOhnbmQPsIQ42HboErfk4MklInotPaPQEUB0oBjM12TbPy4E5VHVtSWEk2y4HBHhSM39HVWHlmtKhGryZYwjO2KcE+/R3lkGt7xu8QjAUXxD2DT4q4Va3jkSTcOnjcHHT6Q8Y73liWaBPSv/Hr90FfIKIYaAWVYPSZ0J8bZG1L2YNUgS9C7dXKg26N/pPeSDyT9k1qyNIxvEccUy3+GN0wMU6HUm8jrzEnpKzsdaQI6EdQZBLjnoAEC7KPQAIUuLlmT4PA5c9Anp0ZEEgY4Or02AaDwhHDIAM1FIHLlBvAfQ04PKJ1oZVCZXNk2ubJtWK0iGUIww8gTTpMpnIYZHwCXqWnmNGtTczJPf6ggjiV+pIibBSYKd5t1F6bBm93ywMEmYRgjDTpQYUVBLNka57Ggm6maBOUKtkmIyta55CzOXEqfHSa1CmgU78vK4I9jS5bU+qFR/C69SK08GsXv0ovUmqVR/C69SK12GsXn9KI/rGbdOWc8Yu0KCfkhgtiad7II2eJTF1pP4+2n2qqIQYFAdhWyVFekxTxIl6VHPIXvUAk1T41KjJL8IWx6jE2UZXhzfK9zmznjw9fDG4IeKT6yF0VviN+hLzarL3fbFL5CxZJqeGnYV/BgUpH6k3mPdBQ0o4JDejVFl/76t7uuwbwrWrEM1WizWLy2Yw04PS4MIlHkkmes8Nu1JbcQt16k9OmBa8N0kcJGXTlj6eIMCRso9tkK5mQ7bAU9XaYen5Fk6PEZy356fEaaJwF5+vceUJ+xIEaSqqO4FeYMGgZFnuz/D/MYIPuY5jTDCvfJdpiB9Tt+Atnw8IJEleMjHf0uNGu/381aXgHZy3ZnIIE/Zo4jigesMeLu3AvaOZ7OgVadrzUsiJI1aEhrCYaeCUsw8pibqVU1JgWkyM7AgK1vtCJw02Ysb9bXn2djyPTCdvJNqqv8+ts6hDIZirdLTFC8MWGC4i0AExR/bZ+g+Lv2BE1Nrx6AriMrk6HrmMpk6DqaMhX6gy5NP+jS9IMuTT/o0vSDLk0/6NL0gy5NP+jS9IMuTT/o0vSTLk0/6dL0ky5NP+nS9JMuTT/p0vSTLk0/6dL0ky5NP8nS9PKZLE0vn8nS9PKZLE0vn8nS9PKZLE0vn8nS9PKZLE0vn8nS9PKZLE0vn+nSFHh6kgylS9MXujR9oUvTF7o0faFL05e1NCUczCc0XaC+0AXqy1qgEH3+upapBNvJhKaL1Ve6WH1dixWmz4jk2wH0WrgwaETu7gCaLl9f1/KFaXgtYgj0S5aIvaxFDIOmS9nLWsoQ6+plLWUYNF2FvdA3xBe6fL3Q9dcLfUN8pW+Ir3TN9UqXqVf6hvhK3xBf6dL0SpemV7o0vWZpq9esDfHbWqYw6LVYYdBryUKM+xtduL7RhesbXbi+0YXrG124vic/n15jA6abZCzdPPBCv7y+0m9lr/Rb2Sv9pPIaOGukTvFrYLdPx9I/7St95b1+T5/k6zy+Ap9wsE+P3idox9R1WRX3RTWqLDN99T91JaQnnPCZaQ4nnmwCcn0GdTSgL32M5hCYkuxDCWBxHsg5gS8GQGu7h2Kcl3O0z9VDSF8T4EAnsFlx4FPYrCgo8LGCLEF+iIlGszOMUpdMdk5R1fL3McdUcijC8LS9ZvRq8kEKXPKKicJ9bMMK1bQ12OR3lg/woWwKFd5qMOmv0CdwlsIy7CRkBZq1OlR6JK6osKmolcG/eW+/tsxCcZaqVhWiPAA50zZ502h56RN84tbAkDIGM7A+xwxIq1WbHFqwTGqDrE3XqloUN/auhvoOU5FfdhaguS7OqeFmM6b3DnxevFCJsDRwqVWbqqAesbh2NWIT9r+OlwvbmOQJJ7uGuf+LeJMzQ/v4SiISWmQQYtuHzho7vmlpeBFVv3Gh9izKfnlnRWesakCzS82DWuxJVzwJDZtRx2nE5xRzGjnwZYY8MjPJ+ZqDoAIdR+OT8uzRmwBTVp926MwOvaDvTCsOaj/Iicba/j1Pu1zyPtbMqxH3H+Si82xGdbqAXKJlr9BnyRgL7jg5sQxjonfjTkDoQZasZ0l5nnznSfYwZfQ9CL9xaHHpo5Qt6FYLg8yi5p+8as54izkUDyCfjiW2Uz1Fj/kfCm6hUsH08E854rXJtocLH0PBxVAc/sZk92h/LT/CmV8E5jX8CK8qQ5jsjJwZC4qTqAGXBGYBr7msOlRY8hKOfny/gKNzMjygaRXLgyT4q+SSxbRKGkA/fl+woNJLLJGExBIjQTBT8bboCFmoZuN2mojGlYJdwc2Zt+5/URe3GEnsldzTLzcVeyXpHreAhhSrwSQtwR35Efsdj41kmt6eNNXZSpE/+4SmffYJnvPZVyTkz95q1YIOPMZ4ut7eFdzFb9y5VjSRkmJBklEEKSTTjOT0ZCKh9kRzguLS0CgLxMVzBxNWj5aBmrLPJGZ4Q+Qawxk65wSko5khnVDuJXu2Sh48pSEm1BzhfmcbjxlsVsIeRdXn+cNXKWkNdKViV6F9xkSrVc18I4/4iNqZwfMukzOi9NPxDBQ8YUZFVgM3yYlG+x8zC5iSuRpqbsUFGGgdOHTHapr156wSkNXn7zDc3qPBHQuB8aNRdWfxvR3gUslbM2SjiyQW2PgWIRLvxBDvHeqjDkSz9EHDZQ1bSzNERSohFCKqtOqw+YRDPJhiUyF8fg9Md8ye4Qto/2jXJ5PUWlx4jXn+OvIcq3ahgbjBGRbmPD6ehJd/8MLdXbOZSFX/Yyw429acpdVw2WdUg9koh+LCa1EKe/P3yWTD/cjQux3SkwEscTRBffDkoSunTTyqrsfUz08jPrYncqBSmhWgbd83ysiUPAnvQGE1XGB9tn2m/mfBW6Zrpjze0SiSZ6PKy4098vhaDcebxb3oX6BrId+GKrqx0hpPZ3dFhPIXxliwx50Zi4ZC6dIwDbXgR1EjHtxPPN5Y7c2KpLnNOSlGSfoyKntwIEuxRNhomnpFkj+s7/sNi+QpCBFRXVcTl6jAWHbm5twXu8OKoC87Uwwhn0ydHiYtOso82g1ZzySOSxuWePKj7c+44/BTvH1POJfxEyWsjcTPVsYjw14LZcmLTYsSoclx9kUo0e6/JQ95yvNmZDAzEQoMPeHZuVuWIyoOLLnoH0artoUyNx5lkw7vrH6k26tbe/WH7MOPEGX0yFi3t55OokDFCo54qHzFrKNWvMwLnXlg9Pf7Ew9Yd3Hw8Mk3iaM934zwHqW8vnRS7Do1F31CayCQhb61FkpStO+dhXiJHYDBb/EMas+gJUwPdmiH0onlSfXJpwpvItosnougIV3MxurJhW2ZsRp4Q9LeU1R/lj2gZyFVwh8pTkLDldc1rl7gDG0sW73Oot2ce7KB4silxPh4JpKaV4ZxWTLDm9anS0RPibst88L7BKxoQHWEa09PYq0Wxy6UyjiNoN+nMgxPPYvQuHKjS/RoJNp8NZXSjTtR3nhAltPM5HWpr1C9B0f+iLYegyVSRJxlKV95SEaa07q5GYbLtbqgEGWdu97uVX4pHfAKwylTb+0dK4/nM5mWF6GaZClEUENhx/d69FPrkiz3UL5kyz5Sz+iUZryu3NXsjEjwvSTySbj3UQ9j0fl9WPJURM+SqSR6knwC+krvyz35uCdMvb8Jr3lfjhlVGnqFHsMvIiGjiX1Qp5MBgq6qNLA3SH1ZNqK8BXWwpqrOtgEt+WT4nqHXJP4pJb7nnmEosS0MaJJFtydppwtBZBpxJMEjZwJFP5PDM7GGY2/hAY7AvCZwTOo0qyePLOi+iKJp+xdeor2s03Y8EbAFOjm5wwLtfrnRgacSuiZBPAddk5BnYUTjZ6Ea0HigVBrKuesww+Ixsg32wMGXuRddrjlvFnzHoAavjf24SRMXIssJIQoRUowEQZ6gqZBERYuuCDH12sYXR9jhQ8afGOO5DDRcWlGgjwohsk4KzMvlO9XuptqRkmofXeEpxtqRpOlqu5OddaTMNgCPRLtZgEdCigl4ODAxW7S0c+uQe0OhY00f4bSY07uUZKrFkYaqeSzoBkrBLdANnhlv4WcUOc/hZzRZlvU7fjS7UiOfxB7xj2KP8MfZiR71kjCA32FS8AkLZmADBTNt6nu2GXDTv/Ecbdcmq+edvUTeHD5FXl6ZOBNavLwy5fUIWhk5qFadBc1M4O3LpnBdvvvHRdKXYeoXD7r92YaHHXbNdQWUFHZ3gtlpnG5x2+lQv1PoQYCGlKoryLfT6DIyiD3wGahiF4IU/FKr+UsPXrUFqajWrCAZzQNYCwms4LokzI66gmaFYLUI1eJ7Fhjd8I/JtUuyjjqCPVxKDzxkm/GCh241XtDQKbL8wg3/EE3XZG6NI8uwDHdgIuy0Q+U11pTfWHGG4s10az/H0+U7sphC4a0dDVjQWSEDDVitGFwK0rumO5piq2mEzFynQu7k21kx0dfqkiljtS6JyCR7LLeBZYflNjBRlpuSwio9PQe/8PquV6nTE+CcSQCVta3N4L42lhdvrIQWP1OPJLT5fmQh36BiTIEaQmSudbZtMhWi1sYzKkThjWdU69zcZCpEYaFnVOvc6mSqdV55MhWi6MKCKsvksGDINTrQM9hFGMjmnDUPzaLzwJP1wOmBaw/1dGej7C8PDJQDjaO4tLI3aQzPZ6pOmDMl+vFuitdwf/x94oUN5ArYFmvJLS0m1AGH/M0+NHUQGqaB1+vDbgJZq3zCDEL/PZJmbHZwyvfMLpFwJ6KVSpjwOSUT7iT7lD4Y+bJrCISIkLUE4hSU742rLZDeo9wX56haA0gqIk2mmXA3+2C2EO9lEdyuLfB8DeRXGdikou33mVUHIjTY6gMRGlwVghVJTjWCiYxalWBGQNSZhCoFI5ZarWDC5wYJZzvw0fUIHoDYugSPcFJ9gokElah/gSIk7A/gCYn7AyzIBP5BBnQi/zvLHgn9l2w7PbLOSfC/4NghuGGPhP+PXHQ/+h4FAOJcGdpkt4IAzxh36eOOnduxV/mbwg6FA5Zc2eaXfQsJrFkzCwo8EGYWFnjGRjtBZhca2CDK6NEua2iX1bPPutlnxeyRq4RWoGBCZxYquPOQc7NOFHk5WkM0+6xPSs7WEDhoIXn6hcgZVkc4MdPqI5wW/apPxcu3b5/ZH8K6i3GGfWfFRLbuPDDRbTva3gMmKeYvhzfw3vmchJFl91Q+Nl7qP2l+RDaqRHd9xNIu0yNac1mqhvpaeHooHH/1mjIKx9C/oc7sRh88QHqvPHHk9mHYl3aicYPBPh64c6i2qylPRO8MWh1jqRuf6b2JhPC2cZe37Otn7OhueKWUm9NjDCocjJNlbB94rmgyQx3755V9wtRB56G30z0euk8c9NbF+DiwN4yOhYF3ohvfoWbTtW92x855tty+EfcLMD7onBSzZxpe1zl+vNyb1BxPSHO6hhMDAHIvcnN81jgyM7XucWvayRJgdJGVh85YniFXlmdOguWy5Lr09drGwhx5+ekDlLmpy8zNWGi8EyvbsWuLlvHiLePePjBIda2hrIa3wJQbsyMar7lH0tNYxzC/4dJE2L+rFDJzSrLe4jkC2nsmh2zNObPz2uRYchzDs1tcAkOvkOjf0Nxyv2Gnq3wGtx68u4Nyl3UcVyFLd48qeE2wlswZSI6ueaw1MYvGfVPJtXrOtqdcq6XtpIQ6yw3dlXvoG8cyvsGgbgmOI2vBdNI7FqhPyru2zXkv5Wth0U7CPmWQD3T0uRG9JzZcNvPJEC5C266Pvr9v/P59OeVdSpyNOMwHvn1Yuo40Nqpt/aLZidbxq2uyOHO/ZPGGPQ83hjLYq22JZ8p8Q2++gTfbsEs16OYYcvEGXIrhlmawzTPU0g20WYbZHIMs1RCbaYDNMbzSDa5ZhlaKgTXLsEo3qOYZUrMNqFTDaY7BNMdQijeQ7mQY3ckguo8hdB8DKNHwORbJwKIIZlIj6jfG2yFqByElVLMq1ZxKNKNmm0+pZlOiuTTbTJpjHs00iwbhJnJLirMQjarG1L4iADOgBa9jB7H44Ce8qCS3ncYsvRE7VXyUyjJ+CplBkCRHOClaV3BpiUbcUFDB50RFtipa1MM7j6kFSFpfVdPqQePh7sAOrY5/QBF5tbTZ4QEYzt27iWy7Yy0K9ga3jV05lSGiEzbhQ+XyyGeKL6oBh9yMKK4NsktjR1cG3oUREzOU46LkNnDKpjsssh0VdAdFnmOC7pAgOyLwDgi644HucKA7GsgOBrpjge5QyHEk0B0IZMeBhaat3X6JMRHak3VCXgNOp3pY0OoTh4gGjOXNOhY/Mvvj7+/F8u9JW35BXtkznCnjARBjhst3vOzgcMlwtOQ5WHIcK2SHCtGRQnSgEBwnDoJDZLtYLqK1UHOfmCLZhILyx0Qb3scLk+d9SfK6xEaA9bVcmivXgfKJ3FrEtjGwrBN7k2gsSN5nwbcRpRc4+YaxgdeuaVjcKl4SjG85kj/aAj2ztxA6P7N+0NGkwV8+nOwN6fG0CpwdYsP3yxz76gXvHLxybtwhtRF/8sEq7JOhprZIdCoSnIlkJ+KHP0jNt8Ax/41oWaI8rTlw4tDjB0miNp8kiP8fAAD//4qByOE="
+	return "eJy8fV+P6zay5/v5FMK9DzsDJEH/8emcDpAFBjcJNg8zm0WC3X0jaKksMy2RapKy2/n0F0VRtmxLtqqoM5kgk5zu34/FYqn4p4rF/5z516f/zP7YKpdtVAWZclkJGqz0UHyX/WQybXxWm0JtDt996hH3/vr0bfYGhx8yDX5Tmf2nLPPKV/BD9h//Av9LZfb/8SnLCnC5VY1XRv+Q/c9PWZZlvyioCpdtrKmz+JuZ1EX262+//Pr/M6Ry333Ksk34tR8C5NtMyxqGTeH//KGBH7LSmraJfzLS2t0Wv4u/Nmxv2Ca2cvzDvtE3OOyNLQZ/PtE0/v3HFgIsM5tj8xZyY4uonjUU2fqQeRwf2IH23326EgM+GmM92AHzdf/vCPJP8LKQXmYWKhz6zJvMb+HInRWwUzlkfiv9yUA6uTqBe2WNKWworSwKC86d/Wxad3fExr9/jiL+D4dGsDf2rW8jUzr79bcf8MfZxthaDrV3JlMJ2otbkqmGJtSvvx2FMJugSRcGN7T0TSZRaygXFCgk/hy1X1pZT4jo2rUIYKEu9dNJWBldEmX86Vw2166/DU10o+y6f7svGby3oHMQuq3XYBcS7vfImnWs55Lek8i0NoflFPW/1w7sTuKPs8LUEs3qJ/xC9luVb4cfQbYGpHcTgnlVg/OyvjSmTrBCeqAJ9oeqIThIhKKKus91ovW2wfZFrapKuYVU87/MPgzuubNorMnBuWwrXbYG0JlttVa6/AaNvWsfcqOLKT3twDpl9PiXqD2UYGli9r41Emetg2LQ9pXhiFHXfqGeG22ezDRyHr28zHLTalSSk3VTQfa3hx/V5lddwMc32eOPrjb6/1ZS/yS9/D0gv8mefgTtf9senMpl9bP29vD3m7IrJFtU+MA4Jv2IHGqTLMCvqJ+NPDb8t6igv/deYI4QiSN4kgGJsr+pzR+HBv6OnruAjdLdvPzrP/71j/HmXQNQLNJ+YMIPZ628yxq0nPDxjLdbKAu5P/94+G0XbVPBB67/gqG2+k2bvUZD3bRV9W33Y7TQraw2x/98/lHpb7LVj6b1Y5aqNkIWtdLCeelbt4iggVA5b6VXO8g6ZhS5iPK2zYQopgG7pCTIF6YKWVHEUFqY3INPEeIP42U1mDA7wsxCDmoHRWa6lYbqZZ2UpM2l86J5S5LmX0c5Wq2QMGtk/sYTqG4rr5YV6kiZINbaGlksK9aRMkGsQrlc2mIZgZRem1YXR3EiOUUesNbYryPNXvlt1vHPlye6MdFY481XlEvqLDaVhaZyU80U0rT+q7mDsK6fL8ZX9gVEaf4tjoAo07/FCxBlWtQFmNan+QC0owWdwJU8dC/QWFMrl7emdQJXNwly/b8t+C3Y81bxHE3pbNDMcRG1kZUDXEJ528LYaqAw/jmsSJyQlSp1DXoB9f2MQmrw2cbKGgbTSlDesaGoxttibXL3tQX65b9+nyWKU7qsQOQGN5XKaNF1b0G5vJXa1crjqZjc4LZDZl2r2bHV2zIGp9X8u6XsWz1JeU+V7yA8uEU/1d//z88Zcs4aywI2YC0UInbGBaET5LhU0n5rHPSqCuzZvtvQhXZvS4fnoqcRXEY9yDl7fOADD1XUbiEpLpWDB/1RNcHWixbwjOvY6mw5w/SkZSVqmfdD+RUc2IS8Ume9ANk///FfneHdFjiX1ircBYJ2sKT1R+IsEM/6BELfhDdGYHtfxb2Gw10cVcAJHGetWn6ouq2738yc+gtuC3k2wJH364zvUWj+4LpDvTbVkqPaMU4Pp1w7U7U+WtKnq2Ne064rGIF18QLRGFOJrSq3wm8tuK2pJs6RbjNUZp9AYL2oZdMoXaaKMmBaTCQ8smkdWKZsm1z0+7FPU8fLN1ECiT5NRa+uodroQ63+CsdCYlPJ0hHaPQN7yLdavbdAIGiaSuVd2+vWKQ3OfWuhgp3UOczV2YAklx5KYw9ULQwoBt8ZjyCENhME2HrfiNaq4NGU8yq/HhK3NdbPoXFgu8AZh0IVHBS/5xhIXGAEvZWbjcq/zSvp3Fwjsl7klcLtTIzUii4i1c0/qSxKL8DiGAxxG0rBYQBD4OpPWHCN0Q7ocA17kRutu/N+Op7f8hEptsp5g6FYsW5RCY8Lcj0tyPW8INdqQa7PC3K9LMj1PYNrxq7tBj6gJdmUEx1JogdJcB297rmSX+CVTsLTZMd0CLCpuh9lUXoBFlZvUgdknEbpJWg4HaLPSh7jh8l6GGVRegEWmhY6OQZuJa1D10RKL0NE6JZzUK8rKPD8sAznwmFCngtvCxURYOdidmBlCQK3FxaPM3bYBVXDTPy6bEQBzivdLT+lu84Tu4fX8IHZeX/KHHvMZtiaRqhmtxpJ9ItbqOY++oWMbizs0qSPqT0c6E5WqlD+EPY5MHensVaYa3sjl2QSpwuGipWOR75hk4//uMZNbY/D/mNsEzWuk+7XaduegFGbfucDulT6Irvwplbw3BTOHMcpAe6KAzP5JimMFTlY38kCxPaNpQ/NGfSFCWUPralro/HQpcFOA2WYjd6oImSlVrCDavYhHO6nOGrSOqGb/RYOV5CiaG3cqE9YyGSPe5bhBEPRmPZW5m/zIZjeKNYHD6RJKKAqpd9wDsNJneZiruDqL5iv60v0aJLgbXSX6OuEhUrJtaqUP1wxrI2pQOoRBqi8FMG5knQ2mEDJxjmcfQO4sbBRHylYUYEu/Xb2mJ2zvKSI/xJFSMEmiI8hrSnpJyeQIQHXSxSqxNDsVrqt2Mmqhbmmg4ebOo/LPmE2Z6sx1Uz2hke3Wy1N+JJM2GdGN8sx7VYLcpF7qJ2Qrd8aq/DwegezDVk7kdPWPoUenXWnzVQ7UX8I+Mi3UpfEhuqP8HGDhbHAxM02tSuIqzrsmf4Q3X0OigYbbzltvTMgljpBaSfeW7CH48aZ0jFryZOxdsIZKeCjURYI5osg2v65R1nYYLSPjPL2QMQ4sEpWNFDNMAxnpOXA7I46hfQoq4wdW7bcRXppS2C0uAdVbok47ym69x9e4OKOoEPjH99F3jpvarCiAEVYxl1iUxcI53yTAzT1GZ7D48yyhCS7So7uIaZHLuAZyuT1GU86Fc7I2nmMZo/JOmE04/iFxPCypDHQFW1N00AhKnkA+9Qli4tue0HaWYzRdAeeHJpUMVLb7868EwSIBAwJnO9j4rfuv13hoMRV5DCHnCtBZDqmIDNh40uOm9gm3k08kdDw8U5Actd3djP7+wed20PjMdkUU2pMZcrDfDdJPgOMgFHdTkFiPpzYgizAEjetR3QjD5WRxRR80sEcCbrh4MPHujwNCytJZnyju/1Mt8IO1qeEievbx7c13cFd7hvhvAVZkxx5hA/OBaIctPYxJoTHSt3xFOcb6mlqcA4jPgkUXDceCVin3Efszd25am4jX3hI7qlOR4BZlvHK/JjpTOmqy4PtBRYWZFXP1dZGWdjLqhKhqAgBhdcDcDEvtNEC6sYferd9jBY5Gt0VEe1It5MpQtdSa7Dz/XjIxRRSF6K7Sm7n6z8c4mIYd9dFp01LGPcO7L1V69aDIwLJcbkO1QcWapVbQ4suXBLcCGDdIABdxKUZVwRkuIkdD58NsOTQ2xGrpeY2a0E64mghjNmaOzjRNpQgfYDSLF8VFdfu3+CAS2T07sZS2qzkGqrgpSmo8GWji0Vpu8XBTlZ8BtfIXOmSRAAYgO2naPru5JyEu8k6Z4keM4nGWCGrEg+TtjXRCJzH1Jo0b9BxcP1Bj+Z5hA7N9AkdmA/kfeBerisQm6p1227apw97R9GAfKNhjd1LW+BHOFFpY8oV9tuA8UsT91CYAhDXkWOz5YSwPdpsNo5y2rnZi3Ul8ze87ewgd3PbC1ncG1W2ePWREtvb7EWO9zZtLlw5eyz2cfDHEyPmgAiLpL3A3K3xReYtRR5RQlae0RhGEXPCOnAvsIKNMA1ommUPgXgbiYOzY9ucSVwtP4TDi6DzE6U3e2HbCkj6cG1dS3vAyiZUjfxlNIhGKspqeogaDYaM48rKrAd7oKQ7a6UF8QaHmb8dIvExKm9a37R+/pF3wAaHPhF+nPw0AlJp5ZXEacuS/EUHbo5HQhMObh54dJd2A9rpSFgMC2NWMR+rNAV7XPCwWr5Ez28bL5jlRnvQE2dgkx9fuFTWn8JMHUbdRncbDtFsrXRAxr63IdXEOM+E1uC3pmCCJ4KMt8HdskLkY6U8pj+m/vaeGL+9N9mkymsc3CKkb6zmLkvOUC8kFP7GjQYne3gNfuGAyb3sUfN7WUbUfIA2uF4a5OQxDhx7lhj+6m/fJ9Jwjz97mlR83NoxCHRXJhcPeaCCcAAfFEwamTESzn3fMSJVEOx3DD8amiNR0FI1xhi6mWgN5ezZZJoFdJHA4aCWmnL5eYyk1co7ik4XC3n2VNSIzxVu3PXcBg9KkaXKzw6gKr1QBFVpegg1LkOFzxva/rwD4tWAWzXubsBoV9tOo8x0Qz2c+uV7sDUUCm95k8M7SqeEd1Qjwq2VkLTa5bgQoIXadLBuydEYpT0BzopXn3DRhompiCrlktcFmBYEHOyJJro8McInHLvLDRbIKUbTZadBDnLhGjVfzJsZANMoXxGEIgbdcUUq1JbQwm4lTEO5XR6asKb1WBkqn2kKuxc8gwKNpzPRoGe3N5hSZnfrQ0nMHdq78dDlHBjRqyG0ewyC2mBEcdrDjHft9mAZwOAIwTGQIZWageu3Ix+eCnbdBVGGhpwXOd7z5GJxrWAP4xvo+XBm65X0yrcjLW8qIyfNCYFGlzykhRI/UWZ/I1rT0THvTOSq2YJlgjGCOuGOp5fdQ4LR5e7ttsPZ0NY48gboCG6tYsE4BzSIVrVTwrVrXPON3Zq+ja6+F7JpxnzchPsegBgawlpAOp97+BwgeK+cGws+ErDDwIHB2ZzpsBDJdVgBy3dYQzizdZbDwnZ5DguRfIc1QDO6i9X1pJ84d2zugl7IIJpvqvC0uF9AU+z37MiPbv5ncPp+O8K5n+8IHO+O427vvZUWHIcnsRcdPEUMB33YnoQ73z2NXwOaWJeOElDj/qMktCTGCitK4JsNhL6bUphmdkfNHqzIlahUra77NlUOoZb2baY8GJZeq7UA7a2aPfKIiohjVU8KNOZekzJpsM1jshwje+gCT84fOsPTM4jO4AxoTG9xogEdUnIwWBjqCs1dfSBNb+4kMx+kLjDs5LSUI6CwRJPgnWH06OidEhgIRyE1SB0z+ednhQRQHBFCMkkfY66LzyLfQv42VolqUs4O63LTwHyQB8vKdq/BWyNgl49BJhcHJ5Q/EKRUWCG18a3t645RoySBAWP/H55eLmgIpq3XEBnunpHTaxFZm6KtqMczCDTrPyFnxvgG+D5ZDyxFUxHMltodtJcfLGhIvRHrseDXfYE7MK0W2xW8lG0JXHDvpS/h0977imG6ooZqZuC7Zafzdiydeq4KjSrY2PB1e5W/Oa4SW+1UqaEg4LEA8w1bnwJq5mJHaXGW7kxf7lwy0Bc85wyMJc85ARmcsghQOnURoDR5EWDWqoJwgEXwTB2odsoVFA9stPIGP8Hj3YjTwpaq6hGugdVQ2ZrKxTsfzmNlsgKa+Vq/BNPG7BIdt3WzB3AC//iQyvCUSvCcSrBKJficSvCSSvB9KsGXVIJXEgErvnyG5EWYA4U3TewBfDRMJDkif41/ScGz6r5dcJyNH5ODMgdcICkbGITuGt1Fr0VXx6xslRuLOE1yYFpPVDll99KQ04E6SJ9NhMd3YvpN+dsWj0T9prs/TSQeUQRh4rtDVnjzBnpu88eEMAunhzI2Mp+/D9My3l+ZqzsEKO1UAcLtclXM72hEEsvmIMpYVYZyR7rkZScFktZzpQ4PJJEkDgjaFhEbem+Nx+Jm3VNZEwMz3Sou9Cc2NzebPd5bIbdIOeXQ4F0ucZWZy/4lFPJlg57ECilliNQStXzCk8Om11hawHYEr3QubSzkRfI851we6gbjuUkdwqza1F4dnVBYhLfN8Qr/ImSE+6cnolzmWxAWCmV7qxuULs6NpTijmaysgsgD8o7Qek+HDrq2lUqH9ERKhO0GlSqon/oZCUcRrbWoiUrl+KAhsrm2BjpRsRZ55TnZJGccuFRYS8f/zoq1qEyp9MR6ZUY3jkWDRkW4Ox7FGq9b0WeJSwKO449g19C2FdfwiSvdNHiaDlp6IuMJH47HeBYQEpSiwwknHtHLgC7Gr+fPEGeKM/jwRVgtaEy0XkrISLeEfKz7khck3ZVatjX0dxH7Est/jU+YFKbcmDeVJszG2L3YrHkGeiSoEgho10FHCKiXQkcobL5LUgLiU3TQFae2SX1obZWGZ6RDjtDslEzCf8SaHljUxtgEKpdq2y7Vtp2oTJ76lbtE43SJxumEA5/uaYY0T3yeblHB7Mv0kmL6WOBIoXKJm5U+mt1Iv+V0o6chJypcURy3TdwJ6ZIHb5X11aZHZ1uqYGeEYzPvTLVjyX+Nh7XFI0umAT48K+MSWZ5S8YtI8ZyKX0SKVSp+ESk+p+JTpOgWq0l7zgGPambFIUaxlWx1PnYWP/dD67oSzk+tNxMpuwyyvdKF2RNPzEfpRlfwJIogUAGVnJuVOEnyp/Lzc2wmWeKd49M7t4kaj6PnU+WyH8kfReBxC8jik2Q5nU1NVuGfqeF407B7wUNow5IncR+JXeIdKiAyuCm+9Vffx2Eh3iu6Yomv0SaxBG8nakA/rlzNHdT+hd+2wTyf6dOLOTJdcN04upjDxv1yonJTLCxSLDMVDMkWmAo6ukQ/PiBJ8OMDluX8+IA0zQLS/O8poT7hg+hJylYV9IPkIQNefg6O3KXxOGDHmIY08QsPxXYw/WUJsnjxgkWVEiPt4y6ji6u7yg2zWYM5+9wo24mBBf4YBI7GvNRdBqf6WW3inOOuDqxpcdqwimlZ0UN6v1nzsYxDHndwlSn7pxk4thMZqO8bXRHgB+m8rBtyHxKjsK1+02avn75/4EMf+dAnPvSZD13xoZ/50Bc+9Hs+9Asf+sqGfuFb0xe+NX3hW9MXvjV94VvTF741feFb0xe+NX3hW9MXvjW98q3plW9Nr3xreuVb0yvfml751vTKt6ZXvjW98q3plW1Nzw9sa3p+YFvT8wPbmp4f2Nb0/MC2pucHtjU9P7Ct6fmBbU3PD2xren7gW9PjAx/Kt6ZHvjU98q3pkW9Nj3xrenzhLMyPaL5BPfIN6vE1ReanB87ZyRHNN6snvlk9PSfJvEpCf05CvySh+fb19CWp4dcU9HOSiT0/JqH5Vvb8nPJdPa+S0HwX9vzCh/Lt65nvv55f2dDVAx/K91wrvk2tnvnQFR/Kt6YV35pWfGtaJXmr1WvKp/f5IQn9mIR+Sun3Z75xfeYb12e+cX3mG9dnvnG9PNNPTnvslwQs/3jgmb95XfF3ZSv+rmzFX6msnl7ZKl49PyVg+UO74n95q5f5St4P8yvoBQe72uqhnjXpXZerx31JjRqs9RFe/zP7WHqDhR+pU8jiSSaISqAzmDWGnroczZiYogo+lhaBHBKExwB4bXdQSvByiMbAE6d8zQgHuYDNFQe9hM0VBQfevyDLsB9modHkCqPcTya5pqhp5HtfY8rNNrjuansl+K/Jj1K88CjQ7zmRG3ya3M++Z3kBj8+mcOGNBTf/FvoRPOJ05jsNJzZKl2BFY8eeHpl2VNRS1MbR77w3T3jDO99qU5nyQMBxK22zJ41GFqHAJ+0biCVjKB0LgFDR0zQHYjt4UaT024la0VMbmMZUKj+IdxPfdzg+8iu2Cqy0+fYwV0knpvcWWph4ImweuLCmcUwsrV1LKJMcfnv6ubDJXeIAp9ta4H86FjrkVzKR0BCTEJsudRZr3nQWUct80v1OG3VgMf7xXeSt86YGK3aVHPVid0QJJDxswjtOPT7lMaeeg/7MUECeLbsZfu+Kg+ECkaPO5SUTU5oRpiSZFhBmASn4M9MVB1cOdqGxANeyOf/kQ65ZcCP4D+JHF9icaW0OqUTnUpHXklMsLzyW2Ce+GCcChgRJtp5k5Wn2nWbZUWX8OYg+cVi167KUPdjGKkesohauvFq8IkpZFEdQKMcyNVPdRff1H3LpoTSj5eHvcsS7P6ogdhefbQsPLlpP+c4jGvdKYg1buVOU2/A9vCwdQ9kJNTPOKDZYN5ZUBOYMXkldtrLktk6/fH8GJ9dkuEDzXiwfJaFvJc9ZXGMwvZ96+f2MhVRe4hzJKCzRE4xWKr5tOkrnpr6xO52Jjtd5uHC3lQ3+P2njNkUydUvu7sgpneJ78AOKJVZHi7SoZgb2hY6dqDR9W2mm9aVhD/sRzRv2Izxl2K9I2MPeWNOA9Qf69/Zu4GR+/cw190mxURKl+SRHjcDHAiRcSaz0dEVaqI0H5sdzAjO+HqtzusXEO0TYGO2gc0jAWpo51grl9GTPrScP7tIwC2r2cLxzd1xmiMET9iSqrs7fjW5M7VAdtIURe2Xx5h2ekVYiNHKJn3A7A3jaZnJApDitj64wJ00WX5UyemYz3S8LfDBn/phYwGcYdyDA2pFF99SbZt06CwPZpCnoBKPNPRZwWQhCrp2pWk+XNsK10Yc6VqObKCxwYyzGSEIQQ723wCAalA+KmzXqW5pjVKwnhMaISmvaZgGBVJGGT5cAi0inangHNlzaxYlVWjweqCjXX3ueddmcHURJRztYGPKEfBJZ/Clz3O8nM7Fe/Z9ieWGzNPgk7yK9isdGKRQ7WakCX9jF/STMna16hhBVG7P/WTieoV5E8sgvpx15TFXFTKf7GR+3FRmpjBU5WJz3c+k5PTN6gyuHHM9ZdlCR3f8geQuzIfo63oLbq7Ta2D0PFmAOFX9I56Jn6Erpt/iK7tTTGne1e0VEihdOsVCXOwMWrIFhC6wxUim5VhXhwv2RJxxWh2NFlm5TVoqTJN0zKktwTB033tXKGRvPU1+RpHfrZblusSIFY0Tc0NWRS5V4MrqVbts9dkc1wfDsTB5TPjGCPZTuxtuBabS71dciflmMOM7uqlmecbf6Cpzsnp/nTxSgyF/GJcNSH8o5L7UsygRN1NiSkpHDf+c8bJWnaSQeMzEeGLrDs7BYXhJeHDjn4g+MNU0DRWo+yk06erD6km4psZaShx3DnyBKkMh5XDVvNion5Qr2eCjRO4u1NbJIS525YETLsxuZQyJ8fOU7i6PZHpwKEaU0WVqtFlXNzm7IHgh0bg+Nh4KV7XtiYW5iI3B0LO5B/RashuOFHd6i9MhynqhLd3hHopuP5xJoxlRyHx5fT849lpe3IGuW9z5m9SedB3QsrJfwe4qNsrCXVTXxSNydwd0oi0lhl7ezaJm252SRYo3Fui1dJeFIW0gsrSjxvoNlqAR3y3iWsese7jetZ3QmkHhv1bodK2U8j6CbOBMOnsLOv1CW9tzoObo/JLp5a2qOGCeitP5g4cxeM2kiIdMyHOk9unUZbCbFRLBszijHYqQprbuDE7Raq2cUqqhSv7fTK78cAYLDQGeKQhxfHk9nco3Mx94km0MEeGzc39fjr1rPyVIX5eds0WEvQmeskBWmbPotocD3ORFeElnIPfSPzi/DkuYiOpZEJ9GRpBPwv/TuuSf8yibyE+/greyeYx4PXd/zdD26T7+YSBmdKYPZbBx4up2WFsQbHIjNhhPUeJpqWt+0ntr9wBCGsbtKSZc8MHQ3QdGYLetEtyNpjo/KT6iRRjK65JxB0WkyXhOr5Uc6h9IcjqM7TZLkkoUsi8rrprvhpZrd6lOWZRQDO0O/sND4mzcEuGuh1yQvKSRsLfRouhbKiKYDtbFQDEOHCScePVs8D4yxzKXoUo/zBsl3AioI3jj0m6W4MbKUFKIx6VTBMMMxntGjQhYVL7tijKnzWeFxhAUGcvqKMZ3LQS21Vzl5qTBGhm+pO47uFz+qVfrisDUVP+5x5pHUbeUXOmdVeqEDYKUXPgFWmn8EHBdMwucNb93aEXhDzjW9hPNyTk9WkugWexqu58EnJqHAKiT8A8+Eu/ADipTr8AOapJP1Ez5aNzvzSS2R/3hB8sIl6Vf0E0q5O7o9fgGl0AsWDMAOcuEaRe/AzfjGfbSvGMJO3Tm8i9ythNoyWtythAl+xJG7uFsJa1osieRyonHtXsLlIo2leuLHQ25/MOFRu11JW/ZPh7FmobPVOP/E7YyGPydGmtTjxBEaVqmuUb6FepdQQeyCz0F/BMXCn3u1sNmju7ZRKu5p1igZLwJYYYw7l7ZgaMfsMZqqRKXG3uK7lxhdy49jaJd1OooEx0hIwonxBQ/7zPiMh39qfEbDp+itg2UVtfxQdVsnTo09S/wMF2BizLTx5TVRF59FvoX8zbU1/fPtWVxu6KcdNXiwSSkDNXhrBOzyMegM4Xs056ymVjrxO1V6odhOrfRC8Z0LpoSv9ZyITbLE56b0Up+b0uzPzWjljT1eB8cba0e/ylXPCOfAArisWLmqC187L/M3UUDjt6kkPH1fssQ5nfGpTzA9PizH9bQc1fNyVKvlqD4vR/WyHNX3y1F9WY7qlUmVdORwxnC+DGaKw6pgN8HAPs655nlZgifpgtMF19nYJ+uaM79cMHAWNEixa3R3pBGvz5StcltO9uPpKN7C6fL3RuLVU6JZY6E+Vk4oAmP9ZuxKbzTCgqxqDlljQsEMhvwByTtsxl5wFqjJTySciHhPJRzxKU8mnEjiiwMjhyEcvpFy3rzhSXhLYJqCM95DlnhLnL2jGOFi7ymuuPi7iisqJk3iMeFi54PJRrzUieDttwXufwNjTwTwJvtRqpc0Kt6rAxM01NcHJmhorxBckYy4L7rb4b5KMCBg+kzGKwU9lvtawRGfmiQcJzH+VxtX6BzRWe8SXMJZ7xMcSUiF+s9QjIL9I3hG4f4RFmIB/1EGciH/E8sSBf3P2Ra6ZJ1S4P+MY4HkhiUK/l9y8ePoSzwAMM2V4E1OJeuXlG6RhwFGGBcUbkGpok9fRKz0+SHtAYEzmsUeErhmjbUhFiI8l5K9fJxie0lji31NF+tElCDRIt/QIl/PMt/NMl/MErVKeA8UHNGJDxWceNi1WY8UaTVax2iW+T4HhCpFmtETkrsjxK6w2sOZlVYv4bzsV7vJnz9/fhB/Ko8b44TznSsm9unOBRP/bMf6U8Lk6ODesXjEO3y3QOeTn91d+7hxU/9O8z2yNgVwsbzNdI+2UhemPoaCieo/XhSevvU6pxd4zI7HLcliBBLefeUjR6oMcV5aiAY7A2wO07QV54roicGa9VTpxnt+70gyut+dKUDKXfZzEpYmg3dLrekRI3z94WS8wO4YjiYx1bG7XomiHH0eeTpd4qL7kYPfuuovB+In7+IZPyxF199DTaZr3vyCwgW2VNmY8wW4kHTOytlzNVboiV8RZ6meupMa4hllTq/hzASA1I3cEJ/Uj8RKrUvsmhY6CXA2T6pD57xMsCsvE5XgpS4wYRzfa4u7pMT69COU52f4DB0fnAdcJCqfHNjFS4SY9sLft0cGbfYVFGV3tZW1Y0aifpu7Zl2NRYbhDpdnwsiyUTpRJTHXgvdBowi8+0yIbNw2UXjrUk5yfH5/FzeDoXNI/DF0h9QxbG2ZzoDfQ0gIAcvrx17pAvdRuawgjYEV6BrmWjOraJwmldRTz8H0lHpq6VutoUoKQ7fFEv4GWfo7GNwpATniDM4UQmMH2FfK26ZJuS8V3sLirYRDyaCQ6BhqI2IvJp7NvNOFnbK+xRwcP5j4N3J8U3ZfrEk2Zjcv+JZhaVtW37hn6zsrNjzB99hkvsUqnqPJm3fGNsCdUwWjZd8w15TpB73pB7zJB7vcA92Ug9z+MHT+OHMObnsMzSDTDmr5B7RJB7MpB7JHLLfNeM6QCKccF56w1APXpINWzgFr0sEq/0A17SA1+QCVe3CacmCaclB6xNJbSzsYXehAdJmD0GUOQI8sRL8bH8mgohjHpE5Vb/iWZJe1Q7AS7rEq9ziVeYx6DSMeO3KPTZnHpdcwnrysVVzEDvadpO3VKNxN7JKmWZiHqs5V4YVATLzEimETC7Hpzh/xqtTStxYY2OOLj3j7R2482FSSNWwMTxRaWaIeFx9UCJVVia2qhnTxLmAqBZonq6nxRoKj74GxXbP+E/KJW0s3BY7A8dq9N5FNu65UjsWLb8zKcxkmfMJNeHy5fGKYpj+qiCNORpzQBsZDeI5ruVAGPYQxZWakwEWBj5hccfADFsmBCn6AIi0wwQ9IsAMR9AAEP/DADzjwAw3sAAM/sMAPKKQEEvgBBHbgwEPdVNKP7s2mQRuPRl4BzacG2OipzzRE1eC8rJu52u9///RY/qloy7fELXtCMKVfAFKO4dIDLwsEXBICLWkBlpTACjugwgykMAMojMAJQmiI5BDLTjUeKhkKU8w+QpmOoChCw1McRA1c8KSh25bQA2qsZVfvpT0+MXjqsPTeklmUXoTGg5ZdFXw/4fSaudgXLpb2FZ8T9Hc5Zg/aGTq6EWbHT+gXPprV+d1HJY/l8aypYHb3G8249UIPDu6ldLhIrdVfMp4Kh2Koc1tkBhUZwUR2EPEjLKSGU2CkwHuwM+3pmoNmDh0+WhK3+VmG+N8DANPiO/g="
 }
@@ -0,0 +1,131 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package redisstreams
+
+import (
+	"sync"
+
+	rd "github.com/gomodule/redigo/redis"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/acker"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// ackHandle is stored in the Private field of published events and
+// identifies the stream entry of the event.
+type ackHandle struct {
+	key string
+	id  string
+}
+
+// newEventACKHandler returns a beat ACKer that hands the entries of ACKed
+// events to the entryACKer.
+func newEventACKHandler(a *entryACKer) beat.EventListener {
+	return acker.ConnectionOnly(
+		acker.EventPrivateReporter(func(_ int, privates []interface{}) {
+			ids := make(map[string][]string)
+			for _, private := range privates {
+				if h, ok := private.(*ackHandle); ok {
+					ids[h.key] = append(ids[h.key], h.id)
+				}
+			}
+			a.add(ids)
+		}),
+	)
+}
+
+// entryACKer acknowledges stream entries with XACK. Entries are collected
+// and sent from a separate goroutine so that the pipeline is not blocked by
+// round trips to the server. Entries that fail to be acknowledged stay in the
+// pending entries list. They are claimed by other consumers of the group or
+// read again when the input restarts.
+type entryACKer struct {
+	pool    *rd.Pool
+	group   string
+	log     *logp.Logger
+	metrics *inputMetrics
+
+	mu      sync.Mutex
+	pending map[string][]string
+	signal  chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newEntryACKer(pool *rd.Pool, group string, log *logp.Logger, metrics *inputMetrics) *entryACKer {
+	a := &entryACKer{
+		pool:    pool,
+		group:   group,
+		log:     log,
+		metrics: metrics,
+		pending: make(map[string][]string),
+		signal:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.run()
+	}()
+	return a
+}
+
+// add queues entry IDs, grouped by stream key, for acknowledgement.
+func (a *entryACKer) add(ids map[string][]string) {
+	if len(ids) == 0 {
+		return
+	}
+	a.mu.Lock()
+	for key, v := range ids {
+		a.pending[key] = append(a.pending[key], v...)
+	}
+	a.mu.Unlock()
+
+	select {
+	case a.signal <- struct{}{}:
+	default:
+	}
+}
+
+// close acknowledges the remaining entries and stops the ACKer.
+func (a *entryACKer) close() {
+	close(a.done)
+	a.wg.Wait()
+}
+
+func (a *entryACKer) run() {
+	for {
+		select {
+		case <-a.signal:
+			a.flush()
+		case <-a.done:
+			a.flush()
+			return
+		}
+	}
+}
+
+func (a *entryACKer) flush() {
+	a.mu.Lock()
+	pending := a.pending
+	a.pending = make(map[string][]string)
+	a.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	conn := a.pool.Get()
+	defer conn.Close()
+	for key, ids := range pending {
+		n, err := ack(conn, key, a.group, ids)
+		if err != nil {
+			a.metrics.ackErrorsTotal.Inc()
+			a.log.Warnw("Failed to acknowledge stream entries, they stay pending", "stream", key, "count", len(ids), "error", err)
+			continue
+		}
+		a.metrics.entriesACKedTotal.Add(uint64(n))
+	}
+}
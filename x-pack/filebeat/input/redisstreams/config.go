@@ -0,0 +1,92 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package redisstreams
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+type config struct {
+	Host         string            `config:"host" validate:"required"`    // Redis server address.
+	Network      string            `config:"network"`                     // Network used to connect to the server (tcp or unix).
+	Username     string            `config:"username"`                    // Username for ACL authentication.
+	Password     string            `config:"password"`                    // Password for authentication.
+	DB           int               `config:"db" validate:"min=0"`         // Database number.
+	TLS          *tlscommon.Config `config:"ssl"`                         // TLS options.
+	Timeout      time.Duration     `config:"timeout" validate:"nonzero"`  // Connect, read and write timeout.
+	Streams      []string          `config:"streams" validate:"required"` // Stream keys to consume.
+	Group        string            `config:"group" validate:"required"`   // Consumer group name.
+	Consumer     string            `config:"consumer"`                    // Consumer name within the group. Defaults to the hostname.
+	CreateGroup  bool              `config:"create_group"`                // Create the consumer group (and the stream) if it does not exist.
+	StartID      string            `config:"start_id"`                    // ID from which a created group starts reading.
+	BatchSize    int               `config:"batch_size" validate:"min=1"` // Maximum number of entries returned per command.
+	Block        time.Duration     `config:"block" validate:"nonzero"`    // Maximum time to block waiting for new entries.
+	MessageField string            `config:"message_field"`               // Entry field used as the event message.
+	Claim        claimConfig       `config:"claim"`                       // Reclaiming of entries pending on other consumers.
+	Backoff      backoffConfig     `config:"backoff"`                     // Reconnection backoff.
+	WaitClose    time.Duration     `config:"wait_close" validate:"min=0"` // Time to wait for pending ACKs on shutdown.
+}
+
+type claimConfig struct {
+	Enabled  bool          `config:"enabled"`
+	MinIdle  time.Duration `config:"min_idle" validate:"nonzero"`
+	Interval time.Duration `config:"interval" validate:"nonzero"`
+}
+
+type backoffConfig struct {
+	Init time.Duration `config:"init" validate:"nonzero"`
+	Max  time.Duration `config:"max" validate:"nonzero"`
+}
+
+func defaultConfig() config {
+	return config{
+		Network:      "tcp",
+		Timeout:      10 * time.Second,
+		CreateGroup:  true,
+		StartID:      "$",
+		BatchSize:    100,
+		Block:        5 * time.Second,
+		MessageField: "message",
+		Claim: claimConfig{
+			Enabled:  true,
+			MinIdle:  5 * time.Minute,
+			Interval: time.Minute,
+		},
+		Backoff: backoffConfig{
+			Init: time.Second,
+			Max:  time.Minute,
+		},
+		WaitClose: 2 * time.Second,
+	}
+}
+
+func (c *config) Validate() error {
+	switch c.Network {
+	case "tcp", "tcp4", "tcp6", "unix":
+	default:
+		return fmt.Errorf("invalid network %q: allowed values are tcp, tcp4, tcp6 and unix", c.Network)
+	}
+	seen := make(map[string]bool, len(c.Streams))
+	for _, s := range c.Streams {
+		if s == "" {
+			return errors.New("stream keys must not be empty")
+		}
+		if seen[s] {
+			return fmt.Errorf("stream %q configured more than once", s)
+		}
+		seen[s] = true
+	}
+	if c.Block < time.Millisecond {
+		return errors.New("block must be at least 1ms")
+	}
+	if c.Backoff.Max < c.Backoff.Init {
+		return errors.New("backoff.max must be greater than or equal to backoff.init")
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package redisstreams
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	conf "github.com/elastic/elastic-agent-libs/config"
+)
+
+func TestConfig(t *testing.T) {
+	testCases := []struct {
+		name        string
+		userConfig  map[string]interface{}
+		expectedErr string
+	}{
+		{
+			"valid",
+			map[string]interface{}{
+				"host":    "localhost:6379",
+				"streams": []string{"logs"},
+				"group":   "filebeat",
+			},
+			"",
+		},
+		{
+			"missing group",
+			map[string]interface{}{
+				"host":    "localhost:6379",
+				"streams": []string{"logs"},
+			},
+			"string value is not set accessing 'group'",
+		},
+		{
+			"duplicate stream",
+			map[string]interface{}{
+				"host":    "localhost:6379",
+				"streams": []string{"logs", "logs"},
+				"group":   "filebeat",
+			},
+			`stream "logs" configured more than once`,
+		},
+		{
+			"invalid network",
+			map[string]interface{}{
+				"host":    "localhost:6379",
+				"streams": []string{"logs"},
+				"group":   "filebeat",
+				"network": "udp",
+			},
+			`invalid network "udp"`,
+		},
+		{
+			"block too small",
+			map[string]interface{}{
+				"host":    "localhost:6379",
+				"streams": []string{"logs"},
+				"group":   "filebeat",
+				"block":   "1us",
+			},
+			"block must be at least 1ms",
+		},
+		{
+			"invalid batch size",
+			map[string]interface{}{
+				"host":       "localhost:6379",
+				"streams":    []string{"logs"},
+				"group":      "filebeat",
+				"batch_size": 0,
+			},
+			"requires value >= 1 accessing 'batch_size'",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c := conf.MustNewConfigFrom(tc.userConfig)
+
+			redisConf := defaultConfig()
+			err := c.Unpack(&redisConf)
+
+			if tc.expectedErr != "" {
+				require.Error(t, err, "expected error: %s", tc.expectedErr)
+				require.Contains(t, err.Error(), tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,355 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package redisstreams
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	rd "github.com/gomodule/redigo/redis"
+
+	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/backoff"
+	"github.com/elastic/beats/v7/libbeat/feature"
+	"github.com/elastic/beats/v7/libbeat/management/status"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+const inputName = "redis_streams"
+
+func Plugin() v2.Plugin {
+	return v2.Plugin{
+		Name:       inputName,
+		Stability:  feature.Experimental,
+		Deprecated: false,
+		Info:       "Redis Streams consumer group",
+		Doc:        "The Redis Streams input reads entries from Redis streams as a member of a consumer group and acknowledges them once they have been published",
+		Manager:    v2.ConfigureWith(configure),
+	}
+}
+
+func configure(cfg *conf.C) (v2.Input, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+	return newInput(config)
+}
+
+type redisInput struct {
+	config   config
+	consumer string
+	tls      *tls.Config
+}
+
+var _ v2.Input = (*redisInput)(nil)
+
+func newInput(config config) (*redisInput, error) {
+	in := &redisInput{config: config, consumer: config.Consumer}
+	if in.consumer == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hostname for the default consumer name: %w", err)
+		}
+		in.consumer = hostname
+	}
+	if config.TLS.IsEnabled() {
+		tlsConfig, err := tlscommon.LoadTLSConfig(config.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS configuration: %w", err)
+		}
+		host := config.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		in.tls = tlsConfig.BuildModuleClientConfig(host)
+	}
+	return in, nil
+}
+
+func (in *redisInput) Name() string { return inputName }
+
+func (in *redisInput) Test(_ v2.TestContext) error {
+	pool := in.newPool()
+	defer pool.Close()
+	conn := pool.Get()
+	defer conn.Close()
+	_, err := conn.Do("PING")
+	return err
+}
+
+func (in *redisInput) Run(ctx v2.Context, pipeline beat.Pipeline) error {
+	log := ctx.Logger.With("streams", in.config.Streams, "group", in.config.Group, "consumer", in.consumer)
+	log.Info("Starting " + inputName + " input")
+	defer log.Info(inputName + " input stopped")
+	ctx.UpdateStatus(status.Starting, "")
+
+	metrics := newInputMetrics(ctx.ID, nil)
+	defer metrics.Close()
+
+	pool := in.newPool()
+	defer pool.Close()
+
+	acks := newEntryACKer(pool, in.config.Group, log, metrics)
+	defer acks.close()
+
+	client, err := pipeline.ConnectWith(beat.ClientConfig{
+		EventListener: newEventACKHandler(acks),
+		WaitClose:     in.config.WaitClose,
+	})
+	if err != nil {
+		ctx.UpdateStatus(status.Failed, "failed to connect to pipeline: "+err.Error())
+		return fmt.Errorf("failed to create pipeline client: %w", err)
+	}
+	defer client.Close()
+
+	r := &streamReader{
+		config:   in.config,
+		consumer: in.consumer,
+		client:   client,
+		acks:     acks,
+		metrics:  metrics,
+		log:      log,
+	}
+
+	connectDelay := backoff.NewEqualJitterBackoff(
+		ctx.Cancelation.Done(),
+		in.config.Backoff.Init,
+		in.config.Backoff.Max,
+	)
+	for ctx.Cancelation.Err() == nil {
+		conn := pool.Get()
+		err := r.setup(conn)
+		if err == nil {
+			// We've successfully connected, reset the backoff timer.
+			connectDelay.Reset()
+			ctx.UpdateStatus(status.Running, "")
+			err = r.consume(ctx.Cancelation, conn)
+		}
+		conn.Close()
+		if ctx.Cancelation.Err() != nil {
+			break
+		}
+		log.Errorw("Failed to read from Redis", "error", err)
+		ctx.UpdateStatus(status.Degraded, "failed to read from Redis: "+err.Error())
+		metrics.errorsTotal.Inc()
+		connectDelay.Wait()
+	}
+
+	ctx.UpdateStatus(status.Stopped, "")
+	return nil
+}
+
+// newPool returns a connection pool for the configured server. The read
+// timeout of the connections allows for blocking reads.
+func (in *redisInput) newPool() *rd.Pool {
+	cfg := in.config
+	return &rd.Pool{
+		MaxIdle:     2,
+		IdleTimeout: cfg.Timeout,
+		Dial: func() (rd.Conn, error) {
+			dialOptions := []rd.DialOption{
+				rd.DialUsername(cfg.Username),
+				rd.DialPassword(cfg.Password),
+				rd.DialDatabase(cfg.DB),
+				rd.DialConnectTimeout(cfg.Timeout),
+				rd.DialReadTimeout(cfg.Timeout + cfg.Block),
+				rd.DialWriteTimeout(cfg.Timeout),
+			}
+			if in.tls != nil {
+				dialOptions = append(dialOptions,
+					rd.DialUseTLS(true),
+					rd.DialTLSConfig(in.tls),
+				)
+			}
+			return rd.Dial(cfg.Network, cfg.Host, dialOptions...)
+		},
+	}
+}
+
+// streamReader reads entries of the configured streams and publishes them.
+type streamReader struct {
+	config   config
+	consumer string
+	client   beat.Client
+	acks     *entryACKer
+	metrics  *inputMetrics
+	log      *logp.Logger
+
+	pendingRead bool      // Whether the entries pending on this consumer were read.
+	lastClaim   time.Time // Time of the last reclaim.
+}
+
+// setup checks the connection and creates the consumer groups.
+func (r *streamReader) setup(conn rd.Conn) error {
+	if !r.config.CreateGroup {
+		_, err := conn.Do("PING")
+		return err
+	}
+	for _, key := range r.config.Streams {
+		if err := createGroup(conn, key, r.config.Group, r.config.StartID); err != nil {
+			return fmt.Errorf("failed to create consumer group on stream %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// consume publishes new entries until the input is cancelled or an error
+// occurs. Entries that were delivered to this consumer but not acknowledged
+// before the input was last stopped are published first.
+func (r *streamReader) consume(cancel v2.Canceler, conn rd.Conn) error {
+	if !r.pendingRead {
+		if err := r.readPending(cancel, conn); err != nil {
+			return err
+		}
+		r.pendingRead = true
+	}
+
+	ids := make([]string, len(r.config.Streams))
+	for i := range ids {
+		ids[i] = ">"
+	}
+	for cancel.Err() == nil {
+		if r.config.Claim.Enabled && time.Since(r.lastClaim) >= r.config.Claim.Interval {
+			if err := r.claimPending(cancel, conn); err != nil {
+				return err
+			}
+			r.lastClaim = time.Now()
+		}
+
+		streams, err := readGroup(conn, r.config.Group, r.consumer, r.config.BatchSize, r.config.Block, r.config.Streams, ids)
+		if err != nil {
+			return fmt.Errorf("failed to read from streams: %w", err)
+		}
+		for _, s := range streams {
+			r.publish(s.key, s.entries, false)
+		}
+	}
+	return nil
+}
+
+// readPending publishes the entries pending on this consumer.
+func (r *streamReader) readPending(cancel v2.Canceler, conn rd.Conn) error {
+	for _, key := range r.config.Streams {
+		id := "0"
+		for cancel.Err() == nil {
+			streams, err := readGroup(conn, r.config.Group, r.consumer, r.config.BatchSize, 0, []string{key}, []string{id})
+			if err != nil {
+				return fmt.Errorf("failed to read pending entries from stream %q: %w", key, err)
+			}
+			if len(streams) == 0 || len(streams[0].entries) == 0 {
+				break
+			}
+			entries := streams[0].entries
+			r.publish(key, entries, false)
+			id = entries[len(entries)-1].id
+		}
+	}
+	return nil
+}
+
+// claimPending claims and publishes the entries that have been pending on
+// other consumers of the group for longer than claim.min_idle. Entries
+// pending on this consumer are not claimed: they were published and are
+// waiting for the outputs to acknowledge them.
+func (r *streamReader) claimPending(cancel v2.Canceler, conn rd.Conn) error {
+	for _, key := range r.config.Streams {
+		start := "-"
+		for cancel.Err() == nil {
+			pending, err := listPending(conn, key, r.config.Group, start, r.config.BatchSize)
+			if err != nil {
+				return fmt.Errorf("failed to list pending entries of stream %q: %w", key, err)
+			}
+			var ids []string
+			for _, p := range pending {
+				if p.consumer != r.consumer && p.idle >= r.config.Claim.MinIdle {
+					ids = append(ids, p.id)
+				}
+			}
+			if len(ids) != 0 {
+				entries, err := claim(conn, key, r.config.Group, r.consumer, r.config.Claim.MinIdle, ids)
+				if err != nil {
+					return fmt.Errorf("failed to claim pending entries from stream %q: %w", key, err)
+				}
+				if len(entries) != 0 {
+					r.log.Debugw("Claimed pending entries", "stream", key, "count", len(entries))
+					r.metrics.entriesClaimedTotal.Add(uint64(len(entries)))
+					r.publish(key, entries, true)
+				}
+			}
+			if len(pending) < r.config.BatchSize {
+				break
+			}
+			var ok bool
+			if start, ok = nextID(pending[len(pending)-1].id); !ok {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// publish publishes the entries of a stream. Entries that were deleted from
+// the stream while pending are acknowledged directly.
+func (r *streamReader) publish(key string, entries []entry, claimed bool) {
+	var deleted []string
+	for _, e := range entries {
+		if e.fields == nil {
+			deleted = append(deleted, e.id)
+			continue
+		}
+		r.metrics.entriesReadTotal.Inc()
+		r.client.Publish(r.newEvent(key, e, claimed))
+	}
+	if len(deleted) != 0 {
+		r.acks.add(map[string][]string{key: deleted})
+	}
+}
+
+func (r *streamReader) newEvent(key string, e entry, claimed bool) beat.Event {
+	ts, ok := idTime(e.id)
+	if !ok {
+		ts = time.Now().UTC()
+	}
+
+	stream := mapstr.M{
+		"key":      key,
+		"id":       e.id,
+		"group":    r.config.Group,
+		"consumer": r.consumer,
+	}
+	if claimed {
+		stream["claimed"] = true
+	}
+	fields := mapstr.M{}
+	var extra mapstr.M
+	for k, v := range e.fields {
+		if r.config.MessageField != "" && k == r.config.MessageField {
+			fields["message"] = v
+			continue
+		}
+		if extra == nil {
+			extra = make(mapstr.M, len(e.fields))
+		}
+		extra[k] = v
+	}
+	if extra != nil {
+		stream["fields"] = extra
+	}
+	fields["redis"] = mapstr.M{"stream": stream}
+
+	return beat.Event{
+		Timestamp: ts,
+		Fields:    fields,
+		Private:   &ackHandle{key: key, id: e.id},
+	}
+}
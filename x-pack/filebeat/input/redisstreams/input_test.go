@@ -0,0 +1,207 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package redisstreams
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	rd "github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+// fakeConn is a redis connection that records commands and replies with
+// the result of the handler.
+type fakeConn struct {
+	mu       sync.Mutex
+	commands []string
+	handler  func(cmd string, args []interface{}) (interface{}, error)
+}
+
+func (c *fakeConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	c.mu.Lock()
+	parts := []string{cmd}
+	for _, a := range args {
+		parts = append(parts, fmt.Sprint(a))
+	}
+	c.commands = append(c.commands, strings.Join(parts, " "))
+	c.mu.Unlock()
+	return c.handler(cmd, args)
+}
+
+func (c *fakeConn) Close() error                      { return nil }
+func (c *fakeConn) Err() error                        { return nil }
+func (c *fakeConn) Send(string, ...interface{}) error { return nil }
+func (c *fakeConn) Flush() error                      { return nil }
+func (c *fakeConn) Receive() (interface{}, error)     { return nil, nil }
+
+// recorded returns the recorded commands, ignoring the empty commands sent
+// by the pool when connections are returned.
+func (c *fakeConn) recorded() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var commands []string
+	for _, cmd := range c.commands {
+		if cmd != "" {
+			commands = append(commands, cmd)
+		}
+	}
+	return commands
+}
+
+var _ rd.Conn = (*fakeConn)(nil)
+
+type fakeClient struct {
+	events []beat.Event
+}
+
+func (c *fakeClient) Publish(e beat.Event) { c.events = append(c.events, e) }
+func (c *fakeClient) PublishAll(es []beat.Event) {
+	for _, e := range es {
+		c.Publish(e)
+	}
+}
+func (c *fakeClient) Close() error { return nil }
+
+func TestStreamReaderConsume(t *testing.T) {
+	metrics := newInputMetrics("test", monitoring.NewRegistry())
+	defer metrics.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pendingReads := 0
+	conn := &fakeConn{handler: func(cmd string, args []interface{}) (interface{}, error) {
+		switch cmd {
+		case "XREADGROUP":
+			switch id := args[len(args)-1]; id {
+			case "0":
+				pendingReads++
+				return []interface{}{[]interface{}{
+					[]byte("logs"),
+					[]interface{}{entryReply("1700000000000-0", "message", "pending")},
+				}}, nil
+			case "1700000000000-0":
+				pendingReads++
+				return []interface{}{[]interface{}{[]byte("logs"), []interface{}{}}}, nil
+			case ">":
+				cancel()
+				return []interface{}{[]interface{}{
+					[]byte("logs"),
+					[]interface{}{entryReply("1700000000002-0", "message", "new", "level", "info")},
+				}}, nil
+			}
+		case "XPENDING":
+			return []interface{}{
+				pendingReply("1700000000000-5", "host-1", 600000),
+				pendingReply("1700000000001-0", "host-2", 600000),
+				pendingReply("1700000000001-1", "host-2", 600000),
+				pendingReply("1700000000001-2", "host-2", 1000),
+			}, nil
+		case "XCLAIM":
+			return []interface{}{
+				entryReply("1700000000001-0", "message", "claimed"),
+				entryReply("1700000000001-1"),
+			}, nil
+		}
+		return nil, fmt.Errorf("unexpected command %s", cmd)
+	}}
+
+	pool := &rd.Pool{Dial: func() (rd.Conn, error) { return conn, nil }}
+	acks := newEntryACKer(pool, "filebeat", logp.NewLogger(inputName), metrics)
+	defer acks.close()
+
+	cfg := defaultConfig()
+	cfg.Streams = []string{"logs"}
+	cfg.Group = "filebeat"
+	client := &fakeClient{}
+	r := &streamReader{
+		config:   cfg,
+		consumer: "host-1",
+		client:   client,
+		acks:     acks,
+		metrics:  metrics,
+		log:      logp.NewLogger(inputName),
+	}
+	require.NoError(t, r.consume(ctx, conn))
+
+	assert.Equal(t, 2, pendingReads)
+	assert.True(t, r.pendingRead)
+	assert.Equal(t, []string{
+		"XREADGROUP GROUP filebeat host-1 COUNT 100 STREAMS logs 0",
+		"XREADGROUP GROUP filebeat host-1 COUNT 100 STREAMS logs 1700000000000-0",
+		"XPENDING logs filebeat - + 100",
+		"XCLAIM logs filebeat host-1 300000 1700000000001-0 1700000000001-1",
+		"XREADGROUP GROUP filebeat host-1 COUNT 100 BLOCK 5000 STREAMS logs >",
+	}, conn.recorded()[:5])
+
+	require.Len(t, client.events, 3)
+	assert.Equal(t, "pending", client.events[0].Fields["message"])
+	assert.Equal(t, "claimed", client.events[1].Fields["message"])
+	assert.Equal(t, true, mustGetValue(t, client.events[1].Fields, "redis.stream.claimed"))
+
+	last := client.events[2]
+	assert.Equal(t, "new", last.Fields["message"])
+	assert.Equal(t, &ackHandle{key: "logs", id: "1700000000002-0"}, last.Private)
+	assert.Equal(t, mapstr.M{
+		"key":      "logs",
+		"id":       "1700000000002-0",
+		"group":    "filebeat",
+		"consumer": "host-1",
+		"fields":   mapstr.M{"level": "info"},
+	}, mustGetValue(t, last.Fields, "redis.stream"))
+	assert.Equal(t, int64(1700000000002), last.Timestamp.UnixMilli())
+
+	assert.Equal(t, uint64(3), metrics.entriesReadTotal.Get())
+	assert.Equal(t, uint64(2), metrics.entriesClaimedTotal.Get())
+}
+
+func TestEventACKHandler(t *testing.T) {
+	metrics := newInputMetrics("test", monitoring.NewRegistry())
+	defer metrics.Close()
+
+	conn := &fakeConn{handler: func(cmd string, args []interface{}) (interface{}, error) {
+		if cmd != "XACK" {
+			return nil, fmt.Errorf("unexpected command %s", cmd)
+		}
+		return int64(len(args) - 2), nil
+	}}
+	pool := &rd.Pool{Dial: func() (rd.Conn, error) { return conn, nil }}
+	acks := newEntryACKer(pool, "filebeat", logp.NewLogger(inputName), metrics)
+
+	listener := newEventACKHandler(acks)
+	events := []beat.Event{
+		{Private: &ackHandle{key: "logs", id: "1-0"}},
+		{Private: &ackHandle{key: "audit", id: "1-1"}},
+		{Private: &ackHandle{key: "logs", id: "2-0"}},
+	}
+	for _, e := range events {
+		listener.AddEvent(e, true)
+	}
+	listener.ACKEvents(len(events))
+	acks.close()
+
+	assert.ElementsMatch(t, []string{
+		"XACK logs filebeat 1-0 2-0",
+		"XACK audit filebeat 1-1",
+	}, conn.recorded())
+	assert.Equal(t, uint64(3), metrics.entriesACKedTotal.Get())
+}
+
+func mustGetValue(t *testing.T, m mapstr.M, key string) interface{} {
+	t.Helper()
+	v, err := m.GetValue(key)
+	require.NoError(t, err, key)
+	return v
+}
@@ -0,0 +1,38 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package redisstreams
+
+import (
+	"github.com/elastic/beats/v7/libbeat/monitoring/inputmon"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+type inputMetrics struct {
+	unregister func()
+
+	entriesReadTotal    *monitoring.Uint // Number of stream entries read (not necessarily processed fully).
+	entriesClaimedTotal *monitoring.Uint // Number of stream entries claimed from other consumers.
+	entriesACKedTotal   *monitoring.Uint // Number of stream entries acknowledged with XACK.
+	ackErrorsTotal      *monitoring.Uint // Number of failed XACK commands.
+	errorsTotal         *monitoring.Uint // Number of connection and command errors.
+}
+
+// Close removes the metrics from the registry.
+func (m *inputMetrics) Close() {
+	m.unregister()
+}
+
+func newInputMetrics(id string, optionalParent *monitoring.Registry) *inputMetrics {
+	reg, unreg := inputmon.NewInputRegistry(inputName, id, optionalParent)
+
+	return &inputMetrics{
+		unregister:          unreg,
+		entriesReadTotal:    monitoring.NewUint(reg, "entries_read_total"),
+		entriesClaimedTotal: monitoring.NewUint(reg, "entries_claimed_total"),
+		entriesACKedTotal:   monitoring.NewUint(reg, "entries_acked_total"),
+		ackErrorsTotal:      monitoring.NewUint(reg, "ack_errors_total"),
+		errorsTotal:         monitoring.NewUint(reg, "errors_total"),
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package redisstreams
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	rd "github.com/gomodule/redigo/redis"
+)
+
+// entry is a stream entry. The fields of entries that were deleted from the
+// stream while pending are nil.
+type entry struct {
+	id     string
+	fields map[string]string
+}
+
+// streamEntries are the entries read from a single stream.
+type streamEntries struct {
+	key     string
+	entries []entry
+}
+
+// createGroup creates the consumer group on the stream, creating the stream
+// if it does not exist. It is not an error if the group already exists.
+func createGroup(conn rd.Conn, key, group, startID string) error {
+	_, err := conn.Do("XGROUP", "CREATE", key, group, startID, "MKSTREAM")
+	var redisErr rd.Error
+	if errors.As(err, &redisErr) && strings.HasPrefix(string(redisErr), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// readGroup runs XREADGROUP for the given streams and IDs. A nil result is
+// returned if no entries were available before the block timeout expired.
+func readGroup(conn rd.Conn, group, consumer string, count int, block time.Duration, keys, ids []string) ([]streamEntries, error) {
+	args := rd.Args{"GROUP", group, consumer, "COUNT", count}
+	if block > 0 {
+		args = append(args, "BLOCK", block.Milliseconds())
+	}
+	args = append(args, "STREAMS")
+	args = args.AddFlat(keys).AddFlat(ids)
+	reply, err := conn.Do("XREADGROUP", args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseStreams(reply)
+}
+
+// pendingEntry is an entry of the pending entries list of a consumer group.
+type pendingEntry struct {
+	id       string
+	consumer string
+	idle     time.Duration
+}
+
+// listPending runs the extended form of XPENDING, returning up to count
+// entries of the pending entries list of the group starting at the ID start.
+func listPending(conn rd.Conn, key, group, start string, count int) ([]pendingEntry, error) {
+	reply, err := conn.Do("XPENDING", key, group, start, "+", count)
+	if err != nil {
+		return nil, err
+	}
+	return parsePending(reply)
+}
+
+// claim runs XCLAIM to transfer the given entries to the consumer. Entries
+// that were claimed by another consumer or delivered again since they were
+// listed are not idle for minIdle anymore and are not claimed.
+func claim(conn rd.Conn, key, group, consumer string, minIdle time.Duration, ids []string) ([]entry, error) {
+	reply, err := conn.Do("XCLAIM", rd.Args{key, group, consumer, minIdle.Milliseconds()}.AddFlat(ids)...)
+	if err != nil {
+		return nil, err
+	}
+	return parseEntries(reply)
+}
+
+// ack runs XACK for the given entries of a stream and returns the number of
+// acknowledged entries.
+func ack(conn rd.Conn, key, group string, ids []string) (int, error) {
+	return rd.Int(conn.Do("XACK", rd.Args{key, group}.AddFlat(ids)...))
+}
+
+// parseStreams parses an XREAD or XREADGROUP reply.
+func parseStreams(reply interface{}) ([]streamEntries, error) {
+	if reply == nil {
+		return nil, nil
+	}
+	streams, err := rd.Values(reply, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]streamEntries, 0, len(streams))
+	for _, s := range streams {
+		kv, err := rd.Values(s, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("unexpected stream reply length %d", len(kv))
+		}
+		key, err := rd.String(kv[0], nil)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := parseEntries(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse entries of stream %q: %w", key, err)
+		}
+		result = append(result, streamEntries{key: key, entries: entries})
+	}
+	return result, nil
+}
+
+// parsePending parses an extended XPENDING reply.
+func parsePending(reply interface{}) ([]pendingEntry, error) {
+	values, err := rd.Values(reply, nil)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]pendingEntry, 0, len(values))
+	for _, v := range values {
+		e, err := rd.Values(v, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(e) != 4 {
+			return nil, fmt.Errorf("unexpected pending entry length %d", len(e))
+		}
+		var p pendingEntry
+		var idle int64
+		if _, err := rd.Scan(e, &p.id, &p.consumer, &idle); err != nil {
+			return nil, fmt.Errorf("failed to parse pending entry: %w", err)
+		}
+		p.idle = time.Duration(idle) * time.Millisecond
+		entries = append(entries, p)
+	}
+	return entries, nil
+}
+
+func parseEntries(reply interface{}) ([]entry, error) {
+	if reply == nil {
+		return nil, nil
+	}
+	values, err := rd.Values(reply, nil)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]entry, 0, len(values))
+	for _, v := range values {
+		// Entries deleted while pending are returned as nil by XCLAIM
+		// before Redis 7.
+		if v == nil {
+			continue
+		}
+		e, err := rd.Values(v, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(e) != 2 {
+			return nil, fmt.Errorf("unexpected entry length %d", len(e))
+		}
+		id, err := rd.String(e[0], nil)
+		if err != nil {
+			return nil, err
+		}
+		if e[1] == nil {
+			entries = append(entries, entry{id: id})
+			continue
+		}
+		fields, err := rd.StringMap(e[1], nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse fields of entry %s: %w", id, err)
+		}
+		entries = append(entries, entry{id: id, fields: fields})
+	}
+	return entries, nil
+}
+
+// idTime returns the time encoded in the millisecond part of an entry ID.
+func idTime(id string) (time.Time, bool) {
+	ms, _, found := strings.Cut(id, "-")
+	if !found {
+		return time.Time{}, false
+	}
+	v, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(v).UTC(), true
+}
+
+// nextID returns the ID following id, to continue listing entries after it.
+// It returns false if id is not a valid entry ID.
+func nextID(id string) (string, bool) {
+	ms, seq, found := strings.Cut(id, "-")
+	if !found {
+		return "", false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return "", false
+	}
+	if n == math.MaxUint64 {
+		m, err := strconv.ParseUint(ms, 10, 64)
+		if err != nil || m == math.MaxUint64 {
+			return "", false
+		}
+		return strconv.FormatUint(m+1, 10) + "-0", true
+	}
+	return ms + "-" + strconv.FormatUint(n+1, 10), true
+}
@@ -0,0 +1,112 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package redisstreams
+
+import (
+	"testing"
+	"time"
+
+	rd "github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func entryReply(id string, kv ...string) interface{} {
+	if kv == nil {
+		return []interface{}{[]byte(id), nil}
+	}
+	fields := make([]interface{}, len(kv))
+	for i, v := range kv {
+		fields[i] = []byte(v)
+	}
+	return []interface{}{[]byte(id), fields}
+}
+
+func TestParseStreams(t *testing.T) {
+	reply := []interface{}{
+		[]interface{}{
+			[]byte("logs"),
+			[]interface{}{
+				entryReply("1700000000000-0", "message", "hello", "level", "info"),
+				entryReply("1700000000000-1"),
+			},
+		},
+		[]interface{}{
+			[]byte("audit"),
+			[]interface{}{},
+		},
+	}
+
+	streams, err := parseStreams(reply)
+	require.NoError(t, err)
+	assert.Equal(t, []streamEntries{
+		{key: "logs", entries: []entry{
+			{id: "1700000000000-0", fields: map[string]string{"message": "hello", "level": "info"}},
+			{id: "1700000000000-1"},
+		}},
+		{key: "audit", entries: []entry{}},
+	}, streams)
+
+	streams, err = parseStreams(nil)
+	require.NoError(t, err)
+	assert.Nil(t, streams)
+
+	_, err = parseStreams([]interface{}{[]interface{}{[]byte("logs")}})
+	assert.ErrorContains(t, err, "unexpected stream reply length 1")
+}
+
+func pendingReply(id, consumer string, idle int64) interface{} {
+	return []interface{}{[]byte(id), []byte(consumer), idle, int64(1)}
+}
+
+func TestParsePending(t *testing.T) {
+	entries, err := parsePending([]interface{}{
+		pendingReply("1700000000001-0", "host-1", 1500),
+		pendingReply("1700000000001-1", "host-2", 0),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []pendingEntry{
+		{id: "1700000000001-0", consumer: "host-1", idle: 1500 * time.Millisecond},
+		{id: "1700000000001-1", consumer: "host-2"},
+	}, entries)
+
+	_, err = parsePending([]interface{}{[]interface{}{[]byte("1700000000001-0")}})
+	assert.ErrorContains(t, err, "unexpected pending entry length 1")
+}
+
+func TestNextID(t *testing.T) {
+	for id, want := range map[string]string{
+		"1700000000001-0":                    "1700000000001-1",
+		"1700000000001-18446744073709551615": "1700000000002-0",
+	} {
+		got, ok := nextID(id)
+		assert.True(t, ok, id)
+		assert.Equal(t, want, got, id)
+	}
+	_, ok := nextID("invalid")
+	assert.False(t, ok)
+}
+
+func TestCreateGroup(t *testing.T) {
+	conn := &fakeConn{handler: func(cmd string, args []interface{}) (interface{}, error) {
+		return nil, rd.Error("BUSYGROUP Consumer Group name already exists")
+	}}
+	require.NoError(t, createGroup(conn, "logs", "filebeat", "$"))
+	assert.Equal(t, []string{"XGROUP CREATE logs filebeat $ MKSTREAM"}, conn.commands)
+
+	conn = &fakeConn{handler: func(cmd string, args []interface{}) (interface{}, error) {
+		return nil, rd.Error("WRONGTYPE Operation against a key holding the wrong kind of value")
+	}}
+	assert.ErrorContains(t, createGroup(conn, "logs", "filebeat", "$"), "WRONGTYPE")
+}
+
+func TestIDTime(t *testing.T) {
+	ts, ok := idTime("1700000000123-4")
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2023, 11, 14, 22, 13, 20, 123000000, time.UTC), ts)
+
+	_, ok = idTime("invalid")
+	assert.False(t, ok)
+}
@@ -0,0 +1,84 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sql
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// cursorPlaceholder matches the :cursor placeholder in queries. Double colons
+// are excluded so that PostgreSQL casts are not replaced.
+var cursorPlaceholder = regexp.MustCompile(`(^|[^:]):cursor\b`)
+
+type config struct {
+	Driver  string        `config:"driver" validate:"required"`          // Database driver: postgres, mysql or sqlite.
+	Hosts   []string      `config:"hosts" validate:"required"`           // Connection strings. Each host has its own cursor.
+	Query   string        `config:"sql_query" validate:"required"`       // Query with a :cursor placeholder.
+	Cursor  cursorConfig  `config:"cursor"`                              // Cursor column of the query results.
+	Period  time.Duration `config:"period" validate:"positive,nonzero"`  // Time between polls once all rows have been read.
+	Timeout time.Duration `config:"timeout" validate:"positive,nonzero"` // Query timeout.
+	Target  string        `config:"target"`                              // Field holding the row columns. Empty for the root of the event.
+}
+
+type cursorConfig struct {
+	Column  string `config:"column" validate:"required"` // Column holding the cursor value.
+	Type    string `config:"type"`                       // integer, timestamp or string.
+	Default string `config:"default"`                    // Cursor value used for the first query.
+}
+
+const (
+	cursorInteger   = "integer"
+	cursorTimestamp = "timestamp"
+	cursorString    = "string"
+)
+
+func defaultConfig() config {
+	return config{
+		Period:  10 * time.Second,
+		Timeout: 30 * time.Second,
+		Target:  "sql",
+		Cursor: cursorConfig{
+			Type: cursorInteger,
+		},
+	}
+}
+
+func (c *config) Validate() error {
+	switch c.Driver {
+	case "postgres", "postgresql", "mysql", "sqlite", "sqlite3":
+	default:
+		return fmt.Errorf("invalid driver %q: allowed values are postgres, mysql and sqlite", c.Driver)
+	}
+	if !cursorPlaceholder.MatchString(c.Query) {
+		return errors.New("sql_query must contain the :cursor placeholder")
+	}
+	switch c.Cursor.Type {
+	case cursorInteger, cursorTimestamp, cursorString:
+	default:
+		return fmt.Errorf("invalid cursor.type %q: allowed values are integer, timestamp and string", c.Cursor.Type)
+	}
+	if _, err := parseCursor(c.Cursor.Type, c.Cursor.defaultValue()); err != nil {
+		return fmt.Errorf("invalid cursor.default: %w", err)
+	}
+	return nil
+}
+
+// defaultValue returns the configured default cursor, or the lowest value
+// of the cursor type.
+func (c cursorConfig) defaultValue() string {
+	if c.Default != "" {
+		return c.Default
+	}
+	switch c.Type {
+	case cursorInteger:
+		return "0"
+	case cursorTimestamp:
+		return time.Unix(0, 0).UTC().Format(time.RFC3339Nano)
+	}
+	return ""
+}
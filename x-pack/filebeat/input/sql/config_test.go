@@ -0,0 +1,90 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	conf "github.com/elastic/elastic-agent-libs/config"
+)
+
+func TestConfig(t *testing.T) {
+	testCases := []struct {
+		name        string
+		userConfig  map[string]interface{}
+		expectedErr string
+	}{
+		{
+			"valid",
+			map[string]interface{}{
+				"driver":        "postgres",
+				"hosts":         []string{"postgres://localhost/app"},
+				"sql_query":     "SELECT * FROM audit WHERE id > :cursor ORDER BY id",
+				"cursor.column": "id",
+			},
+			"",
+		},
+		{
+			"invalid driver",
+			map[string]interface{}{
+				"driver":        "oracle",
+				"hosts":         []string{"localhost"},
+				"sql_query":     "SELECT * FROM audit WHERE id > :cursor",
+				"cursor.column": "id",
+			},
+			`invalid driver "oracle"`,
+		},
+		{
+			"missing placeholder",
+			map[string]interface{}{
+				"driver":        "mysql",
+				"hosts":         []string{"root@tcp(localhost)/app"},
+				"sql_query":     "SELECT * FROM audit WHERE id::text > '0'",
+				"cursor.column": "id",
+			},
+			"sql_query must contain the :cursor placeholder",
+		},
+		{
+			"missing cursor column",
+			map[string]interface{}{
+				"driver":    "sqlite",
+				"hosts":     []string{"audit.db"},
+				"sql_query": "SELECT * FROM audit WHERE id > :cursor",
+			},
+			"string value is not set accessing 'column'",
+		},
+		{
+			"invalid default",
+			map[string]interface{}{
+				"driver":         "sqlite",
+				"hosts":          []string{"audit.db"},
+				"sql_query":      "SELECT * FROM audit WHERE ts > :cursor",
+				"cursor.column":  "ts",
+				"cursor.type":    "timestamp",
+				"cursor.default": "yesterday",
+			},
+			"invalid cursor.default",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c := conf.MustNewConfigFrom(tc.userConfig)
+
+			sqlConf := defaultConfig()
+			err := c.Unpack(&sqlConf)
+
+			if tc.expectedErr != "" {
+				require.Error(t, err, "expected error: %s", tc.expectedErr)
+				require.Contains(t, err.Error(), tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
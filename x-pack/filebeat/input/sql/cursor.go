@@ -0,0 +1,117 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sql
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// state is the cursor state persisted in the registry.
+type state struct {
+	Value string `struct:"value"`
+}
+
+// timestampLayouts are the layouts accepted for timestamp cursors. Layouts
+// without a time zone are interpreted as UTC.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// parseCursor parses a persisted or configured cursor value into the value
+// passed to the query.
+func parseCursor(typ, s string) (interface{}, error) {
+	switch typ {
+	case cursorInteger:
+		return strconv.ParseInt(s, 10, 64)
+	case cursorTimestamp:
+		for _, layout := range timestampLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t.UTC(), nil
+			}
+		}
+		return nil, fmt.Errorf("cannot parse %q as a timestamp", s)
+	default:
+		return s, nil
+	}
+}
+
+// formatCursor formats a cursor value for persistence.
+func formatCursor(v interface{}) string {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// columnCursor converts the value of the cursor column of a row, as returned
+// by the driver, into a cursor value. The value is converted before the SQL
+// helper turns numbers into float64, so that large integers are exact.
+func columnCursor(typ string, v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, fmt.Errorf("cursor column is null")
+	case int64:
+		switch typ {
+		case cursorInteger:
+			return v, nil
+		case cursorString:
+			return strconv.FormatInt(v, 10), nil
+		}
+		return nil, fmt.Errorf("cannot use number %v as a %s cursor", v, typ)
+	case uint64:
+		if v > math.MaxInt64 {
+			return nil, fmt.Errorf("cursor column value %v overflows int64", v)
+		}
+		return columnCursor(typ, int64(v))
+	case float64:
+		switch typ {
+		case cursorInteger:
+			if v != math.Trunc(v) {
+				return nil, fmt.Errorf("cursor column value %v is not an integer", v)
+			}
+			return int64(v), nil
+		case cursorString:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		}
+		return nil, fmt.Errorf("cannot use number %v as a %s cursor", v, typ)
+	case time.Time:
+		switch typ {
+		case cursorTimestamp:
+			return v.UTC(), nil
+		case cursorString:
+			return v.UTC().Format(time.RFC3339Nano), nil
+		}
+		return nil, fmt.Errorf("cannot use timestamp %v as a %s cursor", v, typ)
+	case []byte:
+		return parseCursor(typ, string(v))
+	case string:
+		return parseCursor(typ, v)
+	default:
+		return parseCursor(typ, fmt.Sprint(v))
+	}
+}
+
+// cursorLess returns whether the cursor value a is lower than b.
+func cursorLess(a, b interface{}) bool {
+	switch a := a.(type) {
+	case int64:
+		return a < b.(int64)
+	case time.Time:
+		return a.Before(b.(time.Time))
+	case string:
+		return a < b.(string)
+	}
+	return false
+}
@@ -0,0 +1,308 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sql
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"  // Register the postgres driver.
+	_ "modernc.org/sqlite" // Register the sqlite driver.
+
+	"github.com/elastic/go-concert/ctxtool"
+	"github.com/elastic/go-concert/timed"
+
+	v2 "github.com/elastic/beats/v7/filebeat/input/v2"
+	inputcursor "github.com/elastic/beats/v7/filebeat/input/v2/input-cursor"
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/feature"
+	"github.com/elastic/beats/v7/libbeat/management/status"
+	sqlhelper "github.com/elastic/beats/v7/metricbeat/helper/sql"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+const inputName = "sql"
+
+func Plugin(log *logp.Logger, store inputcursor.StateStore) v2.Plugin {
+	return v2.Plugin{
+		Name:       inputName,
+		Stability:  feature.Experimental,
+		Deprecated: false,
+		Info:       "SQL table tailing",
+		Doc:        "The SQL input periodically runs a query with a cursor and publishes the new rows",
+		Manager: &inputcursor.InputManager{
+			Logger:     log,
+			StateStore: store,
+			Type:       inputName,
+			Configure:  configure,
+		},
+	}
+}
+
+func configure(cfg *conf.C) ([]inputcursor.Source, inputcursor.Input, error) {
+	config := defaultConfig()
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, nil, err
+	}
+	sources := make([]inputcursor.Source, len(config.Hosts))
+	for i, host := range config.Hosts {
+		sources[i] = &source{driver: sqlhelper.SwitchDriverName(config.Driver), dsn: host}
+	}
+	return sources, &sqlInput{config: config}, nil
+}
+
+// source is a database. Cursors are stored per source.
+type source struct {
+	driver string
+	dsn    string
+}
+
+// Name returns the driver and the connection string without credentials.
+func (s *source) Name() string {
+	return s.driver + "::" + redactDSN(s.dsn)
+}
+
+// redactDSN removes the password from URL and MySQL connection strings.
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Host != "" {
+		return u.Redacted()
+	}
+	if cfg, err := mysql.ParseDSN(dsn); err == nil && cfg.Passwd != "" {
+		cfg.Passwd = "xxxxx"
+		return cfg.FormatDSN()
+	}
+	return dsn
+}
+
+type sqlInput struct {
+	config config
+}
+
+var _ inputcursor.Input = (*sqlInput)(nil)
+
+func (in *sqlInput) Name() string { return inputName }
+
+func (in *sqlInput) Test(src inputcursor.Source, ctx v2.TestContext) error {
+	s := src.(*source)
+	db, err := sqlhelper.NewDBClient(s.driver, s.dsn, ctx.Logger)
+	if err != nil {
+		return err
+	}
+	return db.Close()
+}
+
+func (in *sqlInput) Run(env v2.Context, src inputcursor.Source, crsr inputcursor.Cursor, pub inputcursor.Publisher) error {
+	s := src.(*source)
+	log := env.Logger.With("source", s.Name())
+	env.UpdateStatus(status.Starting, "")
+
+	value := in.config.Cursor.defaultValue()
+	if !crsr.IsNew() {
+		var st state
+		if err := crsr.Unpack(&st); err != nil {
+			env.UpdateStatus(status.Failed, "failed to read cursor: "+err.Error())
+			return fmt.Errorf("failed to read cursor: %w", err)
+		}
+		value = st.Value
+	}
+	cursor, err := parseCursor(in.config.Cursor.Type, value)
+	if err != nil {
+		env.UpdateStatus(status.Failed, "invalid cursor: "+err.Error())
+		return fmt.Errorf("invalid cursor %q: %w", value, err)
+	}
+	log.Infow("Starting "+inputName+" input", "cursor", value)
+	defer log.Info(inputName + " input stopped")
+
+	metrics := newInputMetrics(env.ID, nil)
+	defer metrics.Close()
+
+	p := &poller{
+		config:  in.config,
+		source:  s,
+		cursor:  cursor,
+		pub:     pub,
+		metrics: metrics,
+		log:     log,
+	}
+	defer p.close()
+
+	ctx := ctxtool.FromCanceller(env.Cancelation)
+	for {
+		before := p.cursor
+		err := p.poll(ctx)
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			log.Errorw("Failed to query database", "error", err)
+			env.UpdateStatus(status.Degraded, "failed to query database: "+err.Error())
+			metrics.errorsTotal.Inc()
+		} else {
+			env.UpdateStatus(status.Running, "")
+			// Query again without waiting while the cursor advances, so
+			// that queries with a LIMIT catch up with the table.
+			if cursorLess(before, p.cursor) {
+				continue
+			}
+		}
+		if err := timed.Wait(ctx, in.config.Period); err != nil {
+			break
+		}
+	}
+
+	env.UpdateStatus(status.Stopped, "")
+	return nil
+}
+
+// poller runs the query of a source and publishes the returned rows.
+type poller struct {
+	config  config
+	source  *source
+	cursor  interface{}
+	db      *sqlhelper.DbClient
+	pub     inputcursor.Publisher
+	metrics *inputMetrics
+	log     *logp.Logger
+}
+
+// poll runs the query once with the current cursor and publishes the rows.
+func (p *poller) poll(ctx context.Context) error {
+	if p.db == nil {
+		db, err := sqlhelper.NewDBClient(p.source.driver, p.source.dsn, p.log)
+		if err != nil {
+			return fmt.Errorf("failed to connect: %w", err)
+		}
+		p.db = db
+	}
+
+	query, args := bindCursor(p.source.driver, p.config.Query, p.cursor)
+	queryCtx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+	start := time.Now()
+	rows, err := p.fetch(queryCtx, query, args)
+	p.metrics.queryTime.Update(time.Since(start).Nanoseconds())
+	p.metrics.queriesTotal.Inc()
+	if err != nil {
+		// Reconnect on the next poll, the connection may have been lost.
+		p.close()
+		return err
+	}
+
+	for _, r := range rows {
+		if cursorLess(p.cursor, r.cursor) {
+			p.cursor = r.cursor
+		}
+		if err := p.pub.Publish(p.newEvent(r.fields, r.cursor), &state{Value: formatCursor(p.cursor)}); err != nil {
+			return err
+		}
+		p.metrics.rowsTotal.Inc()
+	}
+	return nil
+}
+
+// row is a row returned by the query.
+type row struct {
+	fields mapstr.M
+	cursor interface{}
+}
+
+// fetch runs the query and reads all its rows. The cursor value is read from
+// the cursor column before the SQL helper converts it. No rows are returned
+// if any row can't be read, so that the cursor never moves past lost rows.
+func (p *poller) fetch(ctx context.Context, query string, args []interface{}) ([]row, error) {
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns: %w", err)
+	}
+	cursorCol := -1
+	for i, c := range cols {
+		cols[i] = strings.ToLower(c)
+		if cols[i] == strings.ToLower(p.config.Cursor.Column) {
+			cursorCol = i
+		}
+	}
+	if cursorCol < 0 {
+		return nil, fmt.Errorf("cursor column %q not found in the query results", p.config.Cursor.Column)
+	}
+
+	var result []row
+	vals := make([]interface{}, len(cols))
+	for rows.Next() {
+		for i := range vals {
+			vals[i] = new(interface{})
+		}
+		if err := rows.Scan(vals...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		cursor, err := columnCursor(p.config.Cursor.Type, *vals[cursorCol].(*interface{}))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor column value: %w", err)
+		}
+		fields := mapstr.M{}
+		for i, c := range cols {
+			fields.Put(c, sqlhelper.ConvertValue(*vals[i].(*interface{})))
+		}
+		result = append(result, row{fields: fields, cursor: cursor})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+	return result, nil
+}
+
+func (p *poller) newEvent(fields mapstr.M, cursor interface{}) beat.Event {
+	event := beat.Event{
+		Timestamp: time.Now().UTC(),
+		Fields:    mapstr.M{},
+	}
+	if ts, ok := cursor.(time.Time); ok {
+		event.Timestamp = ts
+	}
+	if p.config.Target == "" {
+		event.Fields = fields
+	} else {
+		event.Fields.Put(p.config.Target, fields)
+	}
+	return event
+}
+
+func (p *poller) close() {
+	if p.db == nil {
+		return
+	}
+	if err := p.db.Close(); err != nil {
+		p.log.Debugw("Failed to close database connection", "error", err)
+	}
+	p.db = nil
+}
+
+// bindCursor replaces the :cursor placeholders of the query with the bind
+// parameters of the driver and returns the query arguments.
+func bindCursor(driver, query string, cursor interface{}) (string, []interface{}) {
+	var args []interface{}
+	query = cursorPlaceholder.ReplaceAllStringFunc(query, func(m string) string {
+		// Keep the character preceding the placeholder.
+		prefix := m[:len(m)-len(":cursor")]
+		if driver == "postgres" {
+			args = []interface{}{cursor}
+			return prefix + "$1"
+		}
+		args = append(args, cursor)
+		return prefix + "?"
+	})
+	return query, args
+}
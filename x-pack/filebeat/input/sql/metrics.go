@@ -0,0 +1,43 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package sql
+
+import (
+	"github.com/rcrowley/go-metrics"
+
+	"github.com/elastic/beats/v7/libbeat/monitoring/inputmon"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+	"github.com/elastic/elastic-agent-libs/monitoring/adapter"
+)
+
+type inputMetrics struct {
+	unregister func()
+
+	queriesTotal *monitoring.Uint // Number of queries run.
+	rowsTotal    *monitoring.Uint // Number of rows published.
+	errorsTotal  *monitoring.Uint // Number of failed connections and queries.
+	queryTime    metrics.Sample   // Histogram of the query execution times in nanoseconds.
+}
+
+// Close removes the metrics from the registry.
+func (m *inputMetrics) Close() {
+	m.unregister()
+}
+
+func newInputMetrics(id string, optionalParent *monitoring.Registry) *inputMetrics {
+	reg, unreg := inputmon.NewInputRegistry(inputName, id, optionalParent)
+
+	out := &inputMetrics{
+		unregister:   unreg,
+		queriesTotal: monitoring.NewUint(reg, "queries_total"),
+		rowsTotal:    monitoring.NewUint(reg, "rows_total"),
+		errorsTotal:  monitoring.NewUint(reg, "errors_total"),
+		queryTime:    metrics.NewUniformSample(1024),
+	}
+	adapter.NewGoMetrics(reg, "query_time", adapter.Accept).
+		Register("histogram", metrics.NewHistogram(out.queryTime)) //nolint:errcheck // A unique namespace is used so name collisions are impossible.
+
+	return out
+}
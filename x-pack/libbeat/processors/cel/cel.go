@@ -0,0 +1,288 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+// Package cel provides a processor that evaluates a CEL program against
+// events, using the github.com/elastic/mito/lib CEL extension library.
+package cel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	"github.com/elastic/beats/v7/libbeat/common/cfgwarn"
+	"github.com/elastic/beats/v7/libbeat/processors"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+	"github.com/elastic/mito/lib"
+)
+
+const (
+	procName = "cel"
+	logName  = "processor." + procName
+
+	// root is the name of the variable holding the event.
+	root = "event"
+)
+
+func init() {
+	processors.RegisterPlugin(procName, New)
+}
+
+type processor struct {
+	config
+	log *logp.Logger
+
+	prg cel.Program
+	ast *cel.Ast
+}
+
+// New constructs a new cel processor built from ucfg config.
+func New(cfg *conf.C) (beat.Processor, error) {
+	c := defaultConfig()
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, fmt.Errorf("fail to unpack the %v processor configuration: %w", procName, err)
+	}
+
+	return newCEL(c)
+}
+
+func newCEL(c config) (*processor, error) {
+	cfgwarn.Beta("The " + procName + " processor is beta.")
+
+	log := logp.NewLogger(logName)
+	if c.ID != "" {
+		log = log.With("instance_id", c.ID)
+	}
+
+	patterns, err := c.regexps()
+	if err != nil {
+		return nil, err
+	}
+	prg, ast, err := newProgram(c.Program, c.MaxCost, patterns, log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile %v processor program: %w", procName, err)
+	}
+	return &processor{config: c, log: log, prg: prg, ast: ast}, nil
+}
+
+func newProgram(src string, maxCost uint64, patterns map[string]*regexp.Regexp, log *logp.Logger) (cel.Program, *cel.Ast, error) {
+	opts := []cel.EnvOption{
+		cel.Variable(root, cel.MapType(cel.StringType, cel.DynType)),
+		cel.OptionalTypes(cel.OptionalTypesVersion(lib.OptionalTypesVersion)),
+		lib.Collections(),
+		lib.Crypto(),
+		lib.JSON(nil),
+		lib.Strings(),
+		lib.Time(),
+		lib.Try(),
+		lib.Debug(debug(log)),
+	}
+	if len(patterns) != 0 {
+		opts = append(opts, lib.Regexp(patterns))
+	}
+	env, err := cel.NewEnv(opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create env: %w", err)
+	}
+
+	ast, iss := env.Compile(src)
+	if iss.Err() != nil {
+		return nil, nil, fmt.Errorf("failed compilation: %w", iss.Err())
+	}
+	if err = checkOutputType(ast.OutputType()); err != nil {
+		return nil, nil, err
+	}
+
+	var prgOpts []cel.ProgramOption
+	if maxCost != 0 {
+		prgOpts = append(prgOpts, cel.CostLimit(maxCost))
+	}
+	prg, err := env.Program(ast, prgOpts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed program instantiation: %w", err)
+	}
+	return prg, ast, nil
+}
+
+// checkOutputType ensures at compile time that the program can return a
+// map of field updates or a bool. Programs with a dynamic result type are
+// checked when they are evaluated.
+func checkOutputType(t *cel.Type) error {
+	switch t.Kind() {
+	case types.BoolKind, types.DynKind, types.AnyKind:
+		return nil
+	case types.MapKind:
+		switch t.Parameters()[0].Kind() {
+		case types.StringKind, types.DynKind:
+			return nil
+		}
+	}
+	return fmt.Errorf("program must return a map of field updates or a bool, but returns %s", t)
+}
+
+func debug(log *logp.Logger) func(string, any) {
+	log = log.Named("cel_debug")
+	return func(tag string, value any) {
+		level := "DEBUG"
+		if _, ok := value.(error); ok {
+			level = "ERROR"
+		}
+		log.Debugw(level, "tag", tag, "value", value)
+	}
+}
+
+func (p *processor) String() string {
+	json, _ := json.Marshal(p.config)
+	return procName + "=" + string(json)
+}
+
+func (p *processor) Run(event *beat.Event) (*beat.Event, error) {
+	out, err := p.run(event)
+	if err != nil && p.IgnoreFailure {
+		return event, nil
+	}
+	return out, err
+}
+
+// run evaluates the program against the event. Returning false drops the
+// event, returning true keeps it unchanged, and returning a map applies its
+// values to the fields of the event named by the keys. Null values delete
+// the field.
+func (p *processor) run(event *beat.Event) (*beat.Event, error) {
+	out, _, err := p.prg.Eval(map[string]interface{}{
+		// Shadow the lib.Time now global, which is static for the lifetime
+		// of the program.
+		"now": time.Now().In(time.UTC),
+		root:  eventMap(event),
+	})
+	if err != nil {
+		return event, fmt.Errorf("failed eval: %w", lib.DecoratedError{AST: p.ast, Err: err})
+	}
+
+	switch out := out.(type) {
+	case types.Bool:
+		if !out {
+			return nil, nil
+		}
+		return event, nil
+	case traits.Mapper:
+		updates, err := toNative(out)
+		if err != nil {
+			return event, fmt.Errorf("failed to convert result: %w", err)
+		}
+		return event, apply(event, updates.(mapstr.M))
+	}
+	return event, fmt.Errorf("program must return a map of field updates or a bool, but returned %s", out.Type())
+}
+
+// eventMap returns the fields of the event with the @timestamp and
+// @metadata fields.
+func eventMap(event *beat.Event) map[string]interface{} {
+	m := make(map[string]interface{}, len(event.Fields)+2)
+	for k, v := range event.Fields {
+		m[k] = v
+	}
+	m["@timestamp"] = event.Timestamp
+	if event.Meta != nil {
+		m["@metadata"] = event.Meta
+	}
+	return m
+}
+
+// apply applies the updates to the event in key order, such that a parent
+// field is written before the fields it contains.
+func apply(event *beat.Event, updates mapstr.M) error {
+	keys := make([]string, 0, len(updates))
+	for k := range updates {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := updates[k]
+		if v == nil {
+			err := event.Delete(k)
+			if err != nil && !errors.Is(err, mapstr.ErrKeyNotFound) {
+				return fmt.Errorf("failed to delete field [%v]: %w", k, err)
+			}
+			continue
+		}
+		if _, err := event.PutValue(k, v); err != nil {
+			return fmt.Errorf("failed to set field [%v]: %w", k, err)
+		}
+	}
+	return nil
+}
+
+// toNative converts a CEL value to the types used in event fields.
+func toNative(v ref.Val) (interface{}, error) {
+	switch v := v.(type) {
+	case types.Null:
+		return nil, nil
+	case types.Bool:
+		return bool(v), nil
+	case types.Int:
+		return int64(v), nil
+	case types.Uint:
+		return uint64(v), nil
+	case types.Double:
+		return float64(v), nil
+	case types.String:
+		return string(v), nil
+	case types.Bytes:
+		return []byte(v), nil
+	case types.Timestamp:
+		return v.Time, nil
+	case types.Duration:
+		return v.Duration, nil
+	case *types.Optional:
+		if !v.HasValue() {
+			return nil, nil
+		}
+		return toNative(v.GetValue())
+	case traits.Mapper:
+		m := mapstr.M{}
+		for it := v.Iterator(); it.HasNext() == types.True; {
+			k := it.Next()
+			key, ok := k.(types.String)
+			if !ok {
+				return nil, fmt.Errorf("unsupported map key type %s", k.Type())
+			}
+			val, err := toNative(v.Get(k))
+			if err != nil {
+				return nil, err
+			}
+			m[string(key)] = val
+		}
+		return m, nil
+	case traits.Lister:
+		n, ok := v.Size().(types.Int)
+		if !ok {
+			return nil, fmt.Errorf("invalid list size: %v", v.Size())
+		}
+		l := make([]interface{}, n)
+		for i := range l {
+			val, err := toNative(v.Get(types.Int(i)))
+			if err != nil {
+				return nil, err
+			}
+			l[i] = val
+		}
+		return l, nil
+	case *types.Err:
+		return nil, v
+	}
+	return v.Value(), nil
+}
@@ -0,0 +1,189 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/v7/libbeat/beat"
+	conf "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/mapstr"
+)
+
+func TestCEL(t *testing.T) {
+	ts := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	tests := map[string]struct {
+		config   mapstr.M
+		fields   mapstr.M
+		expected mapstr.M // nil if the event is dropped
+		meta     mapstr.M
+		time     time.Time
+		err      string
+	}{
+		"updates": {
+			config: mapstr.M{"program": `{
+				"event.kind": "alert",
+				"source.port": int(event.source.port_str),
+				"source.port_str": null,
+				"tags": event.tags + ["cel"],
+			}`},
+			fields: mapstr.M{
+				"source": mapstr.M{"ip": "10.0.0.1", "port_str": "443"},
+				"tags":   []string{"a"},
+			},
+			expected: mapstr.M{
+				"event":  mapstr.M{"kind": "alert"},
+				"source": mapstr.M{"ip": "10.0.0.1", "port": int64(443)},
+				"tags":   []interface{}{"a", "cel"},
+			},
+		},
+		"nested map": {
+			config: mapstr.M{"program": `{"user": {"name": event.message.split("@")[0], "domain": event.message.split("@")[1]}}`},
+			fields: mapstr.M{"message": "alice@example.com"},
+			expected: mapstr.M{
+				"message": "alice@example.com",
+				"user":    mapstr.M{"name": "alice", "domain": "example.com"},
+			},
+		},
+		"keep": {
+			config:   mapstr.M{"program": `event.message != "drop me"`},
+			fields:   mapstr.M{"message": "keep me"},
+			expected: mapstr.M{"message": "keep me"},
+		},
+		"drop": {
+			config: mapstr.M{"program": `event.message != "drop me"`},
+			fields: mapstr.M{"message": "drop me"},
+		},
+		"conditional": {
+			config:   mapstr.M{"program": `has(event.user) ? {"user.name": event.user.name.to_upper()} : {}`},
+			fields:   mapstr.M{"user": mapstr.M{"name": "alice"}},
+			expected: mapstr.M{"user": mapstr.M{"name": "ALICE"}},
+		},
+		"timestamp and metadata": {
+			config: mapstr.M{"program": `{
+				"@timestamp": timestamp(event.ts),
+				"@metadata.index": "logs-" + string(event["@metadata"].pipeline),
+				"ts": null,
+			}`},
+			fields:   mapstr.M{"ts": "2024-05-06T07:08:09Z", "message": "a"},
+			meta:     mapstr.M{"pipeline": "p"},
+			expected: mapstr.M{"message": "a"},
+			time:     ts,
+		},
+		"regexp": {
+			config: mapstr.M{
+				"program": `{"http.version": event.message.re_find("version")}`,
+				"regexp":  mapstr.M{"version": `HTTP/[0-9.]+`},
+			},
+			fields:   mapstr.M{"message": "GET / HTTP/1.1"},
+			expected: mapstr.M{"message": "GET / HTTP/1.1", "http": mapstr.M{"version": "HTTP/1.1"}},
+		},
+		"missing field": {
+			config:   mapstr.M{"program": `{"a": event.missing}`},
+			fields:   mapstr.M{"message": "a"},
+			expected: mapstr.M{"message": "a"},
+			err:      "no such key: missing",
+		},
+		"ignore failure": {
+			config:   mapstr.M{"program": `{"a": event.missing}`, "ignore_failure": true},
+			fields:   mapstr.M{"message": "a"},
+			expected: mapstr.M{"message": "a"},
+		},
+		"dynamic result type": {
+			config:   mapstr.M{"program": `event.message`},
+			fields:   mapstr.M{"message": "a"},
+			expected: mapstr.M{"message": "a"},
+			err:      "but returned string",
+		},
+		"cost limit": {
+			config: mapstr.M{
+				"program":  `{"n": event.list.map(x, event.list.map(y, x * y)).size()}`,
+				"max_cost": 100,
+			},
+			fields:   mapstr.M{"list": []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+			expected: mapstr.M{"list": []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+			err:      "cost limit exceeded",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := New(conf.MustNewConfigFrom(test.config))
+			require.NoError(t, err)
+
+			event, err := p.Run(&beat.Event{Fields: test.fields, Meta: test.meta})
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			if test.expected == nil {
+				assert.Nil(t, event)
+				return
+			}
+			require.NotNil(t, event)
+			assert.Equal(t, test.expected, event.Fields)
+			if !test.time.IsZero() {
+				assert.Equal(t, test.time, event.Timestamp)
+				assert.Equal(t, "logs-p", event.Meta["index"])
+			}
+		})
+	}
+}
+
+func TestCELCompileErrors(t *testing.T) {
+	tests := map[string]struct {
+		config mapstr.M
+		err    string
+	}{
+		"syntax": {
+			config: mapstr.M{"program": `{"a": `},
+			err:    "failed compilation",
+		},
+		"undeclared": {
+			config: mapstr.M{"program": `{"a": state.b}`},
+			err:    "undeclared reference to 'state'",
+		},
+		"result type": {
+			config: mapstr.M{"program": `"string"`},
+			err:    "program must return a map of field updates or a bool, but returns string",
+		},
+		"map key type": {
+			config: mapstr.M{"program": `{1: "a"}`},
+			err:    "but returns map(int, string)",
+		},
+		"regexp": {
+			config: mapstr.M{"program": `true`, "regexp": mapstr.M{"bad": "("}},
+			err:    "failed to compile regexp bad",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(conf.MustNewConfigFrom(test.config))
+			assert.ErrorContains(t, err, test.err)
+		})
+	}
+}
+
+func BenchmarkCEL(b *testing.B) {
+	p, err := New(conf.MustNewConfigFrom(mapstr.M{
+		"program": `{"event.kind": event.message.contains("error") ? "alert" : "event", "message": null}`,
+	}))
+	require.NoError(b, err)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := p.Run(&beat.Event{Fields: mapstr.M{"message": "an error occurred", "host": mapstr.M{"name": "a"}}})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cel
+
+import (
+	"fmt"
+	"regexp"
+)
+
+type config struct {
+	// Program is the CEL program evaluated for each event.
+	Program string `config:"program" validate:"required"`
+	// Regexps is the set of regular expression to be made available to the
+	// program by name.
+	Regexps map[string]string `config:"regexp"`
+	// MaxCost is the maximum evaluation cost of the program for a single
+	// event. Zero disables the limit.
+	MaxCost       uint64 `config:"max_cost"`
+	IgnoreFailure bool   `config:"ignore_failure"`
+	ID            string `config:"id"`
+}
+
+func defaultConfig() config {
+	return config{
+		MaxCost: 1000000,
+	}
+}
+
+func (c *config) Validate() error {
+	_, err := c.regexps()
+	return err
+}
+
+func (c *config) regexps() (map[string]*regexp.Regexp, error) {
+	if len(c.Regexps) == 0 {
+		return nil, nil
+	}
+	patterns := make(map[string]*regexp.Regexp, len(c.Regexps))
+	for name, expr := range c.Regexps {
+		var err error
+		patterns[name], err = regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile regexp %s: %w", name, err)
+		}
+	}
+	return patterns, nil
+}